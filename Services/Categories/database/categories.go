@@ -552,6 +552,56 @@ func (categories_repo *CategoriesMysql) GetCategoryFSBranch(ctx context.Context,
 	return medias, nil
 }
 
+// Returns the subset of the given category uuids that exist on the database.
+func (categories_repo *CategoriesMysql) FilterExistingCategories(ctx context.Context, category_uuids []string) ([]string, error) {
+	return categories_repo.filterExistingUUIDs(ctx, "categorys", category_uuids)
+}
+
+// Returns the subset of the given media uuids that exist on the database.
+func (categories_repo *CategoriesMysql) FilterExistingMedias(ctx context.Context, media_uuids []string) ([]string, error) {
+	return categories_repo.filterExistingUUIDs(ctx, "medias", media_uuids)
+}
+
+func (categories_repo *CategoriesMysql) filterExistingUUIDs(ctx context.Context, table_name string, uuids []string) ([]string, error) {
+	var existing_uuids []string = make([]string, 0)
+
+	if len(uuids) == 0 {
+		return existing_uuids, nil
+	}
+
+	var stmt_placeholders string = helpers.GetPreparedListPlaceholders(len(uuids))
+
+	stmt, err := categories_repo.db.PrepareContext(ctx, fmt.Sprintf("SELECT `uuid` FROM `%s` WHERE `uuid` IN (%s)", table_name, stmt_placeholders))
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In CategoriesService.CategoriesMysql.filterExistingUUIDs: Error preparing statement for table '%s'", table_name))
+	}
+	defer stmt.Close()
+
+	args := make([]interface{}, len(uuids))
+	for h, uuid := range uuids {
+		args[h] = uuid
+	}
+
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In CategoriesService.CategoriesMysql.filterExistingUUIDs: Error querying table '%s'", table_name))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var existing_uuid string
+
+		err = rows.Scan(&existing_uuid)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("In CategoriesService.CategoriesMysql.filterExistingUUIDs: Error scanning row"))
+		}
+
+		existing_uuids = append(existing_uuids, existing_uuid)
+	}
+
+	return existing_uuids, nil
+}
+
 func (categories_repo *CategoriesMysql) DeleteCategoryMedias(ctx context.Context, medias []dungeon_models.Media) error {
 	var err error
 
@@ -308,8 +308,10 @@ func patchCategoryContentHandler(response http.ResponseWriter, request *http.Req
 		}
 	}
 
+	var moved_identities []*dungeon_models.MediaIdentity
+
 	if len(moved_medias) > 0 {
-		err = workflows.ProcessMovedMedias(moved_medias, current_category, &medias_cluster)
+		moved_identities, err = workflows.ProcessMovedMedias(moved_medias, current_category, &medias_cluster)
 		if err != nil {
 			echo.Echo(echo.YellowFG, fmt.Sprintf("Error processing moved medias: %s", err.Error()))
 			response.WriteHeader(500)
@@ -326,6 +328,20 @@ func patchCategoryContentHandler(response http.ResponseWriter, request *http.Req
 		echo.Echo(echo.YellowFG, fmt.Sprintf("Error emitting category changes event: %s", err.Error()))
 	}
 
+	var rejected_identities []*dungeon_models.MediaIdentity = make([]*dungeon_models.MediaIdentity, 0, len(rejected_medias))
+
+	for h := range rejected_medias {
+		rejected_identities = append(rejected_identities, dungeon_models.CreateNewMediaIdentity(&rejected_medias[h], &current_category, &medias_cluster))
+	}
+
+	media_change_events := communication.NewMediaDeletedEvents(app_config.JWT_SECRET, medias_cluster.Uuid, rejected_identities)
+	media_change_events = append(media_change_events, communication.NewMediaMovedEvents(app_config.JWT_SECRET, medias_cluster.Uuid, moved_identities)...)
+
+	err = communication.EmitPlatformEvents(media_change_events)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error emitting media change events: %s", err.Error()))
+	}
+
 	go func() {
 		workflows.ProcessDeletedMedias(rejected_medias)
 		workflows.ApplyCategoryTags(moved_medias, &medias_cluster)
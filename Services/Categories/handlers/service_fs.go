@@ -11,6 +11,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"libery-dungeon-libs/communication"
 	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
 	app_config "libery_categories_service/Config"
@@ -225,13 +226,21 @@ func putClusterSyncHandler(response http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	lerr = fs_sync.SyncCategoryBranch(category_identity)
+	sync_changes, lerr := fs_sync.SyncCategoryBranch(category_identity)
 	if lerr != nil {
 		echo.EchoErr(lerr)
 		http.Error(response, "Error syncing category branch", 500)
 		return
 	}
 
+	media_change_events := communication.NewMediaAddedEvents(app_config.JWT_SECRET, category_identity.ClusterUUID, sync_changes.AddedMedias)
+	media_change_events = append(media_change_events, communication.NewMediaDeletedEvents(app_config.JWT_SECRET, category_identity.ClusterUUID, sync_changes.DeletedMedias)...)
+
+	err = communication.EmitPlatformEvents(media_change_events)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error emitting cluster sync media events: %s", err.Error()))
+	}
+
 	response.WriteHeader(200)
 	return
 }
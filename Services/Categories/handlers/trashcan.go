@@ -141,7 +141,7 @@ func patchTrashcanMediaRestoreHandler(response http.ResponseWriter, request *htt
 		return
 	}
 
-	lerr = workflows.RestoreMediaFromTransaction(*category_identity, *transaction, media_uuid)
+	restored_media, lerr := workflows.RestoreMediaFromTransaction(*category_identity, *transaction, media_uuid)
 	if lerr != nil {
 		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/trashcan.patchTrashcanMediaRestoreHandler: while restoring media from transaction, error: %s", lerr.Error()))
 		response.WriteHeader(500)
@@ -156,6 +156,12 @@ func patchTrashcanMediaRestoreHandler(response http.ResponseWriter, request *htt
 		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/trashcan.patchTrashcanMediaRestoreHandler: while emitting fs_change_event, error: %s", err.Error()))
 	}
 
+	media_added_events := communication.NewMediaAddedEvents(app_config.JWT_SECRET, category_identity.ClusterUUID, []*dungeon_models.MediaIdentity{restored_media})
+	err = communication.EmitPlatformEvents(media_added_events)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/trashcan.patchTrashcanMediaRestoreHandler: while emitting media_added events, error: %s", err.Error()))
+	}
+
 	response.WriteHeader(200)
 }
 
@@ -187,7 +193,14 @@ func patchTrashcanTransactionRestoreHandler(response http.ResponseWriter, reques
 	go func() {
 		medias_restored := len(transaction.Content)
 
-		lerr = workflows.RestoreTransactionToCategory(*transaction, category_identity)
+		restored_medias, lerr := workflows.RestoreTransactionToCategory(*transaction, category_identity)
+
+		media_added_events := communication.NewMediaAddedEvents(app_config.JWT_SECRET, category_identity.ClusterUUID, restored_medias)
+		err := communication.EmitPlatformEvents(media_added_events)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/trashcan.patchTrashcanTransactionRestoreHandler: while emitting media_added events, error: %s", err.Error()))
+		}
+
 		if lerr != nil {
 			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/trashcan.patchTrashcanTransactionRestoreHandler: while restoring transaction to category, error: %s", lerr.Error()))
 			response.WriteHeader(500)
@@ -18,6 +18,8 @@ type CategoriesRepository interface {
 	GetAllCategories(ctx context.Context) ([]dungeon_models.Category, error)
 	GetCategoryContentByFullpath(ctx context.Context, category_path string, category_cluster string) (*dungeon_models.Category, error)
 	GetCategoryFSBranch(ctx context.Context, category_id string) ([]dungeon_models.MediaWeakIdentity, error)
+	FilterExistingCategories(ctx context.Context, category_uuids []string) ([]string, error)
+	FilterExistingMedias(ctx context.Context, media_uuids []string) ([]string, error)
 	DeleteCategoryMedias(ctx context.Context, medias []dungeon_models.Media) error
 	DeleteCategory(ctx context.Context, category_id string) error
 	IsCategoryEmpty(ctx context.Context, category_id string) (bool, error)
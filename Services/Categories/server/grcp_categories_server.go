@@ -79,6 +79,32 @@ func (s *CategoriesServer) GetCategory(ctx context.Context, request *categories_
 	return response, nil
 }
 
+func (s *CategoriesServer) FilterExistingCategories(ctx context.Context, request *categories_service_pb.UuidList) (*categories_service_pb.UuidList, error) {
+	response := new(categories_service_pb.UuidList)
+
+	existing_categories, err := repository.CategoriesRepo.FilterExistingCategories(ctx, request.Uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Uuids = existing_categories
+
+	return response, nil
+}
+
+func (s *CategoriesServer) FilterExistingMedias(ctx context.Context, request *categories_service_pb.UuidList) (*categories_service_pb.UuidList, error) {
+	response := new(categories_service_pb.UuidList)
+
+	existing_medias, err := repository.CategoriesRepo.FilterExistingMedias(ctx, request.Uuids)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Uuids = existing_medias
+
+	return response, nil
+}
+
 func (s *CategoriesServer) Connect() error {
 	listener, err := net.Listen("tcp", s.port)
 	if err != nil {
@@ -136,6 +136,11 @@ func DeleteCategory(category_uuid string, delete_content bool) (deleted bool, er
 		return
 	}
 
+	go func() {
+		ProcessDeletedMedias(medias)
+		ProcessDeletedCategories([]dungeon_models.Category{category})
+	}()
+
 	deleted = true
 
 	return
@@ -10,6 +10,9 @@ import (
 
 // Cleans resources in other services associated to a list of deleted medias
 func ProcessDeletedMedias(medias []dungeon_models.Media) error {
+	if len(medias) == 0 {
+		return nil
+	}
 
 	var media_uuids []string = make([]string, len(medias))
 
@@ -17,14 +20,30 @@ func ProcessDeletedMedias(medias []dungeon_models.Media) error {
 		media_uuids[h] = media.Uuid
 	}
 
-	_, err := communication.Metadata.RemoveAllTaggingsForEntities(media_uuids)
+	_, err := communication.Metadata.NotifyEntitiesDeleted(media_uuids)
 	if err != nil {
-		echo.EchoErr(fmt.Errorf("In workflows/external_resource_cleaners.ProcessDeletedMedias: While calling communication.Metadata.RemoveAllTaggingsForEntities\n\n%s", err))
+		echo.EchoErr(fmt.Errorf("In workflows/external_resource_cleaners.ProcessDeletedMedias: While calling communication.Metadata.NotifyEntitiesDeleted\n\n%s", err))
 	}
 
 	return err
 }
 
+// Cleans resources in other services associated to a list of deleted categories
 func ProcessDeletedCategories(categories []dungeon_models.Category) error {
-	return nil
+	if len(categories) == 0 {
+		return nil
+	}
+
+	var category_uuids []string = make([]string, len(categories))
+
+	for h, category := range categories {
+		category_uuids[h] = category.Uuid
+	}
+
+	_, err := communication.Metadata.NotifyEntitiesDeleted(category_uuids)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("In workflows/external_resource_cleaners.ProcessDeletedCategories: While calling communication.Metadata.NotifyEntitiesDeleted\n\n%s", err))
+	}
+
+	return err
 }
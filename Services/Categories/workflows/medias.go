@@ -63,9 +63,11 @@ func ProcessRejectedMedias(rejected_medias []dungeon_models.Media, rejected_from
 //
 // Returns:
 //
+//	[]*dungeon_models.MediaIdentity: identities of the moved medias on the categories they were moved to
 //	error: nil if everything went ok, error otherwise
-func ProcessMovedMedias(moved_medias map[string][]dungeon_models.Media, current_category dungeon_models.Category, medias_cluster *dungeon_models.CategoryCluster) error {
+func ProcessMovedMedias(moved_medias map[string][]dungeon_models.Media, current_category dungeon_models.Category, medias_cluster *dungeon_models.CategoryCluster) ([]*dungeon_models.MediaIdentity, error) {
 	var err error
+	var moved_identities []*dungeon_models.MediaIdentity = make([]*dungeon_models.MediaIdentity, 0)
 	var moved_to map[string]string = make(map[string]string) // where the media was moved to. uuid -> new_path. used to rollback in case of error
 	var updated_medias []dungeon_models.Media = make([]dungeon_models.Media, 0)
 	var new_category dungeon_models.Category
@@ -78,7 +80,7 @@ func ProcessMovedMedias(moved_medias map[string][]dungeon_models.Media, current_
 		new_category, err = repository.CategoriesRepo.GetCategory(context.Background(), category_id)
 		if err != nil {
 			echo.EchoDebug(fmt.Sprintf("[getting new_category]Error getting category %s: %s", category_id, err.Error()))
-			return err
+			return nil, err
 		}
 
 		for _, media := range medias {
@@ -100,13 +102,16 @@ func ProcessMovedMedias(moved_medias map[string][]dungeon_models.Media, current_
 				if new_err != nil {
 					echo.EchoWarn(fmt.Sprintf("[After os.Rename]Error restoring medias from %s to %s: %s", old_path, new_path, new_err.Error()))
 				}
-				return err
+				return nil, err
 			}
 
 			moved_to[media.Uuid] = new_path
 			media.MainCategory = new_category.Uuid
 
 			updated_medias = append(updated_medias, media)
+
+			moved_media := media
+			moved_identities = append(moved_identities, dungeon_models.CreateNewMediaIdentity(&moved_media, &new_category, medias_cluster))
 		}
 	}
 
@@ -118,10 +123,10 @@ func ProcessMovedMedias(moved_medias map[string][]dungeon_models.Media, current_
 		if new_err != nil {
 			echo.EchoWarn(fmt.Sprintf("[After db update]Error restoring medias from %s to %s: %s", old_path, new_path, new_err.Error()))
 		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	return moved_identities, nil
 }
 
 func rollbackMovedMedias(moved_to map[string]string, medias []dungeon_models.Media, original_path string) error {
@@ -14,6 +14,7 @@ import (
 type unregisteredFile struct {
 	FilePath     string
 	CategoryUUID string
+	CategoryPath string
 }
 
 type unregisteredCategory struct {
@@ -43,10 +44,10 @@ func (sync_errors *stateSyncErrors) addUnregisteredFile(unregistered_file_path s
 		return fmt.Errorf("Parent directory has no registered categories")
 	}
 
-	var parent_uuid string = parent_medias[0].CategoryUUID
 	var new_unregistered_file unregisteredFile = unregisteredFile{
 		FilePath:     unregistered_file_path,
-		CategoryUUID: parent_uuid,
+		CategoryUUID: parent_medias[0].CategoryUUID,
+		CategoryPath: parent_medias[0].CategoryPath,
 	}
 
 	sync_errors.UnregisteredFiles = append(sync_errors.UnregisteredFiles, new_unregistered_file)
@@ -15,9 +15,15 @@ import (
 	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
+// The medias registered and unregistered by a category branch sync.
+type BranchSyncChanges struct {
+	AddedMedias   []*dungeon_models.MediaIdentity
+	DeletedMedias []*dungeon_models.MediaIdentity
+}
+
 // Verfies the fs state of a give category matches its database state. Each supported file found existing in the fs but not in the db will be inserted into the db.
 // Each file an category found in the db but not in the fs will be removed from the db.
-func SyncCategoryBranch(category_identity *dungeon_models.CategoryIdentity) *dungeon_models.LabeledError {
+func SyncCategoryBranch(category_identity *dungeon_models.CategoryIdentity) (*BranchSyncChanges, *dungeon_models.LabeledError) {
 	var branch_content []dungeon_models.MediaWeakIdentity
 	var lerr *dungeon_models.LabeledError
 
@@ -25,7 +31,7 @@ func SyncCategoryBranch(category_identity *dungeon_models.CategoryIdentity) *dun
 
 	branch_content, err := repository.CategoriesRepo.GetCategoryFSBranch(context.Background(), category_identity.Category.Uuid)
 	if err != nil {
-		return dungeon_models.NewLabeledError(err, "in SyncCategoryBranch, while calling CategoriesRepo.GetCategoryFSBranch", dungeon_models.ErrProcessError)
+		return nil, dungeon_models.NewLabeledError(err, "in SyncCategoryBranch, while calling CategoriesRepo.GetCategoryFSBranch", dungeon_models.ErrProcessError)
 	}
 
 	var branch_path string = filepath.Join(category_identity.ClusterPath, category_identity.Category.Fullpath)
@@ -44,7 +50,7 @@ func SyncCategoryBranch(category_identity *dungeon_models.CategoryIdentity) *dun
 	sync_errors, lerr := scanSyncErrors(branch_path, db_state_map)
 	if lerr != nil {
 		lerr.AppendContext(fmt.Sprintf("In SyncCategoryBranch, while scanning category branch: '%s'", branch_path))
-		return lerr
+		return nil, lerr
 	}
 
 	sync_errors.reportGhostFiles(category_identity, branch_content)
@@ -53,23 +59,28 @@ func SyncCategoryBranch(category_identity *dungeon_models.CategoryIdentity) *dun
 		echo.Echo(echo.WhiteFG, sync_errors.String())
 	}
 
-	lerr = amendSyncErrors(sync_errors, category_identity)
+	sync_changes := &BranchSyncChanges{
+		AddedMedias:   make([]*dungeon_models.MediaIdentity, 0),
+		DeletedMedias: make([]*dungeon_models.MediaIdentity, 0),
+	}
+
+	lerr = amendSyncErrors(sync_errors, category_identity, sync_changes)
 
-	return lerr
+	return sync_changes, lerr
 }
 
-func amendSyncErrors(sync_errors *stateSyncErrors, category_identity *dungeon_models.CategoryIdentity) *dungeon_models.LabeledError {
+func amendSyncErrors(sync_errors *stateSyncErrors, category_identity *dungeon_models.CategoryIdentity, sync_changes *BranchSyncChanges) *dungeon_models.LabeledError {
 	var err error
 	var lerr *dungeon_models.LabeledError
 
 	echo.EchoDebug(fmt.Sprintf("%sAmending sync errors%s", echo.BlueFG, echo.CyanFG))
-	lerr = syncUnregisteredContent(sync_errors, category_identity)
+	lerr = syncUnregisteredContent(sync_errors, category_identity, sync_changes)
 	if lerr != nil {
 		return lerr
 	}
 
 	echo.EchoDebug(fmt.Sprintf("%sAmending ghost identities%s", echo.BlueFG, echo.CyanFG))
-	err = syncGhostIdentities(sync_errors)
+	err = syncGhostIdentities(sync_errors, category_identity, sync_changes)
 	if err != nil {
 		lerr = dungeon_models.NewLabeledError(err, "in amendSyncErrors, while calling syncGhostIdentities", dungeon_models.ErrDB_CouldNotConnectToDB)
 		return lerr
@@ -78,19 +89,21 @@ func amendSyncErrors(sync_errors *stateSyncErrors, category_identity *dungeon_mo
 	return nil
 }
 
-func syncUnregisteredContent(sync_errors *stateSyncErrors, root_identity *dungeon_models.CategoryIdentity) *dungeon_models.LabeledError {
-	var err error
+func syncUnregisteredContent(sync_errors *stateSyncErrors, root_identity *dungeon_models.CategoryIdentity, sync_changes *BranchSyncChanges) *dungeon_models.LabeledError {
 	var lerr *dungeon_models.LabeledError
 
 	for _, unregistered_file := range sync_errors.UnregisteredFiles {
-		err = syncUnregisteredMedia(unregistered_file)
+		added_media, err := syncUnregisteredMedia(unregistered_file, root_identity)
 		if err != nil {
 			echo.EchoWarn(fmt.Sprintf("Error could not sync unregistered media<%s> because: %s", unregistered_file.FilePath, err.Error()))
+			continue
 		}
+
+		sync_changes.AddedMedias = append(sync_changes.AddedMedias, added_media)
 	}
 
 	for _, unregistered_category := range sync_errors.UnregisteredCategoriesPaths {
-		lerr = syncUnregisteredCategory(unregistered_category, root_identity)
+		lerr = syncUnregisteredCategory(unregistered_category, root_identity, &sync_changes.AddedMedias)
 		if lerr != nil {
 			echo.EchoWarn(fmt.Sprintf("Error could not sync unregistered category<%s> because: %s", unregistered_category.DirectoryPath, lerr.Error()))
 		}
@@ -99,10 +112,10 @@ func syncUnregisteredContent(sync_errors *stateSyncErrors, root_identity *dungeo
 	return nil
 }
 
-func syncUnregisteredMedia(unregistered_file unregisteredFile) error {
+func syncUnregisteredMedia(unregistered_file unregisteredFile, root_identity *dungeon_models.CategoryIdentity) (*dungeon_models.MediaIdentity, error) {
 	file_stat, err := os.Stat(unregistered_file.FilePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var filename string = file_stat.Name()
@@ -112,22 +125,33 @@ func syncUnregisteredMedia(unregistered_file unregisteredFile) error {
 	echo.EchoDebug(fmt.Sprintf("-> Inserting new media: %s", new_media.Name))
 
 	err = repository.MediasRepo.InsertMedia(context.Background(), new_media)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	media_identity := &dungeon_models.MediaIdentity{
+		Media:        new_media,
+		CategoryUUID: unregistered_file.CategoryUUID,
+		CategoryPath: unregistered_file.CategoryPath,
+		ClusterUUID:  root_identity.ClusterUUID,
+		ClusterPath:  root_identity.ClusterPath,
+	}
+
+	return media_identity, nil
 }
 
-func syncUnregisteredCategory(unregistered_category unregisteredCategory, root_identity *dungeon_models.CategoryIdentity) *dungeon_models.LabeledError {
+func syncUnregisteredCategory(unregistered_category unregisteredCategory, root_identity *dungeon_models.CategoryIdentity, added_medias *[]*dungeon_models.MediaIdentity) *dungeon_models.LabeledError {
 	cluster_identity := root_identity.ToClusterWeakIdentity()
 	file_relative_path := strings.TrimPrefix(unregistered_category.DirectoryPath, cluster_identity.ClusterFsPath)
 	file_relative_path = dungeon_helpers.NormalizePath(file_relative_path)
 
 	echo.EchoDebug(fmt.Sprintf("-> Creating new category tree: %s", file_relative_path))
-	err := servicefs_workflows.CreateClusterTree(cluster_identity, file_relative_path, unregistered_category.ParentUUID)
+	err := servicefs_workflows.CreateClusterTree(cluster_identity, file_relative_path, unregistered_category.ParentUUID, added_medias)
 
 	return err
 }
 
-func syncGhostIdentities(sync_errors *stateSyncErrors) error {
+func syncGhostIdentities(sync_errors *stateSyncErrors, root_identity *dungeon_models.CategoryIdentity, sync_changes *BranchSyncChanges) error {
 	var err error
 
 	for _, ghost_identity := range sync_errors.GhostIdentities {
@@ -135,6 +159,9 @@ func syncGhostIdentities(sync_errors *stateSyncErrors) error {
 
 		if is_media {
 			err = syncGhostMedia(ghost_identity)
+			if err == nil {
+				sync_changes.DeletedMedias = append(sync_changes.DeletedMedias, ghostMediaIdentity(ghost_identity, root_identity))
+			}
 		} else {
 			err = syncGhostCategory(ghost_identity)
 		}
@@ -153,6 +180,21 @@ func syncGhostMedia(identity dungeon_models.MediaWeakIdentity) error {
 	return err
 }
 
+// The ghost media record is already gone when the identity is built, so only the fields known from the weak identity are set.
+func ghostMediaIdentity(identity dungeon_models.MediaWeakIdentity, root_identity *dungeon_models.CategoryIdentity) *dungeon_models.MediaIdentity {
+	return &dungeon_models.MediaIdentity{
+		Media: &dungeon_models.Media{
+			Uuid:         identity.MediaUUID,
+			Name:         identity.MediaName,
+			MainCategory: identity.CategoryUUID,
+		},
+		CategoryUUID: identity.CategoryUUID,
+		CategoryPath: identity.CategoryPath,
+		ClusterUUID:  root_identity.ClusterUUID,
+		ClusterPath:  root_identity.ClusterPath,
+	}
+}
+
 func syncGhostCategory(identity dungeon_models.MediaWeakIdentity) error {
 	err := repository.CategoriesRepo.DeleteCategory(context.Background(), identity.CategoryUUID)
 
@@ -108,7 +108,7 @@ func CreateNewCategoryCluster(new_cluster *dungeon_models.CategoryCluster) (*dun
 		for _, f := range filter_category_content {
 			if f.IsDir() {
 				cluster_identity := new_cluster.ToWeakIdentity()
-				labeled_err := CreateClusterTree(cluster_identity, filepath.Join(filter_category.Fullpath, f.Name()), filter_category.Uuid)
+				labeled_err := CreateClusterTree(cluster_identity, filepath.Join(filter_category.Fullpath, f.Name()), filter_category.Uuid, nil)
 				if labeled_err != nil {
 					labeled_err.AppendContext(fmt.Sprintf(":%s:", f.Name()))
 					return nil, labeled_err
@@ -134,7 +134,7 @@ func CreateNewCategoryCluster(new_cluster *dungeon_models.CategoryCluster) (*dun
 	for _, f := range new_cluster_contents {
 		if f.IsDir() && filter_category.Name != f.Name() {
 			cluster_identity := new_cluster.ToWeakIdentity()
-			labeled_err := CreateClusterTree(cluster_identity, f.Name(), root_category.Uuid)
+			labeled_err := CreateClusterTree(cluster_identity, f.Name(), root_category.Uuid, nil)
 			if labeled_err != nil {
 				labeled_err.AppendContext(fmt.Sprintf(":%s:", f.Name()))
 				return nil, labeled_err
@@ -153,7 +153,9 @@ func CreateNewCategoryCluster(new_cluster *dungeon_models.CategoryCluster) (*dun
 	return new_cluster, nil
 }
 
-func CreateClusterTree(new_cluster_identity *dungeon_models.CategoryClusterWeakIdentity, subdirectory_path string, parent_uuid string) (labeled_err *dungeon_models.LabeledError) {
+// Registers the directory at subdirectory_path and everything under it as categories and medias of the cluster. If added_medias is not nil,
+// the identity of every registered media is appended to it.
+func CreateClusterTree(new_cluster_identity *dungeon_models.CategoryClusterWeakIdentity, subdirectory_path string, parent_uuid string, added_medias *[]*dungeon_models.MediaIdentity) (labeled_err *dungeon_models.LabeledError) {
 	var category_name string = filepath.Base(subdirectory_path)
 	var fs_path string = filepath.Join(new_cluster_identity.ClusterFsPath, subdirectory_path)
 
@@ -176,7 +178,7 @@ func CreateClusterTree(new_cluster_identity *dungeon_models.CategoryClusterWeakI
 	for _, f := range directory_contents {
 		if f.IsDir() {
 			child_path := filepath.Join(subdirectory_path, f.Name())
-			labeled_err = CreateClusterTree(new_cluster_identity, child_path, new_category.Uuid)
+			labeled_err = CreateClusterTree(new_cluster_identity, child_path, new_category.Uuid, added_medias)
 			if labeled_err != nil {
 				labeled_err.AppendContext(fmt.Sprintf(":%s:", child_path))
 				return labeled_err
@@ -189,6 +191,16 @@ func CreateClusterTree(new_cluster_identity *dungeon_models.CategoryClusterWeakI
 					labeled_err = dungeon_models.NewLabeledError(err, "in createClusterTree, while calling MediasRepo.InsertMedia", dungeon_models.ErrProcessError)
 					return labeled_err
 				}
+
+				if added_medias != nil {
+					*added_medias = append(*added_medias, &dungeon_models.MediaIdentity{
+						Media:        new_media,
+						CategoryUUID: new_category.Uuid,
+						CategoryPath: new_category.Fullpath,
+						ClusterUUID:  new_cluster_identity.ClusterUUID,
+						ClusterPath:  new_cluster_identity.ClusterFsPath,
+					})
+				}
 			}
 		}
 	}
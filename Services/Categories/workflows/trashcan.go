@@ -10,8 +10,10 @@ import (
 	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
-func RestoreTransactionToCategory(transaction service_models.TrashcanTransaction, category_identity *dungeon_models.CategoryIdentity) (lerr *dungeon_models.LabeledError) {
+// Restores every media of the transaction into the given category. Returns the identities of the medias restored, even if a later media fails to restore.
+func RestoreTransactionToCategory(transaction service_models.TrashcanTransaction, category_identity *dungeon_models.CategoryIdentity) (restored_medias []*dungeon_models.MediaIdentity, lerr *dungeon_models.LabeledError) {
 	var media_uuids []string = make([]string, 0)
+	restored_medias = make([]*dungeon_models.MediaIdentity, 0)
 
 	for _, media := range transaction.Content {
 		media_uuids = append(media_uuids, media.Uuid)
@@ -24,17 +26,19 @@ func RestoreTransactionToCategory(transaction service_models.TrashcanTransaction
 	}
 
 	for _, media_uuid := range media_uuids {
-		lerr = RestoreMediaFromTransaction(*category_identity, transaction, media_uuid)
+		restored_media, lerr := RestoreMediaFromTransaction(*category_identity, transaction, media_uuid)
 		if lerr != nil {
 			lerr.AppendContext("In workflows.RestoreTransactionToCategory")
-			return
+			return restored_medias, lerr
 		}
+
+		restored_medias = append(restored_medias, restored_media)
 	}
 
 	return
 }
 
-func RestoreMediaFromTransaction(parent_identity dungeon_models.CategoryIdentity, transaction service_models.TrashcanTransaction, media_uuid string) (lerr *dungeon_models.LabeledError) {
+func RestoreMediaFromTransaction(parent_identity dungeon_models.CategoryIdentity, transaction service_models.TrashcanTransaction, media_uuid string) (restored_media *dungeon_models.MediaIdentity, lerr *dungeon_models.LabeledError) {
 	var err error
 	media := transaction.GetMediaByUuid(media_uuid)
 	if media == nil {
@@ -64,5 +68,7 @@ func RestoreMediaFromTransaction(parent_identity dungeon_models.CategoryIdentity
 		return
 	}
 
+	restored_media = parent_identity.ToWeakIdentity().ToMediaIdentity(media)
+
 	return
 }
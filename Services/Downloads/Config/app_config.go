@@ -42,6 +42,35 @@ var GRPC_SERVER string = os.Getenv("GRPC_SERVER")
 
 var service_settings map[string]any = make(map[string]any)
 var DOWNLOAD_USER_AGENT string
+var DOWNLOAD_WORKERS int = 4                      // Files of a batch that are downloaded at the same time
+var DOWNLOAD_HOST_CONCURRENCY int = 2             // Files downloaded at the same time from a single host
+var DOWNLOAD_HOST_REQUESTS_PER_SECOND float64 = 2 // 0 disables the per host rate limit
+var DOWNLOAD_MAX_TRIES int = 4
+var DOWNLOAD_BACKOFF_BASE_MS int64 = 500
+var DOWNLOAD_BACKOFF_MAX_MS int64 = 30000
+var DOWNLOAD_RETRY_AFTER_MAX_SECONDS int64 = 300 // Longer Retry-After values are clamped to this
+var DOWNLOAD_MAX_FILE_SIZE_MB int64 = 512        // 0 disables the size cap
+var DOWNLOAD_RESPONSE_TIMEOUT_SECONDS int64 = 30 // How long a host has to answer a media request before the attempt is given up
+var DOWNLOADER_BACKENDS []DownloaderBackendSettings = make([]DownloaderBackendSettings, 0)
+var DOWNLOADER_BACKENDS_CONCURRENCY int = 1
+var DOWNLOADER_BACKENDS_TIMEOUT_MINUTES int64 = 120
+var SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS int64 = 30 // How often watchers are checked for due polls, 0 disables source watchers
+var SOURCE_WATCHERS_MIN_INTERVAL_SECONDS int64 = 60
+var SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS int64 = 300
+var DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS int64 = 2000 // Minimum time between the progress events of a download published through JD, 0 disables them
+var DOWNLOAD_SKIP_DUPLICATES bool = true              // Files whose content the target cluster already has are not uploaded again
+
+// An external downloader the service can shell out to. In arguments, '{url}' and '{output_directory}' are replaced
+// with the source url and the directory the files must be written to; if '{url}' is missing the url is appended.
+// ItemsPattern and PercentPattern are regular expressions matched against each line the executable prints, the first
+// one must have 'current' and 'total' named groups and the second a 'percent' group.
+type DownloaderBackendSettings struct {
+	Name           string   `json:"name"`
+	Executable     string   `json:"executable"`
+	Arguments      []string `json:"arguments"`
+	ItemsPattern   string   `json:"items_pattern"`
+	PercentPattern string   `json:"percent_pattern"`
+}
 
 func VerifyConfig() {
 
@@ -125,6 +154,98 @@ func loadSettings() error {
 		DOWNLOAD_USER_AGENT = service_settings["DOWNLOAD_USER_AGENT"].(string)
 	}
 
+	if _, exists := service_settings["DOWNLOAD_WORKERS"]; exists {
+		DOWNLOAD_WORKERS = int(service_settings["DOWNLOAD_WORKERS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_HOST_CONCURRENCY"]; exists {
+		DOWNLOAD_HOST_CONCURRENCY = int(service_settings["DOWNLOAD_HOST_CONCURRENCY"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_HOST_REQUESTS_PER_SECOND"]; exists {
+		DOWNLOAD_HOST_REQUESTS_PER_SECOND = service_settings["DOWNLOAD_HOST_REQUESTS_PER_SECOND"].(float64)
+	}
+
+	if _, exists := service_settings["DOWNLOAD_MAX_TRIES"]; exists {
+		DOWNLOAD_MAX_TRIES = int(service_settings["DOWNLOAD_MAX_TRIES"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_BACKOFF_BASE_MS"]; exists {
+		DOWNLOAD_BACKOFF_BASE_MS = int64(service_settings["DOWNLOAD_BACKOFF_BASE_MS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_BACKOFF_MAX_MS"]; exists {
+		DOWNLOAD_BACKOFF_MAX_MS = int64(service_settings["DOWNLOAD_BACKOFF_MAX_MS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_RETRY_AFTER_MAX_SECONDS"]; exists {
+		DOWNLOAD_RETRY_AFTER_MAX_SECONDS = int64(service_settings["DOWNLOAD_RETRY_AFTER_MAX_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_MAX_FILE_SIZE_MB"]; exists {
+		DOWNLOAD_MAX_FILE_SIZE_MB = int64(service_settings["DOWNLOAD_MAX_FILE_SIZE_MB"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_RESPONSE_TIMEOUT_SECONDS"]; exists {
+		DOWNLOAD_RESPONSE_TIMEOUT_SECONDS = int64(service_settings["DOWNLOAD_RESPONSE_TIMEOUT_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOADER_BACKENDS"]; exists {
+		backends_json, err := json.Marshal(service_settings["DOWNLOADER_BACKENDS"])
+		if err != nil {
+			return fmt.Errorf("While reading DOWNLOADER_BACKENDS, found error <%s>", err.Error())
+		}
+
+		err = json.Unmarshal(backends_json, &DOWNLOADER_BACKENDS)
+		if err != nil {
+			return fmt.Errorf("While reading DOWNLOADER_BACKENDS, found error <%s>", err.Error())
+		}
+	}
+
+	if _, exists := service_settings["DOWNLOADER_BACKENDS_CONCURRENCY"]; exists {
+		DOWNLOADER_BACKENDS_CONCURRENCY = int(service_settings["DOWNLOADER_BACKENDS_CONCURRENCY"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOADER_BACKENDS_TIMEOUT_MINUTES"]; exists {
+		DOWNLOADER_BACKENDS_TIMEOUT_MINUTES = int64(service_settings["DOWNLOADER_BACKENDS_TIMEOUT_MINUTES"].(float64))
+	}
+
+	if _, exists := service_settings["SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS"]; exists {
+		SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS = int64(service_settings["SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["SOURCE_WATCHERS_MIN_INTERVAL_SECONDS"]; exists {
+		SOURCE_WATCHERS_MIN_INTERVAL_SECONDS = int64(service_settings["SOURCE_WATCHERS_MIN_INTERVAL_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS"]; exists {
+		SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS = int64(service_settings["SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS"]; exists {
+		DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS = int64(service_settings["DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS"].(float64))
+	}
+
+	if _, exists := service_settings["DOWNLOAD_SKIP_DUPLICATES"]; exists {
+		DOWNLOAD_SKIP_DUPLICATES = service_settings["DOWNLOAD_SKIP_DUPLICATES"].(bool)
+	}
+
+	if DOWNLOADER_BACKENDS_CONCURRENCY < 1 {
+		DOWNLOADER_BACKENDS_CONCURRENCY = 1
+	}
+
+	if DOWNLOAD_WORKERS < 1 {
+		DOWNLOAD_WORKERS = 1
+	}
+
+	if DOWNLOAD_HOST_CONCURRENCY < 1 {
+		DOWNLOAD_HOST_CONCURRENCY = 1
+	}
+
+	if DOWNLOAD_MAX_TRIES < 1 {
+		DOWNLOAD_MAX_TRIES = 1
+	}
+
 	return nil
 }
 
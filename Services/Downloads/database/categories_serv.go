@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"libery-dungeon-libs/categories_service_pb"
+	dungeon_models "libery-dungeon-libs/models"
 	app_config "libery_downloads_service/Config"
 
 	"google.golang.org/grpc"
@@ -31,7 +32,7 @@ func (csc *CategoriesServiceConn) getCredentials() (credentials.TransportCredent
 	return creds, nil
 }
 
-func (csc *CategoriesServiceConn) CreateCategory(ctx context.Context, name string, parent_uuid string) (category_uuid string, err error) {
+func (csc *CategoriesServiceConn) CreateCategory(ctx context.Context, name string, parent_uuid string, cluster_uuid string) (category_uuid string, err error) {
 	creds, err := csc.getCredentials()
 	if err != nil {
 		return "", err
@@ -50,6 +51,7 @@ func (csc *CategoriesServiceConn) CreateCategory(ctx context.Context, name strin
 
 	request_data.Name = name
 	request_data.Parent = parent_uuid
+	request_data.Cluster = cluster_uuid
 
 	request, err := client.CreateCategory(ctx, request_data)
 	if err != nil {
@@ -58,3 +60,66 @@ func (csc *CategoriesServiceConn) CreateCategory(ctx context.Context, name strin
 
 	return request.Uuid, nil
 }
+
+func (csc *CategoriesServiceConn) GetCategoriesCluster(ctx context.Context, cluster_uuid string) (*dungeon_models.CategoryCluster, error) {
+	creds, err := csc.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(csc.CategoriesAddress, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to Categories Service: %s", err.Error())
+	}
+
+	defer conn.Close()
+
+	client := categories_service_pb.NewCategoriesServiceClient(conn)
+
+	request_data := new(categories_service_pb.GetCategoriesClusterRequest)
+
+	request_data.Uuid = cluster_uuid
+
+	response, err := client.GetCategoriesCluster(ctx, request_data)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting categories cluster: %s", err.Error())
+	}
+
+	var category_cluster *dungeon_models.CategoryCluster = new(dungeon_models.CategoryCluster)
+
+	category_cluster.Uuid = response.Cluster.GetUuid()
+	category_cluster.Name = response.Cluster.GetName()
+	category_cluster.FsPath = response.Cluster.GetFsPath()
+	category_cluster.FilterCategory = response.Cluster.GetFilterCategory()
+	category_cluster.RootCategory = response.Cluster.GetRootCategory()
+
+	return category_cluster, nil
+}
+
+// Returns the categories among the given ones that still exist.
+func (csc *CategoriesServiceConn) FilterExistingCategories(ctx context.Context, categories_uuids []string) ([]string, error) {
+	creds, err := csc.getCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.Dial(csc.CategoriesAddress, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("Error connecting to Categories Service: %s", err.Error())
+	}
+
+	defer conn.Close()
+
+	client := categories_service_pb.NewCategoriesServiceClient(conn)
+
+	request_data := new(categories_service_pb.UuidList)
+
+	request_data.Uuids = categories_uuids
+
+	response, err := client.FilterExistingCategories(ctx, request_data)
+	if err != nil {
+		return nil, fmt.Errorf("Error filtering existing categories: %s", err.Error())
+	}
+
+	return response.GetUuids(), nil
+}
@@ -0,0 +1,285 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"libery_downloads_service/models"
+	"strings"
+)
+
+// Downloads on the queue take their status from it, the rest from the status they left the queue with. Downloads
+// registered before statuses were recorded are considered completed.
+const download_history_source string = "FROM downloads d LEFT JOIN queued_downloads q ON q.id = d.id LEFT JOIN download_statuses s ON s.download = d.id"
+
+const download_history_status string = "COALESCE(q.status, s.status, 'completed')"
+
+func (download_db *DownloadDB) ensureDownloadHistorySchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS download_statuses (download TEXT PRIMARY KEY, status TEXT NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating download_statuses table: %s", err)
+	}
+
+	return nil
+}
+
+// Records the status a download left the queue with.
+func (download_db *DownloadDB) SetDownloadStatus(download_uuid string, status models.DownloadRequestStatus) error {
+	_, err := download_db.db.Exec("INSERT OR REPLACE INTO download_statuses(download, status) VALUES (?, ?)", download_uuid, string(status))
+	if err != nil {
+		return fmt.Errorf("Error setting download status: %s", err)
+	}
+
+	return nil
+}
+
+// Returns the downloads that match the filter, newest first, along with how many downloads match it in total.
+func (download_db *DownloadDB) SearchDownloadHistory(filter models.DownloadHistoryFilter) ([]models.DownloadHistoryEntry, int, error) {
+	var history_entries []models.DownloadHistoryEntry = make([]models.DownloadHistoryEntry, 0)
+	var total_matches int
+
+	conditions, condition_args := downloadHistoryConditions(filter)
+
+	err := download_db.db.QueryRow("SELECT count(*) "+download_history_source+conditions, condition_args...).Scan(&total_matches)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error counting download history matches: %s", err)
+	}
+
+	var search_query string = "SELECT d.id, d.download_timestamp, d.category_id, " + download_history_status + ", " +
+		"(SELECT count(*) FROM download_files f WHERE f.download = d.id), " +
+		"(SELECT count(*) FROM skipped_download_files k WHERE k.download = d.id) " +
+		download_history_source + conditions + " ORDER BY d.download_timestamp DESC" + limitClause(filter)
+
+	rows, err := download_db.db.Query(search_query, condition_args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Error searching download history: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var history_entry models.DownloadHistoryEntry
+
+		err = rows.Scan(&history_entry.DownloadUuid, &history_entry.DownloadTimestamp, &history_entry.CategoryUuid, &history_entry.Status, &history_entry.FileCount, &history_entry.SkippedFiles)
+		if err != nil {
+			return nil, 0, fmt.Errorf("Error scanning download history entry: %s", err)
+		}
+
+		history_entries = append(history_entries, history_entry)
+	}
+
+	return history_entries, total_matches, nil
+}
+
+// Returns the downloads that match the filter with the urls of their files, oldest first.
+func (download_db *DownloadDB) GetDownloadHistoryRecords(filter models.DownloadHistoryFilter) ([]models.DownloadHistoryRecord, error) {
+	var history_records []models.DownloadHistoryRecord = make([]models.DownloadHistoryRecord, 0)
+
+	conditions, condition_args := downloadHistoryConditions(filter)
+
+	rows, err := download_db.db.Query("SELECT d.id, d.download_timestamp, d.category_id, "+download_history_status+" "+download_history_source+conditions+" ORDER BY d.download_timestamp"+limitClause(filter), condition_args...)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting download history records: %s", err)
+	}
+
+	for rows.Next() {
+		var history_record models.DownloadHistoryRecord
+
+		err = rows.Scan(&history_record.DownloadUuid, &history_record.DownloadTimestamp, &history_record.CategoryUuid, &history_record.Status)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Error scanning download history record: %s", err)
+		}
+
+		history_records = append(history_records, history_record)
+	}
+
+	rows.Close()
+
+	for h := range history_records {
+		download_files, err := download_db.GetDownloadFiles(history_records[h].DownloadUuid)
+		if err != nil {
+			return nil, err
+		}
+
+		history_records[h].FileUrls = make([]string, len(download_files))
+
+		for k, download_file := range download_files {
+			history_records[h].FileUrls[k] = download_file.Url
+		}
+	}
+
+	return history_records, nil
+}
+
+// Adds the records to the download history. Downloads that are already on it only get the file urls they are missing.
+// Returns how many downloads and files were added.
+func (download_db *DownloadDB) ImportDownloadHistoryRecords(history_records []models.DownloadHistoryRecord) (int, int, error) {
+	var imported_downloads int = 0
+	var imported_files int = 0
+
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	for _, history_record := range history_records {
+		inserted_download, err := tx.Exec("INSERT OR IGNORE INTO downloads(id, download_timestamp, category_id) VALUES (?, ?, ?)", history_record.DownloadUuid, history_record.DownloadTimestamp, history_record.CategoryUuid)
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, fmt.Errorf("Error importing download '%s': %s", history_record.DownloadUuid, err)
+		}
+
+		if rows_affected, _ := inserted_download.RowsAffected(); rows_affected > 0 {
+			imported_downloads++
+
+			if history_record.Status != "" {
+				_, err = tx.Exec("INSERT OR REPLACE INTO download_statuses(download, status) VALUES (?, ?)", history_record.DownloadUuid, string(history_record.Status))
+				if err != nil {
+					tx.Rollback()
+					return 0, 0, fmt.Errorf("Error importing status of download '%s': %s", history_record.DownloadUuid, err)
+				}
+			}
+		}
+
+		added_files, err := importDownloadFileUrls(tx, history_record.DownloadUuid, history_record.FileUrls)
+		if err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+
+		imported_files += added_files
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, fmt.Errorf("Error committing download history import: %s", err)
+	}
+
+	return imported_downloads, imported_files, nil
+}
+
+func importDownloadFileUrls(tx *sql.Tx, download_uuid string, file_urls []string) (int, error) {
+	var added_files int = 0
+
+	for _, file_url := range file_urls {
+		inserted_file, err := tx.Exec("INSERT INTO download_files(download, url) SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM download_files WHERE download = ? AND url = ?)", download_uuid, file_url, download_uuid, file_url)
+		if err != nil {
+			return 0, fmt.Errorf("Error importing file '%s' of download '%s': %s", file_url, download_uuid, err)
+		}
+
+		if rows_affected, _ := inserted_file.RowsAffected(); rows_affected > 0 {
+			added_files++
+		}
+	}
+
+	return added_files, nil
+}
+
+// Removes the downloads registered before the given unix timestamp from the history, optionally only those of a
+// category. Downloads still on the queue and those of active source watchers are kept, otherwise their files would be
+// downloaded again. The medias the downloads produced can still be traced to their source url. Returns how many
+// downloads were removed.
+func (download_db *DownloadDB) DeleteDownloadHistory(before int64, category_uuid string) (int, error) {
+	var selection_query string = "SELECT id FROM downloads WHERE download_timestamp < ? AND id NOT IN (SELECT id FROM queued_downloads) AND id NOT IN (SELECT id FROM source_watchers WHERE status = ?)"
+	var selection_args []interface{} = []interface{}{before, string(models.SourceWatcherStatus_Active)}
+
+	if category_uuid != "" {
+		selection_query += " AND category_id = ?"
+		selection_args = append(selection_args, category_uuid)
+	}
+
+	rows, err := download_db.db.Query(selection_query, selection_args...)
+	if err != nil {
+		return 0, fmt.Errorf("Error selecting expired downloads: %s", err)
+	}
+
+	var expired_downloads []string = make([]string, 0)
+
+	for rows.Next() {
+		var download_uuid string
+
+		err = rows.Scan(&download_uuid)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("Error scanning expired download: %s", err)
+		}
+
+		expired_downloads = append(expired_downloads, download_uuid)
+	}
+
+	rows.Close()
+
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	var history_tables []string = []string{"download_files", "download_statuses", "skipped_download_files", "external_download_files"}
+
+	for _, download_uuid := range expired_downloads {
+		for _, history_table := range history_tables {
+			_, err = tx.Exec("DELETE FROM "+history_table+" WHERE download = ?", download_uuid)
+			if err != nil {
+				tx.Rollback()
+				return 0, fmt.Errorf("Error deleting %s of download '%s': %s", history_table, download_uuid, err)
+			}
+		}
+
+		_, err = tx.Exec("DELETE FROM downloads WHERE id = ?", download_uuid)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("Error deleting download '%s': %s", download_uuid, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("Error committing download history deletion: %s", err)
+	}
+
+	return len(expired_downloads), nil
+}
+
+func downloadHistoryConditions(filter models.DownloadHistoryFilter) (string, []interface{}) {
+	var conditions []string = make([]string, 0)
+	var condition_args []interface{} = make([]interface{}, 0)
+
+	if filter.UrlSubstring != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM download_files f WHERE f.download = d.id AND instr(lower(f.url), lower(?)) > 0)")
+		condition_args = append(condition_args, filter.UrlSubstring)
+	}
+
+	if filter.CategoryUuid != "" {
+		conditions = append(conditions, "d.category_id = ?")
+		condition_args = append(condition_args, filter.CategoryUuid)
+	}
+
+	if filter.Status != "" {
+		conditions = append(conditions, download_history_status+" = ?")
+		condition_args = append(condition_args, string(filter.Status))
+	}
+
+	if filter.From > 0 {
+		conditions = append(conditions, "d.download_timestamp >= ?")
+		condition_args = append(condition_args, filter.From)
+	}
+
+	if filter.To > 0 {
+		conditions = append(conditions, "d.download_timestamp <= ?")
+		condition_args = append(condition_args, filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "", condition_args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), condition_args
+}
+
+func limitClause(filter models.DownloadHistoryFilter) string {
+	if filter.Limit <= 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", filter.Limit, filter.Offset)
+}
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery_downloads_service/models"
+)
+
+// The download queue tables are not part of the downloads schema file, which is only written when the database
+// file is created, so they are created here for new and old databases alike.
+func (download_db *DownloadDB) ensureDownloadQueueSchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS queued_downloads (id TEXT PRIMARY KEY, category_id TEXT NOT NULL, category_cluster TEXT NOT NULL, status TEXT NOT NULL, enqueued_at INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating queued_downloads table: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE TABLE IF NOT EXISTS queued_download_files (download TEXT NOT NULL, position INTEGER NOT NULL, url TEXT NOT NULL, is_downloaded INTEGER NOT NULL DEFAULT 0, failed INTEGER NOT NULL DEFAULT 0, trys INTEGER NOT NULL DEFAULT 0, PRIMARY KEY(download, position), FOREIGN KEY(download) REFERENCES queued_downloads(id) ON DELETE CASCADE)")
+	if err != nil {
+		return fmt.Errorf("Error creating queued_download_files table: %s", err)
+	}
+
+	return nil
+}
+
+// Persists a download request that was just added to the queue, along with its files.
+func (download_db *DownloadDB) InsertQueuedDownload(download *models.DownloadRequest) error {
+	category_cluster, err := json.Marshal(download.CategoryCluster)
+	if err != nil {
+		return fmt.Errorf("Error encoding category cluster of queued download: %s", err)
+	}
+
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	_, err = tx.Exec("INSERT INTO queued_downloads(id, category_id, category_cluster, status, enqueued_at) VALUES (?, ?, ?, ?, ?)", download.DownloadUuid, download.CategoryUuid, string(category_cluster), string(download.Status), download.EnqueuedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error inserting queued download: %s", err)
+	}
+
+	err = insertQueuedDownloadFiles(tx, download.DownloadUuid, download.DownloadFiles(), 0)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Persists files appended to a download request that was already on the queue. first_position is the index of the first appended file.
+func (download_db *DownloadDB) AppendQueuedDownloadFiles(download_uuid string, new_files []models.DownloadFile, first_position int) error {
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	err = insertQueuedDownloadFiles(tx, download_uuid, new_files, first_position)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertQueuedDownloadFiles(tx *sql.Tx, download_uuid string, download_files []models.DownloadFile, first_position int) error {
+	stmt, err := tx.Prepare("INSERT INTO queued_download_files(download, position, url, is_downloaded, failed, trys) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("Error preparing statement for insert queued download files: %s", err)
+	}
+
+	defer stmt.Close()
+
+	for h, download_file := range download_files {
+		_, err = stmt.Exec(download_uuid, first_position+h, download_file.Url, download_file.IsDownloaded, download_file.Failed, download_file.Trys)
+		if err != nil {
+			return fmt.Errorf("Error inserting queued download file '%s': %s", download_file.Url, err)
+		}
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) UpdateQueuedDownloadStatus(download_uuid string, status models.DownloadRequestStatus) error {
+	_, err := download_db.db.Exec("UPDATE queued_downloads SET status = ? WHERE id = ?", string(status), download_uuid)
+	if err != nil {
+		return fmt.Errorf("Error updating queued download status: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) UpdateQueuedDownloadFile(download_uuid string, position int, download_file models.DownloadFile) error {
+	_, err := download_db.db.Exec("UPDATE queued_download_files SET is_downloaded = ?, failed = ?, trys = ? WHERE download = ? AND position = ?", download_file.IsDownloaded, download_file.Failed, download_file.Trys, download_uuid, position)
+	if err != nil {
+		return fmt.Errorf("Error updating queued download file '%s': %s", download_file.Url, err)
+	}
+
+	return nil
+}
+
+// Removes a download request and its files from the persisted queue.
+func (download_db *DownloadDB) DeleteQueuedDownload(download_uuid string) error {
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	// The foreign key cascade depends on the connection having foreign keys enabled, so the files are deleted explicitly.
+	_, err = tx.Exec("DELETE FROM queued_download_files WHERE download = ?", download_uuid)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error deleting queued download files: %s", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM queued_downloads WHERE id = ?", download_uuid)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error deleting queued download: %s", err)
+	}
+
+	return tx.Commit()
+}
+
+// Returns every persisted download request in the order they were enqueued.
+func (download_db *DownloadDB) GetQueuedDownloads() ([]*models.DownloadRequest, error) {
+	var queued_downloads []*models.DownloadRequest = make([]*models.DownloadRequest, 0)
+
+	rows, err := download_db.db.Query("SELECT id, category_id, category_cluster, status, enqueued_at FROM queued_downloads ORDER BY enqueued_at, rowid")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting queued downloads: %s", err)
+	}
+
+	type queuedDownloadRow struct {
+		download_uuid    string
+		category_uuid    string
+		category_cluster string
+		status           string
+		enqueued_at      int64
+	}
+
+	var download_rows []queuedDownloadRow = make([]queuedDownloadRow, 0)
+
+	for rows.Next() {
+		var download_row queuedDownloadRow
+
+		err = rows.Scan(&download_row.download_uuid, &download_row.category_uuid, &download_row.category_cluster, &download_row.status, &download_row.enqueued_at)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("Error scanning queued download: %s", err)
+		}
+
+		download_rows = append(download_rows, download_row)
+	}
+	rows.Close()
+
+	for _, download_row := range download_rows {
+		var category_cluster *dungeon_models.CategoryCluster = new(dungeon_models.CategoryCluster)
+
+		err = json.Unmarshal([]byte(download_row.category_cluster), category_cluster)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding category cluster of queued download '%s': %s", download_row.download_uuid, err)
+		}
+
+		download_files, err := download_db.getQueuedDownloadFiles(download_row.download_uuid)
+		if err != nil {
+			return nil, err
+		}
+
+		queued_download := models.RestoreDownloadRequest(download_row.download_uuid, download_row.category_uuid, category_cluster, models.DownloadRequestStatus(download_row.status), download_row.enqueued_at, download_files)
+
+		queued_downloads = append(queued_downloads, queued_download)
+	}
+
+	return queued_downloads, nil
+}
+
+func (download_db *DownloadDB) getQueuedDownloadFiles(download_uuid string) ([]models.DownloadFile, error) {
+	var download_files []models.DownloadFile = make([]models.DownloadFile, 0)
+
+	rows, err := download_db.db.Query("SELECT url, is_downloaded, failed, trys FROM queued_download_files WHERE download = ? ORDER BY position", download_uuid)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting queued download files: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var download_file models.DownloadFile
+
+		err = rows.Scan(&download_file.Url, &download_file.IsDownloaded, &download_file.Failed, &download_file.Trys)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning queued download file: %s", err)
+		}
+
+		download_files = append(download_files, download_file)
+	}
+
+	return download_files, nil
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"libery_downloads_service/models"
+	"time"
+)
+
+func (download_db *DownloadDB) ensureDownloadedMediasSchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS downloaded_medias (id INTEGER PRIMARY KEY AUTOINCREMENT, download TEXT NOT NULL, source_url TEXT NOT NULL, media_uuid TEXT NOT NULL DEFAULT '', downloaded_at INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		return fmt.Errorf("Error creating downloaded_medias table: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE INDEX IF NOT EXISTS downloaded_medias_download ON downloaded_medias(download)")
+	if err != nil {
+		return fmt.Errorf("Error creating downloaded_medias download index: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE INDEX IF NOT EXISTS downloaded_medias_media ON downloaded_medias(media_uuid)")
+	if err != nil {
+		return fmt.Errorf("Error creating downloaded_medias media index: %s", err)
+	}
+
+	return nil
+}
+
+// Creates the record of a media before it is uploaded, so its id can be sent along as the DownloadedFrom of the media.
+// The record is completed with CompleteDownloadedMedia once the medias service tells the uuid of the media.
+func (download_db *DownloadDB) ReserveDownloadedMedia(download_uuid string, source_url string) (int64, error) {
+	result, err := download_db.db.Exec("INSERT INTO downloaded_medias(download, source_url) VALUES (?, ?)", download_uuid, source_url)
+	if err != nil {
+		return 0, fmt.Errorf("Error inserting downloaded media: %s", err)
+	}
+
+	return result.LastInsertId()
+}
+
+func (download_db *DownloadDB) CompleteDownloadedMedia(downloaded_media_id int64, media_uuid string) error {
+	_, err := download_db.db.Exec("UPDATE downloaded_medias SET media_uuid = ?, downloaded_at = ? WHERE id = ?", media_uuid, time.Now().Unix(), downloaded_media_id)
+	if err != nil {
+		return fmt.Errorf("Error updating downloaded media: %s", err)
+	}
+
+	return nil
+}
+
+// Records that a download produced a media which already existed, so the media is listed among the medias of the download.
+func (download_db *DownloadDB) LinkDownloadedMedia(download_uuid string, source_url string, media_uuid string) error {
+	_, err := download_db.db.Exec("INSERT INTO downloaded_medias(download, source_url, media_uuid, downloaded_at) VALUES (?, ?, ?, ?)", download_uuid, source_url, media_uuid, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("Error linking downloaded media: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) DeleteDownloadedMedia(downloaded_media_id int64) error {
+	_, err := download_db.db.Exec("DELETE FROM downloaded_medias WHERE id = ?", downloaded_media_id)
+	if err != nil {
+		return fmt.Errorf("Error deleting downloaded media: %s", err)
+	}
+
+	return nil
+}
+
+// Returns the record of the download that created the media, or nil if the media wasn't uploaded by the downloads service.
+func (download_db *DownloadDB) GetDownloadedMedia(media_uuid string) (*models.DownloadedMedia, error) {
+	var downloaded_media *models.DownloadedMedia = new(models.DownloadedMedia)
+
+	err := download_db.db.QueryRow("SELECT id, media_uuid, download, source_url, downloaded_at FROM downloaded_medias WHERE media_uuid = ? AND media_uuid != '' ORDER BY id LIMIT 1", media_uuid).
+		Scan(&downloaded_media.Id, &downloaded_media.MediaUuid, &downloaded_media.DownloadUuid, &downloaded_media.SourceUrl, &downloaded_media.DownloadedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Error getting downloaded media: %s", err)
+	}
+
+	return downloaded_media, nil
+}
+
+// Returns the medias a download produced, in the order they were uploaded.
+func (download_db *DownloadDB) GetDownloadMedias(download_uuid string) ([]models.DownloadedMedia, error) {
+	var downloaded_medias []models.DownloadedMedia = make([]models.DownloadedMedia, 0)
+
+	rows, err := download_db.db.Query("SELECT id, media_uuid, download, source_url, downloaded_at FROM downloaded_medias WHERE download = ? AND media_uuid != '' ORDER BY id", download_uuid)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting download medias: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var downloaded_media models.DownloadedMedia
+
+		err = rows.Scan(&downloaded_media.Id, &downloaded_media.MediaUuid, &downloaded_media.DownloadUuid, &downloaded_media.SourceUrl, &downloaded_media.DownloadedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning downloaded media: %s", err)
+		}
+
+		downloaded_medias = append(downloaded_medias, downloaded_media)
+	}
+
+	return downloaded_medias, nil
+}
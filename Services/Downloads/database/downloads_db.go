@@ -23,6 +23,41 @@ func NewDownloadDB() (*DownloadDB, error) {
 
 	download_db.db = db
 
+	err = download_db.ensureDownloadQueueSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureExternalDownloadsSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureSourceWatchersSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureDownloadedMediasSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureSkippedDownloadFilesSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureDownloadHistorySchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = download_db.ensureRoutingRulesSchema()
+	if err != nil {
+		return nil, err
+	}
+
 	return download_db, nil
 }
 
@@ -65,9 +100,15 @@ func (download_db *DownloadDB) GetDownload(download_uuid string) (*models.Regist
 		return nil, err
 	}
 
+	skipped_files, err := download_db.CountSkippedDownloadFiles(download_uuid)
+	if err != nil {
+		return nil, err
+	}
+
 	download.DownloadUuid = download_uuid
 	download.DownloadTimestamp = time.Unix(download_timestamp, 0)
 	download.DownloadFiles = download_files
+	download.SkippedFiles = skipped_files
 
 	return download, nil
 }
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"fmt"
+	"libery_downloads_service/models"
+)
+
+func (download_db *DownloadDB) ensureExternalDownloadsSchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS external_download_files (id INTEGER PRIMARY KEY AUTOINCREMENT, download TEXT NOT NULL, source_url TEXT NOT NULL, backend TEXT NOT NULL, filename TEXT NOT NULL, imported INTEGER NOT NULL DEFAULT 0, imported_at INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		return fmt.Errorf("Error creating external_download_files table: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE INDEX IF NOT EXISTS external_download_files_download ON external_download_files(download)")
+	if err != nil {
+		return fmt.Errorf("Error creating external_download_files index: %s", err)
+	}
+
+	return nil
+}
+
+// Records the files a downloader backend produced for a download.
+func (download_db *DownloadDB) InsertExternalDownloadFiles(external_files []models.ExternalDownloadFile) error {
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO external_download_files(download, source_url, backend, filename, imported, imported_at) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error preparing statement for insert external download files: %s", err)
+	}
+
+	defer stmt.Close()
+
+	for _, external_file := range external_files {
+		_, err = stmt.Exec(external_file.DownloadUuid, external_file.SourceUrl, external_file.Backend, external_file.Filename, external_file.Imported, external_file.ImportedAt)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Error inserting external download file '%s': %s", external_file.Filename, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (download_db *DownloadDB) GetExternalDownloadFiles(download_uuid string) ([]models.ExternalDownloadFile, error) {
+	var external_files []models.ExternalDownloadFile = make([]models.ExternalDownloadFile, 0)
+
+	rows, err := download_db.db.Query("SELECT download, source_url, backend, filename, imported, imported_at FROM external_download_files WHERE download = ? ORDER BY id", download_uuid)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting external download files: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var external_file models.ExternalDownloadFile
+
+		err = rows.Scan(&external_file.DownloadUuid, &external_file.SourceUrl, &external_file.Backend, &external_file.Filename, &external_file.Imported, &external_file.ImportedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning external download file: %s", err)
+		}
+
+		external_files = append(external_files, external_file)
+	}
+
+	return external_files, nil
+}
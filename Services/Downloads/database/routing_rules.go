@@ -0,0 +1,123 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"libery_downloads_service/models"
+)
+
+func (download_db *DownloadDB) ensureRoutingRulesSchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS routing_rules (id TEXT PRIMARY KEY, cluster TEXT NOT NULL, priority INTEGER NOT NULL, match_kind TEXT NOT NULL, pattern TEXT NOT NULL, target_category TEXT NOT NULL DEFAULT '', target_category_name TEXT NOT NULL DEFAULT '', target_parent TEXT NOT NULL DEFAULT '', tag_ids TEXT NOT NULL DEFAULT '[]', created_at INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating routing_rules table: %s", err)
+	}
+
+	// The categories created for rules that target a category by name, one per parent category.
+	_, err = download_db.db.Exec("CREATE TABLE IF NOT EXISTS routing_rule_categories (rule TEXT NOT NULL, parent TEXT NOT NULL, category TEXT NOT NULL, PRIMARY KEY(rule, parent))")
+	if err != nil {
+		return fmt.Errorf("Error creating routing_rule_categories table: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) InsertRoutingRule(routing_rule *models.RoutingRule) error {
+	tag_ids, err := json.Marshal(routing_rule.TagIds)
+	if err != nil {
+		return fmt.Errorf("Error encoding tags of routing rule: %s", err)
+	}
+
+	_, err = download_db.db.Exec("INSERT INTO routing_rules(id, cluster, priority, match_kind, pattern, target_category, target_category_name, target_parent, tag_ids, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		routing_rule.Uuid, routing_rule.ClusterUuid, routing_rule.Priority, string(routing_rule.MatchKind), routing_rule.Pattern, routing_rule.TargetCategoryUuid, routing_rule.TargetCategoryName, routing_rule.TargetParentUuid, string(tag_ids), routing_rule.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("Error inserting routing rule: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) DeleteRoutingRule(rule_uuid string) error {
+	tx, err := download_db.db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error starting transaction: %s", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM routing_rule_categories WHERE rule = ?", rule_uuid)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error deleting categories of routing rule: %s", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM routing_rules WHERE id = ?", rule_uuid)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("Error deleting routing rule: %s", err)
+	}
+
+	return tx.Commit()
+}
+
+// Returns the routing rules of a cluster in the order they are evaluated. All the rules if cluster_uuid is empty.
+func (download_db *DownloadDB) GetRoutingRules(cluster_uuid string) ([]*models.RoutingRule, error) {
+	var routing_rules []*models.RoutingRule = make([]*models.RoutingRule, 0)
+
+	var rows *sql.Rows
+	var err error
+
+	if cluster_uuid == "" {
+		rows, err = download_db.db.Query("SELECT id, cluster, priority, match_kind, pattern, target_category, target_category_name, target_parent, tag_ids, created_at FROM routing_rules ORDER BY cluster, priority, created_at")
+	} else {
+		rows, err = download_db.db.Query("SELECT id, cluster, priority, match_kind, pattern, target_category, target_category_name, target_parent, tag_ids, created_at FROM routing_rules WHERE cluster = ? ORDER BY priority, created_at", cluster_uuid)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error getting routing rules: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var routing_rule *models.RoutingRule = new(models.RoutingRule)
+		var tag_ids string
+
+		err = rows.Scan(&routing_rule.Uuid, &routing_rule.ClusterUuid, &routing_rule.Priority, &routing_rule.MatchKind, &routing_rule.Pattern, &routing_rule.TargetCategoryUuid, &routing_rule.TargetCategoryName, &routing_rule.TargetParentUuid, &tag_ids, &routing_rule.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning routing rule: %s", err)
+		}
+
+		err = json.Unmarshal([]byte(tag_ids), &routing_rule.TagIds)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding tags of routing rule '%s': %s", routing_rule.Uuid, err)
+		}
+
+		routing_rules = append(routing_rules, routing_rule)
+	}
+
+	return routing_rules, nil
+}
+
+// Returns the category created for a rule under the given parent, or an empty string if none was created yet.
+func (download_db *DownloadDB) GetRoutingRuleCategory(rule_uuid string, parent_uuid string) (string, error) {
+	var category_uuid string
+
+	err := download_db.db.QueryRow("SELECT category FROM routing_rule_categories WHERE rule = ? AND parent = ?", rule_uuid, parent_uuid).Scan(&category_uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("Error getting routing rule category: %s", err)
+	}
+
+	return category_uuid, nil
+}
+
+func (download_db *DownloadDB) SetRoutingRuleCategory(rule_uuid string, parent_uuid string, category_uuid string) error {
+	_, err := download_db.db.Exec("INSERT OR REPLACE INTO routing_rule_categories(rule, parent, category) VALUES (?, ?, ?)", rule_uuid, parent_uuid, category_uuid)
+	if err != nil {
+		return fmt.Errorf("Error setting routing rule category: %s", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+	"libery_downloads_service/models"
+)
+
+// The content of the cluster medias is looked up on the medias service, the media_content_hashes table older versions kept is dropped.
+func (download_db *DownloadDB) ensureSkippedDownloadFilesSchema() error {
+	_, err := download_db.db.Exec("DROP TABLE IF EXISTS media_content_hashes")
+	if err != nil {
+		return fmt.Errorf("Error dropping media_content_hashes table: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE TABLE IF NOT EXISTS skipped_download_files (id INTEGER PRIMARY KEY AUTOINCREMENT, download TEXT NOT NULL, source_url TEXT NOT NULL, content_hash TEXT NOT NULL, duplicate_of TEXT NOT NULL, skipped_at INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating skipped_download_files table: %s", err)
+	}
+
+	_, err = download_db.db.Exec("CREATE INDEX IF NOT EXISTS skipped_download_files_download ON skipped_download_files(download)")
+	if err != nil {
+		return fmt.Errorf("Error creating skipped_download_files index: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) InsertSkippedDownloadFile(skipped_file models.SkippedDownloadFile) error {
+	_, err := download_db.db.Exec("INSERT INTO skipped_download_files(download, source_url, content_hash, duplicate_of, skipped_at) VALUES (?, ?, ?, ?, ?)",
+		skipped_file.DownloadUuid, skipped_file.SourceUrl, skipped_file.ContentHash, skipped_file.DuplicateOf, skipped_file.SkippedAt)
+	if err != nil {
+		return fmt.Errorf("Error inserting skipped download file: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) GetSkippedDownloadFiles(download_uuid string) ([]models.SkippedDownloadFile, error) {
+	var skipped_files []models.SkippedDownloadFile = make([]models.SkippedDownloadFile, 0)
+
+	rows, err := download_db.db.Query("SELECT download, source_url, content_hash, duplicate_of, skipped_at FROM skipped_download_files WHERE download = ? ORDER BY id", download_uuid)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting skipped download files: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var skipped_file models.SkippedDownloadFile
+
+		err = rows.Scan(&skipped_file.DownloadUuid, &skipped_file.SourceUrl, &skipped_file.ContentHash, &skipped_file.DuplicateOf, &skipped_file.SkippedAt)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning skipped download file: %s", err)
+		}
+
+		skipped_files = append(skipped_files, skipped_file)
+	}
+
+	return skipped_files, nil
+}
+
+func (download_db *DownloadDB) CountSkippedDownloadFiles(download_uuid string) (int, error) {
+	var skipped_count int
+
+	err := download_db.db.QueryRow("SELECT count(*) FROM skipped_download_files WHERE download = ?", download_uuid).Scan(&skipped_count)
+	if err != nil {
+		return 0, fmt.Errorf("Error counting skipped download files: %s", err)
+	}
+
+	return skipped_count, nil
+}
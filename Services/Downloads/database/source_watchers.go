@@ -0,0 +1,86 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery_downloads_service/models"
+)
+
+func (download_db *DownloadDB) ensureSourceWatchersSchema() error {
+	_, err := download_db.db.Exec("CREATE TABLE IF NOT EXISTS source_watchers (id TEXT PRIMARY KEY, source_url TEXT NOT NULL, source_kind TEXT NOT NULL, category_id TEXT NOT NULL, category_cluster TEXT NOT NULL, interval_seconds INTEGER NOT NULL, status TEXT NOT NULL, stop_reason TEXT NOT NULL DEFAULT '', created_at INTEGER NOT NULL, last_polled_at INTEGER NOT NULL DEFAULT 0, consecutive_failures INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		return fmt.Errorf("Error creating source_watchers table: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) InsertSourceWatcher(source_watcher *models.SourceWatcher) error {
+	category_cluster, err := json.Marshal(source_watcher.CategoryCluster)
+	if err != nil {
+		return fmt.Errorf("Error encoding category cluster of source watcher: %s", err)
+	}
+
+	_, err = download_db.db.Exec("INSERT INTO source_watchers(id, source_url, source_kind, category_id, category_cluster, interval_seconds, status, stop_reason, created_at, last_polled_at, consecutive_failures) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		source_watcher.Uuid, source_watcher.SourceUrl, string(source_watcher.SourceKind), source_watcher.CategoryUuid, string(category_cluster), source_watcher.IntervalSeconds, string(source_watcher.Status), source_watcher.StopReason, source_watcher.CreatedAt, source_watcher.LastPolledAt, source_watcher.ConsecutiveFailures)
+	if err != nil {
+		return fmt.Errorf("Error inserting source watcher: %s", err)
+	}
+
+	return nil
+}
+
+// Saves the state a poll changes: status, stop reason, poll time and failures.
+func (download_db *DownloadDB) UpdateSourceWatcherState(source_watcher *models.SourceWatcher) error {
+	_, err := download_db.db.Exec("UPDATE source_watchers SET status = ?, stop_reason = ?, last_polled_at = ?, consecutive_failures = ? WHERE id = ?",
+		string(source_watcher.Status), source_watcher.StopReason, source_watcher.LastPolledAt, source_watcher.ConsecutiveFailures, source_watcher.Uuid)
+	if err != nil {
+		return fmt.Errorf("Error updating source watcher: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) DeleteSourceWatcher(watcher_uuid string) error {
+	_, err := download_db.db.Exec("DELETE FROM source_watchers WHERE id = ?", watcher_uuid)
+	if err != nil {
+		return fmt.Errorf("Error deleting source watcher: %s", err)
+	}
+
+	return nil
+}
+
+func (download_db *DownloadDB) GetSourceWatchers() ([]*models.SourceWatcher, error) {
+	var source_watchers []*models.SourceWatcher = make([]*models.SourceWatcher, 0)
+
+	rows, err := download_db.db.Query("SELECT id, source_url, source_kind, category_id, category_cluster, interval_seconds, status, stop_reason, created_at, last_polled_at, consecutive_failures FROM source_watchers ORDER BY created_at")
+	if err != nil {
+		return nil, fmt.Errorf("Error getting source watchers: %s", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var source_watcher *models.SourceWatcher = new(models.SourceWatcher)
+		var category_cluster string
+
+		err = rows.Scan(&source_watcher.Uuid, &source_watcher.SourceUrl, &source_watcher.SourceKind, &source_watcher.CategoryUuid, &category_cluster, &source_watcher.IntervalSeconds, &source_watcher.Status, &source_watcher.StopReason, &source_watcher.CreatedAt, &source_watcher.LastPolledAt, &source_watcher.ConsecutiveFailures)
+		if err != nil {
+			return nil, fmt.Errorf("Error scanning source watcher: %s", err)
+		}
+
+		source_watcher.CategoryCluster = new(dungeon_models.CategoryCluster)
+
+		err = json.Unmarshal([]byte(category_cluster), source_watcher.CategoryCluster)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding category cluster of source watcher '%s': %s", source_watcher.Uuid, err)
+		}
+
+		source_watcher.ClusterUuid = source_watcher.CategoryCluster.Uuid
+
+		source_watchers = append(source_watchers, source_watcher)
+	}
+
+	return source_watchers, nil
+}
@@ -11,6 +11,7 @@ import (
 	"libery_downloads_service/server"
 	"libery_downloads_service/workflows/jobs"
 	"libery_downloads_service/workflows/workers"
+	"time"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -21,6 +22,8 @@ func BinderRoutes(server libery_networking.Server, router *patriot_router.Router
 	router.RegisterRoute(patriot_router.NewRoute("/download-history(/.+)?$", false), handlers.DownloadHistoryHandler(server))
 	router.RegisterRoute(patriot_router.NewRoute("/ws/download-progress", true), handlers.DownloadProgressHandler(server))
 	router.RegisterRoute(patriot_router.NewRoute("/downloads(/.+)?$", false), handlers.DownloadsHandler(server))
+	router.RegisterRoute(patriot_router.NewRoute("/source-watchers(/.+)?$", false), handlers.SourceWatchersHandler(server))
+	router.RegisterRoute(patriot_router.NewRoute("/routing-rules(/.+)?$", false), handlers.RoutingRulesHandler(server))
 }
 
 func SetGrpcServers(server libery_networking.GrpcServer) {
@@ -44,12 +47,22 @@ func main() {
 	}
 
 	repository.SetDownloadsRepository(downloads_repo)
+	repository.SetSourceWatchersRepository(downloads_repo)
+	repository.SetRoutingRulesRepository(downloads_repo)
 
 	// ------ WORKERS ------
 
+	workers.RegisterConfiguredDownloaderBackends()
+
 	downloads_worker := workers.NewAsyncDownloader()
 	jobs.SetDownloadWorkerImplementation(downloads_worker)
 
+	var source_watchers_monitor *workers.SourceWatchersMonitor
+
+	if app_config.SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS > 0 {
+		source_watchers_monitor = workers.NewSourceWatchersMonitor(time.Duration(app_config.SOURCE_WATCHERS_CHECK_INTERVAL_SECONDS) * time.Second)
+	}
+
 	// ------ HTTP SERVER ------
 
 	var new_server_config *libery_networking.ServerConfig = new(libery_networking.ServerConfig)
@@ -72,7 +85,14 @@ func main() {
 
 	microservice.SetGrpcServer(downloads_grpc_server)
 
-	microservice.OnAfterShutdown(app_config.ClosePlatformCommunication)
+	microservice.OnAfterShutdown(func() {
+		if source_watchers_monitor != nil {
+			source_watchers_monitor.Stop()
+		}
+
+		downloads_worker.Stop()
+		app_config.ClosePlatformCommunication()
+	})
 
 	microservice.StartServer(BinderRoutes)
 }
@@ -3,13 +3,24 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
+	"libery_downloads_service/models"
 	"libery_downloads_service/repository"
+	"libery_downloads_service/workflows"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
+const history_search_default_limit int = 50
+
+const history_search_max_limit int = 500
+
 func DownloadHistoryHandler(service_instance libery_networking.Server) http.HandlerFunc {
 	return func(response http.ResponseWriter, request *http.Request) {
 		switch request.Method {
@@ -36,6 +47,18 @@ func getDownloadHistoryHandler(response http.ResponseWriter, request *http.Reque
 
 	if resource_path == "/download-history/download" {
 		getDownloadHandler(response, request)
+	} else if resource_path == "/download-history/external-files" {
+		getExternalDownloadFilesHandler(response, request)
+	} else if resource_path == "/download-history/media-source" {
+		getMediaSourceHandler(response, request)
+	} else if resource_path == "/download-history/download-medias" {
+		getDownloadMediasHandler(response, request)
+	} else if resource_path == "/download-history/skipped-files" {
+		getSkippedDownloadFilesHandler(response, request)
+	} else if resource_path == "/download-history/search" {
+		getDownloadHistorySearchHandler(response, request)
+	} else if resource_path == "/download-history/export" {
+		dungeon_middlewares.CheckUserCan_DownloadFiles(getDownloadHistoryExportHandler)(response, request)
 	} else {
 		echo.Echo(echo.RedBG, fmt.Sprintf("Resource not found: %s", resource_path))
 		response.WriteHeader(404)
@@ -63,9 +86,11 @@ func getDownloadHandler(response http.ResponseWriter, request *http.Request) {
 	response_body := &struct {
 		Exists        bool `json:"exists"`
 		DownloadCount int  `json:"download_count"`
+		SkippedCount  int  `json:"skipped_count"`
 	}{
 		Exists:        false,
 		DownloadCount: 0,
+		SkippedCount:  0,
 	}
 
 	if download_exists {
@@ -79,6 +104,7 @@ func getDownloadHandler(response http.ResponseWriter, request *http.Request) {
 
 		response_body.Exists = true
 		response_body.DownloadCount = len(registered_download.DownloadFiles)
+		response_body.SkippedCount = registered_download.SkippedFiles
 	}
 
 	response.Header().Set("Content-Type", "application/json")
@@ -87,18 +113,322 @@ func getDownloadHandler(response http.ResponseWriter, request *http.Request) {
 	err = json.NewEncoder(response).Encode(response_body)
 }
 
+// Returns the files a downloader backend produced for a download, with the source url and backend they came from.
+func getExternalDownloadFilesHandler(response http.ResponseWriter, request *http.Request) {
+	var download_uuid string = request.URL.Query().Get("download_uuid")
+
+	if download_uuid == "" {
+		echo.Echo(echo.YellowFG, "Missing download_uuid parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	external_files, err := repository.Downloads.GetExternalDownloadFiles(download_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error getting external download files: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(external_files)
+}
+
+// Returns the download and source url a media came from. Responds 404 if the media wasn't downloaded.
+func getMediaSourceHandler(response http.ResponseWriter, request *http.Request) {
+	var media_uuid string = request.URL.Query().Get("media_uuid")
+
+	if media_uuid == "" {
+		echo.Echo(echo.YellowFG, "Missing media_uuid parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	downloaded_media, err := repository.Downloads.GetDownloadedMedia(media_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error getting downloaded media: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	if downloaded_media == nil {
+		response.WriteHeader(404)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(downloaded_media)
+}
+
+// Returns the medias a download produced with their current location. Medias that were deleted since have a null
+// media_identity.
+func getDownloadMediasHandler(response http.ResponseWriter, request *http.Request) {
+	var download_uuid string = request.URL.Query().Get("download_uuid")
+
+	if download_uuid == "" {
+		echo.Echo(echo.YellowFG, "Missing download_uuid parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	located_medias, err := workflows.LocateDownloadMedias(download_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error getting download medias: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(located_medias)
+}
+
+// Returns the files of a download that were not uploaded because the cluster already had a media with their content,
+// with the media each of them duplicates.
+func getSkippedDownloadFilesHandler(response http.ResponseWriter, request *http.Request) {
+	var download_uuid string = request.URL.Query().Get("download_uuid")
+
+	if download_uuid == "" {
+		echo.Echo(echo.YellowFG, "Missing download_uuid parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	skipped_files, err := repository.Downloads.GetSkippedDownloadFiles(download_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error getting skipped download files: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(skipped_files)
+}
+
+// Searches the download history. Takes the optional url, category_uuid, status, from and to parameters, from and to
+// being unix timestamps in seconds, and paginates with limit and offset.
+func getDownloadHistorySearchHandler(response http.ResponseWriter, request *http.Request) {
+	history_filter, err := parseDownloadHistoryFilter(request)
+	if err != nil {
+		dungeon_helpers.WriteRejection(response, 400, err.Error())
+		return
+	}
+
+	if history_filter.Limit <= 0 {
+		history_filter.Limit = history_search_default_limit
+	} else if history_filter.Limit > history_search_max_limit {
+		history_filter.Limit = history_search_max_limit
+	}
+
+	history_entries, total_matches, err := repository.Downloads.SearchDownloadHistory(history_filter)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error searching download history: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response_body := &struct {
+		Downloads    []models.DownloadHistoryEntry `json:"downloads"`
+		TotalMatches int                           `json:"total_matches"`
+	}{
+		Downloads:    history_entries,
+		TotalMatches: total_matches,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(response_body)
+}
+
+// Exports the download history, or the part of it that matches the search parameters, as a json file that can be
+// imported on another install.
+func getDownloadHistoryExportHandler(response http.ResponseWriter, request *http.Request) {
+	history_filter, err := parseDownloadHistoryFilter(request)
+	if err != nil {
+		dungeon_helpers.WriteRejection(response, 400, err.Error())
+		return
+	}
+
+	history_records, err := repository.Downloads.GetDownloadHistoryRecords(history_filter)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error exporting download history: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	history_export := &models.DownloadHistoryExport{
+		ExportedAt: time.Now().Unix(),
+		Downloads:  history_records,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"download-history-%d.json\"", history_export.ExportedAt))
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(history_export)
+}
+
+func parseDownloadHistoryFilter(request *http.Request) (models.DownloadHistoryFilter, error) {
+	var query_params url.Values = request.URL.Query()
+
+	history_filter := models.DownloadHistoryFilter{
+		UrlSubstring: query_params.Get("url"),
+		CategoryUuid: query_params.Get("category_uuid"),
+		Status:       models.DownloadRequestStatus(query_params.Get("status")),
+	}
+
+	if !history_filter.IsValidStatus() {
+		return history_filter, fmt.Errorf("'%s' is not a download status", history_filter.Status)
+	}
+
+	var err error
+
+	history_filter.From, err = parsePositiveParam(request, "from")
+	if err != nil {
+		return history_filter, err
+	}
+
+	history_filter.To, err = parsePositiveParam(request, "to")
+	if err != nil {
+		return history_filter, err
+	}
+
+	limit, err := parsePositiveParam(request, "limit")
+	if err != nil {
+		return history_filter, err
+	}
+
+	offset, err := parsePositiveParam(request, "offset")
+	if err != nil {
+		return history_filter, err
+	}
+
+	history_filter.Limit = int(limit)
+	history_filter.Offset = int(offset)
+
+	return history_filter, nil
+}
+
+// Returns 0 if the parameter is missing.
+func parsePositiveParam(request *http.Request, param_name string) (int64, error) {
+	var param_value string = request.URL.Query().Get(param_name)
+	if param_value == "" {
+		return 0, nil
+	}
+
+	parsed_value, err := strconv.ParseInt(param_value, 10, 64)
+	if err != nil || parsed_value < 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", param_name)
+	}
+
+	return parsed_value, nil
+}
+
 func postDownloadHistoryHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/download-history/import":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(postDownloadHistoryImportHandler)
+	}
+
+	resource_handler(response, request)
 }
+
+// Imports a download history exported with /download-history/export, so the urls on it are not downloaded again
+// into the same downloads.
+func postDownloadHistoryImportHandler(response http.ResponseWriter, request *http.Request) {
+	var history_export *models.DownloadHistoryExport = new(models.DownloadHistoryExport)
+
+	err := json.NewDecoder(request.Body).Decode(history_export)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error decoding download history import: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid request body")
+		return
+	}
+
+	for _, history_record := range history_export.Downloads {
+		if history_record.DownloadUuid == "" || history_record.CategoryUuid == "" {
+			dungeon_helpers.WriteRejection(response, 400, "Every download must have a download_uuid and a category_uuid")
+			return
+		}
+	}
+
+	imported_downloads, imported_files, err := repository.Downloads.ImportDownloadHistoryRecords(history_export.Downloads)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error importing download history: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response_body := &struct {
+		ImportedDownloads int `json:"imported_downloads"`
+		ImportedFiles     int `json:"imported_files"`
+	}{
+		ImportedDownloads: imported_downloads,
+		ImportedFiles:     imported_files,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(response_body)
+}
+
 func patchDownloadHistoryHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
 	return
 }
+
 func deleteDownloadHistoryHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/download-history/downloads":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(deleteExpiredDownloadsHandler)
+	}
+
+	resource_handler(response, request)
 }
+
+// Removes the downloads registered before the 'before' unix timestamp in seconds, optionally only those of the
+// category_uuid category. Their urls can be downloaded again afterwards.
+func deleteExpiredDownloadsHandler(response http.ResponseWriter, request *http.Request) {
+	before, err := strconv.ParseInt(request.URL.Query().Get("before"), 10, 64)
+	if err != nil || before <= 0 {
+		dungeon_helpers.WriteRejection(response, 400, "before must be a unix timestamp in seconds")
+		return
+	}
+
+	deleted_downloads, err := repository.Downloads.DeleteDownloadHistory(before, request.URL.Query().Get("category_uuid"))
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error deleting download history: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response_body := &struct {
+		DeletedDownloads int `json:"deleted_downloads"`
+	}{
+		DeletedDownloads: deleted_downloads,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(response_body)
+}
+
 func putDownloadHistoryHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
 	return
@@ -1,9 +1,19 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
+	"libery_downloads_service/helpers"
+	"libery_downloads_service/repository"
+	"libery_downloads_service/workflows"
 	"libery_downloads_service/workflows/jobs"
 	"net/http"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
 func DownloadsHandler(service_instance libery_networking.Server) http.HandlerFunc {
@@ -33,6 +43,12 @@ func getDownloadsHandler(response http.ResponseWriter, request *http.Request) {
 	if resource_path == "/downloads/current-download" {
 		getCurrentDownloadHandler(response, request)
 		return
+	} else if resource_path == "/downloads/queue" {
+		dungeon_middlewares.CheckUserCan_DownloadFiles(getDownloadQueueHandler)(response, request)
+		return
+	} else if resource_path == "/downloads/backends" {
+		dungeon_middlewares.CheckUserCan_DownloadFiles(getDownloaderBackendsHandler)(response, request)
+		return
 	} else {
 		response.WriteHeader(404)
 		return
@@ -50,19 +66,206 @@ func getCurrentDownloadHandler(response http.ResponseWriter, request *http.Reque
 	return
 }
 
+// Returns the state of every download request on the queue, in the order they will be downloaded.
+func getDownloadQueueHandler(response http.ResponseWriter, request *http.Request) {
+	queued_downloads := jobs.DownloadWorker.GetQueuedDownloads()
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(queued_downloads)
+}
+
+// Returns the names of the configured downloader backends.
+func getDownloaderBackendsHandler(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(jobs.DownloaderBackendNames())
+}
+
 func postDownloadsHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/downloads":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(postMediasBatchHandler)
+	case "/downloads/external":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(postExternalDownloadHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Enqueues a batch of media urls to be downloaded into a category. If download_uuid matches a download that is still
+// on the queue, the new urls are added to it.
+func postMediasBatchHandler(response http.ResponseWriter, request *http.Request) {
+	medias_batch_request := &struct {
+		MediaUrls    []string `json:"media_urls"`
+		CategoryUuid string   `json:"category_uuid"`
+		ClusterUuid  string   `json:"cluster_uuid"`
+		DownloadUuid string   `json:"download_uuid"`
+	}{}
+
+	err := json.NewDecoder(request.Body).Decode(medias_batch_request)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error decoding medias batch request: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid request body")
+		return
+	}
+
+	if len(medias_batch_request.MediaUrls) == 0 || medias_batch_request.CategoryUuid == "" || medias_batch_request.ClusterUuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing media_urls, category_uuid or cluster_uuid")
+		return
+	}
+
+	for _, media_url := range medias_batch_request.MediaUrls {
+		if !helpers.IsDownloadableUrl(media_url) {
+			dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("'%s' is not an http or https url", media_url))
+			return
+		}
+	}
+
+	recipient_cluster, err := repository.Categories.GetCategoriesCluster(request.Context(), medias_batch_request.ClusterUuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/downloads.postMediasBatchHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 404, "Cluster not found")
+		return
+	}
+
+	unique_media_urls := workflows.ClearRepeatedDownloadFiles(medias_batch_request.DownloadUuid, medias_batch_request.MediaUrls)
+
+	download_uuid, err := jobs.DownloadWorker.DownloadMediasBatch(medias_batch_request.CategoryUuid, unique_media_urls, medias_batch_request.DownloadUuid, recipient_cluster)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/downloads.postMediasBatchHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 409, err.Error())
+		return
+	}
+
+	response_body := &struct {
+		DownloadUuid string `json:"download_uuid"`
+		QueuedMedias int    `json:"queued_medias"`
+	}{
+		DownloadUuid: download_uuid,
+		QueuedMedias: len(unique_media_urls),
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(202)
+
+	json.NewEncoder(response).Encode(response_body)
+}
+
+// Hands a source url to a downloader backend, the medias it produces are imported into the category. Progress is
+// reported over /ws/download-progress with the returned download_uuid.
+func postExternalDownloadHandler(response http.ResponseWriter, request *http.Request) {
+	external_download_request := &struct {
+		Backend      string `json:"backend"`
+		SourceUrl    string `json:"source_url"`
+		CategoryUuid string `json:"category_uuid"`
+		ClusterUuid  string `json:"cluster_uuid"`
+	}{}
+
+	err := json.NewDecoder(request.Body).Decode(external_download_request)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error decoding external download request: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid request body")
+		return
+	}
+
+	if external_download_request.Backend == "" || external_download_request.CategoryUuid == "" || external_download_request.ClusterUuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing backend, category_uuid or cluster_uuid")
+		return
+	}
+
+	if !helpers.IsDownloadableUrl(external_download_request.SourceUrl) {
+		dungeon_helpers.WriteRejection(response, 400, "source_url must be an http or https url")
+		return
+	}
+
+	if _, exists := jobs.GetDownloaderBackend(external_download_request.Backend); !exists {
+		dungeon_helpers.WriteRejection(response, 404, fmt.Sprintf("Downloader backend '%s' is not configured", external_download_request.Backend))
+		return
+	}
+
+	recipient_cluster, err := repository.Categories.GetCategoriesCluster(request.Context(), external_download_request.ClusterUuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/downloads.postExternalDownloadHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 404, "Cluster not found")
+		return
+	}
+
+	download_uuid, err := jobs.DownloadWorker.DownloadWithBackend(external_download_request.Backend, external_download_request.SourceUrl, external_download_request.CategoryUuid, recipient_cluster)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/downloads.postExternalDownloadHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 500, err.Error())
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(202)
+
+	response.Write([]byte(`{"download_uuid": "` + download_uuid + `"}`))
 }
 
 func patchDownloadsHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/downloads/pause":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(patchPauseDownloadHandler)
+	case "/downloads/resume":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(patchResumeDownloadHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+func patchPauseDownloadHandler(response http.ResponseWriter, request *http.Request) {
+	handleDownloadQueueOperation(response, request, jobs.DownloadWorker.PauseDownload)
+}
+
+func patchResumeDownloadHandler(response http.ResponseWriter, request *http.Request) {
+	handleDownloadQueueOperation(response, request, jobs.DownloadWorker.ResumeDownload)
 }
 
 func deleteDownloadsHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/downloads":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(deleteCancelDownloadHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Cancels a queued download. Files that were already downloaded stay on the download history.
+func deleteCancelDownloadHandler(response http.ResponseWriter, request *http.Request) {
+	handleDownloadQueueOperation(response, request, jobs.DownloadWorker.CancelDownload)
+}
+
+func handleDownloadQueueOperation(response http.ResponseWriter, request *http.Request, queue_operation func(download_uuid string) error) {
+	var download_uuid string = request.URL.Query().Get("download_uuid")
+	if download_uuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing download_uuid parameter")
+		return
+	}
+
+	err := queue_operation(download_uuid)
+	if errors.Is(err, jobs.ErrDownloadNotQueued) {
+		dungeon_helpers.WriteRejection(response, 404, fmt.Sprintf("Download '%s' is not on the queue", download_uuid))
+		return
+	} else if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("In handlers/downloads.handleDownloadQueueOperation: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 409, err.Error())
+		return
+	}
+
+	response.WriteHeader(204)
 }
 
 func putDownloadsHandler(response http.ResponseWriter, request *http.Request) {
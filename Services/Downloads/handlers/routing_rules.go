@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"libery-dungeon-libs/libs/libery_networking"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"net/http"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+func RoutingRulesHandler(service_instance libery_networking.Server) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			getRoutingRulesHandler(response, request)
+		case http.MethodPost:
+			postRoutingRulesHandler(response, request)
+		case http.MethodDelete:
+			deleteRoutingRulesHandler(response, request)
+		case http.MethodOptions:
+			response.WriteHeader(http.StatusOK)
+		default:
+			response.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func getRoutingRulesHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/routing-rules":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(getRoutingRulesListHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Lists the routing rules in the order they are evaluated, only those of the cluster_uuid cluster if given.
+func getRoutingRulesListHandler(response http.ResponseWriter, request *http.Request) {
+	routing_rules, err := repository.RoutingRules.GetRoutingRules(request.URL.Query().Get("cluster_uuid"))
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/routing_rules.getRoutingRulesListHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(routing_rules)
+}
+
+func postRoutingRulesHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/routing-rules":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(postRoutingRuleHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Adds a routing rule to a cluster. The rule needs a target category, by uuid or by name, or tags to apply.
+func postRoutingRuleHandler(response http.ResponseWriter, request *http.Request) {
+	routing_rule_request := &struct {
+		ClusterUuid        string `json:"cluster_uuid"`
+		Priority           int    `json:"priority"`
+		MatchKind          string `json:"match_kind"`
+		Pattern            string `json:"pattern"`
+		TargetCategoryUuid string `json:"target_category_uuid"`
+		TargetCategoryName string `json:"target_category_name"`
+		TargetParentUuid   string `json:"target_parent_uuid"`
+		TagIds             []int  `json:"tag_ids"`
+	}{}
+
+	err := json.NewDecoder(request.Body).Decode(routing_rule_request)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error decoding routing rule request: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid request body")
+		return
+	}
+
+	new_routing_rule := models.CreateNewRoutingRule(routing_rule_request.ClusterUuid, routing_rule_request.Priority, models.RoutingMatchKind(routing_rule_request.MatchKind), routing_rule_request.Pattern)
+
+	new_routing_rule.TargetCategoryUuid = routing_rule_request.TargetCategoryUuid
+	new_routing_rule.TargetCategoryName = routing_rule_request.TargetCategoryName
+	new_routing_rule.TargetParentUuid = routing_rule_request.TargetParentUuid
+
+	if routing_rule_request.TagIds != nil {
+		new_routing_rule.TagIds = routing_rule_request.TagIds
+	}
+
+	err = new_routing_rule.Validate()
+	if err != nil {
+		dungeon_helpers.WriteRejection(response, 400, err.Error())
+		return
+	}
+
+	_, err = repository.Categories.GetCategoriesCluster(request.Context(), new_routing_rule.ClusterUuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/routing_rules.postRoutingRuleHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 404, "Cluster not found")
+		return
+	}
+
+	err = repository.RoutingRules.InsertRoutingRule(new_routing_rule)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/routing_rules.postRoutingRuleHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(new_routing_rule)
+}
+
+func deleteRoutingRulesHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/routing-rules":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(deleteRoutingRuleHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Removes a routing rule. The categories it created and the medias it routed are left where they are.
+func deleteRoutingRuleHandler(response http.ResponseWriter, request *http.Request) {
+	var rule_uuid string = request.URL.Query().Get("rule_uuid")
+	if rule_uuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing rule_uuid parameter")
+		return
+	}
+
+	err := repository.RoutingRules.DeleteRoutingRule(rule_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/routing_rules.deleteRoutingRuleHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.WriteHeader(204)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"libery-dungeon-libs/libs/libery_networking"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/helpers"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"net/http"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+func SourceWatchersHandler(service_instance libery_networking.Server) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			getSourceWatchersHandler(response, request)
+		case http.MethodPost:
+			postSourceWatchersHandler(response, request)
+		case http.MethodDelete:
+			deleteSourceWatchersHandler(response, request)
+		case http.MethodOptions:
+			response.WriteHeader(http.StatusOK)
+		default:
+			response.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func getSourceWatchersHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/source-watchers":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(getSourceWatchersListHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+func getSourceWatchersListHandler(response http.ResponseWriter, request *http.Request) {
+	source_watchers, err := repository.SourceWatchers.GetSourceWatchers()
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/source_watchers.getSourceWatchersListHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(source_watchers)
+}
+
+func postSourceWatchersHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/source-watchers":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(postSourceWatcherHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Starts watching a source url, new medias found on it are downloaded into the category until the source is gone
+// or the watcher is deleted. interval_seconds defaults to SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS and can't be lower
+// than SOURCE_WATCHERS_MIN_INTERVAL_SECONDS.
+func postSourceWatcherHandler(response http.ResponseWriter, request *http.Request) {
+	source_watcher_request := &struct {
+		SourceUrl       string `json:"source_url"`
+		SourceKind      string `json:"source_kind"`
+		CategoryUuid    string `json:"category_uuid"`
+		ClusterUuid     string `json:"cluster_uuid"`
+		IntervalSeconds int64  `json:"interval_seconds"`
+	}{}
+
+	err := json.NewDecoder(request.Body).Decode(source_watcher_request)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Error decoding source watcher request: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid request body")
+		return
+	}
+
+	if source_watcher_request.CategoryUuid == "" || source_watcher_request.ClusterUuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing category_uuid or cluster_uuid")
+		return
+	}
+
+	if !helpers.IsDownloadableUrl(source_watcher_request.SourceUrl) {
+		dungeon_helpers.WriteRejection(response, 400, "source_url must be an http or https url")
+		return
+	}
+
+	if !models.IsWatchedSourceKind(source_watcher_request.SourceKind) {
+		dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("'%s' is not a source kind that can be watched", source_watcher_request.SourceKind))
+		return
+	}
+
+	var interval_seconds int64 = source_watcher_request.IntervalSeconds
+
+	if interval_seconds <= 0 {
+		interval_seconds = app_config.SOURCE_WATCHERS_DEFAULT_INTERVAL_SECONDS
+	} else if interval_seconds < app_config.SOURCE_WATCHERS_MIN_INTERVAL_SECONDS {
+		interval_seconds = app_config.SOURCE_WATCHERS_MIN_INTERVAL_SECONDS
+	}
+
+	recipient_cluster, err := repository.Categories.GetCategoriesCluster(request.Context(), source_watcher_request.ClusterUuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/source_watchers.postSourceWatcherHandler: %s", err.Error()))
+		dungeon_helpers.WriteRejection(response, 404, "Cluster not found")
+		return
+	}
+
+	new_source_watcher := models.CreateNewSourceWatcher(source_watcher_request.SourceUrl, models.WatchedSourceKind(source_watcher_request.SourceKind), source_watcher_request.CategoryUuid, recipient_cluster, interval_seconds)
+
+	err = repository.SourceWatchers.InsertSourceWatcher(new_source_watcher)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/source_watchers.postSourceWatcherHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(new_source_watcher)
+}
+
+func deleteSourceWatchersHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/source-watchers":
+		resource_handler = dungeon_middlewares.CheckUserCan_DownloadFiles(deleteSourceWatcherHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Stops watching a source. What the watcher already downloaded stays on the download history under the watcher uuid.
+func deleteSourceWatcherHandler(response http.ResponseWriter, request *http.Request) {
+	var watcher_uuid string = request.URL.Query().Get("watcher_uuid")
+	if watcher_uuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing watcher_uuid parameter")
+		return
+	}
+
+	err := repository.SourceWatchers.DeleteSourceWatcher(watcher_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/source_watchers.deleteSourceWatcherHandler: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.WriteHeader(204)
+}
@@ -0,0 +1,183 @@
+package helpers
+
+import (
+	"bytes"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var media_type_extensions map[string]string = map[string]string{
+	"image/jpeg":     ".jpg",
+	"image/png":      ".png",
+	"image/gif":      ".gif",
+	"image/bmp":      ".bmp",
+	"image/webp":     ".webp",
+	"video/mp4":      ".mp4",
+	"video/webm":     ".webm",
+	"video/matroska": ".mkv",
+}
+
+// Aliases hosts commonly send for the supported mime types.
+var media_type_aliases map[string]string = map[string]string{
+	"image/jpg":        "image/jpeg",
+	"image/pjpeg":      "image/jpeg",
+	"image/x-ms-bmp":   "image/bmp",
+	"video/x-matroska": "video/matroska",
+	"video/x-m4v":      "video/mp4",
+}
+
+func normalizeMediaType(content_type string) string {
+	media_type, _, err := mime.ParseMediaType(content_type)
+	if err != nil {
+		media_type = strings.TrimSpace(strings.Split(content_type, ";")[0])
+	}
+
+	media_type = strings.ToLower(media_type)
+
+	if alias_of, is_alias := media_type_aliases[media_type]; is_alias {
+		media_type = alias_of
+	}
+
+	return media_type
+}
+
+var ebml_magic_bytes []byte = []byte{0x1A, 0x45, 0xDF, 0xA3}
+var ebml_doctype_id []byte = []byte{0x42, 0x82}
+
+// Sniffs the mime type of a file from its first bytes. http.DetectContentType reports every EBML file as video/webm, so
+// matroska files are told apart by the DocType on their EBML header.
+func SniffMediaType(file_head []byte) string {
+	var sniffed_type string = http.DetectContentType(file_head)
+
+	if sniffed_type == "video/webm" && ebmlDocType(file_head) == "matroska" {
+		sniffed_type = "video/matroska"
+	}
+
+	return sniffed_type
+}
+
+// Returns the DocType declared on the EBML header at the start of the file, or an empty string if it can't be read.
+func ebmlDocType(file_head []byte) string {
+	if !bytes.HasPrefix(file_head, ebml_magic_bytes) {
+		return ""
+	}
+
+	doctype_index := bytes.Index(file_head[len(ebml_magic_bytes):], ebml_doctype_id)
+	if doctype_index < 0 {
+		return ""
+	}
+
+	var size_start int = len(ebml_magic_bytes) + doctype_index + len(ebml_doctype_id)
+	if size_start >= len(file_head) {
+		return ""
+	}
+
+	// The element size is a variable length integer, its length is given by the leading zeros of its first byte.
+	var size_length int = 1
+	for size_length <= 8 && file_head[size_start]&(0x80>>(size_length-1)) == 0 {
+		size_length++
+	}
+
+	if size_length > 8 || size_start+size_length > len(file_head) {
+		return ""
+	}
+
+	var doctype_size int = int(file_head[size_start] & (0xFF >> size_length))
+	for _, size_byte := range file_head[size_start+1 : size_start+size_length] {
+		doctype_size = doctype_size<<8 | int(size_byte)
+	}
+
+	var doctype_start int = size_start + size_length
+	if doctype_size > len(file_head)-doctype_start {
+		return ""
+	}
+
+	return string(bytes.TrimRight(file_head[doctype_start:doctype_start+doctype_size], "\x00"))
+}
+
+// Decides the mime type of a downloaded file from the type sniffed from its content and the type declared by the host.
+// The sniffed type wins, the declared one is only trusted when the content can't be sniffed. Returns false if the
+// file is not a supported media.
+func ResolveMediaType(sniffed_type string, declared_type string) (string, bool) {
+	sniffed_type = normalizeMediaType(sniffed_type)
+
+	if dungeon_helpers.IsSupportedMimeType(sniffed_type) {
+		return sniffed_type, true
+	}
+
+	if sniffed_type != "application/octet-stream" {
+		return sniffed_type, false
+	}
+
+	declared_type = normalizeMediaType(declared_type)
+
+	return declared_type, dungeon_helpers.IsSupportedMimeType(declared_type)
+}
+
+// Names a downloaded media after the filename in its Content-Disposition header or, lacking one, after the last
+// segment of its url. If the name doesn't end in a supported extension, the one matching media_type is appended.
+func MediaFilename(content_disposition string, file_url string, media_type string) string {
+	var filename string
+
+	if content_disposition != "" {
+		_, disposition_params, err := mime.ParseMediaType(content_disposition)
+		if err == nil {
+			filename = disposition_params["filename"]
+		}
+	}
+
+	if filename == "" {
+		parsed_url, err := url.Parse(file_url)
+		if err == nil {
+			filename = path.Base(parsed_url.Path)
+		}
+	}
+
+	filename = sanitizeFilename(filename)
+
+	if filename == "" {
+		filename = "media"
+	}
+
+	if !dungeon_helpers.IsSupportedFileExtension(filename) {
+		filename += media_type_extensions[media_type]
+	}
+
+	return filename
+}
+
+func sanitizeFilename(filename string) string {
+	filename = strings.ReplaceAll(filename, "\\", "/")
+	filename = filepath.Base(filename)
+
+	filename = strings.Map(func(r rune) rune {
+		if r == '"' || r < ' ' {
+			return -1
+		}
+
+		return r
+	}, filename)
+
+	filename = strings.TrimSpace(filename)
+
+	if filename == "." || filename == "/" {
+		return ""
+	}
+
+	return filename
+}
+
+// Only absolute http(s) urls are handed to the downloader.
+func IsDownloadableUrl(file_url string) bool {
+	parsed_url, err := url.Parse(file_url)
+	if err != nil {
+		return false
+	}
+
+	return (parsed_url.Scheme == "http" || parsed_url.Scheme == "https") && parsed_url.Host != ""
+}
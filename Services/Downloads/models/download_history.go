@@ -0,0 +1,47 @@
+package models
+
+// Narrows a search of the download history. Empty fields don't filter.
+type DownloadHistoryFilter struct {
+	UrlSubstring string // Downloads with at least one file whose url contains it, case insensitive
+	CategoryUuid string
+	Status       DownloadRequestStatus
+	From         int64 // Unix timestamp in seconds, inclusive
+	To           int64 // Unix timestamp in seconds, inclusive
+	Limit        int
+	Offset       int
+}
+
+// A download as listed on download history searches.
+type DownloadHistoryEntry struct {
+	DownloadUuid      string                `json:"download_uuid"`
+	DownloadTimestamp int64                 `json:"download_timestamp"` // Unix timestamp in seconds
+	CategoryUuid      string                `json:"category_uuid"`
+	Status            DownloadRequestStatus `json:"status"`
+	FileCount         int                   `json:"file_count"`
+	SkippedFiles      int                   `json:"skipped_files"`
+}
+
+// A download history that can be carried to another install so it knows what was already downloaded.
+type DownloadHistoryExport struct {
+	ExportedAt int64                   `json:"exported_at"` // Unix timestamp in seconds
+	Downloads  []DownloadHistoryRecord `json:"downloads"`
+}
+
+type DownloadHistoryRecord struct {
+	DownloadUuid      string                `json:"download_uuid"`
+	DownloadTimestamp int64                 `json:"download_timestamp"` // Unix timestamp in seconds
+	CategoryUuid      string                `json:"category_uuid"`
+	Status            DownloadRequestStatus `json:"status"`
+	FileUrls          []string              `json:"file_urls"`
+}
+
+// ------ DownloadHistoryFilter Methods ------
+
+func (filter *DownloadHistoryFilter) IsValidStatus() bool {
+	switch filter.Status {
+	case "", DownloadStatus_Queued, DownloadStatus_Downloading, DownloadStatus_Paused, DownloadStatus_Cancelled, DownloadStatus_Completed:
+		return true
+	}
+
+	return false
+}
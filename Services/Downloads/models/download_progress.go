@@ -1,15 +1,18 @@
 package models
 
 type DownloadProgressMessage struct {
-	DownloadUuid    string `json:"download_uuid"`
-	TotalFiles      int    `json:"total_files"`
-	DownloadedFiles int    `json:"downloaded_files"`
-	Completed       bool   `json:"completed"`
+	DownloadUuid    string  `json:"download_uuid"`
+	ClusterUuid     string  `json:"cluster_uuid"`
+	TotalFiles      int     `json:"total_files"`
+	DownloadedFiles int     `json:"downloaded_files"`
+	Completed       bool    `json:"completed"`
+	FileProgress    float64 `json:"file_progress,omitempty"` // Percent of the file being downloaded, only reported by downloader backends
 }
 
-func CreateDownloadProgressMessage(download_uuid string, total_files int, downloaded_files int, completed bool) *DownloadProgressMessage {
+func CreateDownloadProgressMessage(download_uuid string, cluster_uuid string, total_files int, downloaded_files int, completed bool) *DownloadProgressMessage {
 	return &DownloadProgressMessage{
 		DownloadUuid:    download_uuid,
+		ClusterUuid:     cluster_uuid,
 		TotalFiles:      total_files,
 		DownloadedFiles: downloaded_files,
 		Completed:       completed,
@@ -7,6 +7,7 @@ type downloadQueueItem struct {
 	next     *downloadQueueItem
 }
 
+// Download requests in the order they were enqueued. Not safe for concurrent use, the downloader guards it.
 type DownloadQueue struct {
 	head *downloadQueueItem
 	tail *downloadQueueItem
@@ -22,11 +23,15 @@ func (dq *DownloadQueue) Clear() {
 func (dq *DownloadQueue) Enqueue(download *DownloadRequest) {
 	var new_item *downloadQueueItem = new(downloadQueueItem)
 	new_item.Download = download
-	new_item.next = dq.tail
+
+	if dq.tail != nil {
+		dq.tail.next = new_item
+	}
+
 	dq.tail = new_item
 
 	if dq.head == nil {
-		dq.head = dq.tail
+		dq.head = new_item
 	}
 
 	dq.len++
@@ -38,16 +43,16 @@ func (dq *DownloadQueue) Dequeue() (*DownloadRequest, error) {
 		return nil, nil
 	}
 
-	var download_node *downloadQueueItem = dq.tail
+	var download_node *downloadQueueItem = dq.head
 
 	if !download_node.Download.IsDownloaded() {
 		return nil, fmt.Errorf("Download is not completed")
 	}
 
-	dq.tail = download_node.next
+	dq.head = download_node.next
 
-	if dq.head == download_node {
-		dq.head = nil
+	if dq.tail == download_node {
+		dq.tail = nil
 	}
 
 	dq.len--
@@ -60,7 +65,66 @@ func (dq *DownloadQueue) Peek() *DownloadRequest {
 		return nil
 	}
 
-	return dq.tail.Download
+	return dq.head.Download
+}
+
+// Returns the first download that is waiting to be downloaded, skipping paused ones.
+func (dq *DownloadQueue) PeekQueued() *DownloadRequest {
+	for item := dq.head; item != nil; item = item.next {
+		if item.Download.Status == DownloadStatus_Queued {
+			return item.Download
+		}
+	}
+
+	return nil
+}
+
+func (dq *DownloadQueue) Find(download_uuid string) *DownloadRequest {
+	for item := dq.head; item != nil; item = item.next {
+		if item.Download.DownloadUuid == download_uuid {
+			return item.Download
+		}
+	}
+
+	return nil
+}
+
+// Removes the download regardless of its progress. Returns false if it wasn't on the queue.
+func (dq *DownloadQueue) Remove(download_uuid string) bool {
+	var previous_item *downloadQueueItem
+
+	for item := dq.head; item != nil; item = item.next {
+		if item.Download.DownloadUuid != download_uuid {
+			previous_item = item
+			continue
+		}
+
+		if previous_item == nil {
+			dq.head = item.next
+		} else {
+			previous_item.next = item.next
+		}
+
+		if dq.tail == item {
+			dq.tail = previous_item
+		}
+
+		dq.len--
+
+		return true
+	}
+
+	return false
+}
+
+func (dq *DownloadQueue) Items() []*DownloadRequest {
+	var items []*DownloadRequest = make([]*DownloadRequest, 0, dq.len)
+
+	for item := dq.head; item != nil; item = item.next {
+		items = append(items, item.Download)
+	}
+
+	return items
 }
 
 func (dq *DownloadQueue) Len() int {
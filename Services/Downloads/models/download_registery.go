@@ -11,6 +11,7 @@ type RegisteredDownload struct {
 	DownloadTimestamp time.Time                `json:"download_timestamp"`
 	CategoryUuid      string                   `json:"category_uuid"`
 	DownloadFiles     []RegisteredDownloadFile `json:"download_files"`
+	SkippedFiles      int                      `json:"skipped_files"` // Files that were not uploaded because the cluster already had their content
 }
 
 type RegisteredDownloadFile struct {
@@ -35,7 +36,9 @@ func (rd *RegisteredDownload) MarshalJSON() ([]byte, error) {
 
 	json_timestamp := strconv.FormatInt(download_timestamp, 10)
 
-	return []byte(`{"download_uuid":"` + rd.DownloadUuid + `","download_timestamp":` + json_timestamp + `,"category_uuid":"` + rd.CategoryUuid + `","download_files":` + string(json_files) + `}`), nil
+	json_skipped_files := strconv.Itoa(rd.SkippedFiles)
+
+	return []byte(`{"download_uuid":"` + rd.DownloadUuid + `","download_timestamp":` + json_timestamp + `,"category_uuid":"` + rd.CategoryUuid + `","download_files":` + string(json_files) + `,"skipped_files":` + json_skipped_files + `}`), nil
 }
 
 func (rd *RegisteredDownload) DownloadedFilesMap() map[string]bool {
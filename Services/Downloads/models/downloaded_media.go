@@ -0,0 +1,28 @@
+package models
+
+import dungeon_models "libery-dungeon-libs/models"
+
+// Links a media the downloads service uploaded to the download and source url it came from. The id is what the medias
+// service stores as the DownloadedFrom of the media.
+type DownloadedMedia struct {
+	Id           int64  `json:"id"`
+	MediaUuid    string `json:"media_uuid"`
+	DownloadUuid string `json:"download_uuid"`
+	SourceUrl    string `json:"source_url"`
+	DownloadedAt int64  `json:"downloaded_at"` // Unix timestamp in seconds
+}
+
+// A downloaded media along with where the media is now, which may have changed since it was downloaded.
+type LocatedDownloadedMedia struct {
+	DownloadedMedia
+	MediaIdentity *dungeon_models.MediaIdentity `json:"media_identity"` // nil if the media no longer exists
+}
+
+// A downloaded file that was not uploaded because the cluster already had a media with the same content.
+type SkippedDownloadFile struct {
+	DownloadUuid string `json:"download_uuid"`
+	SourceUrl    string `json:"source_url"`
+	ContentHash  string `json:"content_hash"` // Hex encoded sha256 of the file
+	DuplicateOf  string `json:"duplicate_of"` // Uuid of the media with the same content
+	SkippedAt    int64  `json:"skipped_at"`   // Unix timestamp in seconds
+}
@@ -6,16 +6,29 @@ import (
 	"github.com/google/uuid"
 )
 
+type DownloadRequestStatus string
+
+const (
+	DownloadStatus_Queued      DownloadRequestStatus = "queued"
+	DownloadStatus_Downloading DownloadRequestStatus = "downloading"
+	DownloadStatus_Paused      DownloadRequestStatus = "paused"
+	DownloadStatus_Cancelled   DownloadRequestStatus = "cancelled"
+	DownloadStatus_Completed   DownloadRequestStatus = "completed" // Only found on the download history, completed requests leave the queue
+)
+
 type DownloadRequest struct {
 	DownloadUuid    string
 	download_batch  []DownloadFile
 	CategoryCluster *dungeon_models.CategoryCluster
 	CategoryUuid    string
+	Status          DownloadRequestStatus
+	EnqueuedAt      int64 // Unix timestamp in milliseconds
 }
 
 type DownloadFile struct {
 	Url          string
-	IsDownloaded bool
+	IsDownloaded bool // The file won't be attempted again, either because it was downloaded or because it ran out of tries
+	Failed       bool // Only meaningful if IsDownloaded is true
 	Trys         int
 }
 
@@ -26,6 +39,7 @@ func CreateNewDownloadRequest(download_images []string, category_uuid string, ca
 	new_download_request.DownloadUuid = uuid.New().String()
 	new_download_request.CategoryUuid = category_uuid
 	new_download_request.CategoryCluster = category_cluster
+	new_download_request.Status = DownloadStatus_Queued
 
 	new_download_request.download_batch = make([]DownloadFile, 0)
 	var new_download_file *DownloadFile = new(DownloadFile)
@@ -40,6 +54,43 @@ func CreateNewDownloadRequest(download_images []string, category_uuid string, ca
 	return new_download_request
 }
 
+// Rebuilds a download request that was persisted on the download queue.
+func RestoreDownloadRequest(download_uuid, category_uuid string, category_cluster *dungeon_models.CategoryCluster, status DownloadRequestStatus, enqueued_at int64, download_files []DownloadFile) *DownloadRequest {
+	return &DownloadRequest{
+		DownloadUuid:    download_uuid,
+		download_batch:  download_files,
+		CategoryCluster: category_cluster,
+		CategoryUuid:    category_uuid,
+		Status:          status,
+		EnqueuedAt:      enqueued_at,
+	}
+}
+
+// Appends the urls that are not already part of the request. Returns the files that were appended.
+func (dr *DownloadRequest) AppendUrls(download_urls []string) []DownloadFile {
+	var known_urls map[string]struct{} = make(map[string]struct{}, len(dr.download_batch))
+
+	for _, file := range dr.download_batch {
+		known_urls[file.Url] = struct{}{}
+	}
+
+	var appended_files []DownloadFile = make([]DownloadFile, 0)
+
+	for _, download_url := range download_urls {
+		if _, exists := known_urls[download_url]; exists {
+			continue
+		}
+
+		known_urls[download_url] = struct{}{}
+
+		appended_files = append(appended_files, DownloadFile{Url: download_url})
+	}
+
+	dr.download_batch = append(dr.download_batch, appended_files...)
+
+	return appended_files
+}
+
 func (dr *DownloadRequest) IsDownloaded() bool {
 	for _, file := range dr.download_batch {
 		if !file.IsDownloaded {
@@ -60,3 +111,69 @@ func (dr *DownloadRequest) DownloadFiles() []DownloadFile {
 func (dr *DownloadRequest) Len() int {
 	return len(dr.download_batch)
 }
+
+// Returns how many files won't be attempted again.
+func (dr *DownloadRequest) ProcessedCount() int {
+	var processed_count int = 0
+
+	for _, file := range dr.download_batch {
+		if file.IsDownloaded {
+			processed_count++
+		}
+	}
+
+	return processed_count
+}
+
+// Returns a copy of the request holding only the files that won't be attempted again.
+func (dr *DownloadRequest) ProcessedPart() *DownloadRequest {
+	var processed_files []DownloadFile = make([]DownloadFile, 0)
+
+	for _, file := range dr.download_batch {
+		if file.IsDownloaded {
+			processed_files = append(processed_files, file)
+		}
+	}
+
+	return RestoreDownloadRequest(dr.DownloadUuid, dr.CategoryUuid, dr.CategoryCluster, dr.Status, dr.EnqueuedAt, processed_files)
+}
+
+// A summary of a download request on the queue, as reported to clients.
+type QueuedDownloadState struct {
+	DownloadUuid    string                `json:"download_uuid"`
+	CategoryUuid    string                `json:"category_uuid"`
+	ClusterUuid     string                `json:"cluster_uuid"`
+	Status          DownloadRequestStatus `json:"status"`
+	EnqueuedAt      int64                 `json:"enqueued_at"`
+	TotalFiles      int                   `json:"total_files"`
+	DownloadedFiles int                   `json:"downloaded_files"`
+	FailedFiles     int                   `json:"failed_files"`
+}
+
+func (dr *DownloadRequest) State() QueuedDownloadState {
+	var download_state QueuedDownloadState = QueuedDownloadState{
+		DownloadUuid: dr.DownloadUuid,
+		CategoryUuid: dr.CategoryUuid,
+		Status:       dr.Status,
+		EnqueuedAt:   dr.EnqueuedAt,
+		TotalFiles:   dr.Len(),
+	}
+
+	if dr.CategoryCluster != nil {
+		download_state.ClusterUuid = dr.CategoryCluster.Uuid
+	}
+
+	for _, file := range dr.download_batch {
+		if !file.IsDownloaded {
+			continue
+		}
+
+		if file.Failed {
+			download_state.FailedFiles++
+		} else {
+			download_state.DownloadedFiles++
+		}
+	}
+
+	return download_state
+}
@@ -0,0 +1,11 @@
+package models
+
+// A file produced by a downloader backend, kept to know where each imported media came from.
+type ExternalDownloadFile struct {
+	DownloadUuid string `json:"download_uuid"`
+	SourceUrl    string `json:"source_url"`
+	Backend      string `json:"backend"`
+	Filename     string `json:"filename"`
+	Imported     bool   `json:"imported"`
+	ImportedAt   int64  `json:"imported_at"` // Unix timestamp in seconds, 0 if the file wasn't imported
+}
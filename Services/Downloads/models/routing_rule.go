@@ -0,0 +1,124 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoutingMatchKind string
+
+const (
+	RoutingMatch_SourceHost RoutingMatchKind = "source_host"    // The host of the source url is the pattern or one of its subdomains
+	RoutingMatch_Filename   RoutingMatchKind = "filename_regex" // The filename matches the pattern as a regular expression
+	RoutingMatch_MimeType   RoutingMatchKind = "mime_type"      // The mime type is the pattern, patterns like 'video/*' match a whole type
+)
+
+// Decides where the downloaded files of a cluster that match it go and how they are tagged. Rules are evaluated by
+// priority, lowest first: the first matching rule with a target decides the category of the file, and the tags of
+// every matching rule are applied to the media. Files no rule sends elsewhere go to the category of their download.
+type RoutingRule struct {
+	Uuid        string           `json:"uuid"`
+	ClusterUuid string           `json:"cluster_uuid"`
+	Priority    int              `json:"priority"`
+	MatchKind   RoutingMatchKind `json:"match_kind"`
+	Pattern     string           `json:"pattern"`
+	// The category the files are sent to. If empty and TargetCategoryName is set, the files are sent to a category with that
+	// name under TargetParentUuid, or under the category of the download if that is empty as well. That category is
+	// created the first time a file needs it and again if it's deleted.
+	TargetCategoryUuid string `json:"target_category_uuid"`
+	TargetCategoryName string `json:"target_category_name"`
+	TargetParentUuid   string `json:"target_parent_uuid"`
+	TagIds             []int  `json:"tag_ids"`    // Dungeon tags applied to the medias of the matching files
+	CreatedAt          int64  `json:"created_at"` // Unix timestamp in seconds
+}
+
+// The file a routing rule is matched against.
+type RoutedFile struct {
+	SourceUrl string
+	Filename  string
+	MimeType  string
+}
+
+func CreateNewRoutingRule(cluster_uuid string, priority int, match_kind RoutingMatchKind, pattern string) *RoutingRule {
+	return &RoutingRule{
+		Uuid:        uuid.New().String(),
+		ClusterUuid: cluster_uuid,
+		Priority:    priority,
+		MatchKind:   match_kind,
+		Pattern:     pattern,
+		TagIds:      make([]int, 0),
+		CreatedAt:   time.Now().Unix(),
+	}
+}
+
+// ------ RoutingRule Methods ------
+
+// Returns an error describing why the rule can't be used.
+func (rule *RoutingRule) Validate() error {
+	if rule.ClusterUuid == "" {
+		return fmt.Errorf("Missing cluster_uuid")
+	}
+
+	if rule.Pattern == "" {
+		return fmt.Errorf("Missing pattern")
+	}
+
+	switch rule.MatchKind {
+	case RoutingMatch_SourceHost, RoutingMatch_MimeType:
+	case RoutingMatch_Filename:
+		_, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("Invalid filename pattern: %s", err)
+		}
+	default:
+		return fmt.Errorf("'%s' is not a routing match kind", rule.MatchKind)
+	}
+
+	if rule.TargetCategoryUuid == "" && rule.TargetCategoryName == "" && len(rule.TagIds) == 0 {
+		return fmt.Errorf("The rule must have a target category or tags to apply")
+	}
+
+	return nil
+}
+
+func (rule *RoutingRule) HasTarget() bool {
+	return rule.TargetCategoryUuid != "" || rule.TargetCategoryName != ""
+}
+
+func (rule *RoutingRule) Matches(routed_file RoutedFile) bool {
+	switch rule.MatchKind {
+	case RoutingMatch_SourceHost:
+		parsed_url, err := url.Parse(routed_file.SourceUrl)
+		if err != nil {
+			return false
+		}
+
+		var file_host string = strings.ToLower(parsed_url.Hostname())
+		var rule_host string = strings.ToLower(rule.Pattern)
+
+		return file_host == rule_host || strings.HasSuffix(file_host, "."+rule_host)
+	case RoutingMatch_Filename:
+		filename_pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return false
+		}
+
+		return filename_pattern.MatchString(routed_file.Filename)
+	case RoutingMatch_MimeType:
+		var mime_type string = strings.ToLower(routed_file.MimeType)
+		var rule_type string = strings.ToLower(rule.Pattern)
+
+		if strings.HasSuffix(rule_type, "/*") {
+			return strings.HasPrefix(mime_type, strings.TrimSuffix(rule_type, "*"))
+		}
+
+		return mime_type == rule_type
+	}
+
+	return false
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	dungeon_models "libery-dungeon-libs/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WatchedSourceKind string
+
+const (
+	WatchedSource_ThreadJson WatchedSourceKind = "thread_json" // Imageboard thread in the 4chan api format
+	WatchedSource_Feed       WatchedSourceKind = "feed"        // RSS or Atom feed
+	WatchedSource_Directory  WatchedSourceKind = "directory"   // Html directory listing
+)
+
+func IsWatchedSourceKind(source_kind string) bool {
+	switch WatchedSourceKind(source_kind) {
+	case WatchedSource_ThreadJson, WatchedSource_Feed, WatchedSource_Directory:
+		return true
+	}
+
+	return false
+}
+
+type SourceWatcherStatus string
+
+const (
+	SourceWatcherStatus_Active  SourceWatcherStatus = "active"
+	SourceWatcherStatus_Stopped SourceWatcherStatus = "stopped" // The source is gone, it won't be polled again
+)
+
+// A source url polled on a schedule, new medias found on it are downloaded into a fixed category. The watcher uuid is
+// used as the download uuid of everything it enqueues, so the download history of the watcher is what tells new medias apart.
+type SourceWatcher struct {
+	Uuid                string                          `json:"uuid"`
+	SourceUrl           string                          `json:"source_url"`
+	SourceKind          WatchedSourceKind               `json:"source_kind"`
+	CategoryUuid        string                          `json:"category_uuid"`
+	ClusterUuid         string                          `json:"cluster_uuid"`
+	CategoryCluster     *dungeon_models.CategoryCluster `json:"-"`
+	IntervalSeconds     int64                           `json:"interval_seconds"`
+	Status              SourceWatcherStatus             `json:"status"`
+	StopReason          string                          `json:"stop_reason"`
+	CreatedAt           int64                           `json:"created_at"`     // Unix timestamp in seconds
+	LastPolledAt        int64                           `json:"last_polled_at"` // Unix timestamp in seconds, 0 if never polled
+	ConsecutiveFailures int                             `json:"consecutive_failures"`
+}
+
+func CreateNewSourceWatcher(source_url string, source_kind WatchedSourceKind, category_uuid string, category_cluster *dungeon_models.CategoryCluster, interval_seconds int64) *SourceWatcher {
+	return &SourceWatcher{
+		Uuid:            uuid.New().String(),
+		SourceUrl:       source_url,
+		SourceKind:      source_kind,
+		CategoryUuid:    category_uuid,
+		ClusterUuid:     category_cluster.Uuid,
+		CategoryCluster: category_cluster,
+		IntervalSeconds: interval_seconds,
+		Status:          SourceWatcherStatus_Active,
+		CreatedAt:       time.Now().Unix(),
+	}
+}
+
+func (sw *SourceWatcher) IsDue(now time.Time) bool {
+	if sw.Status != SourceWatcherStatus_Active {
+		return false
+	}
+
+	return now.Unix()-sw.LastPolledAt >= sw.IntervalSeconds
+}
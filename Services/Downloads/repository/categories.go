@@ -1,9 +1,14 @@
 package repository
 
-import "context"
+import (
+	"context"
+	dungeon_models "libery-dungeon-libs/models"
+)
 
 type CategoriesRepository interface {
-	CreateCategory(ctx context.Context, name string, parent_uuid string) (category_uuid string, err error)
+	CreateCategory(ctx context.Context, name string, parent_uuid string, cluster_uuid string) (category_uuid string, err error)
+	FilterExistingCategories(ctx context.Context, categories_uuids []string) ([]string, error)
+	GetCategoriesCluster(ctx context.Context, cluster_uuid string) (*dungeon_models.CategoryCluster, error)
 }
 
 var Categories CategoriesRepository
@@ -9,6 +9,28 @@ type DownloadsRepository interface {
 	InsertDownload(download *models.DownloadRequest) error
 	InsertDownloadFiles(download *models.DownloadRequest) error
 	UpdateDownloadFiles(download_uuid string, new_file_urls []models.DownloadFile) error
+	InsertQueuedDownload(download *models.DownloadRequest) error
+	AppendQueuedDownloadFiles(download_uuid string, new_files []models.DownloadFile, first_position int) error
+	UpdateQueuedDownloadStatus(download_uuid string, status models.DownloadRequestStatus) error
+	UpdateQueuedDownloadFile(download_uuid string, position int, download_file models.DownloadFile) error
+	DeleteQueuedDownload(download_uuid string) error
+	GetQueuedDownloads() ([]*models.DownloadRequest, error)
+	InsertExternalDownloadFiles(external_files []models.ExternalDownloadFile) error
+	GetExternalDownloadFiles(download_uuid string) ([]models.ExternalDownloadFile, error)
+	ReserveDownloadedMedia(download_uuid string, source_url string) (int64, error)
+	CompleteDownloadedMedia(downloaded_media_id int64, media_uuid string) error
+	LinkDownloadedMedia(download_uuid string, source_url string, media_uuid string) error
+	DeleteDownloadedMedia(downloaded_media_id int64) error
+	GetDownloadedMedia(media_uuid string) (*models.DownloadedMedia, error)
+	GetDownloadMedias(download_uuid string) ([]models.DownloadedMedia, error)
+	InsertSkippedDownloadFile(skipped_file models.SkippedDownloadFile) error
+	GetSkippedDownloadFiles(download_uuid string) ([]models.SkippedDownloadFile, error)
+	CountSkippedDownloadFiles(download_uuid string) (int, error)
+	SetDownloadStatus(download_uuid string, status models.DownloadRequestStatus) error
+	SearchDownloadHistory(filter models.DownloadHistoryFilter) ([]models.DownloadHistoryEntry, int, error)
+	GetDownloadHistoryRecords(filter models.DownloadHistoryFilter) ([]models.DownloadHistoryRecord, error)
+	ImportDownloadHistoryRecords(history_records []models.DownloadHistoryRecord) (int, int, error)
+	DeleteDownloadHistory(before int64, category_uuid string) (int, error)
 	Close() error
 }
 
@@ -0,0 +1,17 @@
+package repository
+
+import "libery_downloads_service/models"
+
+type RoutingRulesRepository interface {
+	InsertRoutingRule(routing_rule *models.RoutingRule) error
+	DeleteRoutingRule(rule_uuid string) error
+	GetRoutingRules(cluster_uuid string) ([]*models.RoutingRule, error)
+	GetRoutingRuleCategory(rule_uuid string, parent_uuid string) (string, error)
+	SetRoutingRuleCategory(rule_uuid string, parent_uuid string, category_uuid string) error
+}
+
+var RoutingRules RoutingRulesRepository
+
+func SetRoutingRulesRepository(routing_rules_impl RoutingRulesRepository) {
+	RoutingRules = routing_rules_impl
+}
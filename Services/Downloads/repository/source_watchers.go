@@ -0,0 +1,16 @@
+package repository
+
+import "libery_downloads_service/models"
+
+type SourceWatchersRepository interface {
+	InsertSourceWatcher(source_watcher *models.SourceWatcher) error
+	UpdateSourceWatcherState(source_watcher *models.SourceWatcher) error
+	DeleteSourceWatcher(watcher_uuid string) error
+	GetSourceWatchers() ([]*models.SourceWatcher, error)
+}
+
+var SourceWatchers SourceWatchersRepository
+
+func SetSourceWatchersRepository(source_watchers_impl SourceWatchersRepository) {
+	SourceWatchers = source_watchers_impl
+}
@@ -41,13 +41,14 @@ func (s *DownloadsServer) DownloadImagesBatch(ctx context.Context, in *downloads
 
 	echo.EchoDebug(fmt.Sprintf("Received download request with: %s", recipient_cluster.FsPath))
 
-	unique_image_urls := workflows.ClearRepeatedDownloadFiles(*in.DownloadUuid, in.ImageUrls)
+	// Despite the message name, image_urls can hold urls of any supported media
+	unique_media_urls := workflows.ClearRepeatedDownloadFiles(in.GetDownloadUuid(), in.ImageUrls)
 
-	if len(unique_image_urls) != len(in.ImageUrls) {
-		echo.EchoDebug(fmt.Sprintf("Removed %d repeated images from download %s", len(in.ImageUrls)-len(unique_image_urls), *in.DownloadUuid))
+	if len(unique_media_urls) != len(in.ImageUrls) {
+		echo.EchoDebug(fmt.Sprintf("Removed %d repeated medias from download %s", len(in.ImageUrls)-len(unique_media_urls), in.GetDownloadUuid()))
 	}
 
-	new_download_uuid, err := jobs.DownloadWorker.DownloadImagesBatch(in.CategoryUuid, unique_image_urls, *in.DownloadUuid, recipient_cluster)
+	new_download_uuid, err := jobs.DownloadWorker.DownloadMediasBatch(in.CategoryUuid, unique_media_urls, in.GetDownloadUuid(), recipient_cluster)
 	if err != nil {
 		return nil, err
 	}
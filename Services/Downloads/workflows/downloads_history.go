@@ -2,6 +2,8 @@ package workflows
 
 import (
 	"fmt"
+	"libery-dungeon-libs/communication"
+	"libery_downloads_service/models"
 	"libery_downloads_service/repository"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -25,3 +27,27 @@ func ClearRepeatedDownloadFiles(download_uuid string, images_urls []string) []st
 
 	return unique_image_urls
 }
+
+// Returns the medias a download produced along with where they are now, so medias can be found after being moved.
+func LocateDownloadMedias(download_uuid string) ([]models.LocatedDownloadedMedia, error) {
+	downloaded_medias, err := repository.Downloads.GetDownloadMedias(download_uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	var located_medias []models.LocatedDownloadedMedia = make([]models.LocatedDownloadedMedia, len(downloaded_medias))
+
+	for h, downloaded_media := range downloaded_medias {
+		located_medias[h].DownloadedMedia = downloaded_media
+
+		media_identity, err := communication.Medias.GetMediaIdentity(downloaded_media.MediaUuid)
+		if err != nil {
+			echo.EchoDebug(fmt.Sprintf("Could not locate media '%s' of download '%s': %s", downloaded_media.MediaUuid, download_uuid, err.Error()))
+			continue
+		}
+
+		located_medias[h].MediaIdentity = media_identity
+	}
+
+	return located_medias, nil
+}
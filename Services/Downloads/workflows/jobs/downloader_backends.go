@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+)
+
+// Receives the progress a downloader backend parsed from its output. total_files is 0 while the backend doesn't
+// know how many files it will produce, file_percent is the progress of the file currently being downloaded.
+type BackendProgressCallback func(downloaded_files int, total_files int, file_percent float64)
+
+// A downloader that resolves a source url(a video page, a gallery, a playlist...) into media files by itself.
+type DownloaderBackend interface {
+	Name() string
+	// Downloads every media behind source_url into output_directory. Returns once the backend finished or ctx was cancelled.
+	Download(ctx context.Context, source_url string, output_directory string, report_progress BackendProgressCallback) error
+}
+
+var downloader_backends map[string]DownloaderBackend = make(map[string]DownloaderBackend)
+
+// Not safe for concurrent use, backends are registered on startup.
+func RegisterDownloaderBackend(backend DownloaderBackend) {
+	downloader_backends[backend.Name()] = backend
+}
+
+func GetDownloaderBackend(name string) (DownloaderBackend, bool) {
+	backend, exists := downloader_backends[name]
+
+	return backend, exists
+}
+
+func DownloaderBackendNames() []string {
+	var backend_names []string = make([]string, 0, len(downloader_backends))
+
+	for name := range downloader_backends {
+		backend_names = append(backend_names, name)
+	}
+
+	sort.Strings(backend_names)
+
+	return backend_names
+}
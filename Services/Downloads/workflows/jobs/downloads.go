@@ -1,16 +1,27 @@
 package jobs
 
 import (
+	"errors"
 	dungeon_models "libery-dungeon-libs/models"
+	"libery_downloads_service/models"
 
 	"github.com/gorilla/websocket"
 )
 
+// Returned by the queue operations when the download uuid doesn't match any download request on the queue.
+var ErrDownloadNotQueued = errors.New("Download is not on the queue")
+
 type DownloadManager interface {
-	DownloadImagesBatch(category_uuid string, image_urls []string, custom_download_id string, category_cluster *dungeon_models.CategoryCluster) (string, error)
+	DownloadMediasBatch(category_uuid string, media_urls []string, custom_download_id string, category_cluster *dungeon_models.CategoryCluster) (string, error)
+	DownloadWithBackend(backend_name string, source_url string, category_uuid string, category_cluster *dungeon_models.CategoryCluster) (string, error)
 	GetCurrentDownloadUUID() string
+	GetQueuedDownloads() []models.QueuedDownloadState
+	PauseDownload(download_uuid string) error
+	ResumeDownload(download_uuid string) error
+	CancelDownload(download_uuid string) error
 	RegisterDownloadListener(download_uuid string, ws *websocket.Conn) error
 	GetUpgrader() *websocket.Upgrader
+	Stop()
 }
 
 var DownloadWorker DownloadManager
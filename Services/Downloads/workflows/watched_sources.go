@@ -0,0 +1,207 @@
+package workflows
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"libery_downloads_service/models"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const chan_media_host string = "https://i.4cdn.org"
+
+var directory_href_pattern *regexp.Regexp = regexp.MustCompile(`(?i)href\s*=\s*["']([^"'#]+)["']`)
+
+// Returns the media urls found on the content of a watched source, in the order they appear.
+func ExtractSourceMediaUrls(source_kind models.WatchedSourceKind, source_url string, source_content []byte) ([]string, error) {
+	var media_urls []string
+	var err error
+
+	switch source_kind {
+	case models.WatchedSource_ThreadJson:
+		media_urls, err = extractThreadMediaUrls(source_url, source_content)
+	case models.WatchedSource_Feed:
+		media_urls, err = extractFeedMediaUrls(source_url, source_content)
+	case models.WatchedSource_Directory:
+		media_urls, err = extractDirectoryMediaUrls(source_url, source_content)
+	default:
+		return nil, fmt.Errorf("Unknown watched source kind '%s'", source_kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return uniqueUrls(media_urls), nil
+}
+
+// Threads in the 4chan api format, e.g. https://a.4cdn.org/{board}/thread/{thread_id}.json
+func extractThreadMediaUrls(source_url string, source_content []byte) ([]string, error) {
+	parsed_url, err := url.Parse(source_url)
+	if err != nil {
+		return nil, err
+	}
+
+	var board_name string = strings.Split(strings.Trim(parsed_url.Path, "/"), "/")[0]
+	if board_name == "" {
+		return nil, fmt.Errorf("Could not tell the board of thread '%s'", source_url)
+	}
+
+	thread_content := &struct {
+		Posts []struct {
+			Tim int64  `json:"tim"`
+			Ext string `json:"ext"`
+		} `json:"posts"`
+	}{}
+
+	err = json.Unmarshal(source_content, thread_content)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding thread: %s", err)
+	}
+
+	var media_urls []string = make([]string, 0)
+
+	for _, post := range thread_content.Posts {
+		if post.Tim == 0 || post.Ext == "" {
+			continue
+		}
+
+		media_urls = append(media_urls, fmt.Sprintf("%s/%s/%d%s", chan_media_host, board_name, post.Tim, post.Ext))
+	}
+
+	return media_urls, nil
+}
+
+// RSS enclosures and media:content elements, and Atom links with rel="enclosure".
+func extractFeedMediaUrls(source_url string, source_content []byte) ([]string, error) {
+	var media_urls []string = make([]string, 0)
+
+	feed_decoder := xml.NewDecoder(bytes.NewReader(source_content))
+	feed_decoder.Strict = false
+
+	for {
+		token, err := feed_decoder.Token()
+		if err != nil {
+			if err != io.EOF && len(media_urls) == 0 {
+				return nil, fmt.Errorf("Error decoding feed: %s", err)
+			}
+
+			break
+		}
+
+		start_element, is_start := token.(xml.StartElement)
+		if !is_start {
+			continue
+		}
+
+		var element_attributes map[string]string = make(map[string]string, len(start_element.Attr))
+
+		for _, attribute := range start_element.Attr {
+			element_attributes[strings.ToLower(attribute.Name.Local)] = attribute.Value
+		}
+
+		var media_url string
+
+		switch strings.ToLower(start_element.Name.Local) {
+		case "enclosure", "content":
+			media_url = element_attributes["url"]
+		case "link":
+			if strings.ToLower(element_attributes["rel"]) == "enclosure" {
+				media_url = element_attributes["href"]
+			}
+		}
+
+		if media_url == "" {
+			continue
+		}
+
+		media_type := element_attributes["type"]
+
+		if media_type != "" && !dungeon_helpers.IsSupportedMimeType(media_type) {
+			continue
+		}
+
+		resolved_url, is_valid := resolveSourceUrl(source_url, media_url)
+		if !is_valid {
+			continue
+		}
+
+		if media_type == "" && !dungeon_helpers.IsSupportedFileExtension(resolvedUrlPath(resolved_url)) {
+			continue
+		}
+
+		media_urls = append(media_urls, resolved_url)
+	}
+
+	return media_urls, nil
+}
+
+// Html directory listings, every link to a file with a supported extension is a media.
+func extractDirectoryMediaUrls(source_url string, source_content []byte) ([]string, error) {
+	var media_urls []string = make([]string, 0)
+
+	for _, href_match := range directory_href_pattern.FindAllSubmatch(source_content, -1) {
+		resolved_url, is_valid := resolveSourceUrl(source_url, string(href_match[1]))
+		if !is_valid {
+			continue
+		}
+
+		if !dungeon_helpers.IsSupportedFileExtension(resolvedUrlPath(resolved_url)) {
+			continue
+		}
+
+		media_urls = append(media_urls, resolved_url)
+	}
+
+	return media_urls, nil
+}
+
+func resolveSourceUrl(source_url string, reference string) (string, bool) {
+	base_url, err := url.Parse(source_url)
+	if err != nil {
+		return "", false
+	}
+
+	reference_url, err := url.Parse(strings.TrimSpace(reference))
+	if err != nil {
+		return "", false
+	}
+
+	resolved_url := base_url.ResolveReference(reference_url)
+
+	if resolved_url.Scheme != "http" && resolved_url.Scheme != "https" {
+		return "", false
+	}
+
+	return resolved_url.String(), true
+}
+
+func resolvedUrlPath(resolved_url string) string {
+	parsed_url, err := url.Parse(resolved_url)
+	if err != nil {
+		return ""
+	}
+
+	return parsed_url.Path
+}
+
+func uniqueUrls(urls []string) []string {
+	var seen_urls map[string]struct{} = make(map[string]struct{}, len(urls))
+	var unique_urls []string = make([]string, 0, len(urls))
+
+	for _, media_url := range urls {
+		if _, seen := seen_urls[media_url]; seen {
+			continue
+		}
+
+		seen_urls[media_url] = struct{}{}
+		unique_urls = append(unique_urls, media_url)
+	}
+
+	return unique_urls
+}
@@ -1,15 +1,21 @@
 package workers
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"libery-dungeon-libs/communication"
 	dungeon_models "libery-dungeon-libs/models"
 	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/helpers"
 	"libery_downloads_service/models"
 	"libery_downloads_service/repository"
+	"libery_downloads_service/workflows/jobs"
 	"net/http"
-	"path"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -19,18 +25,41 @@ import (
 type AsyncDownloader struct {
 	download_queue     *models.DownloadQueue
 	download_enqueued  chan bool
+	stop_signal        chan struct{}
+	queue_mutex        *sync.Mutex // Guards the download queue and the status and files of the requests on it
+	progress_mutex     *sync.Mutex
+	upload_mutex       *sync.Mutex
+	host_limiter       *hostLimiter
+	http_client        *http.Client
 	listener_upgrader  *websocket.Upgrader
-	progress_listeners map[string]*websocket.Conn
+	progress_listeners map[string][]*websocket.Conn
+	progress_snapshots map[string]*models.DownloadProgressMessage // Last progress sent for every download that didn't complete, guarded by listeners_mutex
+	progress_events    chan *models.DownloadProgressMessage
+	events_emitted_at  map[string]time.Time // When the last progress event of every download was published, guarded by listeners_mutex
+	listeners_mutex    *sync.Mutex
+	backend_slots      chan struct{}
+	external_downloads map[string]context.CancelFunc // Running downloader backend jobs, guarded by queue_mutex
+	downloads_context  context.Context               // Cancelled on Stop, aborts the media requests and downloader backend jobs in flight
+	stop_downloads     context.CancelFunc
 }
 
 func NewAsyncDownloader() *AsyncDownloader {
 	var download_queue *models.DownloadQueue = new(models.DownloadQueue)
-	var download_enqueued chan bool = make(chan bool)
 
 	var async_downloader *AsyncDownloader = new(AsyncDownloader)
 
 	async_downloader.download_queue = download_queue
-	async_downloader.download_enqueued = download_enqueued
+	async_downloader.download_enqueued = make(chan bool, 1)
+	async_downloader.stop_signal = make(chan struct{})
+	async_downloader.queue_mutex = new(sync.Mutex)
+	async_downloader.listeners_mutex = new(sync.Mutex)
+	async_downloader.progress_mutex = new(sync.Mutex)
+	async_downloader.upload_mutex = new(sync.Mutex)
+	async_downloader.host_limiter = newHostLimiter(app_config.DOWNLOAD_HOST_CONCURRENCY, app_config.DOWNLOAD_HOST_REQUESTS_PER_SECOND)
+	async_downloader.http_client = newMediaHttpClient(time.Duration(app_config.DOWNLOAD_RESPONSE_TIMEOUT_SECONDS) * time.Second)
+	async_downloader.backend_slots = make(chan struct{}, app_config.DOWNLOADER_BACKENDS_CONCURRENCY)
+	async_downloader.external_downloads = make(map[string]context.CancelFunc)
+	async_downloader.downloads_context, async_downloader.stop_downloads = context.WithCancel(context.Background())
 
 	async_downloader.listener_upgrader = &websocket.Upgrader{
 		ReadBufferSize:  512,
@@ -40,203 +69,627 @@ func NewAsyncDownloader() *AsyncDownloader {
 		},
 	}
 
-	async_downloader.progress_listeners = make(map[string]*websocket.Conn)
+	async_downloader.progress_listeners = make(map[string][]*websocket.Conn)
+	async_downloader.progress_snapshots = make(map[string]*models.DownloadProgressMessage)
+	async_downloader.events_emitted_at = make(map[string]time.Time)
+
+	if app_config.DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS > 0 {
+		async_downloader.progress_events = make(chan *models.DownloadProgressMessage, progress_events_buffer)
+
+		go async_downloader.emitProgressEvents()
+	}
+
+	async_downloader.restorePersistedQueue()
 
 	go async_downloader.monitorDownloadQueue()
 
 	return async_downloader
 }
 
+// Loads the download requests that were left unfinished when the service last stopped. Requests that were being
+// downloaded are queued again, paused ones stay paused.
+func (ad *AsyncDownloader) restorePersistedQueue() {
+	queued_downloads, err := repository.Downloads.GetQueuedDownloads()
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error restoring download queue: %s", err))
+		return
+	}
+
+	for _, queued_download := range queued_downloads {
+		if queued_download.Status == models.DownloadStatus_Cancelled {
+			ad.discardDownloadRequest(queued_download)
+			continue
+		}
+
+		if queued_download.Status == models.DownloadStatus_Downloading {
+			queued_download.Status = models.DownloadStatus_Queued
+			ad.persistDownloadStatus(queued_download)
+		}
+
+		ad.download_queue.Enqueue(queued_download)
+	}
+
+	if len(queued_downloads) > 0 {
+		echo.Echo(echo.GreenFG, fmt.Sprintf("Restored %d unfinished downloads", ad.download_queue.Len()))
+		ad.notifyDownloadEnqueued()
+	}
+}
+
 func (ad *AsyncDownloader) GetUpgrader() *websocket.Upgrader {
 	return ad.listener_upgrader
 }
 
 func (ad *AsyncDownloader) GetCurrentDownloadUUID() string {
-	if ad.download_queue.IsEmpty() {
-		return ""
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	for _, download_request := range ad.download_queue.Items() {
+		if download_request.Status == models.DownloadStatus_Downloading {
+			return download_request.DownloadUuid
+		}
+	}
+
+	return ""
+}
+
+// Returns the state of every download request on the queue, in the order they will be downloaded.
+func (ad *AsyncDownloader) GetQueuedDownloads() []models.QueuedDownloadState {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	var queued_downloads []models.QueuedDownloadState = make([]models.QueuedDownloadState, 0, ad.download_queue.Len())
+
+	for _, download_request := range ad.download_queue.Items() {
+		queued_downloads = append(queued_downloads, download_request.State())
 	}
 
-	return ad.download_queue.Peek().DownloadUuid
+	return queued_downloads
 }
 
-func (ad *AsyncDownloader) DownloadImagesBatch(category_uuid string, image_urls []string, custom_download_id string, category_cluster *dungeon_models.CategoryCluster) (string, error) {
-	new_download_request := models.CreateNewDownloadRequest(image_urls, category_uuid, category_cluster)
+func (ad *AsyncDownloader) DownloadMediasBatch(category_uuid string, media_urls []string, custom_download_id string, category_cluster *dungeon_models.CategoryCluster) (string, error) {
+	if len(media_urls) == 0 {
+		if custom_download_id != "" {
+			return custom_download_id, nil
+		}
+
+		return models.CreateNewDownloadRequest(media_urls, category_uuid, category_cluster).DownloadUuid, nil
+	}
+
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
 
 	if custom_download_id != "" {
-		new_download_request.DownloadUuid = custom_download_id
+		queued_download := ad.download_queue.Find(custom_download_id)
+
+		if queued_download != nil {
+			return custom_download_id, ad.appendToQueuedDownload(queued_download, media_urls)
+		}
 	}
 
-	if len(image_urls) > 0 {
-		ad.download_queue.Enqueue(new_download_request)
+	new_download_request := models.CreateNewDownloadRequest(media_urls, category_uuid, category_cluster)
+	new_download_request.EnqueuedAt = time.Now().UnixMilli()
+
+	if custom_download_id != "" {
+		new_download_request.DownloadUuid = custom_download_id
+	}
 
-		ad.download_enqueued <- true
+	err := repository.Downloads.InsertQueuedDownload(new_download_request)
+	if err != nil {
+		echo.EchoWarn(fmt.Sprintf("Download '%s' could not be persisted, it will not be resumed if the service stops: %s", new_download_request.DownloadUuid, err))
 	}
 
+	ad.download_queue.Enqueue(new_download_request)
+
+	ad.notifyDownloadEnqueued()
+
 	return new_download_request.DownloadUuid, nil
 }
 
-func (ad *AsyncDownloader) monitorDownloadQueue() {
-	var new_download_received bool
+// Adds the new urls of a batch whose download uuid is already on the queue to that download request. Must be
+// called with the queue mutex held.
+func (ad *AsyncDownloader) appendToQueuedDownload(queued_download *models.DownloadRequest, media_urls []string) error {
+	if queued_download.Status == models.DownloadStatus_Cancelled {
+		return fmt.Errorf("Download '%s' is being cancelled", queued_download.DownloadUuid)
+	}
+
+	var first_position int = queued_download.Len()
+
+	appended_files := queued_download.AppendUrls(media_urls)
+	if len(appended_files) == 0 {
+		return nil
+	}
+
+	err := repository.Downloads.AppendQueuedDownloadFiles(queued_download.DownloadUuid, appended_files, first_position)
+	if err != nil {
+		echo.EchoWarn(fmt.Sprintf("Files appended to download '%s' could not be persisted: %s", queued_download.DownloadUuid, err))
+	}
+
+	if queued_download.Status == models.DownloadStatus_Queued {
+		ad.notifyDownloadEnqueued()
+	}
+
+	return nil
+}
+
+// Stops downloading the request after the file currently being downloaded. A paused request keeps its place on the queue.
+func (ad *AsyncDownloader) PauseDownload(download_uuid string) error {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	download_request := ad.download_queue.Find(download_uuid)
+	if download_request == nil {
+		return jobs.ErrDownloadNotQueued
+	}
+
+	if download_request.Status != models.DownloadStatus_Queued && download_request.Status != models.DownloadStatus_Downloading {
+		return fmt.Errorf("Download '%s' cannot be paused while %s", download_uuid, download_request.Status)
+	}
+
+	download_request.Status = models.DownloadStatus_Paused
+	ad.persistDownloadStatus(download_request)
+
+	return nil
+}
+
+func (ad *AsyncDownloader) ResumeDownload(download_uuid string) error {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	download_request := ad.download_queue.Find(download_uuid)
+	if download_request == nil {
+		return jobs.ErrDownloadNotQueued
+	}
+
+	if download_request.Status != models.DownloadStatus_Paused {
+		return fmt.Errorf("Download '%s' cannot be resumed while %s", download_uuid, download_request.Status)
+	}
+
+	download_request.Status = models.DownloadStatus_Queued
+	ad.persistDownloadStatus(download_request)
+
+	ad.notifyDownloadEnqueued()
+
+	return nil
+}
 
+// Removes the request from the queue. The files that were already downloaded are kept on the download history. If the
+// request is being downloaded, it is removed once the file currently being downloaded finishes.
+func (ad *AsyncDownloader) CancelDownload(download_uuid string) error {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	download_request := ad.download_queue.Find(download_uuid)
+	if download_request == nil {
+		if cancel_external_download, is_external := ad.external_downloads[download_uuid]; is_external {
+			cancel_external_download()
+			return nil
+		}
+
+		return jobs.ErrDownloadNotQueued
+	}
+
+	if download_request.Status == models.DownloadStatus_Cancelled {
+		return nil
+	}
+
+	var was_downloading bool = download_request.Status == models.DownloadStatus_Downloading
+
+	download_request.Status = models.DownloadStatus_Cancelled
+	ad.persistDownloadStatus(download_request)
+
+	if !was_downloading {
+		ad.download_queue.Remove(download_uuid)
+		ad.discardDownloadRequest(download_request)
+	}
+
+	return nil
+}
+
+func (ad *AsyncDownloader) notifyDownloadEnqueued() {
+	select {
+	case ad.download_enqueued <- true:
+	default:
+		// The monitor already has a pending notification
+	}
+}
+
+func (ad *AsyncDownloader) monitorDownloadQueue() {
 	for {
+		ad.queue_mutex.Lock()
 
-		if ad.download_queue.IsEmpty() {
+		download_request := ad.download_queue.PeekQueued()
+		if download_request != nil {
+			download_request.Status = models.DownloadStatus_Downloading
+			ad.persistDownloadStatus(download_request)
+		}
+
+		ad.queue_mutex.Unlock()
+
+		if download_request == nil {
 			select {
-			case new_download_received = <-ad.download_enqueued:
-				if !new_download_received {
-					return
-				}
+			case <-ad.download_enqueued:
+				continue
+			case <-ad.stop_signal:
+				return
 			}
 		}
 
 		echo.Echo(echo.GreenFG, "Downloading batch")
 
-		download_request := ad.download_queue.Peek()
-		if download_request == nil {
-			echo.EchoWarn("Download received but queue is empty")
-			continue
+		ad.downloadMediasRequest(download_request)
+
+		ad.settleDownloadRequest(download_request)
+
+		select {
+		case <-ad.stop_signal:
+			return
+		default:
 		}
+	}
+}
+
+// Downloads the pending files of the request with a pool of workers. Returns once every file was processed or the
+// request stopped being downloaded.
+func (ad *AsyncDownloader) downloadMediasRequest(download_request *models.DownloadRequest) {
+	ad.queue_mutex.Lock()
+	var total_files int = download_request.Len()
+	var processed_files int = download_request.ProcessedCount()
+	ad.queue_mutex.Unlock()
 
-		// time.Sleep(500 * time.Millisecond) // Simulate download time
+	err := communication.Medias.GetUploadStreamTicket(download_request.DownloadUuid, download_request.CategoryUuid, total_files-processed_files)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error getting upload stream ticket for download '%s': %s", download_request.DownloadUuid, err))
+	}
 
-		ad.downloadImagesRequest(download_request)
+	defer communication.Medias.ReleaseUploadStreamTicket(download_request.DownloadUuid)
 
-		_, err := ad.download_queue.Dequeue()
-		if err != nil {
-			echo.EchoErr(err)
-		}
+	if processed_files > 0 {
+		// Resumed download, let listeners know where it starts from
+		ad.updateProgress(download_request, processed_files, total_files)
+	}
 
-		err = ad.registerDownload(download_request)
+	var pending_positions chan int = make(chan int)
+	var workers_group sync.WaitGroup
 
-		echo.Echo(echo.GreenFG, fmt.Sprintf("Downloaded batch: %s", download_request.DownloadUuid))
+	for w := 0; w < app_config.DOWNLOAD_WORKERS; w++ {
+		workers_group.Add(1)
+
+		go func() {
+			defer workers_group.Done()
+
+			for position := range pending_positions {
+				ad.downloadRequestFile(download_request, position)
+			}
+		}()
 	}
+
+	var is_stopping bool = false
+
+	for h := 0; h < total_files && !is_stopping; h++ {
+		if !ad.isBeingDownloaded(download_request) {
+			break
+		}
+
+		select {
+		case pending_positions <- h:
+		case <-ad.stop_signal:
+			is_stopping = true
+		}
+	}
+
+	close(pending_positions)
+
+	workers_group.Wait()
 }
 
-func (ad *AsyncDownloader) downloadImagesRequest(download_request *models.DownloadRequest) {
-	var current_file *models.DownloadFile
+func (ad *AsyncDownloader) isBeingDownloaded(download_request *models.DownloadRequest) bool {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
 
-	communication.Medias.GetUploadStreamTicket(download_request.DownloadUuid, download_request.CategoryUuid, download_request.Len())
+	return download_request.Status == models.DownloadStatus_Downloading
+}
 
-	for h := 0; h < download_request.Len(); h++ {
-		current_file = download_request.Get(h)
+// Downloads the file at the given position of the request, retrying with backoff until it runs out of tries. The
+// file state is persisted after every attempt so a restart doesn't grant it extra tries.
+func (ad *AsyncDownloader) downloadRequestFile(download_request *models.DownloadRequest, position int) {
+	ad.queue_mutex.Lock()
+	var current_file models.DownloadFile = *download_request.Get(position)
+	ad.queue_mutex.Unlock()
 
-		if current_file.IsDownloaded {
-			continue
+	if current_file.IsDownloaded {
+		return
+	}
+
+	var base_delay time.Duration = time.Duration(app_config.DOWNLOAD_BACKOFF_BASE_MS) * time.Millisecond
+	var max_delay time.Duration = time.Duration(app_config.DOWNLOAD_BACKOFF_MAX_MS) * time.Millisecond
+	var file_host string = urlHost(current_file.Url)
+
+	for current_file.Trys < app_config.DOWNLOAD_MAX_TRIES {
+		if !ad.isBeingDownloaded(download_request) {
+			return
+		}
+
+		if !ad.host_limiter.acquire(file_host, ad.stop_signal) {
+			return
 		}
 
-		downloaded := ad.downloadImageFile(current_file.Url, download_request.CategoryUuid)
+		attempt := ad.downloadMediaFile(current_file.Url, download_request.DownloadUuid, download_request.CategoryUuid, clusterUuidOf(download_request.CategoryCluster))
 
-		for !downloaded && current_file.Trys < 3 {
-			if !downloaded {
-				time.Sleep(500 * time.Millisecond) // give the server some time to recover
+		ad.host_limiter.release(file_host)
+
+		if !attempt.downloaded {
+			select {
+			case <-ad.stop_signal:
+				// The attempt was likely aborted by the downloader stopping, it is not held against the file.
+				return
+			default:
 			}
+		}
 
-			downloaded = ad.downloadImageFile(current_file.Url, download_request.CategoryUuid)
+		current_file.Trys++
 
-			current_file.Trys++
+		if attempt.downloaded || !attempt.retryable || current_file.Trys >= app_config.DOWNLOAD_MAX_TRIES {
+			current_file.IsDownloaded = true
+			current_file.Failed = !attempt.downloaded
+			break
 		}
 
-		// Update download progress for listeners
-		ad.updateProgress(download_request.DownloadUuid, h+1, download_request.Len())
+		ad.saveRequestFile(download_request, position, current_file, false)
 
-		current_file.IsDownloaded = true
+		var retry_delay time.Duration = backoffDelay(current_file.Trys-1, base_delay, max_delay)
+
+		if attempt.retry_after > 0 {
+			ad.host_limiter.blockUntil(file_host, time.Now().Add(attempt.retry_after))
+
+			if attempt.retry_after > retry_delay {
+				retry_delay = attempt.retry_after
+			}
+		}
+
+		if !sleepOrStop(retry_delay, ad.stop_signal) {
+			return
+		}
 	}
+
+	ad.saveRequestFile(download_request, position, current_file, true)
 }
 
-func (ad *AsyncDownloader) downloadImageFile(image_url string, target_category string) bool {
-	client := &http.Client{}
+// Writes the file state back to the request and persists it. If report_progress is set, listeners are sent the
+// request progress; the progress mutex keeps concurrent workers from sending it out of order.
+func (ad *AsyncDownloader) saveRequestFile(download_request *models.DownloadRequest, position int, download_file models.DownloadFile, report_progress bool) {
+	if report_progress {
+		ad.progress_mutex.Lock()
+		defer ad.progress_mutex.Unlock()
+	}
+
+	ad.queue_mutex.Lock()
 
-	image_http_request, err := http.NewRequest("GET", image_url, nil)
+	*download_request.Get(position) = download_file
+
+	err := repository.Downloads.UpdateQueuedDownloadFile(download_request.DownloadUuid, position, download_file)
 	if err != nil {
-		echo.EchoErr(fmt.Errorf("Error creating image request: %s", err))
-		return false
+		echo.EchoErr(err)
 	}
 
-	image_http_request.Header.Set("User-Agent", app_config.DOWNLOAD_USER_AGENT)
+	var processed_files int = download_request.ProcessedCount()
+	var total_files int = download_request.Len()
 
-	image_http_response, err := client.Do(image_http_request)
-	if err != nil {
-		echo.EchoErr(fmt.Errorf("Error requesting image: %s", err))
-		return false
+	ad.queue_mutex.Unlock()
+
+	if report_progress {
+		ad.updateProgress(download_request, processed_files, total_files)
 	}
-	defer image_http_response.Body.Close()
+}
 
-	if image_http_response.StatusCode != 200 {
-		echo.EchoErr(fmt.Errorf("Error requesting image: %s", image_http_response.Status))
-		return false
+// Decides what happens to a download request after the downloader stopped working on it. Completed and cancelled
+// requests leave the queue, requests that got new files while being downloaded are queued again.
+func (ad *AsyncDownloader) settleDownloadRequest(download_request *models.DownloadRequest) {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	switch download_request.Status {
+	case models.DownloadStatus_Paused:
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Paused batch: %s", download_request.DownloadUuid))
+	case models.DownloadStatus_Cancelled:
+		ad.download_queue.Remove(download_request.DownloadUuid)
+		ad.discardDownloadRequest(download_request)
+
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Cancelled batch: %s", download_request.DownloadUuid))
+	case models.DownloadStatus_Downloading:
+		if !download_request.IsDownloaded() {
+			download_request.Status = models.DownloadStatus_Queued
+			ad.persistDownloadStatus(download_request)
+			return
+		}
+
+		ad.download_queue.Remove(download_request.DownloadUuid)
+		ad.discardDownloadRequest(download_request)
+
+		echo.Echo(echo.GreenFG, fmt.Sprintf("Downloaded batch: %s", download_request.DownloadUuid))
 	}
+}
+
+// Registers the processed files of a download request that left the queue on the download history and removes it
+// from the persisted queue.
+func (ad *AsyncDownloader) discardDownloadRequest(download_request *models.DownloadRequest) {
+	processed_part := download_request.ProcessedPart()
+
+	if processed_part.Len() > 0 {
+		err := ad.registerDownload(processed_part)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+
+		var final_status models.DownloadRequestStatus = models.DownloadStatus_Completed
 
-	filename := path.Base(image_url)
-	echo.Echo(echo.CyanFG, fmt.Sprintf("Downloading image: %s", filename))
+		if download_request.Status == models.DownloadStatus_Cancelled {
+			final_status = models.DownloadStatus_Cancelled
+		}
+
+		err = repository.Downloads.SetDownloadStatus(download_request.DownloadUuid, final_status)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+	}
 
-	err = communication.Medias.UploadMediaFile(image_http_response.Body, filename)
+	err := repository.Downloads.DeleteQueuedDownload(download_request.DownloadUuid)
 	if err != nil {
-		echo.EchoErr(fmt.Errorf("Error uploading image: %s", err))
-		return false
+		echo.EchoErr(err)
 	}
 
-	return true
+	ad.closeProgressListener(download_request.DownloadUuid)
 }
 
-func (ad *AsyncDownloader) registerDownload(download_request *models.DownloadRequest) (err error) {
-	download_exists, err := repository.Downloads.DownloadExists(download_request.DownloadUuid)
+func (ad *AsyncDownloader) persistDownloadStatus(download_request *models.DownloadRequest) {
+	err := repository.Downloads.UpdateQueuedDownloadStatus(download_request.DownloadUuid, download_request.Status)
 	if err != nil {
-		return fmt.Errorf("Error checking if download exists: %s", err)
+		echo.EchoErr(err)
 	}
+}
 
-	if !download_exists {
-		err = repository.Downloads.InsertDownload(download_request)
-		if err != nil {
-			echo.EchoErr(err)
-		}
-	} else {
-		repository.Downloads.UpdateDownloadFiles(download_request.DownloadUuid, download_request.DownloadFiles())
+// The client used for the media requests. The response timeout only covers the wait for the host to answer, reading the
+// body has no deadline as large files on slow hosts can take long; stalled reads end when the downloader is stopped.
+func newMediaHttpClient(response_timeout time.Duration) *http.Client {
+	var media_transport *http.Transport = http.DefaultTransport.(*http.Transport).Clone()
+
+	media_transport.ResponseHeaderTimeout = response_timeout
+
+	return &http.Client{
+		Transport: media_transport,
 	}
+}
 
-	return
+type downloadAttempt struct {
+	downloaded  bool
+	retryable   bool          // False when trying again won't change the outcome, e.g. a 404
+	retry_after time.Duration // Wait requested by the host through the Retry-After header
 }
 
-func (ad *AsyncDownloader) RegisterDownloadListener(download_uuid string, ws *websocket.Conn) error {
-	if _, exists := ad.progress_listeners[download_uuid]; exists {
-		return fmt.Errorf("Download progress connection already exists")
+func (ad *AsyncDownloader) downloadMediaFile(media_url string, upload_uuid string, category_uuid string, cluster_uuid string) downloadAttempt {
+	var attempt downloadAttempt = downloadAttempt{retryable: true}
+
+	media_http_request, err := http.NewRequestWithContext(ad.downloads_context, "GET", media_url, nil)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error creating media request: %s", err))
+		attempt.retryable = false
+		return attempt
 	}
 
-	ad.progress_listeners[download_uuid] = ws
+	media_http_request.Header.Set("User-Agent", app_config.DOWNLOAD_USER_AGENT)
 
-	return nil
-}
+	media_http_response, err := ad.http_client.Do(media_http_request)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error requesting media: %s", err))
+		return attempt
+	}
+	defer media_http_response.Body.Close()
 
-func (ad *AsyncDownloader) updateProgress(download_uuid string, downloaded_files int, total_files int) {
+	if media_http_response.StatusCode != 200 {
+		echo.EchoErr(fmt.Errorf("Error requesting media: %s", media_http_response.Status))
 
-	peer, has_listener := ad.progress_listeners[download_uuid]
-	if !has_listener {
-		echo.EchoWarn(fmt.Sprintf("No listener for download: %s", download_uuid))
-		return
+		var status_code int = media_http_response.StatusCode
+
+		attempt.retryable = status_code == http.StatusRequestTimeout || status_code == http.StatusTooManyRequests || status_code >= 500
+		attempt.retry_after = parseRetryAfter(media_http_response.Header.Get("Retry-After"), time.Duration(app_config.DOWNLOAD_RETRY_AFTER_MAX_SECONDS)*time.Second)
+
+		return attempt
+	}
+
+	var max_file_size int64 = app_config.DOWNLOAD_MAX_FILE_SIZE_MB * 1024 * 1024
+
+	if max_file_size > 0 && media_http_response.ContentLength > max_file_size {
+		echo.EchoErr(fmt.Errorf("Skipping media '%s': it is %d bytes, the limit is %d", media_url, media_http_response.ContentLength, max_file_size))
+		attempt.retryable = false
+		return attempt
 	}
 
-	is_completed := downloaded_files == total_files
+	var media_body io.Reader = media_http_response.Body
 
-	progress := models.CreateDownloadProgressMessage(download_uuid, total_files, downloaded_files, is_completed)
-	progress_json, err := json.Marshal(progress)
+	if max_file_size > 0 {
+		// One byte over the limit is enough to tell the file is too large when the host didn't send a Content-Length
+		media_body = io.LimitReader(media_body, max_file_size+1)
+	}
+
+	// The file is fetched before it is uploaded so workers only take turns on the upload stream, not on the download.
+	media_file, err := os.CreateTemp("", "libery-download-*")
 	if err != nil {
-		echo.EchoErr(err)
-		return
+		echo.EchoErr(fmt.Errorf("Error creating temporary file: %s", err))
+		return attempt
 	}
+	defer os.Remove(media_file.Name())
+	defer media_file.Close()
+
+	// The content hash is computed while the file is written, duplicates are told apart by it
+	content_hasher := sha256.New()
 
-	err = peer.WriteMessage(websocket.TextMessage, progress_json)
+	media_size, err := io.Copy(io.MultiWriter(media_file, content_hasher), media_body)
 	if err != nil {
-		// Peer disconnected
-		peer.Close()
-		delete(ad.progress_listeners, download_uuid)
-		return
+		echo.EchoErr(fmt.Errorf("Error reading media: %s", err))
+		return attempt
+	}
+
+	if max_file_size > 0 && media_size > max_file_size {
+		echo.EchoErr(fmt.Errorf("Skipping media '%s': it is larger than the %d bytes limit", media_url, max_file_size))
+		attempt.retryable = false
+		return attempt
+	}
+
+	var sniff_buffer []byte = make([]byte, 512)
+
+	sniffed_bytes, err := media_file.ReadAt(sniff_buffer, 0)
+	if err != nil && err != io.EOF {
+		echo.EchoErr(fmt.Errorf("Error reading media type: %s", err))
+		return attempt
+	}
+
+	media_type, is_supported := helpers.ResolveMediaType(helpers.SniffMediaType(sniff_buffer[:sniffed_bytes]), media_http_response.Header.Get("Content-Type"))
+	if !is_supported {
+		echo.EchoErr(fmt.Errorf("Skipping media '%s': '%s' is not a supported media type", media_url, media_type))
+		attempt.retryable = false
+		return attempt
+	}
+
+	filename := helpers.MediaFilename(media_http_response.Header.Get("Content-Disposition"), media_url, media_type)
+	echo.Echo(echo.CyanFG, fmt.Sprintf("Downloading media: %s", filename))
+
+	_, err = media_file.Seek(0, io.SeekStart)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error rewinding media file: %s", err))
+		return attempt
+	}
+
+	_, err = ad.uploadDownloadedMedia(upload_uuid, category_uuid, cluster_uuid, media_url, media_file, filename, media_type, hex.EncodeToString(content_hasher.Sum(nil)))
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error uploading media: %s", err))
+		return attempt
+	}
+
+	attempt.downloaded = true
+
+	return attempt
+}
+
+func (ad *AsyncDownloader) registerDownload(download_request *models.DownloadRequest) (err error) {
+	download_exists, err := repository.Downloads.DownloadExists(download_request.DownloadUuid)
+	if err != nil {
+		return fmt.Errorf("Error checking if download exists: %s", err)
 	}
 
-	if is_completed {
-		peer.Close()
-		delete(ad.progress_listeners, download_uuid)
+	if !download_exists {
+		err = repository.Downloads.InsertDownload(download_request)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+	} else {
+		repository.Downloads.UpdateDownloadFiles(download_request.DownloadUuid, download_request.DownloadFiles())
 	}
+
+	return
 }
 
+// Stops the downloader. Media requests in flight are aborted, unfinished requests stay on the persisted queue and
+// are resumed the next time the service starts, running downloader backends are killed.
 func (ad *AsyncDownloader) Stop() {
-	ad.download_enqueued <- false
+	close(ad.stop_signal)
+	ad.stop_downloads()
 }
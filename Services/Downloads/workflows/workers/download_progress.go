@@ -0,0 +1,180 @@
+package workers
+
+import (
+	"encoding/json"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/models"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+	"github.com/gorilla/websocket"
+)
+
+// A listener that doesn't take a progress message within this time is disconnected, so it can't hold back the rest.
+const progress_write_timeout time.Duration = 5 * time.Second
+
+// Progress events waiting to be published through JD. When the buffer is full, progress events are dropped.
+const progress_events_buffer int = 64
+
+// Adds a progress listener to the download. Any number of listeners can follow the same download, and they are sent
+// the current progress of the download as soon as they connect.
+func (ad *AsyncDownloader) RegisterDownloadListener(download_uuid string, ws *websocket.Conn) error {
+	queued_snapshot := ad.queuedProgressSnapshot(download_uuid)
+
+	ad.listeners_mutex.Lock()
+	defer ad.listeners_mutex.Unlock()
+
+	progress_snapshot, has_snapshot := ad.progress_snapshots[download_uuid]
+	if !has_snapshot {
+		progress_snapshot = queued_snapshot
+	}
+
+	if progress_snapshot != nil {
+		err := writeProgressMessage(ws, progress_snapshot)
+		if err != nil {
+			return fmt.Errorf("Error sending current progress of download '%s': %s", download_uuid, err)
+		}
+	}
+
+	ad.progress_listeners[download_uuid] = append(ad.progress_listeners[download_uuid], ws)
+
+	return nil
+}
+
+// Returns the progress of a download that is on the queue but didn't send any progress yet, nil if it's not on the queue.
+func (ad *AsyncDownloader) queuedProgressSnapshot(download_uuid string) *models.DownloadProgressMessage {
+	ad.queue_mutex.Lock()
+	defer ad.queue_mutex.Unlock()
+
+	download_request := ad.download_queue.Find(download_uuid)
+	if download_request == nil {
+		return nil
+	}
+
+	return models.CreateDownloadProgressMessage(download_uuid, clusterUuidOf(download_request.CategoryCluster), download_request.Len(), download_request.ProcessedCount(), false)
+}
+
+func (ad *AsyncDownloader) updateProgress(download_request *models.DownloadRequest, downloaded_files int, total_files int) {
+	is_completed := downloaded_files == total_files
+
+	ad.sendProgress(models.CreateDownloadProgressMessage(download_request.DownloadUuid, clusterUuidOf(download_request.CategoryCluster), total_files, downloaded_files, is_completed))
+}
+
+// Sends the progress to every listener of the download and publishes it through JD. Listeners are disconnected once
+// the download completes.
+func (ad *AsyncDownloader) sendProgress(progress *models.DownloadProgressMessage) {
+	var download_uuid string = progress.DownloadUuid
+
+	ad.listeners_mutex.Lock()
+	defer ad.listeners_mutex.Unlock()
+
+	ad.publishProgressEvent(progress)
+
+	if progress.Completed {
+		delete(ad.progress_snapshots, download_uuid)
+	} else {
+		ad.progress_snapshots[download_uuid] = progress
+	}
+
+	var listeners []*websocket.Conn = ad.progress_listeners[download_uuid]
+	var connected_listeners []*websocket.Conn = make([]*websocket.Conn, 0, len(listeners))
+
+	for _, peer := range listeners {
+		err := writeProgressMessage(peer, progress)
+		if err != nil || progress.Completed {
+			// Peer disconnected or there is nothing left to report
+			peer.Close()
+			continue
+		}
+
+		connected_listeners = append(connected_listeners, peer)
+	}
+
+	if len(connected_listeners) == 0 {
+		delete(ad.progress_listeners, download_uuid)
+		return
+	}
+
+	ad.progress_listeners[download_uuid] = connected_listeners
+}
+
+// Disconnects the listeners of a download that won't report any more progress.
+func (ad *AsyncDownloader) closeProgressListener(download_uuid string) {
+	ad.listeners_mutex.Lock()
+	defer ad.listeners_mutex.Unlock()
+
+	for _, peer := range ad.progress_listeners[download_uuid] {
+		peer.Close()
+	}
+
+	delete(ad.progress_listeners, download_uuid)
+	delete(ad.progress_snapshots, download_uuid)
+	delete(ad.events_emitted_at, download_uuid)
+}
+
+// Hands the progress to the events emitter unless an event for the download was published less than
+// DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS ago. Completions are always published. Must be called with the listeners mutex held.
+func (ad *AsyncDownloader) publishProgressEvent(progress *models.DownloadProgressMessage) {
+	if ad.progress_events == nil {
+		return
+	}
+
+	var now time.Time = time.Now()
+	var events_interval time.Duration = time.Duration(app_config.DOWNLOAD_PROGRESS_EVENTS_INTERVAL_MS) * time.Millisecond
+
+	if progress.Completed {
+		delete(ad.events_emitted_at, progress.DownloadUuid)
+	} else if emitted_at, was_emitted := ad.events_emitted_at[progress.DownloadUuid]; was_emitted && now.Sub(emitted_at) < events_interval {
+		return
+	} else {
+		ad.events_emitted_at[progress.DownloadUuid] = now
+	}
+
+	select {
+	case ad.progress_events <- progress:
+	default:
+		echo.EchoWarn(fmt.Sprintf("Progress events buffer is full, dropped progress event of download '%s'", progress.DownloadUuid))
+	}
+}
+
+// Publishes the progress events in the order they were produced, until the downloader stops.
+func (ad *AsyncDownloader) emitProgressEvents() {
+	for {
+		select {
+		case progress := <-ad.progress_events:
+			progress_event := communication.NewDownloadProgressEvent(app_config.JWT_SECRET, progress.ClusterUuid, progress.DownloadUuid, progress.TotalFiles, progress.DownloadedFiles, progress.Completed, progress.FileProgress)
+			if progress_event == nil {
+				continue
+			}
+
+			err := progress_event.Emit()
+			if err != nil {
+				echo.EchoErr(fmt.Errorf("Error emitting progress event of download '%s': %s", progress.DownloadUuid, err))
+			}
+		case <-ad.stop_signal:
+			return
+		}
+	}
+}
+
+func writeProgressMessage(peer *websocket.Conn, progress *models.DownloadProgressMessage) error {
+	progress_json, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+
+	peer.SetWriteDeadline(time.Now().Add(progress_write_timeout))
+
+	return peer.WriteMessage(websocket.TextMessage, progress_json)
+}
+
+func clusterUuidOf(category_cluster *dungeon_models.CategoryCluster) string {
+	if category_cluster == nil {
+		return ""
+	}
+
+	return category_cluster.Uuid
+}
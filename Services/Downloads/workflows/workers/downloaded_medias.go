@@ -0,0 +1,146 @@
+package workers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"libery-dungeon-libs/communication"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"os"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+	"github.com/google/uuid"
+)
+
+// Uploads a media to the upload stream of the download and records which media it became, so the media can be traced
+// back to its download and source url. Files the routing rules of the cluster send to another category get an upload
+// stream of their own. If the cluster already has a media with the same content, the file is not uploaded and the
+// download is linked to that media instead. Returns whether the file was skipped as a duplicate.
+func (ad *AsyncDownloader) uploadDownloadedMedia(download_uuid, category_uuid, cluster_uuid, source_url string, media_file io.Reader, filename, media_type, content_hash string) (bool, error) {
+	// Also keeps two workers from uploading the same content at once. The upload stream ticket is a cookie that the
+	// medias service updates on every upload, so uploads have to take turns anyway.
+	ad.upload_mutex.Lock()
+	defer ad.upload_mutex.Unlock()
+
+	if app_config.DOWNLOAD_SKIP_DUPLICATES {
+		duplicate_of := findDuplicateMedia(cluster_uuid, content_hash)
+
+		if duplicate_of != "" {
+			echo.Echo(echo.YellowFG, fmt.Sprintf("Skipping '%s': its content is already on media '%s'", filename, duplicate_of))
+			linkDuplicateMedia(download_uuid, source_url, content_hash, duplicate_of)
+			return true, nil
+		}
+	}
+
+	file_route := routeDownloadedFile(cluster_uuid, category_uuid, models.RoutedFile{
+		SourceUrl: source_url,
+		Filename:  filename,
+		MimeType:  media_type,
+	})
+
+	var upload_stream string = download_uuid
+
+	if file_route.category_uuid != "" {
+		routed_stream := fmt.Sprintf("%s/%s", download_uuid, uuid.New().String())
+
+		err := communication.Medias.GetUploadStreamTicket(routed_stream, file_route.category_uuid, 1)
+		if err != nil {
+			echo.EchoErr(fmt.Errorf("Error getting upload stream ticket for category '%s', '%s' stays in the download category: %s", file_route.category_uuid, filename, err))
+		} else {
+			upload_stream = routed_stream
+			defer communication.Medias.ReleaseUploadStreamTicket(routed_stream)
+		}
+	}
+
+	downloaded_media_id, err := repository.Downloads.ReserveDownloadedMedia(download_uuid, source_url)
+	if err != nil {
+		// Not being able to trace the media is no reason to lose it
+		echo.EchoWarn(fmt.Sprintf("Media '%s' won't be linked to download '%s': %s", filename, download_uuid, err))
+		downloaded_media_id = 0
+	}
+
+	media_uuid, err := communication.Medias.UploadMediaStream(upload_stream, media_file, filename, media_type, downloaded_media_id)
+	if err != nil {
+		if downloaded_media_id != 0 {
+			lerr := repository.Downloads.DeleteDownloadedMedia(downloaded_media_id)
+			if lerr != nil {
+				echo.EchoErr(lerr)
+			}
+		}
+
+		return false, err
+	}
+
+	if downloaded_media_id != 0 {
+		err = repository.Downloads.CompleteDownloadedMedia(downloaded_media_id, media_uuid)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+	}
+
+	applyRouteTags(media_uuid, file_route.tag_ids)
+
+	return false, nil
+}
+
+// Returns the uuid of the media of the cluster that has the given content, or an empty string if there is none. The content
+// hashes of the cluster medias are kept by the medias service, so medias that didn't come from a download are found too.
+func findDuplicateMedia(cluster_uuid string, content_hash string) string {
+	if content_hash == "" || cluster_uuid == "" {
+		return ""
+	}
+
+	media_identity, err := communication.Medias.GetClusterMediaByContentHash(cluster_uuid, content_hash)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error looking up content hash '%s' on cluster '%s': %s", content_hash, cluster_uuid, err))
+		return ""
+	}
+
+	if media_identity == nil || media_identity.Media == nil {
+		return ""
+	}
+
+	return media_identity.Media.Uuid
+}
+
+// Records that a downloaded file was skipped for being a duplicate, and lists the media it duplicates among the medias
+// of the download.
+func linkDuplicateMedia(download_uuid, source_url, content_hash, duplicate_of string) {
+	err := repository.Downloads.InsertSkippedDownloadFile(models.SkippedDownloadFile{
+		DownloadUuid: download_uuid,
+		SourceUrl:    source_url,
+		ContentHash:  content_hash,
+		DuplicateOf:  duplicate_of,
+		SkippedAt:    time.Now().Unix(),
+	})
+	if err != nil {
+		echo.EchoErr(err)
+	}
+
+	err = repository.Downloads.LinkDownloadedMedia(download_uuid, source_url, duplicate_of)
+	if err != nil {
+		echo.EchoErr(err)
+	}
+}
+
+// Returns the hex encoded sha256 of a file.
+func fileContentHash(file_path string) (string, error) {
+	content_file, err := os.Open(file_path)
+	if err != nil {
+		return "", err
+	}
+	defer content_file.Close()
+
+	content_hasher := sha256.New()
+
+	_, err = io.Copy(content_hasher, content_file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(content_hasher.Sum(nil)), nil
+}
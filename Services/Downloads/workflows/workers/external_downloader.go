@@ -0,0 +1,187 @@
+package workers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/workflows/jobs"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// A downloader backend that shells out to an executable like yt-dlp or gallery-dl.
+type ExternalDownloader struct {
+	name            string
+	executable      string
+	arguments       []string
+	items_pattern   *regexp.Regexp
+	percent_pattern *regexp.Regexp
+}
+
+func NewExternalDownloader(backend_settings app_config.DownloaderBackendSettings) (*ExternalDownloader, error) {
+	if backend_settings.Name == "" || backend_settings.Executable == "" {
+		return nil, fmt.Errorf("Downloader backends need a name and an executable")
+	}
+
+	executable_path, err := exec.LookPath(backend_settings.Executable)
+	if err != nil {
+		return nil, fmt.Errorf("Executable of downloader backend '%s' not found: %s", backend_settings.Name, err)
+	}
+
+	var external_downloader *ExternalDownloader = new(ExternalDownloader)
+
+	external_downloader.name = backend_settings.Name
+	external_downloader.executable = executable_path
+	external_downloader.arguments = backend_settings.Arguments
+
+	if backend_settings.ItemsPattern != "" {
+		external_downloader.items_pattern, err = regexp.Compile(backend_settings.ItemsPattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid items_pattern for downloader backend '%s': %s", backend_settings.Name, err)
+		}
+
+		if external_downloader.items_pattern.SubexpIndex("current") < 0 || external_downloader.items_pattern.SubexpIndex("total") < 0 {
+			return nil, fmt.Errorf("items_pattern of downloader backend '%s' must have 'current' and 'total' groups", backend_settings.Name)
+		}
+	}
+
+	if backend_settings.PercentPattern != "" {
+		external_downloader.percent_pattern, err = regexp.Compile(backend_settings.PercentPattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid percent_pattern for downloader backend '%s': %s", backend_settings.Name, err)
+		}
+
+		if external_downloader.percent_pattern.SubexpIndex("percent") < 0 {
+			return nil, fmt.Errorf("percent_pattern of downloader backend '%s' must have a 'percent' group", backend_settings.Name)
+		}
+	}
+
+	return external_downloader, nil
+}
+
+func (ed *ExternalDownloader) Name() string {
+	return ed.name
+}
+
+func (ed *ExternalDownloader) commandArguments(source_url string, output_directory string) []string {
+	var command_arguments []string = make([]string, 0, len(ed.arguments)+1)
+	var has_url_placeholder bool = false
+
+	for _, argument := range ed.arguments {
+		if strings.Contains(argument, "{url}") {
+			has_url_placeholder = true
+		}
+
+		argument = strings.ReplaceAll(argument, "{url}", source_url)
+		argument = strings.ReplaceAll(argument, "{output_directory}", output_directory)
+
+		command_arguments = append(command_arguments, argument)
+	}
+
+	if !has_url_placeholder {
+		command_arguments = append(command_arguments, source_url)
+	}
+
+	return command_arguments
+}
+
+func (ed *ExternalDownloader) Download(ctx context.Context, source_url string, output_directory string, report_progress jobs.BackendProgressCallback) error {
+	command := exec.CommandContext(ctx, ed.executable, ed.commandArguments(source_url, output_directory)...)
+	command.Dir = output_directory
+
+	output_reader, output_writer := io.Pipe()
+	command.Stdout = output_writer
+	command.Stderr = output_writer
+
+	err := command.Start()
+	if err != nil {
+		return fmt.Errorf("Error starting downloader backend '%s': %s", ed.name, err)
+	}
+
+	var output_parsed chan struct{} = make(chan struct{})
+
+	go func() {
+		defer close(output_parsed)
+		ed.parseProgress(output_reader, report_progress)
+	}()
+
+	err = command.Wait()
+	output_writer.Close()
+	<-output_parsed
+
+	if err != nil {
+		return fmt.Errorf("Downloader backend '%s' failed: %s", ed.name, err)
+	}
+
+	return nil
+}
+
+// Reads the backend output line by line. Progress bars are usually redrawn with carriage returns, so those end a
+// line too.
+func (ed *ExternalDownloader) parseProgress(output io.Reader, report_progress jobs.BackendProgressCallback) {
+	var output_scanner *bufio.Scanner = bufio.NewScanner(output)
+
+	output_scanner.Split(scanProgressLines)
+
+	var downloaded_files, total_files int = 0, 0
+	var file_percent float64 = 0
+
+	for output_scanner.Scan() {
+		var output_line string = output_scanner.Text()
+		var has_progress bool = false
+
+		if ed.items_pattern != nil {
+			if items_match := ed.items_pattern.FindStringSubmatch(output_line); items_match != nil {
+				current_item, current_err := strconv.Atoi(items_match[ed.items_pattern.SubexpIndex("current")])
+				items_total, total_err := strconv.Atoi(items_match[ed.items_pattern.SubexpIndex("total")])
+
+				if current_err == nil && total_err == nil {
+					// The backend announces the item it starts, the previous ones are done
+					downloaded_files = current_item - 1
+					total_files = items_total
+					file_percent = 0
+					has_progress = true
+				}
+			}
+		}
+
+		if ed.percent_pattern != nil {
+			if percent_match := ed.percent_pattern.FindStringSubmatch(output_line); percent_match != nil {
+				percent, err := strconv.ParseFloat(percent_match[ed.percent_pattern.SubexpIndex("percent")], 64)
+				if err == nil {
+					file_percent = percent
+					has_progress = true
+				}
+			}
+		}
+
+		if has_progress && report_progress != nil {
+			report_progress(downloaded_files, total_files, file_percent)
+		} else {
+			echo.EchoDebug(fmt.Sprintf("[%s] %s", ed.name, output_line))
+		}
+	}
+}
+
+func scanProgressLines(data []byte, at_eof bool) (advance int, token []byte, err error) {
+	if at_eof && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if line_end := bytes.IndexAny(data, "\r\n"); line_end >= 0 {
+		return line_end + 1, bytes.TrimSpace(data[:line_end]), nil
+	}
+
+	if at_eof {
+		return len(data), bytes.TrimSpace(data), nil
+	}
+
+	return 0, nil, nil
+}
@@ -0,0 +1,294 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/helpers"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"libery_downloads_service/workflows/jobs"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+	"github.com/google/uuid"
+)
+
+// Extensions downloader backends use for files they are still writing.
+var partial_file_extensions = [...]string{".part", ".ytdl", ".tmp", ".temp"}
+
+// Registers a downloader backend for every backend in the service settings. Backends that can't be set up are skipped.
+func RegisterConfiguredDownloaderBackends() {
+	for _, backend_settings := range app_config.DOWNLOADER_BACKENDS {
+		external_downloader, err := NewExternalDownloader(backend_settings)
+		if err != nil {
+			echo.EchoWarn(fmt.Sprintf("Skipping downloader backend: %s", err))
+			continue
+		}
+
+		jobs.RegisterDownloaderBackend(external_downloader)
+
+		echo.Echo(echo.GreenFG, fmt.Sprintf("Registered downloader backend '%s'", external_downloader.Name()))
+	}
+}
+
+// Hands source_url to a downloader backend and imports the medias it produces into the category. Returns the uuid
+// progress listeners and CancelDownload use to refer to the job.
+func (ad *AsyncDownloader) DownloadWithBackend(backend_name string, source_url string, category_uuid string, category_cluster *dungeon_models.CategoryCluster) (string, error) {
+	backend, exists := jobs.GetDownloaderBackend(backend_name)
+	if !exists {
+		return "", fmt.Errorf("Downloader backend '%s' is not configured", backend_name)
+	}
+
+	var download_uuid string = uuid.New().String()
+
+	download_context, cancel_download := context.WithTimeout(ad.downloads_context, time.Duration(app_config.DOWNLOADER_BACKENDS_TIMEOUT_MINUTES)*time.Minute)
+
+	ad.queue_mutex.Lock()
+	ad.external_downloads[download_uuid] = cancel_download
+	ad.queue_mutex.Unlock()
+
+	go ad.runExternalDownload(download_context, backend, download_uuid, source_url, category_uuid, category_cluster)
+
+	return download_uuid, nil
+}
+
+func (ad *AsyncDownloader) runExternalDownload(download_context context.Context, backend jobs.DownloaderBackend, download_uuid, source_url, category_uuid string, category_cluster *dungeon_models.CategoryCluster) {
+	var completion_progress *models.DownloadProgressMessage = models.CreateDownloadProgressMessage(download_uuid, clusterUuidOf(category_cluster), 0, 0, true)
+
+	defer func() {
+		ad.queue_mutex.Lock()
+		cancel_download := ad.external_downloads[download_uuid]
+		delete(ad.external_downloads, download_uuid)
+		ad.queue_mutex.Unlock()
+
+		cancel_download()
+
+		ad.sendProgress(completion_progress)
+	}()
+
+	select {
+	case ad.backend_slots <- struct{}{}:
+	case <-download_context.Done():
+		return
+	}
+	defer func() { <-ad.backend_slots }()
+
+	output_directory, err := os.MkdirTemp("", "libery-backend-*")
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error creating output directory for download '%s': %s", download_uuid, err))
+		return
+	}
+	defer os.RemoveAll(output_directory)
+
+	echo.Echo(echo.GreenFG, fmt.Sprintf("Downloading '%s' with %s", source_url, backend.Name()))
+
+	err = backend.Download(download_context, source_url, output_directory, func(downloaded_files, total_files int, file_percent float64) {
+		ad.sendProgress(&models.DownloadProgressMessage{
+			DownloadUuid:    download_uuid,
+			ClusterUuid:     completion_progress.ClusterUuid,
+			TotalFiles:      total_files,
+			DownloadedFiles: downloaded_files,
+			FileProgress:    file_percent,
+		})
+	})
+
+	if download_context.Err() != nil {
+		echo.EchoWarn(fmt.Sprintf("Download '%s' with %s was stopped: %s", download_uuid, backend.Name(), download_context.Err()))
+		return
+	}
+
+	if err != nil {
+		// Backends often fail on a single item of a gallery or playlist, whatever they produced is still imported
+		echo.EchoErr(err)
+	}
+
+	produced_files, err := collectProducedFiles(output_directory)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error reading files produced for download '%s': %s", download_uuid, err))
+		return
+	}
+
+	external_files := ad.importProducedFiles(download_uuid, source_url, category_uuid, completion_progress.ClusterUuid, produced_files)
+
+	var imported_files int = 0
+
+	for h := range external_files {
+		external_files[h].SourceUrl = source_url
+		external_files[h].Backend = backend.Name()
+
+		if external_files[h].Imported {
+			imported_files++
+		}
+	}
+
+	completion_progress.TotalFiles = len(external_files)
+	completion_progress.DownloadedFiles = imported_files
+
+	err = repository.Downloads.InsertExternalDownloadFiles(external_files)
+	if err != nil {
+		echo.EchoErr(err)
+	}
+
+	if imported_files > 0 {
+		// The source url goes on the download history like any other download, so repeated downloads can be spotted
+		history_request := models.CreateNewDownloadRequest([]string{source_url}, category_uuid, category_cluster)
+		history_request.DownloadUuid = download_uuid
+
+		err = ad.registerDownload(history_request)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+	}
+
+	echo.Echo(echo.GreenFG, fmt.Sprintf("Downloaded '%s' with %s: imported %d of %d files", source_url, backend.Name(), imported_files, len(produced_files)))
+}
+
+// Uploads the supported medias among the produced files into the category. Returns the provenance of every produced file.
+func (ad *AsyncDownloader) importProducedFiles(download_uuid string, source_url string, category_uuid string, cluster_uuid string, produced_files []string) []models.ExternalDownloadFile {
+	var external_files []models.ExternalDownloadFile = make([]models.ExternalDownloadFile, len(produced_files))
+	var media_types []string = make([]string, len(produced_files))
+	var importable_files int = 0
+
+	for h, produced_file := range produced_files {
+		external_files[h] = models.ExternalDownloadFile{
+			DownloadUuid: download_uuid,
+			Filename:     filepath.Base(produced_file),
+		}
+
+		media_type, err := producedFileMediaType(produced_file)
+		if err != nil {
+			echo.EchoWarn(fmt.Sprintf("Not importing '%s': %s", external_files[h].Filename, err))
+			continue
+		}
+
+		media_types[h] = media_type
+		importable_files++
+	}
+
+	if importable_files == 0 {
+		return external_files
+	}
+
+	err := communication.Medias.GetUploadStreamTicket(download_uuid, category_uuid, importable_files)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error getting upload stream ticket for download '%s': %s", download_uuid, err))
+		return external_files
+	}
+	defer communication.Medias.ReleaseUploadStreamTicket(download_uuid)
+
+	var imported_files int = 0
+
+	for h, produced_file := range produced_files {
+		if media_types[h] == "" {
+			continue
+		}
+
+		was_skipped, err := ad.uploadProducedFile(download_uuid, category_uuid, cluster_uuid, source_url, produced_file, media_types[h])
+		if err != nil {
+			echo.EchoErr(fmt.Errorf("Error importing '%s': %s", external_files[h].Filename, err))
+			continue
+		}
+
+		imported_files++
+
+		if !was_skipped {
+			external_files[h].Imported = true
+			external_files[h].ImportedAt = time.Now().Unix()
+		}
+
+		ad.sendProgress(models.CreateDownloadProgressMessage(download_uuid, cluster_uuid, importable_files, imported_files, false))
+	}
+
+	return external_files
+}
+
+// Returns whether the file was skipped for being a duplicate of a media the cluster already has.
+func (ad *AsyncDownloader) uploadProducedFile(upload_uuid string, category_uuid string, cluster_uuid string, source_url string, produced_file string, media_type string) (bool, error) {
+	content_hash, err := fileContentHash(produced_file)
+	if err != nil {
+		return false, err
+	}
+
+	media_file, err := os.Open(produced_file)
+	if err != nil {
+		return false, err
+	}
+	defer media_file.Close()
+
+	return ad.uploadDownloadedMedia(upload_uuid, category_uuid, cluster_uuid, source_url, media_file, filepath.Base(produced_file), media_type, content_hash)
+}
+
+// Returns the mime type of a produced file, or an error if it can't be imported.
+func producedFileMediaType(produced_file string) (string, error) {
+	file_info, err := os.Stat(produced_file)
+	if err != nil {
+		return "", err
+	}
+
+	var max_file_size int64 = app_config.DOWNLOAD_MAX_FILE_SIZE_MB * 1024 * 1024
+
+	if max_file_size > 0 && file_info.Size() > max_file_size {
+		return "", fmt.Errorf("it is larger than the %d bytes limit", max_file_size)
+	}
+
+	media_file, err := os.Open(produced_file)
+	if err != nil {
+		return "", err
+	}
+	defer media_file.Close()
+
+	var sniff_buffer []byte = make([]byte, 512)
+
+	sniffed_bytes, err := media_file.Read(sniff_buffer)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	media_type, is_supported := helpers.ResolveMediaType(helpers.SniffMediaType(sniff_buffer[:sniffed_bytes]), mime.TypeByExtension(filepath.Ext(produced_file)))
+	if !is_supported {
+		return "", fmt.Errorf("'%s' is not a supported media type", media_type)
+	}
+
+	return media_type, nil
+}
+
+// Lists the files a backend left on its output directory, skipping hidden and partially written ones.
+func collectProducedFiles(output_directory string) ([]string, error) {
+	var produced_files []string = make([]string, 0)
+
+	err := filepath.WalkDir(output_directory, func(file_path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			return nil
+		}
+
+		var file_extension string = strings.ToLower(filepath.Ext(entry.Name()))
+
+		for _, partial_extension := range partial_file_extensions {
+			if file_extension == partial_extension {
+				return nil
+			}
+		}
+
+		produced_files = append(produced_files, file_path)
+
+		return nil
+	})
+
+	sort.Strings(produced_files)
+
+	return produced_files, err
+}
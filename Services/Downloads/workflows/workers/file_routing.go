@@ -0,0 +1,128 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+const routing_categories_timeout time.Duration = 10 * time.Second
+
+// Where a downloaded file goes and how its media is tagged, according to the routing rules of its cluster.
+type fileRoute struct {
+	category_uuid string // Empty if the file goes to the category of its download
+	tag_ids       []int
+}
+
+// Evaluates the routing rules of the cluster against a downloaded file. The categories rules target by name are
+// created if missing. Rules whose category can't be resolved are skipped, so the file is never lost to a rule.
+func routeDownloadedFile(cluster_uuid string, download_category string, routed_file models.RoutedFile) fileRoute {
+	var file_route fileRoute = fileRoute{
+		tag_ids: make([]int, 0),
+	}
+
+	if cluster_uuid == "" {
+		return file_route
+	}
+
+	routing_rules, err := repository.RoutingRules.GetRoutingRules(cluster_uuid)
+	if err != nil {
+		echo.EchoErr(err)
+		return file_route
+	}
+
+	var applied_tags map[int]bool = make(map[int]bool)
+
+	for _, routing_rule := range routing_rules {
+		if !routing_rule.Matches(routed_file) {
+			continue
+		}
+
+		for _, tag_id := range routing_rule.TagIds {
+			if !applied_tags[tag_id] {
+				applied_tags[tag_id] = true
+				file_route.tag_ids = append(file_route.tag_ids, tag_id)
+			}
+		}
+
+		if file_route.category_uuid != "" || !routing_rule.HasTarget() {
+			continue
+		}
+
+		target_category, err := resolveRuleCategory(routing_rule, download_category)
+		if err != nil {
+			echo.EchoErr(fmt.Errorf("Error resolving the category of routing rule '%s': %s", routing_rule.Uuid, err))
+			continue
+		}
+
+		file_route.category_uuid = target_category
+	}
+
+	if file_route.category_uuid == download_category {
+		file_route.category_uuid = ""
+	}
+
+	return file_route
+}
+
+// Returns the category a rule sends files to, creating it if the rule targets it by name and it doesn't exist.
+func resolveRuleCategory(routing_rule *models.RoutingRule, download_category string) (string, error) {
+	if routing_rule.TargetCategoryUuid != "" {
+		return routing_rule.TargetCategoryUuid, nil
+	}
+
+	var parent_uuid string = routing_rule.TargetParentUuid
+	if parent_uuid == "" {
+		parent_uuid = download_category
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), routing_categories_timeout)
+	defer cancel()
+
+	category_uuid, err := repository.RoutingRules.GetRoutingRuleCategory(routing_rule.Uuid, parent_uuid)
+	if err != nil {
+		return "", err
+	}
+
+	if category_uuid != "" {
+		existing_categories, err := repository.Categories.FilterExistingCategories(ctx, []string{category_uuid})
+		if err != nil {
+			return "", err
+		}
+
+		if len(existing_categories) > 0 {
+			return category_uuid, nil
+		}
+
+		echo.EchoDebug(fmt.Sprintf("Category '%s' of routing rule '%s' was deleted, creating it again", category_uuid, routing_rule.Uuid))
+	}
+
+	category_uuid, err = repository.Categories.CreateCategory(ctx, routing_rule.TargetCategoryName, parent_uuid, routing_rule.ClusterUuid)
+	if err != nil {
+		return "", err
+	}
+
+	err = repository.RoutingRules.SetRoutingRuleCategory(routing_rule.Uuid, parent_uuid, category_uuid)
+	if err != nil {
+		echo.EchoErr(err)
+	}
+
+	echo.Echo(echo.GreenFG, fmt.Sprintf("Created category '%s' for routing rule '%s'", routing_rule.TargetCategoryName, routing_rule.Uuid))
+
+	return category_uuid, nil
+}
+
+func applyRouteTags(media_uuid string, tag_ids []int) {
+	for _, tag_id := range tag_ids {
+		_, err := communication.Metadata.TagEntities(tag_id, []string{media_uuid}, dungeon_models.ENTITY_TYPE_MEDIA)
+		if err != nil {
+			echo.EchoErr(fmt.Errorf("Error applying tag %d to media '%s': %s", tag_id, media_uuid, err))
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package workers
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type hostQuota struct {
+	slots         chan struct{}
+	next_request  time.Time // Earliest moment a new request can be sent to the host
+	blocked_until time.Time // Set when the host answers with a Retry-After header
+}
+
+// Limits how many requests are sent to each host at the same time and how often they are sent.
+type hostLimiter struct {
+	quotas           map[string]*hostQuota
+	quotas_mutex     *sync.Mutex
+	host_concurrency int
+	request_interval time.Duration // 0 means no rate limit
+}
+
+func newHostLimiter(host_concurrency int, requests_per_second float64) *hostLimiter {
+	var limiter *hostLimiter = new(hostLimiter)
+
+	limiter.quotas = make(map[string]*hostQuota)
+	limiter.quotas_mutex = new(sync.Mutex)
+	limiter.host_concurrency = host_concurrency
+
+	if requests_per_second > 0 {
+		limiter.request_interval = time.Duration(float64(time.Second) / requests_per_second)
+	}
+
+	return limiter
+}
+
+func (limiter *hostLimiter) getQuota(host string) *hostQuota {
+	quota, exists := limiter.quotas[host]
+	if !exists {
+		quota = &hostQuota{
+			slots: make(chan struct{}, limiter.host_concurrency),
+		}
+
+		limiter.quotas[host] = quota
+	}
+
+	return quota
+}
+
+// Blocks until a request can be sent to the host. Returns false if stop_signal was closed while waiting, otherwise
+// the caller must call release once the request finishes.
+func (limiter *hostLimiter) acquire(host string, stop_signal <-chan struct{}) bool {
+	limiter.quotas_mutex.Lock()
+	quota := limiter.getQuota(host)
+	limiter.quotas_mutex.Unlock()
+
+	select {
+	case quota.slots <- struct{}{}:
+	case <-stop_signal:
+		return false
+	}
+
+	for {
+		limiter.quotas_mutex.Lock()
+
+		var now time.Time = time.Now()
+		var send_at time.Time = quota.next_request
+
+		if quota.blocked_until.After(send_at) {
+			send_at = quota.blocked_until
+		}
+
+		if !send_at.After(now) {
+			quota.next_request = now.Add(limiter.request_interval)
+			limiter.quotas_mutex.Unlock()
+			return true
+		}
+
+		limiter.quotas_mutex.Unlock()
+
+		if !sleepOrStop(send_at.Sub(now), stop_signal) {
+			<-quota.slots
+			return false
+		}
+	}
+}
+
+func (limiter *hostLimiter) release(host string) {
+	limiter.quotas_mutex.Lock()
+	quota := limiter.getQuota(host)
+	limiter.quotas_mutex.Unlock()
+
+	<-quota.slots
+}
+
+// Holds every request to the host until the given moment.
+func (limiter *hostLimiter) blockUntil(host string, until time.Time) {
+	limiter.quotas_mutex.Lock()
+	defer limiter.quotas_mutex.Unlock()
+
+	quota := limiter.getQuota(host)
+
+	if until.After(quota.blocked_until) {
+		quota.blocked_until = until
+	}
+}
+
+func urlHost(file_url string) string {
+	parsed_url, err := url.Parse(file_url)
+	if err != nil {
+		return ""
+	}
+
+	return parsed_url.Hostname()
+}
+
+// Exponential backoff with jitter, the delay is picked at random from the upper half of the exponential delay so
+// retries from concurrent workers spread out.
+func backoffDelay(attempt int, base_delay, max_delay time.Duration) time.Duration {
+	var delay time.Duration = base_delay
+
+	for h := 0; h < attempt && delay < max_delay; h++ {
+		delay *= 2
+	}
+
+	if delay > max_delay {
+		delay = max_delay
+	}
+
+	if delay <= 1 {
+		return delay
+	}
+
+	var half_delay time.Duration = delay / 2
+
+	return half_delay + time.Duration(rand.Int63n(int64(delay-half_delay)))
+}
+
+// Parses a Retry-After header, which is either a number of seconds or an http date. Returns 0 if the header is
+// missing or invalid.
+func parseRetryAfter(retry_after string, max_wait time.Duration) time.Duration {
+	if retry_after == "" {
+		return 0
+	}
+
+	var wait time.Duration
+
+	if seconds, err := strconv.Atoi(retry_after); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if retry_at, err := http.ParseTime(retry_after); err == nil {
+		wait = time.Until(retry_at)
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+
+	if wait > max_wait {
+		wait = max_wait
+	}
+
+	return wait
+}
+
+// Sleeps for the given duration. Returns false if stop_signal was closed before it elapsed.
+func sleepOrStop(duration time.Duration, stop_signal <-chan struct{}) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-stop_signal:
+		return false
+	}
+}
@@ -0,0 +1,149 @@
+package workers
+
+import (
+	"fmt"
+	"io"
+	app_config "libery_downloads_service/Config"
+	"libery_downloads_service/models"
+	"libery_downloads_service/repository"
+	"libery_downloads_service/workflows"
+	"libery_downloads_service/workflows/jobs"
+	"net/http"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Sources larger than this are not read past it, thread json and feeds are far smaller.
+const max_source_size int64 = 16 * 1024 * 1024
+
+// Periodically polls the source watchers that are due and enqueues the medias they didn't download yet. Watchers
+// whose source is gone are stopped.
+type SourceWatchersMonitor struct {
+	check_interval time.Duration
+	http_client    *http.Client
+	stop_signal    chan bool
+}
+
+func NewSourceWatchersMonitor(check_interval time.Duration) *SourceWatchersMonitor {
+	var watchers_monitor *SourceWatchersMonitor = new(SourceWatchersMonitor)
+
+	watchers_monitor.check_interval = check_interval
+	watchers_monitor.http_client = &http.Client{Timeout: 30 * time.Second}
+	watchers_monitor.stop_signal = make(chan bool)
+
+	go watchers_monitor.monitorWatchersSchedule()
+
+	return watchers_monitor
+}
+
+func (monitor *SourceWatchersMonitor) monitorWatchersSchedule() {
+	checks_ticker := time.NewTicker(monitor.check_interval)
+	defer checks_ticker.Stop()
+
+	for {
+		select {
+		case <-checks_ticker.C:
+			monitor.pollDueWatchers()
+		case <-monitor.stop_signal:
+			return
+		}
+	}
+}
+
+func (monitor *SourceWatchersMonitor) pollDueWatchers() {
+	source_watchers, err := repository.SourceWatchers.GetSourceWatchers()
+	if err != nil {
+		echo.EchoErr(err)
+		return
+	}
+
+	var now time.Time = time.Now()
+
+	for _, source_watcher := range source_watchers {
+		if !source_watcher.IsDue(now) {
+			continue
+		}
+
+		monitor.pollWatcher(source_watcher)
+	}
+}
+
+func (monitor *SourceWatchersMonitor) pollWatcher(source_watcher *models.SourceWatcher) {
+	source_watcher.LastPolledAt = time.Now().Unix()
+
+	defer func() {
+		err := repository.SourceWatchers.UpdateSourceWatcherState(source_watcher)
+		if err != nil {
+			echo.EchoErr(err)
+		}
+	}()
+
+	source_content, status_code, err := monitor.fetchSource(source_watcher.SourceUrl)
+	if status_code == http.StatusNotFound || status_code == http.StatusGone {
+		source_watcher.Status = models.SourceWatcherStatus_Stopped
+		source_watcher.StopReason = fmt.Sprintf("Source answered with status %d", status_code)
+
+		echo.Echo(echo.YellowFG, fmt.Sprintf("Stopped watching '%s', the source is gone", source_watcher.SourceUrl))
+		return
+	}
+
+	if err != nil {
+		source_watcher.ConsecutiveFailures++
+		echo.EchoWarn(fmt.Sprintf("Error polling watched source '%s': %s", source_watcher.SourceUrl, err))
+		return
+	}
+
+	media_urls, err := workflows.ExtractSourceMediaUrls(source_watcher.SourceKind, source_watcher.SourceUrl, source_content)
+	if err != nil {
+		source_watcher.ConsecutiveFailures++
+		echo.EchoWarn(fmt.Sprintf("Error reading watched source '%s': %s", source_watcher.SourceUrl, err))
+		return
+	}
+
+	source_watcher.ConsecutiveFailures = 0
+
+	new_media_urls := workflows.ClearRepeatedDownloadFiles(source_watcher.Uuid, media_urls)
+	if len(new_media_urls) == 0 {
+		return
+	}
+
+	_, err = jobs.DownloadWorker.DownloadMediasBatch(source_watcher.CategoryUuid, new_media_urls, source_watcher.Uuid, source_watcher.CategoryCluster)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error enqueuing medias of watched source '%s': %s", source_watcher.SourceUrl, err))
+		return
+	}
+
+	echo.Echo(echo.GreenFG, fmt.Sprintf("Enqueued %d new medias from '%s'", len(new_media_urls), source_watcher.SourceUrl))
+}
+
+// Returns the content of the source and the status code it was answered with, 0 if the request didn't get an answer.
+func (monitor *SourceWatchersMonitor) fetchSource(source_url string) ([]byte, int, error) {
+	source_request, err := http.NewRequest(http.MethodGet, source_url, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	source_request.Header.Set("User-Agent", app_config.DOWNLOAD_USER_AGENT)
+
+	source_response, err := monitor.http_client.Do(source_request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer source_response.Body.Close()
+
+	if source_response.StatusCode != http.StatusOK {
+		return nil, source_response.StatusCode, fmt.Errorf("Source answered with status %d", source_response.StatusCode)
+	}
+
+	source_content, err := io.ReadAll(io.LimitReader(source_response.Body, max_source_size))
+	if err != nil {
+		return nil, source_response.StatusCode, err
+	}
+
+	return source_content, source_response.StatusCode, nil
+}
+
+func (monitor *SourceWatchersMonitor) Stop() {
+	monitor.stop_signal <- true
+}
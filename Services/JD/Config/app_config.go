@@ -21,6 +21,28 @@ var OPERATION_DATA_PATH string = os.Getenv("OPERATION_DATA_PATH")
 
 var JD_GRPC_SERVER_PORT string = os.Getenv("JD_GRPC_SERVER_PORT")
 
+// --------Platform events--------
+
+var PLATFORM_EVENTS_LOG_FILE string = "platform_events.log"
+var PLATFORM_EVENTS_TTL_MINUTES int64 = 1440               // How long events stay available for replay
+var PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES int64 = 30 // 0 disables the platform events log compaction
+var PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS int64 = 10       // Listeners that take longer to receive an event are dropped
+var PLATFORM_EVENTS_SSE_HEARTBEAT_SECONDS int64 = 15
+
+// --------Webhooks--------
+
+var WEBHOOKS_FILE string = "webhooks.json"
+var WEBHOOK_DELIVERIES_LOG_FILE string = "webhook_deliveries.log"
+var WEBHOOK_MAX_ATTEMPTS int = 6
+var WEBHOOK_RETRY_BASE_DELAY_SECONDS int64 = 30 // Doubled on every failed attempt
+var WEBHOOK_TIMEOUT_SECONDS int64 = 10
+
+// --------Platform services--------
+
+var PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS int64 = 30 // 0 disables the services health checks
+var PLATFORM_SERVICES_CHECK_TIMEOUT_SECONDS int64 = 5
+var PLATFORM_SERVICES_FAILED_CHECKS_BEFORE_OFFLINE int = 2
+
 // --------Settings--------
 
 var service_settings map[string]any = make(map[string]any)
@@ -81,6 +103,50 @@ func loadSettings() error {
 
 	service_settings = settings
 
+	if _, exists := service_settings["PLATFORM_EVENTS_LOG_FILE"]; exists {
+		PLATFORM_EVENTS_LOG_FILE = service_settings["PLATFORM_EVENTS_LOG_FILE"].(string)
+	}
+
+	if _, exists := service_settings["PLATFORM_EVENTS_TTL_MINUTES"]; exists {
+		PLATFORM_EVENTS_TTL_MINUTES = int64(service_settings["PLATFORM_EVENTS_TTL_MINUTES"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES"]; exists {
+		PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES = int64(service_settings["PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS"]; exists {
+		PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS = int64(service_settings["PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_EVENTS_SSE_HEARTBEAT_SECONDS"]; exists {
+		PLATFORM_EVENTS_SSE_HEARTBEAT_SECONDS = int64(service_settings["PLATFORM_EVENTS_SSE_HEARTBEAT_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["WEBHOOK_MAX_ATTEMPTS"]; exists {
+		WEBHOOK_MAX_ATTEMPTS = int(service_settings["WEBHOOK_MAX_ATTEMPTS"].(float64))
+	}
+
+	if _, exists := service_settings["WEBHOOK_RETRY_BASE_DELAY_SECONDS"]; exists {
+		WEBHOOK_RETRY_BASE_DELAY_SECONDS = int64(service_settings["WEBHOOK_RETRY_BASE_DELAY_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["WEBHOOK_TIMEOUT_SECONDS"]; exists {
+		WEBHOOK_TIMEOUT_SECONDS = int64(service_settings["WEBHOOK_TIMEOUT_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS"]; exists {
+		PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS = int64(service_settings["PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_SERVICES_CHECK_TIMEOUT_SECONDS"]; exists {
+		PLATFORM_SERVICES_CHECK_TIMEOUT_SECONDS = int64(service_settings["PLATFORM_SERVICES_CHECK_TIMEOUT_SECONDS"].(float64))
+	}
+
+	if _, exists := service_settings["PLATFORM_SERVICES_FAILED_CHECKS_BEFORE_OFFLINE"]; exists {
+		PLATFORM_SERVICES_FAILED_CHECKS_BEFORE_OFFLINE = int(service_settings["PLATFORM_SERVICES_FAILED_CHECKS_BEFORE_OFFLINE"].(float64))
+	}
+
 	err = setupPlatformCommunication()
 	if err != nil {
 		panic(fmt.Sprintf("Error setting up platform communication: %s", err.Error()))
@@ -11,6 +11,8 @@ import (
 	"libery_JD_service/server"
 	"libery_JD_service/workflows/jobs"
 	"libery_JD_service/workflows/workers"
+	"path/filepath"
+	"time"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -19,6 +21,8 @@ import (
 func BinderRoutes(server libery_networking.Server, router *patriot_router.Router) {
 	router.RegisterRoute(patriot_router.NewRoute("/alive", true), handlers.AliveHandler(server))
 	router.RegisterRoute(patriot_router.NewRoute("/platform-events(/.+)?$", false), handlers.PlatformEventsHandler(server))
+	router.RegisterRoute(patriot_router.NewRoute("/webhooks(/.+)?$", false), handlers.WebhooksHandler(server))
+	router.RegisterRoute(patriot_router.NewRoute("/platform-services", true), handlers.PlatformServicesHandler(server))
 }
 
 func main() {
@@ -31,15 +35,39 @@ func main() {
 	platform_services_datastore := databases.NewPlatformServicesDatastore()
 	repository.SetPlatformFeaturesRepo(platform_services_datastore)
 
-	platform_events_store := databases.NewPlatformEventsStore()
+	platform_events_store, err := databases.NewPlatformEventsStore(filepath.Join(app_config.OPERATION_DATA_PATH, app_config.PLATFORM_EVENTS_LOG_FILE))
+	if err != nil {
+		echo.EchoFatal(err)
+	}
 	repository.SetPlatformEventsRepo(platform_events_store)
 
+	webhooks_store, err := databases.NewWebhooksStore(filepath.Join(app_config.OPERATION_DATA_PATH, app_config.WEBHOOKS_FILE), filepath.Join(app_config.OPERATION_DATA_PATH, app_config.WEBHOOK_DELIVERIES_LOG_FILE))
+	if err != nil {
+		echo.EchoFatal(err)
+	}
+	repository.SetWebhooksRepo(webhooks_store)
+
 	// ------ WORKERS ------
 
 	town_crier := workers.NewEventCrier()
 	jobs.SetTownCrierImplementation(town_crier)
 	defer town_crier.Close()
 
+	webhooks_courier := workers.NewWebhooksCourier(app_config.DOMAIN_SECRET, app_config.WEBHOOK_MAX_ATTEMPTS, time.Duration(app_config.WEBHOOK_RETRY_BASE_DELAY_SECONDS)*time.Second, time.Duration(app_config.WEBHOOK_TIMEOUT_SECONDS)*time.Second)
+	jobs.SetWebhooksCourierImplementation(webhooks_courier)
+
+	var event_log_compactor *workers.EventLogCompactor
+
+	if app_config.PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES > 0 {
+		event_log_compactor = workers.NewEventLogCompactor(time.Duration(app_config.PLATFORM_EVENTS_COMPACTION_INTERVAL_MINUTES)*time.Minute, time.Duration(app_config.PLATFORM_EVENTS_TTL_MINUTES)*time.Minute)
+	}
+
+	var services_health_monitor *workers.ServicesHealthMonitor
+
+	if app_config.PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS > 0 {
+		services_health_monitor = workers.NewServicesHealthMonitor(time.Duration(app_config.PLATFORM_SERVICES_CHECK_INTERVAL_SECONDS)*time.Second, time.Duration(app_config.PLATFORM_SERVICES_CHECK_TIMEOUT_SECONDS)*time.Second, app_config.PLATFORM_SERVICES_FAILED_CHECKS_BEFORE_OFFLINE)
+	}
+
 	// ------ HTTP SERVER ------
 
 	var new_server_config *libery_networking.ServerConfig = new(libery_networking.ServerConfig)
@@ -64,5 +92,20 @@ func main() {
 
 	JD_service.SetGrpcServer(grpc_server)
 
+	JD_service.OnAfterShutdown(func() {
+		if event_log_compactor != nil {
+			event_log_compactor.Stop()
+		}
+
+		if services_health_monitor != nil {
+			services_health_monitor.Stop()
+		}
+
+		webhooks_courier.Close()
+
+		platform_events_store.Close()
+		webhooks_store.Close()
+	})
+
 	JD_service.StartServer(BinderRoutes)
 }
@@ -0,0 +1,50 @@
+package databases
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+)
+
+// Replaces the json lines log on log_path with a line for each record. The records are written to a separate file that is
+// then renamed over the log, so if the rewrite fails the log is left as it was.
+func rewriteJSONLinesLog[T any](log_path string, records []T) error {
+	var rewritten_path string = log_path + ".compacting"
+
+	rewritten_file, err := os.Create(rewritten_path)
+	if err != nil {
+		return err
+	}
+
+	var log_writer *bufio.Writer = bufio.NewWriter(rewritten_file)
+
+	for _, record := range records {
+		log_line, err := json.Marshal(record)
+		if err == nil {
+			_, err = log_writer.Write(append(log_line, '\n'))
+		}
+
+		if err != nil {
+			rewritten_file.Close()
+			os.Remove(rewritten_path)
+			return err
+		}
+	}
+
+	err = log_writer.Flush()
+	if err == nil {
+		err = rewritten_file.Close()
+	} else {
+		rewritten_file.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(rewritten_path, log_path)
+	}
+
+	if err != nil {
+		os.Remove(rewritten_path)
+	}
+
+	return err
+}
@@ -1,55 +1,231 @@
 package databases
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
-	dungeon_models "libery-dungeon-libs/models"
-
-	// "libery-dungeon-libs/communication"
 	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
 	service_models "libery_JD_service/models"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
+// Append-only platform events log. Every registered event is written as a json line to the log file
+// and indexed in memory, the log is only ever rewritten by Compact.
 type PlatformEventsStore struct {
-	PublicEvents  map[string]*communication.PlatformEvent
-	privateEvents map[string]*communication.PlatformEvent
+	log_path      string
+	log_file      *os.File
+	events        []*service_models.SequencedPlatformEvent // Ordered by sequence
+	events_index  map[string]*service_models.SequencedPlatformEvent
+	last_sequence uint64
+	mutex         sync.RWMutex
 }
 
-func NewPlatformEventsStore() *PlatformEventsStore {
-	return &PlatformEventsStore{
-		PublicEvents:  make(map[string]*communication.PlatformEvent),
-		privateEvents: make(map[string]*communication.PlatformEvent),
+func NewPlatformEventsStore(log_path string) (*PlatformEventsStore, error) {
+	var new_store *PlatformEventsStore = new(PlatformEventsStore)
+
+	new_store.log_path = log_path
+	new_store.events = make([]*service_models.SequencedPlatformEvent, 0)
+	new_store.events_index = make(map[string]*service_models.SequencedPlatformEvent)
+
+	err := new_store.loadLog()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In databases.NewPlatformEventsStore: While loading the platform events log<%s>", log_path), err)
+	}
+
+	new_store.log_file, err = os.OpenFile(log_path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In databases.NewPlatformEventsStore: While opening the platform events log<%s> for appending", log_path), err)
 	}
+
+	return new_store, nil
 }
 
-func (pes *PlatformEventsStore) RegisterEvent(event *communication.PlatformEvent) {
-	if communication.IsPublicEvent(event.EventType) {
-		pes.PublicEvents[event.Uuid] = event
-		return
+// Reads the events already on the log. Lines that cannot be parsed(e.g. a write cut short by a crash) are skipped.
+func (pes *PlatformEventsStore) loadLog() error {
+	log_file, err := os.Open(pes.log_path)
+	if os.IsNotExist(err) {
+		return nil
 	}
+	if err != nil {
+		return err
+	}
+	defer log_file.Close()
+
+	var log_scanner *bufio.Scanner = bufio.NewScanner(log_file)
+	log_scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	for log_scanner.Scan() {
+		var stored_event *service_models.SequencedPlatformEvent = new(service_models.SequencedPlatformEvent)
+
+		err = json.Unmarshal(log_scanner.Bytes(), stored_event)
+		if err != nil {
+			echo.EchoWarn(fmt.Sprintf("In PlatformEventsStore.loadLog: Skipping malformed log line: %s", err.Error()))
+			continue
+		}
 
-	pes.privateEvents[event.Uuid] = event
+		pes.indexEvent(stored_event)
+	}
+
+	sort.SliceStable(pes.events, func(i, j int) bool {
+		return pes.events[i].Sequence < pes.events[j].Sequence
+	})
+
+	return log_scanner.Err()
 }
 
-func (pes PlatformEventsStore) GetPublicEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError) {
-	var labeled_error *dungeon_models.LabeledError
+func (pes *PlatformEventsStore) indexEvent(stored_event *service_models.SequencedPlatformEvent) {
+	pes.events = append(pes.events, stored_event)
+	pes.events_index[stored_event.Uuid] = stored_event
 
-	event, exists := pes.PublicEvents[event_uuid]
+	if stored_event.Sequence > pes.last_sequence {
+		pes.last_sequence = stored_event.Sequence
+	}
+}
+
+func (pes *PlatformEventsStore) RegisterEvent(event *communication.PlatformEvent) (*service_models.SequencedPlatformEvent, *dungeon_models.LabeledError) {
+	pes.mutex.Lock()
+	defer pes.mutex.Unlock()
+
+	var stored_event *service_models.SequencedPlatformEvent = service_models.NewSequencedPlatformEvent(*event, pes.last_sequence+1, time.Now().UnixMilli())
+
+	log_line, err := json.Marshal(stored_event)
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, "In PlatformEventsStore.RegisterEvent while marshalling event", service_models.ErrService_EventLogWriteFailed)
+	}
 
-	if !exists {
-		labeled_error = dungeon_models.NewLabeledError(fmt.Errorf("Event with UUID %s not found", event_uuid), "In PlatformEventsStore.GetPublicEvent", service_models.ErrService_NoSuchEventRegistered)
+	_, err = pes.log_file.Write(append(log_line, '\n'))
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, "In PlatformEventsStore.RegisterEvent while appending event to the log", service_models.ErrService_EventLogWriteFailed)
 	}
 
-	return event, labeled_error
+	pes.indexEvent(stored_event)
+
+	return stored_event, nil
 }
 
-func (pes PlatformEventsStore) GetPrivateEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError) {
-	var labeled_error *dungeon_models.LabeledError
+func (pes *PlatformEventsStore) GetPublicEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError) {
+	pes.mutex.RLock()
+	defer pes.mutex.RUnlock()
+
+	stored_event, exists := pes.events_index[event_uuid]
+	if !exists || !stored_event.IsPublic() {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("Event with UUID %s not found", event_uuid), "In PlatformEventsStore.GetPublicEvent", service_models.ErrService_NoSuchEventRegistered)
+	}
+
+	var event communication.PlatformEvent = stored_event.PlatformEvent
+
+	return &event, nil
+}
 
-	event, exists := pes.privateEvents[event_uuid]
+func (pes *PlatformEventsStore) GetPrivateEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError) {
+	pes.mutex.RLock()
+	defer pes.mutex.RUnlock()
 
-	if !exists {
-		labeled_error = dungeon_models.NewLabeledError(fmt.Errorf("Event with UUID %s not found", event_uuid), "In PlatformEventsStore.GetPrivateEvent", service_models.ErrService_NoSuchEventRegistered)
+	stored_event, exists := pes.events_index[event_uuid]
+	if !exists || stored_event.IsPublic() {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("Event with UUID %s not found", event_uuid), "In PlatformEventsStore.GetPrivateEvent", service_models.ErrService_NoSuchEventRegistered)
 	}
 
-	return event, labeled_error
+	var event communication.PlatformEvent = stored_event.PlatformEvent
+
+	return &event, nil
+}
+
+// Returns the public events with a sequence greater than last_sequence, oldest first.
+func (pes *PlatformEventsStore) GetPublicEventsSince(last_sequence uint64) []*service_models.SequencedPlatformEvent {
+	pes.mutex.RLock()
+	defer pes.mutex.RUnlock()
+
+	var missed_events []*service_models.SequencedPlatformEvent = make([]*service_models.SequencedPlatformEvent, 0)
+
+	first_missed := sort.Search(len(pes.events), func(h int) bool {
+		return pes.events[h].Sequence > last_sequence
+	})
+
+	for _, stored_event := range pes.events[first_missed:] {
+		if stored_event.IsPublic() {
+			missed_events = append(missed_events, stored_event)
+		}
+	}
+
+	return missed_events
+}
+
+func (pes *PlatformEventsStore) GetEventBySequence(sequence uint64) (*service_models.SequencedPlatformEvent, *dungeon_models.LabeledError) {
+	pes.mutex.RLock()
+	defer pes.mutex.RUnlock()
+
+	event_position := sort.Search(len(pes.events), func(h int) bool {
+		return pes.events[h].Sequence >= sequence
+	})
+
+	if event_position == len(pes.events) || pes.events[event_position].Sequence != sequence {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("Event with sequence %d not found", sequence), "In PlatformEventsStore.GetEventBySequence", service_models.ErrService_NoSuchEventRegistered)
+	}
+
+	return pes.events[event_position], nil
+}
+
+func (pes *PlatformEventsStore) GetLastSequence() uint64 {
+	pes.mutex.RLock()
+	defer pes.mutex.RUnlock()
+
+	return pes.last_sequence
+}
+
+// Drops the events older than max_age and rewrites the log with the ones that remain. The newest event is
+// always kept so the sequence keeps counting from where it was after a restart. Returns the amount of events dropped.
+func (pes *PlatformEventsStore) Compact(max_age time.Duration) (int, *dungeon_models.LabeledError) {
+	pes.mutex.Lock()
+	defer pes.mutex.Unlock()
+
+	var oldest_allowed int64 = time.Now().Add(-max_age).UnixMilli()
+
+	var retained_events []*service_models.SequencedPlatformEvent = make([]*service_models.SequencedPlatformEvent, 0, len(pes.events))
+
+	for h, stored_event := range pes.events {
+		if stored_event.RegisteredAt >= oldest_allowed || h == len(pes.events)-1 {
+			retained_events = append(retained_events, stored_event)
+		}
+	}
+
+	var dropped_count int = len(pes.events) - len(retained_events)
+	if dropped_count == 0 {
+		return 0, nil
+	}
+
+	err := rewriteJSONLinesLog(pes.log_path, retained_events)
+	if err != nil {
+		return 0, dungeon_models.NewLabeledError(err, "In PlatformEventsStore.Compact while rewriting the log", service_models.ErrService_EventLogWriteFailed)
+	}
+
+	pes.events = retained_events
+	pes.events_index = make(map[string]*service_models.SequencedPlatformEvent, len(retained_events))
+
+	for _, stored_event := range retained_events {
+		pes.events_index[stored_event.Uuid] = stored_event
+	}
+
+	pes.log_file.Close()
+
+	pes.log_file, err = os.OpenFile(pes.log_path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return dropped_count, dungeon_models.NewLabeledError(err, "In PlatformEventsStore.Compact while reopening the log for appending", service_models.ErrService_EventLogWriteFailed)
+	}
+
+	return dropped_count, nil
+}
+
+func (pes *PlatformEventsStore) Close() error {
+	pes.mutex.Lock()
+	defer pes.mutex.Unlock()
+
+	return pes.log_file.Close()
 }
@@ -5,10 +5,15 @@ import (
 	dungeon_models "libery-dungeon-libs/models"
 	"libery-dungeon-libs/models/platform_services"
 	service_models "libery_JD_service/models"
+	"sync"
+	"time"
 )
 
+// Keeps the state of the platform services. It's read by the http handlers and written by the grpc server and the
+// services health monitor, so every access goes through the mutex.
 type PlatformServicesDatastore struct {
 	onlineServices map[platform_services.PlatformServiceName]*service_models.PlatformService
+	mutex          sync.RWMutex
 }
 
 func NewPlatformServicesDatastore() *PlatformServicesDatastore {
@@ -32,7 +37,10 @@ func (psd *PlatformServicesDatastore) fillOnlineServicesTable() {
 	}
 }
 
-func (psd PlatformServicesDatastore) IsServiceOnline(service_name string) bool {
+func (psd *PlatformServicesDatastore) IsServiceOnline(service_name string) bool {
+	psd.mutex.RLock()
+	defer psd.mutex.RUnlock()
+
 	var service_platform_name platform_services.PlatformServiceName = platform_services.PlatformServiceName(service_name)
 
 	service, ok := psd.onlineServices[service_platform_name]
@@ -52,6 +60,9 @@ func (psd *PlatformServicesDatastore) SetServiceOnline(service_name string, onli
 
 	var service_platform_name platform_services.PlatformServiceName = platform_services.PlatformServiceName(service_name)
 
+	psd.mutex.Lock()
+	defer psd.mutex.Unlock()
+
 	_, ok := psd.onlineServices[service_platform_name]
 	if !ok {
 		lerr = dungeon_models.NewLabeledError(fmt.Errorf("service '%s' does not exist", service_name), "In NewPLatformServicesDatastore.SetServiceOnline while checking if service exists", service_models.ErrService_NotSuchService)
@@ -64,6 +75,9 @@ func (psd *PlatformServicesDatastore) SetServiceOnline(service_name string, onli
 }
 
 func (psd *PlatformServicesDatastore) SetServiceMetadata(new_service service_models.PlatformService) (lerr *dungeon_models.LabeledError) {
+	psd.mutex.Lock()
+	defer psd.mutex.Unlock()
+
 	service, exists := psd.onlineServices[new_service.ServiceName]
 	if !exists {
 		lerr = dungeon_models.NewLabeledError(fmt.Errorf("service '%s' does not exist", new_service.ServiceName), "In NewPLatformServicesDatastore.SetServiceMetadata while checking if service exists", service_models.ErrService_NotSuchService)
@@ -74,5 +88,60 @@ func (psd *PlatformServicesDatastore) SetServiceMetadata(new_service service_mod
 	service.ServicePort = new_service.ServicePort
 	service.IsOnline = new_service.IsOnline
 
+	if new_service.IsOnline {
+		service.LastSeenAt = time.Now().UnixMilli()
+		service.ConsecutiveFailures = 0
+	}
+
 	return nil
 }
+
+// Returns a copy of every platform service state, in the order of platform_services.ExistingServices.
+func (psd *PlatformServicesDatastore) GetPlatformServices() []service_models.PlatformService {
+	psd.mutex.RLock()
+	defer psd.mutex.RUnlock()
+
+	var services []service_models.PlatformService = make([]service_models.PlatformService, 0, len(psd.onlineServices))
+
+	for _, service_name := range platform_services.ExistingServices {
+		service, exists := psd.onlineServices[service_name]
+		if !exists {
+			continue
+		}
+
+		services = append(services, *service)
+	}
+
+	return services
+}
+
+// Records the result of a health check. A service that answers is marked online, one that fails failures_before_offline
+// checks in a row is marked offline. Returns a copy of the service state after the check.
+func (psd *PlatformServicesDatastore) RecordServiceHealthCheck(service_name string, is_alive bool, failures_before_offline int) (service_models.PlatformService, *dungeon_models.LabeledError) {
+	psd.mutex.Lock()
+	defer psd.mutex.Unlock()
+
+	service, exists := psd.onlineServices[platform_services.PlatformServiceName(service_name)]
+	if !exists {
+		lerr := dungeon_models.NewLabeledError(fmt.Errorf("service '%s' does not exist", service_name), "In PlatformServicesDatastore.RecordServiceHealthCheck while checking if service exists", service_models.ErrService_NotSuchService)
+		return service_models.PlatformService{}, lerr
+	}
+
+	var now int64 = time.Now().UnixMilli()
+
+	service.LastCheckedAt = now
+
+	if is_alive {
+		service.LastSeenAt = now
+		service.ConsecutiveFailures = 0
+		service.IsOnline = true
+	} else {
+		service.ConsecutiveFailures++
+
+		if service.ConsecutiveFailures >= failures_before_offline {
+			service.IsOnline = false
+		}
+	}
+
+	return *service, nil
+}
@@ -0,0 +1,322 @@
+package databases
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	service_models "libery_JD_service/models"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Keeps the registered webhooks on a json file that is rewritten on every change, and the delivery attempts
+// on an append-only json lines log that is only rewritten by CompactDeliveries.
+type WebhooksStore struct {
+	webhooks_path   string
+	deliveries_path string
+	deliveries_file *os.File
+	webhooks        map[string]*service_models.Webhook
+	deliveries      []*service_models.WebhookDelivery // Ordered by attempt time
+	mutex           sync.RWMutex
+}
+
+func NewWebhooksStore(webhooks_path, deliveries_path string) (*WebhooksStore, error) {
+	var new_store *WebhooksStore = new(WebhooksStore)
+
+	new_store.webhooks_path = webhooks_path
+	new_store.deliveries_path = deliveries_path
+	new_store.webhooks = make(map[string]*service_models.Webhook)
+	new_store.deliveries = make([]*service_models.WebhookDelivery, 0)
+
+	err := new_store.loadWebhooks()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In databases.NewWebhooksStore: While loading the webhooks<%s>", webhooks_path), err)
+	}
+
+	err = new_store.loadDeliveries()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In databases.NewWebhooksStore: While loading the webhook deliveries<%s>", deliveries_path), err)
+	}
+
+	new_store.deliveries_file, err = os.OpenFile(deliveries_path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In databases.NewWebhooksStore: While opening the webhook deliveries<%s> for appending", deliveries_path), err)
+	}
+
+	return new_store, nil
+}
+
+func (ws *WebhooksStore) loadWebhooks() error {
+	webhooks_content, err := os.ReadFile(ws.webhooks_path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var stored_webhooks []*service_models.Webhook
+
+	err = json.Unmarshal(webhooks_content, &stored_webhooks)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range stored_webhooks {
+		ws.webhooks[webhook.UUID] = webhook
+	}
+
+	return nil
+}
+
+// Lines that cannot be parsed(e.g. a write cut short by a crash) are skipped.
+func (ws *WebhooksStore) loadDeliveries() error {
+	deliveries_file, err := os.Open(ws.deliveries_path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer deliveries_file.Close()
+
+	var deliveries_scanner *bufio.Scanner = bufio.NewScanner(deliveries_file)
+
+	for deliveries_scanner.Scan() {
+		var delivery *service_models.WebhookDelivery = new(service_models.WebhookDelivery)
+
+		err = json.Unmarshal(deliveries_scanner.Bytes(), delivery)
+		if err != nil {
+			echo.EchoWarn(fmt.Sprintf("In WebhooksStore.loadDeliveries: Skipping malformed log line: %s", err.Error()))
+			continue
+		}
+
+		ws.deliveries = append(ws.deliveries, delivery)
+	}
+
+	return deliveries_scanner.Err()
+}
+
+// Rewrites the webhooks file. The caller must hold the write lock.
+func (ws *WebhooksStore) persistWebhooks() error {
+	var stored_webhooks []*service_models.Webhook = ws.sortedWebhooks()
+
+	webhooks_content, err := json.MarshalIndent(stored_webhooks, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	var temporary_path string = ws.webhooks_path + ".tmp"
+
+	err = os.WriteFile(temporary_path, webhooks_content, 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(temporary_path, ws.webhooks_path)
+}
+
+func (ws *WebhooksStore) sortedWebhooks() []*service_models.Webhook {
+	var sorted_webhooks []*service_models.Webhook = make([]*service_models.Webhook, 0, len(ws.webhooks))
+
+	for _, webhook := range ws.webhooks {
+		sorted_webhooks = append(sorted_webhooks, webhook)
+	}
+
+	sort.Slice(sorted_webhooks, func(i, j int) bool {
+		return sorted_webhooks[i].CreatedAt < sorted_webhooks[j].CreatedAt
+	})
+
+	return sorted_webhooks
+}
+
+func (ws *WebhooksStore) CreateWebhook(new_webhook *service_models.Webhook) *dungeon_models.LabeledError {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	ws.webhooks[new_webhook.UUID] = new_webhook
+
+	err := ws.persistWebhooks()
+	if err != nil {
+		delete(ws.webhooks, new_webhook.UUID)
+		return dungeon_models.NewLabeledError(err, "In WebhooksStore.CreateWebhook while persisting webhooks", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	return nil
+}
+
+func (ws *WebhooksStore) GetWebhook(webhook_uuid string) (*service_models.Webhook, *dungeon_models.LabeledError) {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+
+	webhook, exists := ws.webhooks[webhook_uuid]
+	if !exists {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("Webhook with UUID %s not found", webhook_uuid), "In WebhooksStore.GetWebhook", service_models.ErrService_NoSuchWebhook)
+	}
+
+	return webhook, nil
+}
+
+func (ws *WebhooksStore) GetWebhooks() []*service_models.Webhook {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+
+	return ws.sortedWebhooks()
+}
+
+func (ws *WebhooksStore) GetWebhooksForEvent(event_type string) []*service_models.Webhook {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+
+	var interested_webhooks []*service_models.Webhook = make([]*service_models.Webhook, 0)
+
+	for _, webhook := range ws.sortedWebhooks() {
+		if webhook.WantsEventType(event_type) {
+			interested_webhooks = append(interested_webhooks, webhook)
+		}
+	}
+
+	return interested_webhooks
+}
+
+func (ws *WebhooksStore) DeleteWebhook(webhook_uuid string) *dungeon_models.LabeledError {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	deleted_webhook, exists := ws.webhooks[webhook_uuid]
+	if !exists {
+		return dungeon_models.NewLabeledError(fmt.Errorf("Webhook with UUID %s not found", webhook_uuid), "In WebhooksStore.DeleteWebhook", service_models.ErrService_NoSuchWebhook)
+	}
+
+	delete(ws.webhooks, webhook_uuid)
+
+	err := ws.persistWebhooks()
+	if err != nil {
+		ws.webhooks[webhook_uuid] = deleted_webhook
+		return dungeon_models.NewLabeledError(err, "In WebhooksStore.DeleteWebhook while persisting webhooks", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	return nil
+}
+
+func (ws *WebhooksStore) RecordDelivery(delivery *service_models.WebhookDelivery) *dungeon_models.LabeledError {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	log_line, err := json.Marshal(delivery)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In WebhooksStore.RecordDelivery while marshalling delivery", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	_, err = ws.deliveries_file.Write(append(log_line, '\n'))
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In WebhooksStore.RecordDelivery while appending delivery to the log", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	ws.deliveries = append(ws.deliveries, delivery)
+
+	return nil
+}
+
+// Returns the delivery attempts newest first. An empty webhook_uuid returns the attempts of every webhook, a limit of 0 or less returns them all.
+func (ws *WebhooksStore) GetDeliveries(webhook_uuid string, limit int) []*service_models.WebhookDelivery {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+
+	var deliveries []*service_models.WebhookDelivery = make([]*service_models.WebhookDelivery, 0)
+
+	for h := len(ws.deliveries) - 1; h >= 0; h-- {
+		if limit > 0 && len(deliveries) >= limit {
+			break
+		}
+
+		if webhook_uuid == "" || ws.deliveries[h].WebhookUUID == webhook_uuid {
+			deliveries = append(deliveries, ws.deliveries[h])
+		}
+	}
+
+	return deliveries
+}
+
+// Returns the last attempt of every delivery that failed and still has a retry scheduled, skipping the ones whose webhook was deleted.
+func (ws *WebhooksStore) GetPendingRetries() []*service_models.WebhookDelivery {
+	ws.mutex.RLock()
+	defer ws.mutex.RUnlock()
+
+	var last_attempts map[string]*service_models.WebhookDelivery = make(map[string]*service_models.WebhookDelivery)
+	var deliveries_order []string = make([]string, 0)
+
+	for _, delivery := range ws.deliveries {
+		if _, seen := last_attempts[delivery.DeliveryUUID]; !seen {
+			deliveries_order = append(deliveries_order, delivery.DeliveryUUID)
+		}
+
+		last_attempts[delivery.DeliveryUUID] = delivery
+	}
+
+	var pending_retries []*service_models.WebhookDelivery = make([]*service_models.WebhookDelivery, 0)
+
+	for _, delivery_uuid := range deliveries_order {
+		last_attempt := last_attempts[delivery_uuid]
+
+		if _, webhook_exists := ws.webhooks[last_attempt.WebhookUUID]; !webhook_exists {
+			continue
+		}
+
+		if !last_attempt.Succeeded && last_attempt.NextRetryAt != 0 {
+			pending_retries = append(pending_retries, last_attempt)
+		}
+	}
+
+	return pending_retries
+}
+
+// Drops the delivery attempts older than max_age and rewrites the deliveries log with the ones that remain. Returns the amount of attempts dropped.
+func (ws *WebhooksStore) CompactDeliveries(max_age time.Duration) (int, *dungeon_models.LabeledError) {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	var oldest_allowed int64 = time.Now().Add(-max_age).UnixMilli()
+
+	var retained_deliveries []*service_models.WebhookDelivery = make([]*service_models.WebhookDelivery, 0, len(ws.deliveries))
+
+	for _, delivery := range ws.deliveries {
+		if delivery.AttemptedAt >= oldest_allowed {
+			retained_deliveries = append(retained_deliveries, delivery)
+		}
+	}
+
+	var dropped_count int = len(ws.deliveries) - len(retained_deliveries)
+	if dropped_count == 0 {
+		return 0, nil
+	}
+
+	err := rewriteJSONLinesLog(ws.deliveries_path, retained_deliveries)
+	if err != nil {
+		return 0, dungeon_models.NewLabeledError(err, "In WebhooksStore.CompactDeliveries while rewriting the log", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	ws.deliveries = retained_deliveries
+
+	ws.deliveries_file.Close()
+
+	ws.deliveries_file, err = os.OpenFile(ws.deliveries_path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return dropped_count, dungeon_models.NewLabeledError(err, "In WebhooksStore.CompactDeliveries while reopening the log for appending", service_models.ErrService_WebhooksWriteFailed)
+	}
+
+	return dropped_count, nil
+}
+
+func (ws *WebhooksStore) Close() error {
+	ws.mutex.Lock()
+	defer ws.mutex.Unlock()
+
+	return ws.deliveries_file.Close()
+}
@@ -2,9 +2,17 @@ package handlers
 
 import (
 	"fmt"
+	"libery-dungeon-libs/communication"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
+	app_config "libery_JD_service/Config"
+	service_models "libery_JD_service/models"
 	"libery_JD_service/workflows/jobs"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
 )
@@ -36,12 +44,23 @@ func getPlatformEventsHandler(response http.ResponseWriter, request *http.Reques
 	switch resource {
 	case "/platform-events/public/suscribe":
 		getPlatformEventsPublicSubscriptionHandler(response, request)
+	case "/platform-events/public/stream":
+		getPlatformEventsPublicStreamHandler(response, request)
 	default:
 		response.WriteHeader(404)
 	}
 }
 
+// Subscribes to the public platform events through a websocket. See parseEventSubscriptionRequest for the accepted query params.
+// Subscribers that are reconnecting can pass the sequence of the last event they received as the 'last_sequence' query param
+// to get the events they missed before any new one.
 func getPlatformEventsPublicSubscriptionHandler(response http.ResponseWriter, request *http.Request) {
+	subscription, last_sequence, should_resume, rejection_status := parseEventSubscriptionRequest(request, request.URL.Query().Get("last_sequence"))
+	if rejection_status != 0 {
+		response.WriteHeader(rejection_status)
+		return
+	}
+
 	upgrader := jobs.TownCrier.GetUpgrader()
 
 	connection, err := upgrader.Upgrade(response, request, nil)
@@ -51,10 +70,157 @@ func getPlatformEventsPublicSubscriptionHandler(response http.ResponseWriter, re
 		return
 	}
 
-	jobs.TownCrier.RegisterListener(connection)
+	var events_connection *websocketEventsConnection = newWebsocketEventsConnection(connection, time.Duration(app_config.PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS)*time.Second)
+
+	if !should_resume {
+		jobs.TownCrier.RegisterListener(events_connection, subscription)
+		return
+	}
+
+	err = jobs.TownCrier.ResumeListener(events_connection, subscription, last_sequence)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getPlatformEventsPublicSubscriptionHandler while resuming listener: %s", err.Error()))
+	}
+
 	return
 }
 
+// Subscribes to the public platform events through a Server-Sent Events stream, for clients that can't use websockets. Every
+// event's id is its sequence, so clients resume with the standard 'Last-Event-ID' header('last_sequence' query param is also accepted).
+// The request is held open until the client leaves, a heartbeat comment is written periodically to detect dead clients.
+func getPlatformEventsPublicStreamHandler(response http.ResponseWriter, request *http.Request) {
+	var resume_from string = request.Header.Get("Last-Event-ID")
+	if resume_from == "" {
+		resume_from = request.URL.Query().Get("last_sequence")
+	}
+
+	subscription, last_sequence, should_resume, rejection_status := parseEventSubscriptionRequest(request, resume_from)
+	if rejection_status != 0 {
+		response.WriteHeader(rejection_status)
+		return
+	}
+
+	var events_connection *sseEventsConnection = newSSEEventsConnection(response, request, time.Duration(app_config.PLATFORM_EVENTS_WRITE_TIMEOUT_SECONDS)*time.Second)
+	defer events_connection.Close()
+
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.Header().Set("Connection", "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+
+	err := events_connection.SendHeartbeat() // Sends the headers right away
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getPlatformEventsPublicStreamHandler while opening the stream: %s", err.Error()))
+		return
+	}
+
+	if should_resume {
+		err = jobs.TownCrier.ResumeListener(events_connection, subscription, last_sequence)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In getPlatformEventsPublicStreamHandler while resuming listener: %s", err.Error()))
+			return
+		}
+	} else {
+		jobs.TownCrier.RegisterListener(events_connection, subscription)
+	}
+
+	heartbeat_ticker := time.NewTicker(time.Duration(app_config.PLATFORM_EVENTS_SSE_HEARTBEAT_SECONDS) * time.Second)
+	defer heartbeat_ticker.Stop()
+
+	for {
+		select {
+		case <-heartbeat_ticker.C:
+			err = events_connection.SendHeartbeat()
+			if err != nil {
+				echo.EchoDebug(fmt.Sprintf("SSE listener %s stopped answering: %s", events_connection.RemoteAddr(), err.Error()))
+				return
+			}
+		case <-events_connection.Done():
+			return
+		case <-request.Context().Done():
+			return
+		}
+	}
+}
+
+// Reads the listener's claims, event filters and resume point shared by every platform events transport. The 'event_types'
+// and 'cluster_uuids' query params take comma separated lists to only receive some events. Events about private clusters
+// are only sent to users that can view private clusters. If the request must be rejected, rejection_status is the http status to reply with.
+func parseEventSubscriptionRequest(request *http.Request, resume_from string) (subscription *service_models.EventSubscription, last_sequence uint64, should_resume bool, rejection_status int) {
+	user_claims, err := dungeon_middlewares.GetUserClaims(request, app_config.JWT_SECRET)
+	if err != nil {
+		rejection_status = 401
+		return
+	}
+
+	event_types, err := parseSubscriptionFilter(request, "event_types")
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In parseEventSubscriptionRequest while parsing event types: %s", err.Error()))
+		rejection_status = 400
+		return
+	}
+
+	cluster_uuids, err := parseSubscriptionFilter(request, "cluster_uuids")
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In parseEventSubscriptionRequest while parsing cluster uuids: %s", err.Error()))
+		rejection_status = 400
+		return
+	}
+
+	for _, event_type := range event_types {
+		if !communication.IsPublicEvent(event_type) {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In parseEventSubscriptionRequest: '%s' is not a public event type", event_type))
+			rejection_status = 400
+			return
+		}
+	}
+
+	subscription = service_models.NewEventSubscription(user_claims, event_types, cluster_uuids)
+
+	if resume_from == "" {
+		return
+	}
+
+	last_sequence, err = strconv.ParseUint(resume_from, 10, 64)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In parseEventSubscriptionRequest while parsing the last sequence: %s", err.Error()))
+		rejection_status = 400
+		return
+	}
+
+	should_resume = true
+
+	return
+}
+
+// Reads a comma separated filter from the query params. A missing filter means no filtering and returns nil, a filter
+// that is present but lists no values is an error.
+func parseSubscriptionFilter(request *http.Request, key string) ([]string, error) {
+	if !request.URL.Query().Has(key) {
+		return nil, nil
+	}
+
+	raw_values, err := dungeon_helpers.ParseQueryParameterAsStringSlice(request, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string = make([]string, 0, len(raw_values))
+
+	for _, raw_value := range raw_values {
+		if value := strings.TrimSpace(raw_value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("Query parameter %s lists no values", key)
+	}
+
+	return values, nil
+}
+
 func postPlatformEventsHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
 	return
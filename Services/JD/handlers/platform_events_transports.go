@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Delivers platform events to a websocket listener.
+type websocketEventsConnection struct {
+	connection    *websocket.Conn
+	write_timeout time.Duration
+}
+
+func newWebsocketEventsConnection(connection *websocket.Conn, write_timeout time.Duration) *websocketEventsConnection {
+	return &websocketEventsConnection{
+		connection:    connection,
+		write_timeout: write_timeout,
+	}
+}
+
+func (wec *websocketEventsConnection) SendEvent(event_sequence uint64, event_message []byte) error {
+	wec.connection.SetWriteDeadline(time.Now().Add(wec.write_timeout))
+
+	return wec.connection.WriteMessage(websocket.TextMessage, event_message)
+}
+
+func (wec *websocketEventsConnection) Close() {
+	wec.connection.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(time.Second))
+	wec.connection.Close()
+}
+
+func (wec *websocketEventsConnection) RemoteAddr() string {
+	return wec.connection.RemoteAddr().String()
+}
+
+// Delivers platform events to a Server-Sent Events listener. The connection lives as long as the request
+// that opened it, so the handler must wait on Done and call Close before returning.
+type sseEventsConnection struct {
+	response            http.ResponseWriter
+	response_controller *http.ResponseController
+	remote_addr         string
+	write_timeout       time.Duration
+	write_mutex         sync.Mutex
+	is_closed           bool
+	closed              chan struct{}
+}
+
+func newSSEEventsConnection(response http.ResponseWriter, request *http.Request, write_timeout time.Duration) *sseEventsConnection {
+	return &sseEventsConnection{
+		response:            response,
+		response_controller: http.NewResponseController(response),
+		remote_addr:         request.RemoteAddr,
+		write_timeout:       write_timeout,
+		closed:              make(chan struct{}),
+	}
+}
+
+// Writes a raw chunk of the event stream and flushes it. Chunks written after the connection closed are rejected,
+// the response writer must not be used once the handler returned.
+func (sec *sseEventsConnection) writeChunk(chunk string) error {
+	sec.write_mutex.Lock()
+	defer sec.write_mutex.Unlock()
+
+	if sec.is_closed {
+		return fmt.Errorf("SSE connection with %s is closed", sec.remote_addr)
+	}
+
+	sec.response_controller.SetWriteDeadline(time.Now().Add(sec.write_timeout))
+
+	_, err := fmt.Fprint(sec.response, chunk)
+	if err != nil {
+		return err
+	}
+
+	return sec.response_controller.Flush()
+}
+
+func (sec *sseEventsConnection) SendEvent(event_sequence uint64, event_message []byte) error {
+	return sec.writeChunk(fmt.Sprintf("id: %d\ndata: %s\n\n", event_sequence, event_message))
+}
+
+// Comment lines are ignored by SSE clients, they keep proxies from dropping an idle stream and detect dead listeners.
+func (sec *sseEventsConnection) SendHeartbeat() error {
+	return sec.writeChunk(": heartbeat\n\n")
+}
+
+func (sec *sseEventsConnection) Close() {
+	sec.write_mutex.Lock()
+	defer sec.write_mutex.Unlock()
+
+	if sec.is_closed {
+		return
+	}
+
+	sec.is_closed = true
+	close(sec.closed)
+}
+
+func (sec *sseEventsConnection) Done() <-chan struct{} {
+	return sec.closed
+}
+
+func (sec *sseEventsConnection) RemoteAddr() string {
+	return sec.remote_addr
+}
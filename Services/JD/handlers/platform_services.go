@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"libery-dungeon-libs/libs/libery_networking"
+	"libery_JD_service/repository"
+	"net/http"
+)
+
+func PlatformServicesHandler(service_instance libery_networking.Server) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			getPlatformServicesHandler(response, request)
+		case http.MethodOptions:
+			response.WriteHeader(http.StatusOK)
+		default:
+			response.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func getPlatformServicesHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/platform-services":
+		resource_handler = dungeon_middlewares.CheckUserCan_ReadPlatformServices(get__PlatformServicesHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+// Returns the state of every platform service. Services use it, signed with the domain secret, to resolve each other's routes.
+func get__PlatformServicesHandler(response http.ResponseWriter, request *http.Request) {
+	platform_services := repository.PlatformFeaturesRepo.GetPlatformServices()
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(response).Encode(platform_services)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	"libery-dungeon-libs/communication/service_requests/jd_requests"
+	"libery-dungeon-libs/dungeonsec"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	"libery-dungeon-libs/libs/libery_networking"
+	app_config "libery_JD_service/Config"
+	service_models "libery_JD_service/models"
+	"libery_JD_service/repository"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+	"github.com/google/uuid"
+)
+
+const default_deliveries_limit int = 100
+
+func WebhooksHandler(service_instance libery_networking.Server) http.HandlerFunc {
+	return func(response http.ResponseWriter, request *http.Request) {
+		switch request.Method {
+		case http.MethodGet:
+			getWebhooksHandler(response, request)
+		case http.MethodPost:
+			postWebhooksHandler(response, request)
+		case http.MethodDelete:
+			deleteWebhooksHandler(response, request)
+		case http.MethodOptions:
+			response.WriteHeader(http.StatusOK)
+		default:
+			response.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func getWebhooksHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/webhooks":
+		resource_handler = dungeon_middlewares.CheckUserCan_ManageWebhooks(get__WebhooksHandler)
+	case "/webhooks/deliveries":
+		resource_handler = dungeon_middlewares.CheckUserCan_ManageWebhooks(get__WebhookDeliveriesHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+func get__WebhooksHandler(response http.ResponseWriter, request *http.Request) {
+	webhooks := repository.Webhooks.GetWebhooks()
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(response).Encode(webhooks)
+}
+
+// Returns the delivery log newest first. Takes an optional 'webhook_uuid' to only get the attempts of one webhook
+// and an optional 'limit'(defaults to 100, 0 returns every recorded attempt).
+func get__WebhookDeliveriesHandler(response http.ResponseWriter, request *http.Request) {
+	var webhook_uuid string = request.URL.Query().Get("webhook_uuid")
+	var limit int = default_deliveries_limit
+
+	if webhook_uuid != "" {
+		_, lerr := repository.Webhooks.GetWebhook(webhook_uuid)
+		if lerr != nil {
+			dungeon_helpers.WriteRejection(response, 404, "Webhook not found")
+			return
+		}
+	}
+
+	if limit_param := request.URL.Query().Get("limit"); limit_param != "" {
+		parsed_limit, err := strconv.Atoi(limit_param)
+		if err != nil || parsed_limit < 0 {
+			dungeon_helpers.WriteRejection(response, 400, "Invalid limit")
+			return
+		}
+
+		limit = parsed_limit
+	}
+
+	deliveries := repository.Webhooks.GetDeliveries(webhook_uuid, limit)
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusOK)
+
+	json.NewEncoder(response).Encode(deliveries)
+}
+
+func postWebhooksHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/webhooks":
+		resource_handler = dungeon_middlewares.CheckUserCan_ManageWebhooks(post__WebhookHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+func post__WebhookHandler(response http.ResponseWriter, request *http.Request) {
+	var request_body *jd_requests.CreateWebhookRequest
+
+	err := json.NewDecoder(request.Body).Decode(&request_body)
+	if err != nil || request_body == nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/webhooks.post__WebhookHandler: error decoding request body\n\n%v", err))
+		dungeon_helpers.WriteRejection(response, 400, "Malformed request")
+		return
+	}
+
+	target_url, err := url.Parse(request_body.TargetURL)
+	if err != nil || (target_url.Scheme != "http" && target_url.Scheme != "https") || target_url.Host == "" {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/webhooks.post__WebhookHandler: '%s' is not a valid http(s) url", request_body.TargetURL))
+		dungeon_helpers.WriteRejection(response, 400, "Invalid target url")
+		return
+	}
+
+	for _, event_type := range request_body.EventTypes {
+		if !communication.IsPublicEvent(event_type) {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/webhooks.post__WebhookHandler: '%s' is not a public event type", event_type))
+			dungeon_helpers.WriteRejection(response, 400, "Invalid event type")
+			return
+		}
+	}
+
+	var new_webhook *service_models.Webhook = &service_models.Webhook{
+		UUID:       uuid.New().String(),
+		TargetURL:  target_url.String(),
+		EventTypes: request_body.EventTypes,
+		CreatedAt:  time.Now().UnixMilli(),
+	}
+
+	if new_webhook.EventTypes == nil {
+		new_webhook.EventTypes = make([]string, 0)
+	}
+
+	// Requests authorized with the domain secret have no user behind them and are trusted with every cluster.
+	if user_claims, err := dungeon_middlewares.GetUserClaims(request, app_config.JWT_SECRET); err == nil {
+		new_webhook.CreatedBy = user_claims.UserUUID
+		new_webhook.CanViewPrivateClusters = dungeonsec.CanViewPrivateClusters(user_claims.UserGrants)
+	} else {
+		new_webhook.CanViewPrivateClusters = true
+	}
+
+	lerr := repository.Webhooks.CreateWebhook(new_webhook)
+	if lerr != nil {
+		lerr.AppendContext("In handlers/webhooks.post__WebhookHandler")
+		echo.Echo(echo.RedFG, lerr.Error())
+		dungeon_helpers.WriteRejection(response, 500, "Could not register webhook")
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(response).Encode(new_webhook)
+}
+
+func deleteWebhooksHandler(response http.ResponseWriter, request *http.Request) {
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case "/webhooks":
+		resource_handler = dungeon_middlewares.CheckUserCan_ManageWebhooks(delete__WebhookHandler)
+	}
+
+	resource_handler(response, request)
+}
+
+func delete__WebhookHandler(response http.ResponseWriter, request *http.Request) {
+	var webhook_uuid string = request.URL.Query().Get("uuid")
+	if webhook_uuid == "" {
+		dungeon_helpers.WriteRejection(response, 400, "Missing uuid")
+		return
+	}
+
+	lerr := repository.Webhooks.DeleteWebhook(webhook_uuid)
+	if lerr != nil {
+		if lerr.Label == service_models.ErrService_NoSuchWebhook {
+			dungeon_helpers.WriteRejection(response, 404, "Webhook not found")
+			return
+		}
+
+		lerr.AppendContext("In handlers/webhooks.delete__WebhookHandler")
+		echo.Echo(echo.RedFG, lerr.Error())
+		dungeon_helpers.WriteRejection(response, 500, "Could not delete webhook")
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
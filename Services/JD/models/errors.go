@@ -5,4 +5,7 @@ import dungeon_models "libery-dungeon-libs/models"
 var (
 	ErrService_NotSuchService        dungeon_models.ErrorLabel = "Service not found"
 	ErrService_NoSuchEventRegistered dungeon_models.ErrorLabel = "An event with the given UUID is not registered"
+	ErrService_EventLogWriteFailed   dungeon_models.ErrorLabel = "The platform events log could not be written"
+	ErrService_NoSuchWebhook         dungeon_models.ErrorLabel = "A webhook with the given UUID is not registered"
+	ErrService_WebhooksWriteFailed   dungeon_models.ErrorLabel = "The webhooks data could not be written"
 )
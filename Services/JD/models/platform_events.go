@@ -0,0 +1,83 @@
+package models
+
+import (
+	"libery-dungeon-libs/communication"
+	"libery-dungeon-libs/dungeonsec"
+	dungeon_models "libery-dungeon-libs/models"
+	"time"
+)
+
+// A platform event as it is kept on the platform events log. Sequence numbers are assigned by the log
+// in registration order and never go backwards, so subscribers can use the last sequence they saw
+// to ask for whatever they missed.
+type SequencedPlatformEvent struct {
+	communication.PlatformEvent
+	Sequence     uint64 `json:"sequence"`
+	RegisteredAt int64  `json:"registered_at"` // Unix timestamp in milliseconds
+}
+
+func NewSequencedPlatformEvent(event communication.PlatformEvent, sequence uint64, registered_at int64) *SequencedPlatformEvent {
+	return &SequencedPlatformEvent{
+		PlatformEvent: event,
+		Sequence:      sequence,
+		RegisteredAt:  registered_at,
+	}
+}
+
+func (spe SequencedPlatformEvent) IsPublic() bool {
+	return communication.IsPublicEvent(spe.EventType)
+}
+
+// What a platform events listener wants to receive and what it is allowed to see. Empty filters mean no filtering.
+type EventSubscription struct {
+	UserUUID               string
+	CanViewPrivateClusters bool
+	ExpiresAt              int64 // Unix timestamp in seconds of the listener's claims expiration, 0 if they don't expire
+	EventTypes             map[string]struct{}
+	ClusterUUIDs           map[string]struct{}
+}
+
+func NewEventSubscription(user_claims *dungeon_models.PlatformUserClaims, event_types, cluster_uuids []string) *EventSubscription {
+	var new_subscription *EventSubscription = new(EventSubscription)
+
+	new_subscription.UserUUID = user_claims.UserUUID
+	new_subscription.CanViewPrivateClusters = dungeonsec.CanViewPrivateClusters(user_claims.UserGrants)
+	new_subscription.ExpiresAt = user_claims.ExpiresAt
+	new_subscription.EventTypes = make(map[string]struct{}, len(event_types))
+	new_subscription.ClusterUUIDs = make(map[string]struct{}, len(cluster_uuids))
+
+	for _, event_type := range event_types {
+		new_subscription.EventTypes[event_type] = struct{}{}
+	}
+
+	for _, cluster_uuid := range cluster_uuids {
+		new_subscription.ClusterUUIDs[cluster_uuid] = struct{}{}
+	}
+
+	return new_subscription
+}
+
+func (es EventSubscription) WantsEventType(event_type string) bool {
+	if len(es.EventTypes) == 0 {
+		return true
+	}
+
+	_, wanted := es.EventTypes[event_type]
+
+	return wanted
+}
+
+// The cluster filter only applies to events that concern a cluster, events that don't are always wanted.
+func (es EventSubscription) WantsCluster(cluster_uuid string) bool {
+	if len(es.ClusterUUIDs) == 0 || cluster_uuid == "" {
+		return true
+	}
+
+	_, wanted := es.ClusterUUIDs[cluster_uuid]
+
+	return wanted
+}
+
+func (es EventSubscription) IsExpired() bool {
+	return es.ExpiresAt != 0 && time.Now().Unix() > es.ExpiresAt
+}
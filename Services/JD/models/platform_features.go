@@ -3,10 +3,13 @@ package models
 import "libery-dungeon-libs/models/platform_services"
 
 type PlatformService struct {
-	ServiceName  platform_services.PlatformServiceName `json:"service_name"`
-	ServiceRoute string                                `json:"service_route"`
-	ServicePort  string                                `json:"service_port"`
-	IsOnline     bool                                  `json:"is_online"`
+	ServiceName         platform_services.PlatformServiceName `json:"service_name"`
+	ServiceRoute        string                                `json:"service_route"`
+	ServicePort         string                                `json:"service_port"`
+	IsOnline            bool                                  `json:"is_online"`
+	LastSeenAt          int64                                 `json:"last_seen_at"`         // Unix timestamp in milliseconds of the last time the service notified or answered a health check, 0 if never
+	LastCheckedAt       int64                                 `json:"last_checked_at"`      // Unix timestamp in milliseconds of the last health check, 0 if never
+	ConsecutiveFailures int                                   `json:"consecutive_failures"` // Health checks failed since the last one that succeeded
 }
 
 func NewPlatformService(service_name platform_services.PlatformServiceName, service_route, service_port string) *PlatformService {
@@ -17,3 +20,8 @@ func NewPlatformService(service_name platform_services.PlatformServiceName, serv
 		IsOnline:     false,
 	}
 }
+
+// Whether JD can check on the service, services that never notified JD have no route to reach them at.
+func (ps PlatformService) IsCheckable() bool {
+	return ps.ServiceRoute != "" && ps.ServiceName != platform_services.JD_SERVICE
+}
@@ -0,0 +1,48 @@
+package models
+
+// An external url that gets the public platform events posted to it.
+type Webhook struct {
+	UUID                   string   `json:"uuid"`
+	TargetURL              string   `json:"target_url"`
+	EventTypes             []string `json:"event_types"`               // Empty means every public event
+	CreatedBy              string   `json:"created_by"`                // UUID of the user that registered the webhook
+	CreatedAt              int64    `json:"created_at"`                // Unix timestamp in milliseconds
+	CanViewPrivateClusters bool     `json:"can_view_private_clusters"` // Whether the webhook's creator could view private clusters, if not, events about them are not posted
+}
+
+func (w Webhook) WantsEventType(event_type string) bool {
+	if len(w.EventTypes) == 0 {
+		return true
+	}
+
+	for _, wanted_type := range w.EventTypes {
+		if wanted_type == event_type {
+			return true
+		}
+	}
+
+	return false
+}
+
+// A single attempt to deliver an event to a webhook.
+type WebhookDelivery struct {
+	WebhookUUID   string `json:"webhook_uuid"`
+	DeliveryUUID  string `json:"delivery_uuid"` // Shared by every attempt to deliver the same event to the same webhook
+	EventUUID     string `json:"event_uuid"`
+	EventType     string `json:"event_type"`
+	EventSequence uint64 `json:"event_sequence"`
+	Attempt       int    `json:"attempt"`
+	AttemptedAt   int64  `json:"attempted_at"` // Unix timestamp in milliseconds
+	StatusCode    int    `json:"status_code"`  // 0 if the target could not be reached
+	Error         string `json:"error,omitempty"`
+	Succeeded     bool   `json:"succeeded"`
+	NextRetryAt   int64  `json:"next_retry_at"` // Unix timestamp in milliseconds, 0 if the delivery won't be retried
+}
+
+// Body posted to the webhooks target url. Its hmac-sha256, keyed with the domain secret, is sent on the X-Dungeon-Signature header.
+type WebhookPayload struct {
+	WebhookUUID  string                  `json:"webhook_uuid"`
+	DeliveryUUID string                  `json:"delivery_uuid"`
+	Attempt      int                     `json:"attempt"`
+	Event        *SequencedPlatformEvent `json:"event"`
+}
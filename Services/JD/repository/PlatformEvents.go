@@ -3,12 +3,19 @@ package repository
 import (
 	"libery-dungeon-libs/communication"
 	dungeon_models "libery-dungeon-libs/models"
+	service_models "libery_JD_service/models"
+	"time"
 )
 
 type PlatformEventsRegistry interface {
-	RegisterEvent(event *communication.PlatformEvent)
+	RegisterEvent(event *communication.PlatformEvent) (*service_models.SequencedPlatformEvent, *dungeon_models.LabeledError)
 	GetPublicEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError)
 	GetPrivateEvent(event_uuid string) (*communication.PlatformEvent, *dungeon_models.LabeledError)
+	GetPublicEventsSince(last_sequence uint64) []*service_models.SequencedPlatformEvent
+	GetEventBySequence(sequence uint64) (*service_models.SequencedPlatformEvent, *dungeon_models.LabeledError)
+	GetLastSequence() uint64
+	Compact(max_age time.Duration) (int, *dungeon_models.LabeledError)
+	Close() error
 }
 
 var PlatformEvents PlatformEventsRegistry
@@ -9,6 +9,8 @@ type PlatformFeatures interface {
 	SetServiceOnline(service_name string, online bool) (lerr *dungeon_models.LabeledError)
 	IsServiceOnline(service_name string) bool
 	SetServiceMetadata(new_service service_models.PlatformService) (lerr *dungeon_models.LabeledError)
+	GetPlatformServices() []service_models.PlatformService
+	RecordServiceHealthCheck(service_name string, is_alive bool, failures_before_offline int) (service_models.PlatformService, *dungeon_models.LabeledError)
 }
 
 var PlatformFeaturesRepo PlatformFeatures
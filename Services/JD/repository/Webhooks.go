@@ -0,0 +1,26 @@
+package repository
+
+import (
+	dungeon_models "libery-dungeon-libs/models"
+	service_models "libery_JD_service/models"
+	"time"
+)
+
+type WebhooksRegistry interface {
+	CreateWebhook(new_webhook *service_models.Webhook) *dungeon_models.LabeledError
+	GetWebhook(webhook_uuid string) (*service_models.Webhook, *dungeon_models.LabeledError)
+	GetWebhooks() []*service_models.Webhook
+	GetWebhooksForEvent(event_type string) []*service_models.Webhook
+	DeleteWebhook(webhook_uuid string) *dungeon_models.LabeledError
+	RecordDelivery(delivery *service_models.WebhookDelivery) *dungeon_models.LabeledError
+	GetDeliveries(webhook_uuid string, limit int) []*service_models.WebhookDelivery
+	GetPendingRetries() []*service_models.WebhookDelivery
+	CompactDeliveries(max_age time.Duration) (int, *dungeon_models.LabeledError)
+	Close() error
+}
+
+var Webhooks WebhooksRegistry
+
+func SetWebhooksRepo(impl WebhooksRegistry) {
+	Webhooks = impl
+}
@@ -44,6 +44,10 @@ func (s *JDGrpcServer) EmitPlatformEvent(ctx context.Context, new_event *JD_serv
 		return nil, fmt.Errorf("Error registering event")
 	}
 
+	if jobs.WebhooksCourier != nil {
+		jobs.WebhooksCourier.NotifyEventsRegistered()
+	}
+
 	echo.EchoDebug(fmt.Sprintf("Event '%s' registered", new_event.Uuid))
 
 	return new(emptypb.Empty), nil
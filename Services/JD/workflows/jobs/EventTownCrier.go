@@ -2,15 +2,24 @@ package jobs
 
 import (
 	"libery-dungeon-libs/communication"
+	service_models "libery_JD_service/models"
 
 	"github.com/gorilla/websocket"
 )
 
 type Closer func()
 
+// A transport through which a listener receives the platform events(e.g. websocket, Server-Sent Events).
+type EventsConnection interface {
+	SendEvent(event_sequence uint64, event_message []byte) error
+	Close()
+	RemoteAddr() string
+}
+
 type EventTownCrier interface {
 	RegisterEvent(new_event communication.PlatformEvent) error
-	RegisterListener(ws *websocket.Conn)
+	RegisterListener(connection EventsConnection, subscription *service_models.EventSubscription)
+	ResumeListener(connection EventsConnection, subscription *service_models.EventSubscription, last_sequence uint64) error
 	GetUpgrader() *websocket.Upgrader
 	GetCloser() Closer
 	Close()
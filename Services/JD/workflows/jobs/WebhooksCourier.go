@@ -0,0 +1,12 @@
+package jobs
+
+type EventWebhooksCourier interface {
+	NotifyEventsRegistered()
+	Close()
+}
+
+var WebhooksCourier EventWebhooksCourier
+
+func SetWebhooksCourierImplementation(impl EventWebhooksCourier) {
+	WebhooksCourier = impl
+}
@@ -4,22 +4,108 @@ import (
 	"encoding/json"
 	"fmt"
 	"libery-dungeon-libs/communication"
-	dungeon_models "libery-dungeon-libs/models"
-	"libery-dungeon-libs/models/collections"
+	"libery-dungeon-libs/libs/platform_claims/resource_access"
+	app_config "libery_JD_service/Config"
+	service_models "libery_JD_service/models"
 	"libery_JD_service/repository"
 	"libery_JD_service/workflows/jobs"
 	"net/http"
-	"time"
+	"sync"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
 	"github.com/gorilla/websocket"
 )
 
+type eventListener struct {
+	connection    jobs.EventsConnection
+	subscription  *service_models.EventSubscription
+	last_sequence uint64 // Sequence of the last event the listener received or had filtered out
+}
+
+// Cluster visibility of the events being announced. Resolved before taking the listeners mutex, as checking the
+// privacy of a cluster calls the metadata service, and only once per announcement instead of once per listener.
+type announcementScopes struct {
+	event_clusters    map[uint64]string // Events without a cluster map to an empty string
+	unreadable_events map[uint64]bool   // Events whose cluster could not be read, they are never announced
+	private_clusters  map[string]bool
+}
+
+// Resolves the cluster and cluster privacy of every public event registered after since_sequence. If the privacy
+// of a cluster cannot be checked, the cluster is treated as private.
+func resolveAnnouncementScopes(since_sequence uint64) *announcementScopes {
+	var scopes *announcementScopes = &announcementScopes{
+		event_clusters:    make(map[uint64]string),
+		unreadable_events: make(map[uint64]bool),
+		private_clusters:  make(map[string]bool),
+	}
+
+	for _, pending_event := range repository.PlatformEvents.GetPublicEventsSince(since_sequence) {
+		cluster_uuid, err := pending_event.GetClusterUUID(app_config.JWT_SECRET)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In resolveAnnouncementScopes while reading cluster of event '%s': %s", pending_event.Uuid, err.Error()))
+			scopes.unreadable_events[pending_event.Sequence] = true
+			continue
+		}
+
+		scopes.event_clusters[pending_event.Sequence] = cluster_uuid
+
+		if _, resolved := scopes.private_clusters[cluster_uuid]; cluster_uuid == "" || resolved {
+			continue
+		}
+
+		is_private, err := resource_access.IsClusterPrivate(cluster_uuid)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In resolveAnnouncementScopes while checking cluster '%s': %s", cluster_uuid, err.Error()))
+			is_private = true
+		}
+
+		scopes.private_clusters[cluster_uuid] = is_private
+	}
+
+	return scopes
+}
+
+// Whether the event was registered before the scopes were resolved. Later events wait for the next announcement.
+func (as *announcementScopes) covers(event *service_models.SequencedPlatformEvent) bool {
+	_, resolved := as.event_clusters[event.Sequence]
+
+	return resolved || as.unreadable_events[event.Sequence]
+}
+
+// Whether the event can be seen by someone that can or cannot view private clusters. Events whose cluster could not be read are never visible.
+func (as *announcementScopes) isVisible(event *service_models.SequencedPlatformEvent, can_view_private_clusters bool) bool {
+	if as.unreadable_events[event.Sequence] {
+		return false
+	}
+
+	cluster_uuid := as.event_clusters[event.Sequence]
+	if cluster_uuid == "" || can_view_private_clusters {
+		return true
+	}
+
+	is_private, resolved := as.private_clusters[cluster_uuid]
+
+	return resolved && !is_private
+}
+
+// Whether the event passes the listener's filters and the listener is allowed to see it.
+func (as *announcementScopes) listenerShouldReceive(listener *eventListener, event *service_models.SequencedPlatformEvent) bool {
+	if !listener.subscription.WantsEventType(event.EventType) {
+		return false
+	}
+
+	if cluster_uuid := as.event_clusters[event.Sequence]; cluster_uuid != "" && !listener.subscription.WantsCluster(cluster_uuid) {
+		return false
+	}
+
+	return as.isVisible(event, listener.subscription.CanViewPrivateClusters)
+}
+
 type EventCrier struct {
-	announcement_queue   *collections.Queue[string]
 	announcement_arrived chan bool
 	listeners_upgrader   *websocket.Upgrader
-	event_listeners      []*websocket.Conn
+	event_listeners      []*eventListener
+	listeners_mutex      sync.Mutex
 }
 
 func (ec *EventCrier) GetUpgrader() *websocket.Upgrader {
@@ -30,91 +116,166 @@ func (ec *EventCrier) Close() {
 	echo.EchoDebug("Closing EventCrier")
 	ec.announcement_arrived <- false
 
+	ec.listeners_mutex.Lock()
+	defer ec.listeners_mutex.Unlock()
+
 	for _, listener := range ec.event_listeners {
-		echo.EchoDebug(fmt.Sprintf("Closing listener: %s", listener.RemoteAddr().String()))
-		listener.Close()
+		echo.EchoDebug(fmt.Sprintf("Closing listener: %s", listener.connection.RemoteAddr()))
+		listener.connection.Close()
 	}
 }
 
-func (ec *EventCrier) cleanUpConn(conn *websocket.Conn) {
-	echo.EchoDebug(fmt.Sprintf("Cleaning up connection: %s. Current listeners: %d", conn.RemoteAddr().String(), len(ec.event_listeners)))
+// Removes the listener from the listeners list and closes its connection. The caller must hold the listeners mutex.
+func (ec *EventCrier) cleanUpConn(stale_listener *eventListener) {
+	echo.EchoDebug(fmt.Sprintf("Cleaning up connection: %s. Current listeners: %d", stale_listener.connection.RemoteAddr(), len(ec.event_listeners)))
 
 	for h, listener := range ec.event_listeners {
-		if listener == conn {
+		if listener == stale_listener {
 			ec.event_listeners = append(ec.event_listeners[:h], ec.event_listeners[h+1:]...)
 			break
 		}
-
-		conn.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(time.Second))
 	}
 
+	stale_listener.connection.Close()
+
 	echo.EchoDebug(fmt.Sprintf("Connection cleaned up. Current listeners: %d", len(ec.event_listeners)))
 }
 
 func (ec *EventCrier) monitorAnnouncementQueue() {
-	var new_event_uuid_received bool
-
 	for {
-		if ec.announcement_queue.IsEmpty() {
-			select {
-			case new_event_uuid_received = <-ec.announcement_arrived:
-				if !new_event_uuid_received {
-					return
-				}
-			}
-		}
-
-		new_event_uuid, err := ec.announcement_queue.Dequeue()
-		if err != nil {
-			echo.Echo(echo.RedFG, fmt.Sprintf("Error dequeuing announcement: %s", err.Error()))
-			continue
+		new_event_received := <-ec.announcement_arrived
+		if !new_event_received {
+			return
 		}
 
-		ec.oyez(*new_event_uuid) // announce the event
+		ec.oyez() // announce the pending events
 	}
 }
 
 // In English-speaking countries, a town crier carried a handbell to attract people's attention,
 // as they shouted the words "Oyez, Oyez, Oyez!" before making their announcements.
-func (ec *EventCrier) oyez(event_uuid string) {
+func (ec *EventCrier) oyez() {
+	ec.listeners_mutex.Lock()
+
 	if len(ec.event_listeners) == 0 {
+		ec.listeners_mutex.Unlock()
 		return
 	}
 
-	var announcement_event *communication.PlatformEvent
-	var lerr *dungeon_models.LabeledError
+	var oldest_sequence uint64 = ec.event_listeners[0].last_sequence
 
-	announcement_event, lerr = repository.PlatformEvents.GetPublicEvent(event_uuid)
-	if lerr != nil {
-		lerr.AppendContext("EventCrier.oyez while getting public event")
-		echo.Echo(echo.RedFG, lerr.Error())
-		return
+	for _, listener := range ec.event_listeners {
+		if listener.last_sequence < oldest_sequence {
+			oldest_sequence = listener.last_sequence
+		}
 	}
 
-	announcement_message, err := json.Marshal(announcement_event)
-	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("Error marshalling announcement event: %s", err.Error()))
-		return
-	}
+	ec.listeners_mutex.Unlock()
+
+	var scopes *announcementScopes = resolveAnnouncementScopes(oldest_sequence)
+
+	ec.listeners_mutex.Lock()
+	defer ec.listeners_mutex.Unlock()
+
+	var stale_listeners []*eventListener = make([]*eventListener, 0)
 
 	for _, listener := range ec.event_listeners {
-		echo.EchoDebug(fmt.Sprintf("Announcing event to listener: %s", listener.RemoteAddr().String()))
+		if listener.subscription.IsExpired() {
+			echo.EchoDebug(fmt.Sprintf("Claims of listener %s expired", listener.connection.RemoteAddr()))
+			stale_listeners = append(stale_listeners, listener)
+			continue
+		}
+
+		echo.EchoDebug(fmt.Sprintf("Announcing events to listener: %s", listener.connection.RemoteAddr()))
 
-		err = listener.WriteMessage(websocket.TextMessage, announcement_message)
+		err := ec.announcePendingEvents(listener, scopes)
 		if err != nil {
 			echo.Echo(echo.RedFG, fmt.Sprintf("Error writing announcement message to listener: %s", err.Error()))
-			defer ec.cleanUpConn(listener)
+			stale_listeners = append(stale_listeners, listener)
+		}
+	}
+
+	for _, stale_listener := range stale_listeners {
+		ec.cleanUpConn(stale_listener)
+	}
+
+	echo.Echo(echo.GreenFG, fmt.Sprintf("Announced events to %d listeners", len(ec.event_listeners)))
+}
+
+// Sends the listener every public event registered after the last one it received that it subscribed to and can see.
+func (ec *EventCrier) announcePendingEvents(listener *eventListener, scopes *announcementScopes) error {
+	pending_events := repository.PlatformEvents.GetPublicEventsSince(listener.last_sequence)
+
+	for _, pending_event := range pending_events {
+		if !scopes.covers(pending_event) {
+			break
+		}
+
+		if !scopes.listenerShouldReceive(listener, pending_event) {
+			listener.last_sequence = pending_event.Sequence
+			continue
+		}
+
+		announcement_message, err := json.Marshal(pending_event)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("Error marshalling announcement event: %s", err.Error()))
 			continue
 		}
+
+		err = listener.connection.SendEvent(pending_event.Sequence, announcement_message)
+		if err != nil {
+			return err
+		}
+
+		listener.last_sequence = pending_event.Sequence
 	}
 
-	echo.Echo(echo.GreenFG, fmt.Sprintf("Announced event to %d listeners", len(ec.event_listeners)))
+	return nil
+}
+
+// Registers a listener that will only receive the events registered from now on.
+func (ec *EventCrier) RegisterListener(new_listener jobs.EventsConnection, subscription *service_models.EventSubscription) {
+	ec.listeners_mutex.Lock()
+	defer ec.listeners_mutex.Unlock()
+
+	ec.event_listeners = append(ec.event_listeners, &eventListener{
+		connection:    new_listener,
+		subscription:  subscription,
+		last_sequence: repository.PlatformEvents.GetLastSequence(),
+	})
 }
 
-func (ec *EventCrier) RegisterListener(new_listener *websocket.Conn) {
-	// new_listener.SetWriteDeadline(time.Now().Add(10 * time.Second))
+// Registers a listener that already received the events up to last_sequence, the public events it missed
+// since then are sent before any new event. If sending the missed events fails, the connection is closed.
+func (ec *EventCrier) ResumeListener(new_listener jobs.EventsConnection, subscription *service_models.EventSubscription, last_sequence uint64) error {
+	// A sequence ahead of the log means the log was reset, there is nothing the listener could have missed.
+	if current_sequence := repository.PlatformEvents.GetLastSequence(); last_sequence > current_sequence {
+		last_sequence = current_sequence
+	}
+
+	var scopes *announcementScopes = resolveAnnouncementScopes(last_sequence)
+
+	ec.listeners_mutex.Lock()
+	defer ec.listeners_mutex.Unlock()
+
+	var resumed_listener *eventListener = &eventListener{
+		connection:    new_listener,
+		subscription:  subscription,
+		last_sequence: last_sequence,
+	}
+
+	err := ec.announcePendingEvents(resumed_listener, scopes)
+	if err != nil {
+		new_listener.Close()
+		return fmt.Errorf("In EventCrier.ResumeListener while replaying missed events: %s", err.Error())
+	}
+
+	ec.event_listeners = append(ec.event_listeners, resumed_listener)
+
+	// Events registered while the missed ones were resolved are left for the next announcement.
+	ec.wakeUp()
 
-	ec.event_listeners = append(ec.event_listeners, new_listener)
+	return nil
 }
 
 func (ec *EventCrier) RegisterEvent(new_event communication.PlatformEvent) error {
@@ -123,15 +284,25 @@ func (ec *EventCrier) RegisterEvent(new_event communication.PlatformEvent) error
 		return fmt.Errorf("Event crier only announces public events")
 	}
 
-	repository.PlatformEvents.RegisterEvent(&new_event)
-
-	ec.announcement_queue.Enqueue(&new_event.Uuid)
+	_, lerr := repository.PlatformEvents.RegisterEvent(&new_event)
+	if lerr != nil {
+		lerr.AppendContext("EventCrier.RegisterEvent while registering event")
+		return lerr
+	}
 
-	ec.announcement_arrived <- true
+	ec.wakeUp()
 
 	return nil
 }
 
+// The monitor announces every pending event on each wake up, so a wake up that is already pending is enough.
+func (ec *EventCrier) wakeUp() {
+	select {
+	case ec.announcement_arrived <- true:
+	default:
+	}
+}
+
 func (ec *EventCrier) GetCloser() jobs.Closer {
 	return ec.Close
 }
@@ -139,9 +310,8 @@ func (ec *EventCrier) GetCloser() jobs.Closer {
 func NewEventCrier() *EventCrier {
 	var new_event_crier *EventCrier = new(EventCrier)
 
-	new_event_crier.announcement_queue = &collections.Queue[string]{}
-	new_event_crier.announcement_arrived = make(chan bool)
-	new_event_crier.event_listeners = make([]*websocket.Conn, 0)
+	new_event_crier.announcement_arrived = make(chan bool, 1)
+	new_event_crier.event_listeners = make([]*eventListener, 0)
 	new_event_crier.listeners_upgrader = &websocket.Upgrader{
 		ReadBufferSize:  512,
 		WriteBufferSize: 512,
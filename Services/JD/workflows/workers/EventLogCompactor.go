@@ -0,0 +1,68 @@
+package workers
+
+import (
+	"fmt"
+	"libery_JD_service/repository"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Periodically drops the platform events and webhook delivery attempts that are older than the configured ttl from their logs.
+type EventLogCompactor struct {
+	compaction_interval time.Duration
+	events_ttl          time.Duration
+	stop_signal         chan bool
+}
+
+func NewEventLogCompactor(compaction_interval, events_ttl time.Duration) *EventLogCompactor {
+	var log_compactor *EventLogCompactor = new(EventLogCompactor)
+
+	log_compactor.compaction_interval = compaction_interval
+	log_compactor.events_ttl = events_ttl
+	log_compactor.stop_signal = make(chan bool)
+
+	log_compactor.compact() // Events may have expired while the service was down
+
+	go log_compactor.monitorCompactionSchedule()
+
+	return log_compactor
+}
+
+func (compactor *EventLogCompactor) monitorCompactionSchedule() {
+	compaction_ticker := time.NewTicker(compactor.compaction_interval)
+	defer compaction_ticker.Stop()
+
+	for {
+		select {
+		case <-compaction_ticker.C:
+			compactor.compact()
+		case <-compactor.stop_signal:
+			return
+		}
+	}
+}
+
+func (compactor *EventLogCompactor) compact() {
+	dropped_count, lerr := repository.PlatformEvents.Compact(compactor.events_ttl)
+	if lerr != nil {
+		lerr.AppendContext("EventLogCompactor.compact while compacting the platform events log")
+		echo.Echo(echo.RedFG, lerr.Error())
+		return
+	}
+
+	echo.EchoDebug(fmt.Sprintf("Platform events log compacted, dropped %d expired events", dropped_count))
+
+	dropped_count, lerr = repository.Webhooks.CompactDeliveries(compactor.events_ttl)
+	if lerr != nil {
+		lerr.AppendContext("EventLogCompactor.compact while compacting the webhook deliveries log")
+		echo.Echo(echo.RedFG, lerr.Error())
+		return
+	}
+
+	echo.EchoDebug(fmt.Sprintf("Webhook deliveries log compacted, dropped %d expired attempts", dropped_count))
+}
+
+func (compactor *EventLogCompactor) Stop() {
+	close(compactor.stop_signal)
+}
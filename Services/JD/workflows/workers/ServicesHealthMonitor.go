@@ -0,0 +1,92 @@
+package workers
+
+import (
+	"fmt"
+	"libery-dungeon-libs/communication"
+	service_models "libery_JD_service/models"
+	"libery_JD_service/repository"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Periodically calls the /alive endpoint of every service that notified JD it was online. Services that fail enough
+// checks in a row are marked offline, and services that answer again are marked back online.
+type ServicesHealthMonitor struct {
+	check_interval          time.Duration
+	check_timeout           time.Duration
+	failures_before_offline int
+	stop_signal             chan bool
+}
+
+func NewServicesHealthMonitor(check_interval, check_timeout time.Duration, failures_before_offline int) *ServicesHealthMonitor {
+	var health_monitor *ServicesHealthMonitor = new(ServicesHealthMonitor)
+
+	health_monitor.check_interval = check_interval
+	health_monitor.check_timeout = check_timeout
+	health_monitor.failures_before_offline = failures_before_offline
+	health_monitor.stop_signal = make(chan bool)
+
+	go health_monitor.monitorChecksSchedule()
+
+	return health_monitor
+}
+
+func (monitor *ServicesHealthMonitor) monitorChecksSchedule() {
+	checks_ticker := time.NewTicker(monitor.check_interval)
+	defer checks_ticker.Stop()
+
+	for {
+		select {
+		case <-checks_ticker.C:
+			monitor.checkServices()
+		case <-monitor.stop_signal:
+			return
+		}
+	}
+}
+
+// Checks every registered service at the same time, so a service that hangs until the timeout doesn't delay the checks of the rest.
+func (monitor *ServicesHealthMonitor) checkServices() {
+	var checks_group sync.WaitGroup
+
+	for _, service := range repository.PlatformFeaturesRepo.GetPlatformServices() {
+		if !service.IsCheckable() {
+			continue
+		}
+
+		checks_group.Add(1)
+
+		go func(service service_models.PlatformService) {
+			defer checks_group.Done()
+			monitor.checkService(service)
+		}(service)
+	}
+
+	checks_group.Wait()
+}
+
+func (monitor *ServicesHealthMonitor) checkService(service service_models.PlatformService) {
+	is_alive, err := communication.ServiceRouteAlive(service.ServiceRoute, monitor.check_timeout)
+	if err != nil {
+		echo.EchoDebug(fmt.Sprintf("Health check of service '%s' failed: %s", service.ServiceName, err.Error()))
+	}
+
+	checked_service, lerr := repository.PlatformFeaturesRepo.RecordServiceHealthCheck(string(service.ServiceName), is_alive, monitor.failures_before_offline)
+	if lerr != nil {
+		lerr.AppendContext("ServicesHealthMonitor.checkService while recording the health check")
+		echo.Echo(echo.RedFG, lerr.Error())
+		return
+	}
+
+	if service.IsOnline && !checked_service.IsOnline {
+		echo.EchoWarn(fmt.Sprintf("Service %s failed %d health checks in a row, marked offline", service.ServiceName, checked_service.ConsecutiveFailures))
+	} else if !service.IsOnline && checked_service.IsOnline {
+		echo.Echo(echo.SkyBlueFG, fmt.Sprintf("Service %s is answering health checks again, marked online", service.ServiceName))
+	}
+}
+
+func (monitor *ServicesHealthMonitor) Stop() {
+	close(monitor.stop_signal)
+}
@@ -0,0 +1,237 @@
+package workers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	service_models "libery_JD_service/models"
+	"libery_JD_service/repository"
+	"net/http"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+	"github.com/google/uuid"
+)
+
+const WEBHOOK_SIGNATURE_HEADER string = "X-Dungeon-Signature"
+const WEBHOOK_EVENT_HEADER string = "X-Dungeon-Event"
+const WEBHOOK_DELIVERY_HEADER string = "X-Dungeon-Delivery"
+
+const max_webhook_retry_delay time.Duration = time.Hour
+
+// Posts the public platform events to the registered webhooks. Failed deliveries are retried with an exponential backoff
+// and every attempt is recorded on the webhooks repository, so retries that were pending survive restarts.
+type WebhooksCourier struct {
+	events_registered        chan bool
+	stop_signal              chan bool
+	last_dispatched_sequence uint64
+	pending_retries          []*service_models.WebhookDelivery // Only touched by the deliveries goroutine
+	http_client              *http.Client
+	signing_secret           string
+	max_attempts             int
+	retry_base_delay         time.Duration
+}
+
+func NewWebhooksCourier(signing_secret string, max_attempts int, retry_base_delay, delivery_timeout time.Duration) *WebhooksCourier {
+	var new_courier *WebhooksCourier = new(WebhooksCourier)
+
+	new_courier.events_registered = make(chan bool, 1)
+	new_courier.stop_signal = make(chan bool)
+	new_courier.last_dispatched_sequence = repository.PlatformEvents.GetLastSequence()
+	new_courier.pending_retries = repository.Webhooks.GetPendingRetries()
+	new_courier.http_client = &http.Client{
+		Timeout: delivery_timeout,
+	}
+	new_courier.signing_secret = signing_secret
+	new_courier.max_attempts = max_attempts
+	new_courier.retry_base_delay = retry_base_delay
+
+	go new_courier.monitorDeliveries()
+
+	return new_courier
+}
+
+func (wc *WebhooksCourier) NotifyEventsRegistered() {
+	// Every pending event is dispatched on each wake up, so a wake up that is already pending is enough.
+	select {
+	case wc.events_registered <- true:
+	default:
+	}
+}
+
+func (wc *WebhooksCourier) Close() {
+	close(wc.stop_signal)
+}
+
+func (wc *WebhooksCourier) monitorDeliveries() {
+	retry_ticker := time.NewTicker(time.Second)
+	defer retry_ticker.Stop()
+
+	for {
+		select {
+		case <-wc.events_registered:
+			wc.dispatchNewEvents()
+		case <-retry_ticker.C:
+			wc.dispatchDueRetries()
+		case <-wc.stop_signal:
+			return
+		}
+	}
+}
+
+// Events about private clusters are only posted to the webhooks whose creator could view private clusters.
+func (wc *WebhooksCourier) dispatchNewEvents() {
+	var scopes *announcementScopes = resolveAnnouncementScopes(wc.last_dispatched_sequence)
+
+	new_events := repository.PlatformEvents.GetPublicEventsSince(wc.last_dispatched_sequence)
+
+	for _, new_event := range new_events {
+		if !scopes.covers(new_event) {
+			break
+		}
+
+		wc.last_dispatched_sequence = new_event.Sequence
+
+		for _, webhook := range repository.Webhooks.GetWebhooksForEvent(new_event.EventType) {
+			if !scopes.isVisible(new_event, webhook.CanViewPrivateClusters) {
+				continue
+			}
+
+			wc.deliver(webhook, new_event, uuid.New().String(), 1)
+		}
+	}
+}
+
+func (wc *WebhooksCourier) dispatchDueRetries() {
+	if len(wc.pending_retries) == 0 {
+		return
+	}
+
+	var now int64 = time.Now().UnixMilli()
+	var due_retries []*service_models.WebhookDelivery = make([]*service_models.WebhookDelivery, 0)
+	var waiting_retries []*service_models.WebhookDelivery = make([]*service_models.WebhookDelivery, 0)
+
+	for _, failed_attempt := range wc.pending_retries {
+		if failed_attempt.NextRetryAt <= now {
+			due_retries = append(due_retries, failed_attempt)
+		} else {
+			waiting_retries = append(waiting_retries, failed_attempt)
+		}
+	}
+
+	wc.pending_retries = waiting_retries
+
+	for _, failed_attempt := range due_retries {
+		webhook, lerr := repository.Webhooks.GetWebhook(failed_attempt.WebhookUUID)
+		if lerr != nil {
+			echo.EchoDebug(fmt.Sprintf("Dropping retry of delivery %s, its webhook was deleted", failed_attempt.DeliveryUUID))
+			continue
+		}
+
+		event, lerr := repository.PlatformEvents.GetEventBySequence(failed_attempt.EventSequence)
+		if lerr != nil {
+			echo.EchoWarn(fmt.Sprintf("Dropping retry of delivery %s, its event is no longer on the platform events log", failed_attempt.DeliveryUUID))
+			continue
+		}
+
+		wc.deliver(webhook, event, failed_attempt.DeliveryUUID, failed_attempt.Attempt+1)
+	}
+}
+
+// Posts the event to the webhook and records the attempt. If it fails and attempts remain, a retry is scheduled.
+func (wc *WebhooksCourier) deliver(webhook *service_models.Webhook, event *service_models.SequencedPlatformEvent, delivery_uuid string, attempt int) {
+	var delivery *service_models.WebhookDelivery = &service_models.WebhookDelivery{
+		WebhookUUID:   webhook.UUID,
+		DeliveryUUID:  delivery_uuid,
+		EventUUID:     event.Uuid,
+		EventType:     event.EventType,
+		EventSequence: event.Sequence,
+		Attempt:       attempt,
+		AttemptedAt:   time.Now().UnixMilli(),
+	}
+
+	status_code, err := wc.post(webhook, event, delivery_uuid, attempt)
+
+	delivery.StatusCode = status_code
+	delivery.Succeeded = err == nil
+
+	if err != nil {
+		delivery.Error = err.Error()
+
+		if attempt < wc.max_attempts {
+			delivery.NextRetryAt = time.Now().Add(wc.retryDelay(attempt)).UnixMilli()
+			wc.pending_retries = append(wc.pending_retries, delivery)
+		}
+
+		echo.EchoWarn(fmt.Sprintf("Delivery %s of event %d to webhook %s failed on attempt %d: %s", delivery_uuid, event.Sequence, webhook.UUID, attempt, err.Error()))
+	}
+
+	lerr := repository.Webhooks.RecordDelivery(delivery)
+	if lerr != nil {
+		lerr.AppendContext("WebhooksCourier.deliver while recording delivery")
+		echo.Echo(echo.RedFG, lerr.Error())
+	}
+}
+
+func (wc *WebhooksCourier) post(webhook *service_models.Webhook, event *service_models.SequencedPlatformEvent, delivery_uuid string, attempt int) (int, error) {
+	var payload *service_models.WebhookPayload = &service_models.WebhookPayload{
+		WebhookUUID:  webhook.UUID,
+		DeliveryUUID: delivery_uuid,
+		Attempt:      attempt,
+		Event:        event,
+	}
+
+	payload_body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("While marshalling payload: %s", err.Error())
+	}
+
+	request, err := http.NewRequest(http.MethodPost, webhook.TargetURL, bytes.NewReader(payload_body))
+	if err != nil {
+		return 0, fmt.Errorf("While creating request: %s", err.Error())
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WEBHOOK_EVENT_HEADER, event.EventType)
+	request.Header.Set(WEBHOOK_DELIVERY_HEADER, delivery_uuid)
+	request.Header.Set(WEBHOOK_SIGNATURE_HEADER, "sha256="+wc.sign(payload_body))
+
+	response, err := wc.http_client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("Target answered with status %d", response.StatusCode)
+	}
+
+	return response.StatusCode, nil
+}
+
+func (wc *WebhooksCourier) sign(payload_body []byte) string {
+	signer := hmac.New(sha256.New, []byte(wc.signing_secret))
+	signer.Write(payload_body)
+
+	return hex.EncodeToString(signer.Sum(nil))
+}
+
+func (wc *WebhooksCourier) retryDelay(failed_attempt int) time.Duration {
+	var retry_delay time.Duration = wc.retry_base_delay
+
+	for h := 1; h < failed_attempt && retry_delay < max_webhook_retry_delay; h++ {
+		retry_delay *= 2
+	}
+
+	if retry_delay > max_webhook_retry_delay {
+		retry_delay = max_webhook_retry_delay
+	}
+
+	return retry_delay
+}
@@ -47,6 +47,9 @@ var THUMBNAIL_WIDTH int = 100
 var MIN_THUMBNAIL_WIDTH int = 50
 var MOBILE_MAX_WIDTH int = 580
 
+// How often medias without a content hash are hashed. 0 disables the backfill.
+var CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES int64 = 60
+
 func VerifyConfig() {
 
 	if SERVICE_PORT == "" {
@@ -153,6 +156,10 @@ func loadSettings() error {
 		MOBILE_MAX_WIDTH = int(service_settings["MOBILE_MAX_WIDTH"].(float64))
 	}
 
+	if _, exists := service_settings["CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES"]; exists {
+		CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES = int64(service_settings["CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES"].(float64))
+	}
+
 	return nil
 }
 
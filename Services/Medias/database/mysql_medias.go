@@ -21,7 +21,62 @@ func NewMediasMysql() (*MediasMysql, error) {
 		return nil, err
 	}
 
-	return &MediasMysql{db: db}, nil
+	var medias_mysql *MediasMysql = &MediasMysql{db: db}
+
+	err = medias_mysql.ensureDownloadForeignKeyDropped()
+	if err != nil {
+		return nil, err
+	}
+
+	err = medias_mysql.ensureContentHashColumn()
+	if err != nil {
+		return nil, err
+	}
+
+	return medias_mysql, nil
+}
+
+// `medias`.`downloaded_from` holds the id of a downloaded media record kept by the downloads service on its own database. Databases
+// created with an older schema still reference the `downloads` table through the `fk_download` foreign key, which rejects those ids.
+func (db *MediasMysql) ensureDownloadForeignKeyDropped() error {
+	var constraint_count int
+
+	err := db.db.QueryRow("SELECT COUNT(*) FROM `information_schema`.`TABLE_CONSTRAINTS` WHERE `CONSTRAINT_SCHEMA` = DATABASE() AND `TABLE_NAME` = 'medias' AND `CONSTRAINT_NAME` = 'fk_download' AND `CONSTRAINT_TYPE` = 'FOREIGN KEY'").Scan(&constraint_count)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/mysql_medias.ensureDownloadForeignKeyDropped: While looking for the fk_download constraint."), err)
+	}
+
+	if constraint_count == 0 {
+		return nil
+	}
+
+	_, err = db.db.Exec("ALTER TABLE `medias` DROP FOREIGN KEY `fk_download`")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/mysql_medias.ensureDownloadForeignKeyDropped: While dropping the fk_download constraint."), err)
+	}
+
+	return nil
+}
+
+// Databases created before medias kept the hash of their content lack the `content_hash` column.
+func (db *MediasMysql) ensureContentHashColumn() error {
+	var column_count int
+
+	err := db.db.QueryRow("SELECT COUNT(*) FROM `information_schema`.`COLUMNS` WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = 'medias' AND `COLUMN_NAME` = 'content_hash'").Scan(&column_count)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/mysql_medias.ensureContentHashColumn: While looking for the content_hash column."), err)
+	}
+
+	if column_count > 0 {
+		return nil
+	}
+
+	_, err = db.db.Exec("ALTER TABLE `medias` ADD COLUMN `content_hash` CHAR(64) DEFAULT NULL, ADD KEY `content_hash_idx` (`content_hash`)")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/mysql_medias.ensureContentHashColumn: While adding the content_hash column."), err)
+	}
+
+	return nil
 }
 
 func (db *MediasMysql) GetRandomMedia(ctx context.Context, cluster_id string, category_id string, only_image bool) (*dungeon_models.Media, *dungeon_models.Category, error) {
@@ -236,6 +291,97 @@ func (db *MediasMysql) UpdateMediaName(ctx context.Context, media_id string, new
 	return nil
 }
 
+func (db *MediasMysql) SetMediaContentHash(ctx context.Context, media_uuid string, content_hash string) error {
+	stmt, err := db.db.PrepareContext(ctx, "UPDATE `medias` SET `content_hash` = ? WHERE `uuid` = ?")
+	if err != nil {
+		return errors.Join(err, fmt.Errorf("In database/mysql_medias.SetMediaContentHash: Error preparing statement"))
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, content_hash, media_uuid)
+	if err != nil {
+		return errors.Join(err, fmt.Errorf("In database/mysql_medias.SetMediaContentHash: Error executing statement"))
+	}
+
+	return nil
+}
+
+// Returns the uuid of a media of the cluster with the given content hash, or an empty string if the cluster has none.
+func (db *MediasMysql) GetClusterMediaByContentHash(ctx context.Context, cluster_uuid string, content_hash string) (string, error) {
+	var media_uuid string
+
+	stmt, err := db.db.PrepareContext(ctx, `
+		SELECT m.uuid
+		FROM medias m
+		INNER JOIN categorys c ON m.main_category=c.uuid
+		WHERE c.cluster=? AND m.content_hash=?
+		LIMIT 1
+	`)
+	if err != nil {
+		return "", errors.Join(err, fmt.Errorf("In database/mysql_medias.GetClusterMediaByContentHash: Error preparing statement"))
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, cluster_uuid, content_hash).Scan(&media_uuid)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", errors.Join(err, fmt.Errorf("In database/mysql_medias.GetClusterMediaByContentHash: Error scanning row"))
+	}
+
+	return media_uuid, nil
+}
+
+// Returns up to limit medias without a content hash whose uuid sorts after after_uuid, ordered by uuid so callers can page through them.
+func (db *MediasMysql) GetUnhashedMediaIdentities(ctx context.Context, after_uuid string, limit int) ([]dungeon_models.MediaIdentity, error) {
+	var media_identities []dungeon_models.MediaIdentity = make([]dungeon_models.MediaIdentity, 0)
+
+	stmt, err := db.db.PrepareContext(ctx, `
+		SELECT
+			m.uuid, m.name, m.main_category, m.type,
+			c.uuid, c.fullpath,
+			cc.uuid, cc.fs_path
+		FROM medias m
+		INNER JOIN categorys c ON m.main_category=c.uuid
+		INNER JOIN categories_clusters cc ON c.cluster=cc.uuid
+		WHERE m.content_hash IS NULL AND m.uuid > ?
+		ORDER BY m.uuid
+		LIMIT ?
+	`)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In database/mysql_medias.GetUnhashedMediaIdentities: Error preparing statement"))
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, after_uuid, limit)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In database/mysql_medias.GetUnhashedMediaIdentities: Error executing statement"))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var media_identity dungeon_models.MediaIdentity = dungeon_models.MediaIdentity{Media: new(dungeon_models.Media)}
+
+		err = rows.Scan(
+			&media_identity.Media.Uuid,
+			&media_identity.Media.Name,
+			&media_identity.Media.MainCategory,
+			&media_identity.Media.Type,
+			&media_identity.CategoryUUID,
+			&media_identity.CategoryPath,
+			&media_identity.ClusterUUID,
+			&media_identity.ClusterPath,
+		)
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("In database/mysql_medias.GetUnhashedMediaIdentities: Error scanning row"))
+		}
+
+		media_identities = append(media_identities, media_identity)
+	}
+
+	return media_identities, nil
+}
+
 func (db *MediasMysql) Close() error {
 	return db.db.Close()
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"libery-dungeon-libs/communication"
 	medias_http_requests "libery-dungeon-libs/communication/service_requests/medias_requests"
 	"libery-dungeon-libs/dungeonsec/access_sec"
 	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	"libery-dungeon-libs/dungeonsec/dungeon_secrets"
 	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
 	dungeon_models "libery-dungeon-libs/models"
@@ -53,6 +55,10 @@ func getMediasHandler(response http.ResponseWriter, request *http.Request) {
 		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getMediaByUUIDHandler)
 	case "/medias/identity":
 		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getMediaIdentityHandler)
+	case "/medias/chapters":
+		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getMediaChaptersHandler)
+	case "/medias/by-content-hash":
+		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getMediaByContentHashHandler)
 	default:
 		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediasHandler: Resource not found: %s", resource))
 	}
@@ -94,7 +100,7 @@ func getMediaIdentityHandler(response http.ResponseWriter, request *http.Request
 		return
 	}
 
-	has_cluster_access := access_sec.RequestHasClusterAccess(media_identity.ClusterUUID, request)
+	has_cluster_access := isInternalRequest(request) || access_sec.RequestHasClusterAccess(media_identity.ClusterUUID, request)
 	if !has_cluster_access {
 		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaIdentityHandler: Request does not have access to cluster '%s'", media_identity.ClusterUUID))
 		response.WriteHeader(403)
@@ -109,10 +115,193 @@ func getMediaIdentityHandler(response http.ResponseWriter, request *http.Request
 	json.NewEncoder(response).Encode(media_identity)
 }
 
-// Deprecated: This endpoint is now removed.
+// Returns the chapters embedded on a video media file.
+func getMediaChaptersHandler(response http.ResponseWriter, request *http.Request) {
+	var media_uuid string = request.URL.Query().Get("uuid")
+
+	if media_uuid == "" {
+		echo.Echo(echo.RedBG, "In MediasService.medias.getMediaChaptersHandler: Missing media uuid query parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	media_identity, err := repository.MediasRepo.GetMediaIdentity(request.Context(), media_uuid)
+	if err != nil {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaChaptersHandler: Error getting media by ID: %s", err.Error()))
+		response.WriteHeader(404)
+		return
+	}
+
+	has_cluster_access := isInternalRequest(request) || access_sec.RequestHasClusterAccess(media_identity.ClusterUUID, request)
+	if !has_cluster_access {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaChaptersHandler: Request does not have access to cluster '%s'", media_identity.ClusterUUID))
+		response.WriteHeader(403)
+		return
+	}
+
+	if media_identity.Media.Type != dungeon_models.Video {
+		dungeon_helpers.WriteRejection(response, 400, "Only video medias have chapters")
+		return
+	}
+
+	media_chapters, err := workflows.ProbeMediaChapters(request.Context(), *media_identity)
+	if err != nil {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaChaptersHandler: Error probing media chapters: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(media_chapters)
+}
+
+// Returns the MediaIdentity of a media of the cluster with the given content, used to tell whether a file is already on the cluster.
+// Responds 404 if the cluster has no media with that content.
+func getMediaByContentHashHandler(response http.ResponseWriter, request *http.Request) {
+	var cluster_uuid string = request.URL.Query().Get("cluster_uuid")
+	var content_hash string = request.URL.Query().Get("content_hash")
+
+	if cluster_uuid == "" || content_hash == "" {
+		echo.Echo(echo.RedBG, "In MediasService.medias.getMediaByContentHashHandler: Missing cluster_uuid or content_hash query parameter")
+		response.WriteHeader(400)
+		return
+	}
+
+	has_cluster_access := isInternalRequest(request) || access_sec.RequestHasClusterAccess(cluster_uuid, request)
+	if !has_cluster_access {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaByContentHashHandler: Request does not have access to cluster '%s'", cluster_uuid))
+		response.WriteHeader(403)
+		return
+	}
+
+	media_uuid, err := repository.MediasRepo.GetClusterMediaByContentHash(request.Context(), cluster_uuid, content_hash)
+	if err != nil {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaByContentHashHandler: Error looking up content hash: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	if media_uuid == "" {
+		response.WriteHeader(404)
+		return
+	}
+
+	media_identity, err := repository.MediasRepo.GetMediaIdentity(request.Context(), media_uuid)
+	if err != nil {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In MediasService.medias.getMediaByContentHashHandler: Error getting media identity: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(media_identity)
+}
+
+// Internal requests(e.g. chapter imports or moment searches from the metadata service) carry the domain secret instead of cluster access cookies.
+func isInternalRequest(request *http.Request) bool {
+	return request.Header.Get(dungeon_secrets.DOMAIN_SECRET_HEADER) == dungeon_secrets.GetDungeonDomainSecret()
+}
+
 func postMediasHandler(response http.ResponseWriter, request *http.Request) {
-	echo.Echo(echo.RedBG, "DEPRECATED: use POST '/upload-streams/stream-fragment' instead")
-	dungeon_helpers.ResourceNotFoundHandler(response, request)
+	var resource_path string = request.URL.Path
+	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
+
+	switch resource_path {
+	case fmt.Sprintf("%s/clip", medias_resource_path):
+		resource_handler = dungeon_middlewares.CheckUserCan_ContentAlter(postExportMediaClipHandler)
+	default:
+		// Deprecated: POST /medias used to upload medias.
+		echo.Echo(echo.RedBG, "DEPRECATED: use POST '/upload-streams/stream-fragment' instead")
+	}
+
+	resource_handler(response, request)
+}
+
+// Exports a time range of a video media as a new media on the requested category. Responds with the new media.
+func postExportMediaClipHandler(response http.ResponseWriter, request *http.Request) {
+	var clip_request *medias_http_requests.ExportMediaClipRequest = new(medias_http_requests.ExportMediaClipRequest)
+
+	err := json.NewDecoder(request.Body).Decode(clip_request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error decoding request body: %s", err.Error()))
+		response.WriteHeader(400)
+		return
+	}
+
+	if clip_request.MomentID <= 0 || clip_request.CategoryUUID == "" {
+		echo.Echo(echo.RedFG, "In handlers/medias.postExportMediaClipHandler: Missing moment_id or category_uuid")
+		dungeon_helpers.WriteRejection(response, 400, "Missing parameters")
+		return
+	}
+
+	video_moment, err := communication.Metadata.GetVideoMoment(clip_request.MomentID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error getting video moment<%d>: %s", clip_request.MomentID, err.Error()))
+		response.WriteHeader(404)
+		return
+	}
+
+	if !video_moment.IsRange() {
+		dungeon_helpers.WriteRejection(response, 400, "Moment is not a range")
+		return
+	}
+
+	if clip_request.ClipName == "" {
+		clip_request.ClipName = video_moment.MomentTitle
+	}
+
+	var request_context context.Context = request.Context()
+
+	source_media, err := repository.MediasRepo.GetMediaIdentity(request_context, video_moment.VideoUUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error getting media by ID: %s", err.Error()))
+		response.WriteHeader(404)
+		return
+	}
+
+	if !access_sec.RequestHasClusterAccess(source_media.ClusterUUID, request) {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Request does not have access to cluster '%s'", source_media.ClusterUUID))
+		response.WriteHeader(403)
+		return
+	}
+
+	if source_media.Media.Type != dungeon_models.Video {
+		dungeon_helpers.WriteRejection(response, 400, "Only video medias can be clipped")
+		return
+	}
+
+	clip_identity, err := workflows.ExportMediaClip(request_context, *source_media, clip_request.CategoryUUID, video_moment.MomentTime, video_moment.MomentEndTime, clip_request.ClipName)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error exporting clip: %s", err.Error()))
+		response.WriteHeader(500)
+		return
+	}
+
+	fs_change_event := communication.NewClusterFSChangeEvent(dungeon_secrets.GetDungeonJwtSecret(), clip_identity.ClusterUUID, 0, 1, 0)
+
+	err = fs_change_event.Emit()
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error emitting fs_change_event: %s", err.Error()))
+	}
+
+	media_added_events := communication.NewMediaAddedEvents(dungeon_secrets.GetDungeonJwtSecret(), clip_identity.ClusterUUID, []*dungeon_models.MediaIdentity{clip_identity})
+
+	err = communication.EmitPlatformEvents(media_added_events)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/medias.postExportMediaClipHandler: Error emitting media_added events: %s", err.Error()))
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(clip_identity.Media)
 }
 
 func patchMediasHandler(response http.ResponseWriter, request *http.Request) {
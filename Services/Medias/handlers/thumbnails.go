@@ -4,8 +4,10 @@ import (
 	"fmt"
 	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
+	dungeon_models "libery-dungeon-libs/models"
 	app_config "libery_medias_service/Config"
 	service_helpers "libery_medias_service/helpers"
+	service_models "libery_medias_service/models"
 	service_workflows "libery_medias_service/workflows"
 	service_common_workflows "libery_medias_service/workflows/common"
 	service_workflows_errors "libery_medias_service/workflows/errors"
@@ -87,7 +89,22 @@ func getMediaThumbnailsHandler(response http.ResponseWriter, request *http.Reque
 		}
 	}
 
-	thumbnail_response, labeled_error := service_workflows.GetFileThumbnail(file_descriptor, thumbnail_width)
+	var thumbnail_response *service_models.ThumbnailResponse
+	var labeled_error *dungeon_models.LabeledError
+
+	// at: a video timestamp in milliseconds, used to get the frame of a specific scene(e.g. a video moment) instead of the default thumbnail.
+	if str_at := request.URL.Query().Get("at"); str_at != "" && dungeon_helpers.IsVideoFile(media_path) {
+		at_time, err := strconv.ParseInt(str_at, 10, 64)
+		if err != nil {
+			http.Error(response, fmt.Sprintf("Invalid at parameter: %s", str_at), http.StatusBadRequest)
+			return
+		}
+
+		thumbnail_response, labeled_error = service_workflows.GetVideoFrameThumbnail(file_descriptor, at_time, thumbnail_width)
+	} else {
+		thumbnail_response, labeled_error = service_workflows.GetFileThumbnail(file_descriptor, thumbnail_width)
+	}
+
 	if labeled_error != nil {
 		var status_code int = 500 // Internal Server Error
 		if labeled_error.Label == service_workflows_errors.ErrMediaNotSupported {
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"libery-dungeon-libs/communication"
@@ -220,13 +221,20 @@ func postChunkedUploadStreamsHandler(response http.ResponseWriter, request *http
 	var is_last_chunk bool = upload_chunk_serial == (upload_ticket_claims.UploadChunks - 1)
 
 	if is_last_chunk {
-		err = upload_workflows.CreateMediaFromChunkedUpload(upload_ticket_claims)
+		new_media_identity, err := upload_workflows.CreateMediaFromChunkedUpload(upload_ticket_claims)
 		if err != nil {
 			echo.Echo(echo.RedBG, fmt.Sprintf("In postUploadStreamsHandler: Error creating media from chunked upload because '%s'", err.Error()))
 			response.WriteHeader(500)
 			return
 		}
 
+		media_added_events := communication.NewMediaAddedEvents(dungeon_secrets.GetDungeonJwtSecret(), new_media_identity.ClusterUUID, []*dungeon_models.MediaIdentity{new_media_identity})
+
+		err = communication.EmitPlatformEvents(media_added_events)
+		if err != nil {
+			echo.Echo(echo.RedBG, fmt.Sprintf("In postUploadStreamsHandler: Error emitting media_added events because '%s'", err.Error()))
+		}
+
 		upload_stream_ticket, err := service_models.ParseUploadStreamTicketFromRequest(request, dungeon_secrets.GetDungeonJwtSecret())
 		if err != nil {
 			echo.Echo(echo.RedBG, fmt.Sprintf("In postUploadStreamsHandler: Error getting upload ticket because '%s'", err.Error()))
@@ -281,7 +289,7 @@ func postStreamFragmentUploadStreamsHandler(response http.ResponseWriter, reques
 	echo.Echo(echo.CyanFG, fmt.Sprintf("Processing upload %d of %d", upload_ticket.UploadedMedias+1, upload_ticket.TotalMedias))
 	echo.EchoDebug(fmt.Sprintf("Uploading with claims: %+v", upload_ticket))
 
-	download_from := request.URL.Query().Get("download_from") // ? is this being used for anything? i was under the impression that this was a junk property
+	download_from := request.URL.Query().Get("download_from") // Id of the downloaded media record on the downloads service
 
 	if download_from != "" {
 		downloaded_from_uuid, err = strconv.ParseInt(download_from, 10, 64)
@@ -319,6 +327,8 @@ func postStreamFragmentUploadStreamsHandler(response http.ResponseWriter, reques
 		return
 	}
 
+	var added_medias []*dungeon_models.MediaIdentity = make([]*dungeon_models.MediaIdentity, 0, files_in_request)
+
 	for _, file_headers := range request.MultipartForm.File {
 		echo.Echo(echo.CyanFG, fmt.Sprintf("Processing %d files", len(file_headers)))
 		for _, file_header := range file_headers {
@@ -356,10 +366,24 @@ func postStreamFragmentUploadStreamsHandler(response http.ResponseWriter, reques
 				return
 			}
 
+			// Hashed right away so uploaders like the downloads service can tell the media apart from the next files they upload
+			err = workflows.StoreMediaContentHash(request.Context(), *media_identity)
+			if err != nil {
+				echo.Echo(echo.RedBG, fmt.Sprintf("Error storing media content hash, the backfill will retry it: %s", err.Error()))
+			}
+
 			upload_ticket.UploadedMedias++
+			added_medias = append(added_medias, media_identity)
 		}
 	}
 
+	media_added_events := communication.NewMediaAddedEvents(app_config.JWT_SECRET, upload_ticket.UploadCategoryIdentity.ClusterUUID, added_medias)
+
+	err = communication.EmitPlatformEvents(media_added_events)
+	if err != nil {
+		echo.Echo(echo.RedBG, fmt.Sprintf("In postStreamFragmentUploadStreamsHandler: Error emitting media_added events because '%s'", err.Error()))
+	}
+
 	if upload_ticket.UploadComplete() {
 		fs_change_event := communication.NewClusterFSChangeEvent(app_config.JWT_SECRET, upload_ticket.UploadCategoryIdentity.ClusterUUID, 0, upload_ticket.TotalMedias, 0)
 
@@ -370,6 +394,9 @@ func postStreamFragmentUploadStreamsHandler(response http.ResponseWriter, reques
 
 		echo.Echo(echo.GreenFG, "Finished uploading all medias")
 		upload_workflows.DeleteUploadStreamTicketCookie(response)
+
+		// The added medias are sent back so uploaders can keep track of the medias their files became
+		response.Header().Set("Content-Type", "application/json")
 		response.WriteHeader(201)
 	} else {
 		echo.Echo(echo.GreenFG, fmt.Sprintf("Uploaded %d of %d medias", upload_ticket.UploadedMedias, upload_ticket.TotalMedias))
@@ -380,8 +407,11 @@ func postStreamFragmentUploadStreamsHandler(response http.ResponseWriter, reques
 			return
 		}
 
-		response.WriteHeader(204)
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(200)
 	}
+
+	json.NewEncoder(response).Encode(added_medias)
 }
 
 func patchUploadStreamsHandler(response http.ResponseWriter, request *http.Request) {
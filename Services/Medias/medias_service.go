@@ -9,6 +9,8 @@ import (
 	"libery_medias_service/handlers"
 	"libery_medias_service/middleware"
 	"libery_medias_service/repository"
+	"libery_medias_service/workflows"
+	"time"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -68,7 +70,16 @@ func main() {
 		echo.EchoFatal(err)
 	}
 
-	media_service.OnAfterShutdown(app_config.ClosePlatformCommunication)
+	backfill_context, stop_backfill := context.WithCancel(context.Background())
+
+	if app_config.CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES > 0 {
+		go workflows.RunContentHashesBackfill(backfill_context, time.Duration(app_config.CONTENT_HASHES_BACKFILL_INTERVAL_MINUTES)*time.Minute)
+	}
+
+	media_service.OnAfterShutdown(func() {
+		stop_backfill()
+		app_config.ClosePlatformCommunication()
+	})
 
 	media_service.StartServer(BinderRoutes)
 }
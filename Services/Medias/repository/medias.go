@@ -12,6 +12,9 @@ type MediasRepository interface {
 	GetMediaIdentity(ctx context.Context, media_uuid string) (*dungeon_models.MediaIdentity, error)
 	UpdateMediaName(ctx context.Context, media_id string, new_name string) error
 	GetRandomMedia(ctx context.Context, cluster_id string, category_id string, only_image bool) (*dungeon_models.Media, *dungeon_models.Category, error)
+	SetMediaContentHash(ctx context.Context, media_uuid string, content_hash string) error
+	GetClusterMediaByContentHash(ctx context.Context, cluster_uuid string, content_hash string) (string, error)
+	GetUnhashedMediaIdentities(ctx context.Context, after_uuid string, limit int) ([]dungeon_models.MediaIdentity, error)
 	Close() error
 }
 
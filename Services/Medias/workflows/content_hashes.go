@@ -0,0 +1,98 @@
+package workflows
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery_medias_service/repository"
+	"os"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+const content_hashes_backfill_batch_size int = 50
+
+// Returns the hex encoded sha256 of a media file. Medias with the same content hash are duplicates of each other.
+func MediaContentHash(media_identity dungeon_models.MediaIdentity) (string, error) {
+	media_file, err := os.Open(media_identity.FsPath())
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("In workflows/content_hashes.MediaContentHash: While opening Media<%s>", media_identity.Media.Uuid), err)
+	}
+	defer media_file.Close()
+
+	content_hasher := sha256.New()
+
+	_, err = io.Copy(content_hasher, media_file)
+	if err != nil {
+		return "", errors.Join(fmt.Errorf("In workflows/content_hashes.MediaContentHash: While reading Media<%s>", media_identity.Media.Uuid), err)
+	}
+
+	return hex.EncodeToString(content_hasher.Sum(nil)), nil
+}
+
+// Hashes the file of a media and stores the hash on the media record.
+func StoreMediaContentHash(ctx context.Context, media_identity dungeon_models.MediaIdentity) error {
+	content_hash, err := MediaContentHash(media_identity)
+	if err != nil {
+		return err
+	}
+
+	return repository.MediasRepo.SetMediaContentHash(ctx, media_identity.Media.Uuid, content_hash)
+}
+
+// Hashes the medias that don't have a content hash yet, e.g. medias added before medias kept one or by a workflow that doesn't
+// hash the files it adds. Medias whose file can't be read are skipped until the next run.
+func BackfillContentHashes(ctx context.Context) {
+	var last_uuid string = ""
+	var hashed_medias int = 0
+
+	for ctx.Err() == nil {
+		media_identities, err := repository.MediasRepo.GetUnhashedMediaIdentities(ctx, last_uuid, content_hashes_backfill_batch_size)
+		if err != nil {
+			echo.EchoErr(err)
+			return
+		}
+
+		if len(media_identities) == 0 {
+			break
+		}
+
+		for _, media_identity := range media_identities {
+			last_uuid = media_identity.Media.Uuid
+
+			err = StoreMediaContentHash(ctx, media_identity)
+			if err != nil {
+				echo.EchoWarn(fmt.Sprintf("Could not hash Media<%s>: %s", media_identity.Media.Uuid, err.Error()))
+				continue
+			}
+
+			hashed_medias++
+		}
+	}
+
+	if hashed_medias > 0 {
+		echo.Echo(echo.GreenFG, fmt.Sprintf("Backfilled the content hash of %d medias", hashed_medias))
+	}
+}
+
+// Runs BackfillContentHashes right away and then on every interval until the context is done.
+func RunContentHashesBackfill(ctx context.Context, interval time.Duration) {
+	BackfillContentHashes(ctx)
+
+	backfill_ticker := time.NewTicker(interval)
+	defer backfill_ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-backfill_ticker.C:
+			BackfillContentHashes(ctx)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	"math"
+	"os/exec"
+	"strconv"
+)
+
+// The subset of the ffprobe -show_chapters json output that we care about.
+type ffprobeChaptersOutput struct {
+	Chapters []struct {
+		StartTime string            `json:"start_time"`
+		EndTime   string            `json:"end_time"`
+		Tags      map[string]string `json:"tags"`
+	} `json:"chapters"`
+}
+
+// Reads the chapters embedded on a video media file(mp4 chapter atoms, mkv chapters, etc) using ffprobe.
+func ProbeMediaChapters(ctx context.Context, media_identity dungeon_models.MediaIdentity) ([]dungeon_models.MediaChapter, error) {
+	var media_chapters []dungeon_models.MediaChapter = make([]dungeon_models.MediaChapter, 0)
+
+	if media_identity.Media.Type != dungeon_models.Video {
+		return nil, fmt.Errorf("In workflows/media_chapters.ProbeMediaChapters: Media<%s> is not a video", media_identity.Media.Uuid)
+	}
+
+	ffprobe_command := exec.CommandContext(ctx, "ffprobe", "-v", "quiet", "-print_format", "json", "-show_chapters", media_identity.FsPath())
+
+	ffprobe_output, err := ffprobe_command.Output()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/media_chapters.ProbeMediaChapters: While running ffprobe on media<%s>", media_identity.Media.Uuid), err)
+	}
+
+	var probed_chapters ffprobeChaptersOutput
+
+	err = json.Unmarshal(ffprobe_output, &probed_chapters)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/media_chapters.ProbeMediaChapters: While parsing ffprobe output"), err)
+	}
+
+	for h, probed_chapter := range probed_chapters.Chapters {
+		start_seconds, err := strconv.ParseFloat(probed_chapter.StartTime, 64)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In workflows/media_chapters.ProbeMediaChapters: Chapter %d has an invalid start time '%s'", h, probed_chapter.StartTime), err)
+		}
+
+		end_seconds, err := strconv.ParseFloat(probed_chapter.EndTime, 64)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In workflows/media_chapters.ProbeMediaChapters: Chapter %d has an invalid end time '%s'", h, probed_chapter.EndTime), err)
+		}
+
+		media_chapter := dungeon_models.MediaChapter{
+			StartTime: int64(math.Round(start_seconds * 1000)),
+			EndTime:   int64(math.Round(end_seconds * 1000)),
+			Title:     probed_chapter.Tags["title"],
+		}
+
+		media_chapters = append(media_chapters, media_chapter)
+	}
+
+	return media_chapters, nil
+}
@@ -0,0 +1,97 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery_medias_service/repository"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Cuts the range [start_time, end_time](milliseconds) out of a video media and saves it as a new media on the given category. The cut is a
+// stream copy so no re-encoding happens, which means the clip boundaries snap to the nearest keyframes. The new media inherits the dungeon
+// tags of the source video. Source and target category must belong to the same cluster as tags are scoped to a cluster.
+func ExportMediaClip(ctx context.Context, source_identity dungeon_models.MediaIdentity, category_uuid string, start_time, end_time int64, clip_name string) (*dungeon_models.MediaIdentity, error) {
+	if source_identity.Media.Type != dungeon_models.Video {
+		return nil, fmt.Errorf("In workflows/media_clips.ExportMediaClip: Media<%s> is not a video", source_identity.Media.Uuid)
+	}
+
+	if start_time < 0 || end_time <= start_time {
+		return nil, fmt.Errorf("In workflows/media_clips.ExportMediaClip: Invalid clip range %d-%d", start_time, end_time)
+	}
+
+	target_category, err := repository.CategoriesRepo.GetCategoryByID(ctx, category_uuid)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/media_clips.ExportMediaClip: While getting category<%s>", category_uuid), err)
+	}
+
+	if target_category.Cluster != source_identity.ClusterUUID {
+		return nil, fmt.Errorf("In workflows/media_clips.ExportMediaClip: Category<%s> is not on the source media cluster<%s>", category_uuid, source_identity.ClusterUUID)
+	}
+
+	target_cluster, err := repository.CategoriesClustersRepo.GetClusterByID(ctx, target_category.Cluster)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/media_clips.ExportMediaClip: While getting cluster<%s>", target_category.Cluster), err)
+	}
+
+	var source_extension string = filepath.Ext(source_identity.Media.Name)
+
+	if clip_name == "" {
+		clip_name = fmt.Sprintf("%s.clip-%d-%d", source_identity.Media.Name[:len(source_identity.Media.Name)-len(source_extension)], start_time, end_time)
+	}
+
+	clip_name = filepath.Base(clip_name)
+
+	if filepath.Ext(clip_name) != source_extension {
+		clip_name += source_extension
+	}
+
+	clip_media := dungeon_models.CreateNewMedia(clip_name, target_category.Uuid, true, 0)
+	clip_identity := dungeon_models.CreateNewMediaIdentity(clip_media, &target_category, &target_cluster)
+
+	err = SetUniqueMediaName(clip_identity)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/media_clips.ExportMediaClip: While setting a unique name for '%s'", clip_name), err)
+	}
+
+	ffmpeg_command := exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-n",
+		"-ss", formatFFmpegTime(start_time),
+		"-to", formatFFmpegTime(end_time),
+		"-i", source_identity.FsPath(),
+		"-map", "0",
+		"-c", "copy",
+		"-avoid_negative_ts", "make_zero",
+		clip_identity.FsPath(),
+	)
+
+	ffmpeg_output, err := ffmpeg_command.CombinedOutput()
+	if err != nil {
+		os.Remove(clip_identity.FsPath())
+		return nil, errors.Join(fmt.Errorf("In workflows/media_clips.ExportMediaClip: While cutting media<%s>: %s", source_identity.Media.Uuid, string(ffmpeg_output)), err)
+	}
+
+	err = repository.MediasRepo.InsertMedia(ctx, clip_identity.Media)
+	if err != nil {
+		os.Remove(clip_identity.FsPath())
+		return nil, errors.Join(fmt.Errorf("In workflows/media_clips.ExportMediaClip: While inserting clip media '%s'", clip_identity.Media.Name), err)
+	}
+
+	_, err = communication.Metadata.CopyEntityTagsToEntities(source_identity.Media.Uuid, source_identity.ClusterUUID, dungeon_models.ENTITY_TYPE_MEDIA, []string{clip_identity.Media.Uuid})
+	if err != nil {
+		echo.EchoWarn(fmt.Sprintf("In workflows/media_clips.ExportMediaClip: Clip media<%s> was created but the tags of media<%s> could not be copied: %s", clip_identity.Media.Uuid, source_identity.Media.Uuid, err.Error()))
+	}
+
+	return clip_identity, nil
+}
+
+// Formats a millisecond time as seconds with millisecond precision, which is a duration format ffmpeg accepts.
+func formatFFmpegTime(milliseconds int64) string {
+	return strconv.FormatFloat(float64(milliseconds)/1000, 'f', 3, 64)
+}
@@ -19,6 +19,7 @@ import (
 	workflow_errors "libery_medias_service/workflows/errors"
 	"mime/multipart"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 
@@ -307,6 +308,59 @@ func GetVideoThumbnail(f *os.File, thumbnail_width int) (*service_models.Thumbna
 	return new_thumbnail_response, nil
 }
 
+// creates a thumbnail from the frame of a video file found at at_time(milliseconds), resized to the specified width. If the width is 0, the default thumbnail width will be used.
+// Like GetVideoThumbnail, the frame is never upscaled.
+func GetVideoFrameThumbnail(f *os.File, at_time int64, thumbnail_width int) (*service_models.ThumbnailResponse, *dungeon_models.LabeledError) {
+	if at_time < 0 {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("Invalid frame time: %d", at_time), "While getting video frame thumbnail", dungeon_models.ErrPreconditionFailed)
+	}
+
+	if thumbnail_width == 0 {
+		thumbnail_width = app_config.THUMBNAIL_WIDTH
+	}
+
+	ffmpeg_command := exec.Command("ffmpeg", "-v", "error",
+		"-ss", formatFFmpegTime(at_time),
+		"-i", f.Name(),
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", thumbnail_width),
+		"-f", "image2pipe",
+		"-vcodec", "mjpeg",
+		"-",
+	)
+
+	var frame_buffer *bytes.Buffer = new(bytes.Buffer)
+	ffmpeg_command.Stdout = frame_buffer
+
+	err := ffmpeg_command.Run()
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, "Error extracting video frame with ffmpeg", dungeon_models.ErrProcessError)
+	}
+
+	if frame_buffer.Len() == 0 {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("No frame found at %dms", at_time), "While extracting video frame", dungeon_models.ErrProcessError)
+	}
+
+	frame_config, err := jpeg.DecodeConfig(bytes.NewReader(frame_buffer.Bytes()))
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, "Error decoding extracted video frame", dungeon_models.ErrProcessError)
+	}
+
+	var new_thumbnail_response *service_models.ThumbnailResponse = new(service_models.ThumbnailResponse)
+
+	new_thumbnail_response.MimeType = "image/jpeg"
+	new_thumbnail_response.Filename = filepath.Base(f.Name())
+	new_thumbnail_response.Resized = true
+	new_thumbnail_response.MediaStream = frame_buffer
+	new_thumbnail_response.MediaLength = int64(frame_buffer.Len())
+	new_thumbnail_response.Size = &service_models.MediaSize{
+		Width:  frame_config.Width,
+		Height: frame_config.Height,
+	}
+
+	return new_thumbnail_response, nil
+}
+
 func SaveMediaFile(media_identity *dungeon_models.MediaIdentity, file *multipart.File) error {
 	SetUniqueMediaName(media_identity)
 
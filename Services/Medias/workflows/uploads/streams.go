@@ -55,28 +55,28 @@ func WriteChunkToFile(chunk_data []byte, upload_ticket *service_models.ChunkedUp
 	return err
 }
 
-// Creates a new media from a chunked upload
-func CreateMediaFromChunkedUpload(ticket *service_models.ChunkedUploadTicket) error {
+// Creates a new media from a chunked upload and returns its identity
+func CreateMediaFromChunkedUpload(ticket *service_models.ChunkedUploadTicket) (*dungeon_models.MediaIdentity, error) {
 	if !CheckChunksPresent(ticket) {
-		return fmt.Errorf("Not all chunks are present")
+		return nil, fmt.Errorf("Not all chunks are present")
 	}
 
 	err := JoinUploadChunks(ticket)
 	if err != nil {
 		labeled_err := dungeon_models.NewLabeledError(err, "In CreateMediaFromChunkedUpload, while joining chunks", dungeon_models.ErrIOError)
-		return labeled_err
+		return nil, labeled_err
 	}
 
 	new_media_identity, err := CreateMediaIdentityFromTicket(ticket)
 	if err != nil {
 		labeled_err := dungeon_models.NewLabeledError(err, "In CreateMediaFromChunkedUpload, while creating media identity", dungeon_models.ErrIOError)
-		return labeled_err
+		return nil, labeled_err
 	}
 
 	err = workflows.InsertUniqueMediaIdentity(new_media_identity)
 	if err != nil {
 		labeled_err := dungeon_models.NewLabeledError(err, "In CreateMediaFromChunkedUpload, while inserting media identity", dungeon_models.ErrDB_CouldNotConnectToDB)
-		return labeled_err
+		return nil, labeled_err
 	}
 
 	// TODO: This function takes too long to execute. we should probably either move it to a goroutine or change the entire process
@@ -84,12 +84,15 @@ func CreateMediaFromChunkedUpload(ticket *service_models.ChunkedUploadTicket) er
 	err = MoveChunkedMedia(&completeChunkedMediaData{new_media_identity, ticket})
 	if err != nil {
 		labeled_err := dungeon_models.NewLabeledError(err, "In CreateMediaFromChunkedUpload, while moving media file", dungeon_models.ErrIOError)
-		return labeled_err
+		return nil, labeled_err
 	}
 
 	err = DeleteChunks(ticket)
+	if err != nil {
+		return nil, err
+	}
 
-	return err
+	return new_media_identity, nil
 }
 
 // Create new media identity from a chunked upload
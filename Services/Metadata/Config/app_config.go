@@ -21,6 +21,7 @@ var METADATA_GRPC_SERVER_PORT string = os.Getenv("METADATA_GRPC_SERVER_PORT")
 // --------Settings--------
 
 var SERVICE_ID string
+var ORPHAN_SWEEP_INTERVAL_MINUTES int64 = 360 // 0 disables the orphaned metadata sweeper
 
 var service_settings map[string]any = make(map[string]any)
 
@@ -92,6 +93,10 @@ func loadSettings() error {
 
 	service_settings = settings
 
+	if _, exists := service_settings["ORPHAN_SWEEP_INTERVAL_MINUTES"]; exists {
+		ORPHAN_SWEEP_INTERVAL_MINUTES = int64(service_settings["ORPHAN_SWEEP_INTERVAL_MINUTES"].(float64))
+	}
+
 	return nil
 }
 
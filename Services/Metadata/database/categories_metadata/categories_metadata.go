@@ -44,6 +44,17 @@ func (category_config_db *categoryConfigDB) CreateCategoryConfig(category_uuid s
 	return category_config
 }
 
+func (category_config_db *categoryConfigDB) DeleteCategoryConfig(category_uuid string) error {
+	delete(category_config_db.categoryConfigs, category_uuid)
+
+	err := removeCategoryConfig(category_uuid, category_config_db.categoriesConfigPath)
+	if err != nil {
+		return fmt.Errorf("In database/categories_metadata/categories_metadata.DeleteCategoryConfig: while removing category config from fs.\n\n%s", err)
+	}
+
+	return nil
+}
+
 func (category_config_db *categoryConfigDB) GetCategoryConfig(category_uuid string) *service_models.CategoryConfig {
 	var category_config, exists = category_config_db.categoryConfigs[category_uuid]
 
@@ -62,6 +73,33 @@ func (category_config_db *categoryConfigDB) GetCategoryConfig(category_uuid stri
 	return category_config_db.CreateCategoryConfig(category_uuid)
 }
 
+func (category_config_db *categoryConfigDB) GetAllCategoryConfigs() ([]*service_models.CategoryConfig, error) {
+	stored_category_uuids, err := listCategoryConfigs(category_config_db.categoriesConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("In database/categories_metadata/categories_metadata.GetAllCategoryConfigs: while listing category configs.\n\n%s", err)
+	}
+
+	var category_configs []*service_models.CategoryConfig = make([]*service_models.CategoryConfig, 0, len(stored_category_uuids))
+
+	for _, category_uuid := range stored_category_uuids {
+		category_config, exists := category_config_db.categoryConfigs[category_uuid]
+
+		if !exists {
+			category_config, err = loadCategoryConfig(category_uuid, category_config_db.categoriesConfigPath)
+			if err != nil {
+				echo.EchoWarn(fmt.Sprintf("In database/categories_metadata/categories_metadata.GetAllCategoryConfigs: while loading category config<%s>.\n\n%s", category_uuid, err))
+				continue
+			}
+
+			category_config_db.categoryConfigs[category_uuid] = category_config
+		}
+
+		category_configs = append(category_configs, category_config)
+	}
+
+	return category_configs, nil
+}
+
 func (category_config_db *categoryConfigDB) UpdateCategoryConfig(category_config *service_models.CategoryConfig) error {
 	if category_config.CategoryUUID == "" {
 		return fmt.Errorf("In database/categories_metadata/categories_metadata.UpdateCategoryConfig: category config has no category uuid")
@@ -7,6 +7,7 @@ import (
 	"libery-metadata-service/models"
 	"os"
 	"path"
+	"strings"
 )
 
 const CATEGORIES_METADATA_DIRECTORY_NAME string = "categories_metadata"
@@ -80,3 +81,47 @@ func loadCategoryConfig(category_uuid string, load_path string) (*models.Categor
 
 	return category_config, nil
 }
+
+// Removes the category config file matching the given category uuid. Removing a config that was never saved is not an error.
+func removeCategoryConfig(category_uuid string, load_path string) error {
+	if category_uuid == "" {
+		return fmt.Errorf("Category uuid is empty")
+	}
+
+	var category_config_file_path string = path.Join(load_path, category_uuid+".json")
+
+	if !dungeon_helpers.FileExists(category_config_file_path) {
+		return nil
+	}
+
+	err := os.Remove(category_config_file_path)
+	if err != nil {
+		return fmt.Errorf("Error removing category config file: %s", err.Error())
+	}
+
+	return nil
+}
+
+// Returns the category uuids of all the category config files stored on the given path.
+func listCategoryConfigs(load_path string) ([]string, error) {
+	if load_path == "" || !dungeon_helpers.FileExists(load_path) {
+		return nil, fmt.Errorf("Load path <%s> does not exist", load_path)
+	}
+
+	entries, err := os.ReadDir(load_path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading categories metadata directory: %s", err.Error())
+	}
+
+	var category_uuids []string = make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		category_uuids = append(category_uuids, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	return category_uuids, nil
+}
@@ -29,6 +29,11 @@ func NewDungeonTagsDB() *DungeonTagsDB {
 
 	dungeon_tags_dbd.db_conn = db
 
+	err = dungeon_tags_dbd.ensureTagHierarchySchema()
+	if err != nil {
+		panic(err)
+	}
+
 	return dungeon_tags_dbd
 }
 
@@ -49,12 +54,12 @@ func (dt_db *DungeonTagsDB) CreateTaxonomy(taxonomy *service_models.TagTaxonomy)
 }
 
 func (dt_db *DungeonTagsDB) CreateTagCTX(ctx context.Context, tag *service_models.DungeonTag) error {
-	stmt, err := dt_db.db_conn.PrepareContext(ctx, "INSERT INTO `dungeon_tags`(`name`, `taxonomy`, `name_taxonomy`) VALUES (?, ?, ?)")
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "INSERT INTO `dungeon_tags`(`name`, `taxonomy`, `name_taxonomy`, `parent_id`) VALUES (?, ?, ?, ?)")
 	if err != nil {
 		return err
 	}
 
-	results, err := stmt.ExecContext(ctx, tag.Name, tag.Taxonomy, tag.NameTaxonomy)
+	results, err := stmt.ExecContext(ctx, tag.Name, tag.Taxonomy, tag.NameTaxonomy, nullableTagID(tag.ParentID))
 	if err != nil {
 		return err
 	}
@@ -260,13 +265,13 @@ func (db_db *DungeonTagsDB) GetClusterTagsByInternalValue(cluster_uuid string, i
 func (dt_db *DungeonTagsDB) GetTagByIdCTX(ctx context.Context, tag_id int) (service_models.DungeonTag, error) {
 	var tag service_models.DungeonTag
 
-	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy` FROM `dungeon_tags` WHERE `id`=?")
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy`, IFNULL(`parent_id`, 0) FROM `dungeon_tags` WHERE `id`=?")
 	if err != nil {
 		return tag, err
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, tag_id).Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy)
+	err = stmt.QueryRowContext(ctx, tag_id).Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
 	if err != nil {
 		return tag, err
 	}
@@ -287,7 +292,7 @@ func (dt_db *DungeonTagsDB) GetTagsByIDsCTX(ctx context.Context, tag_ids []int)
 
 	var stmt_placeholder string = dungeon_helpers.GetPreparedListPlaceholders(len(tag_ids))
 
-	stmt, err := dt_db.db_conn.PrepareContext(ctx, fmt.Sprintf("SELECT `id`, `name`, `taxonomy`, `name_taxonomy` FROM `dungeon_tags` WHERE `id` IN (%s)", stmt_placeholder))
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, fmt.Sprintf("SELECT `id`, `name`, `taxonomy`, `name_taxonomy`, IFNULL(`parent_id`, 0) FROM `dungeon_tags` WHERE `id` IN (%s)", stmt_placeholder))
 	if err != nil {
 		return tags, err
 	}
@@ -307,7 +312,7 @@ func (dt_db *DungeonTagsDB) GetTagsByIDsCTX(ctx context.Context, tag_ids []int)
 	for rows.Next() {
 		var tag service_models.DungeonTag
 
-		err = rows.Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy)
+		err = rows.Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
 		if err != nil {
 			return tags, err
 		}
@@ -325,13 +330,13 @@ func (dt_db *DungeonTagsDB) GetTagsByIDs(tag_ids []int) ([]service_models.Dungeo
 func (dt_db *DungeonTagsDB) GetTagByNameCTX(ctx context.Context, tag_name, taxonomy string) (service_models.DungeonTag, error) {
 	var tag service_models.DungeonTag
 
-	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy` FROM `dungeon_tags` WHERE `name`=? AND `taxonomy`=?")
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy`, IFNULL(`parent_id`, 0) FROM `dungeon_tags` WHERE `name`=? AND `taxonomy`=?")
 	if err != nil {
 		return tag, err
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, tag_name, taxonomy).Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy)
+	err = stmt.QueryRowContext(ctx, tag_name, taxonomy).Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
 	if err != nil {
 		return tag, err
 	}
@@ -346,14 +351,14 @@ func (dt_db *DungeonTagsDB) GetTagByName(tag_name, taxonomy string) (service_mod
 func (dt_db *DungeonTagsDB) GetTaxonomyTagsCTX(ctx context.Context, taxonomy_uuid string) ([]service_models.DungeonTag, error) {
 	var tags []service_models.DungeonTag = make([]service_models.DungeonTag, 0)
 
-	rows, err := dt_db.db_conn.QueryContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy` FROM `dungeon_tags` WHERE `taxonomy`=? ORDER BY `name`", taxonomy_uuid)
+	rows, err := dt_db.db_conn.QueryContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy`, IFNULL(`parent_id`, 0) FROM `dungeon_tags` WHERE `taxonomy`=? ORDER BY `name`", taxonomy_uuid)
 	if err != nil {
 		return tags, err
 	}
 
 	for rows.Next() {
 		var tag service_models.DungeonTag
-		err = rows.Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy)
+		err = rows.Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
 		if err != nil {
 			return tags, err
 		}
@@ -432,7 +437,7 @@ func (dt_db *DungeonTagsDB) GetEntityTaggings(entity_uuid, cluster_domain string
 	return dt_db.GetEntityTaggingsCTX(context.Background(), entity_uuid, cluster_domain)
 }
 
-// returns a map of taggable entities that are tagged by all the provided tags. The taggable entities are grouped by entity type
+// returns a map of taggable entities that are tagged by all the provided tags(or any of their descendants). The taggable entities are grouped by entity type
 func (dt_db *DungeonTagsDB) GetEntitiesWithTaggingsCTX(ctx context.Context, tags []int) ([]service_models.DungeonTaggingCompact, error) {
 	var matching_entities []service_models.DungeonTaggingCompact = make([]service_models.DungeonTaggingCompact, 0)
 
@@ -442,13 +447,20 @@ func (dt_db *DungeonTagsDB) GetEntitiesWithTaggingsCTX(ctx context.Context, tags
 
 	var stmt_placeholder string = dungeon_helpers.GetPreparedListPlaceholders(len(tags))
 
+	// tag_tree pairs every requested tag(root) with itself and all its descendants, an entity matches a requested tag if it's tagged
+	// with any tag on that tag's tree.
 	sql_query := fmt.Sprintf(`
+		WITH RECURSIVE tag_tree(root, id) AS (
+			SELECT id, id FROM dungeon_tags WHERE id IN (%s)
+			UNION
+			SELECT tag_tree.root, dungeon_tags.id FROM dungeon_tags JOIN tag_tree ON dungeon_tags.parent_id = tag_tree.id
+		)
 		SELECT t.taggable_id, t.entity_type
 		FROM taggings t
-		WHERE t.tag IN (%s)
+		JOIN tag_tree tt ON t.tag = tt.id
 		GROUP BY t.taggable_id
-		HAVING COUNT(DISTINCT t.tag) = %d
-		ORDER BY rowid
+		HAVING COUNT(DISTINCT tt.root) = %d
+		ORDER BY MIN(t.tagging_id)
 	`, stmt_placeholder, len(tags))
 
 	stmt, err := dt_db.db_conn.PrepareContext(ctx, sql_query)
@@ -486,6 +498,40 @@ func (dt_db *DungeonTagsDB) GetEntitiesWithTaggings(tags []int) ([]service_model
 	return dt_db.GetEntitiesWithTaggingsCTX(context.Background(), tags)
 }
 
+// Returns the distinct uuids of all the tagged entities of the given entity type.
+func (dt_db *DungeonTagsDB) GetTaggedEntitiesByTypeCTX(ctx context.Context, entity_type string) ([]string, error) {
+	var entities_uuids []string = make([]string, 0)
+
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT DISTINCT `taggable_id` FROM `taggings` WHERE `entity_type` = ?")
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTaggedEntitiesByTypeCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, entity_type)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTaggedEntitiesByTypeCTX: Failed to execute statement"), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entity_uuid string
+
+		err = rows.Scan(&entity_uuid)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTaggedEntitiesByTypeCTX: Failed to scan row"), err)
+		}
+
+		entities_uuids = append(entities_uuids, entity_uuid)
+	}
+
+	return entities_uuids, nil
+}
+
+func (dt_db *DungeonTagsDB) GetTaggedEntitiesByType(entity_type string) ([]string, error) {
+	return dt_db.GetTaggedEntitiesByTypeCTX(context.Background(), entity_type)
+}
+
 func (dt_db *DungeonTagsDB) MultiTagEntityCTX(ctx context.Context, tag_ids []int, entity_uuid string, entity_type string) error {
 	tx, err := dt_db.db_conn.BeginTx(ctx, nil)
 	if err != nil {
@@ -0,0 +1,268 @@
+package dungeon_tags
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	service_models "libery-metadata-service/models"
+)
+
+// Databases created before tag hierarchies and aliases existed lack the `parent_id` column and the `tag_aliases` table. The schema is only
+// written when the database file is created so we add them here.
+func (dt_db *DungeonTagsDB) ensureTagHierarchySchema() error {
+	rows, err := dt_db.db_conn.Query("PRAGMA table_info(`dungeon_tags`)")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.ensureTagHierarchySchema: Failed to read table info"), err)
+	}
+
+	var parent_column_exists bool = false
+
+	for rows.Next() {
+		var column_id int
+		var column_name, column_type string
+		var not_null, primary_key int
+		var default_value sql.NullString
+
+		err = rows.Scan(&column_id, &column_name, &column_type, &not_null, &default_value, &primary_key)
+		if err != nil {
+			rows.Close()
+			return errors.Join(fmt.Errorf("In database/dungeon_tags.ensureTagHierarchySchema: Failed to scan table info"), err)
+		}
+
+		if column_name == "parent_id" {
+			parent_column_exists = true
+		}
+	}
+	rows.Close()
+
+	if !parent_column_exists {
+		_, err = dt_db.db_conn.Exec("ALTER TABLE `dungeon_tags` ADD COLUMN `parent_id` INTEGER DEFAULT NULL REFERENCES `dungeon_tags`(`id`) ON DELETE SET NULL")
+		if err != nil {
+			return errors.Join(fmt.Errorf("In database/dungeon_tags.ensureTagHierarchySchema: Failed to add parent_id column"), err)
+		}
+	}
+
+	_, err = dt_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `tag_aliases` (`alias_id` INTEGER PRIMARY KEY AUTOINCREMENT, `tag` INTEGER NOT NULL, `alias` TEXT NOT NULL, `taxonomy` TEXT NOT NULL, FOREIGN KEY(`tag`) REFERENCES `dungeon_tags`(`id`) ON DELETE CASCADE, UNIQUE(`alias`, `taxonomy`))")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.ensureTagHierarchySchema: Failed to create tag_aliases table"), err)
+	}
+
+	return nil
+}
+
+// Root tags store a NULL parent_id, the models use 0 instead.
+func nullableTagID(tag_id int64) interface{} {
+	if tag_id <= 0 {
+		return nil
+	}
+
+	return tag_id
+}
+
+func (dt_db *DungeonTagsDB) CreateTagAliasCTX(ctx context.Context, tag_alias *service_models.TagAlias) error {
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "INSERT INTO `tag_aliases`(`tag`, `alias`, `taxonomy`) VALUES (?, ?, ?)")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.CreateTagAliasCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	results, err := stmt.ExecContext(ctx, tag_alias.TagID, tag_alias.Alias, tag_alias.Taxonomy)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.CreateTagAliasCTX: Failed to execute statement"), err)
+	}
+
+	tag_alias.ID, err = results.LastInsertId()
+
+	return err
+}
+
+func (dt_db *DungeonTagsDB) CreateTagAlias(tag_alias *service_models.TagAlias) error {
+	return dt_db.CreateTagAliasCTX(context.Background(), tag_alias)
+}
+
+func (dt_db *DungeonTagsDB) DeleteTagAliasCTX(ctx context.Context, alias_id int) error {
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "DELETE FROM `tag_aliases` WHERE `alias_id`=?")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.DeleteTagAliasCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, alias_id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.DeleteTagAliasCTX: Failed to execute statement"), err)
+	}
+
+	return nil
+}
+
+func (dt_db *DungeonTagsDB) DeleteTagAlias(alias_id int) error {
+	return dt_db.DeleteTagAliasCTX(context.Background(), alias_id)
+}
+
+func (dt_db *DungeonTagsDB) GetTagAliasesCTX(ctx context.Context, tag_id int) ([]service_models.TagAlias, error) {
+	var tag_aliases []service_models.TagAlias = make([]service_models.TagAlias, 0)
+
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `alias_id`, `tag`, `alias`, `taxonomy` FROM `tag_aliases` WHERE `tag`=? ORDER BY `alias`")
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagAliasesCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, tag_id)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagAliasesCTX: Failed to execute statement"), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag_alias service_models.TagAlias
+
+		err = rows.Scan(&tag_alias.ID, &tag_alias.TagID, &tag_alias.Alias, &tag_alias.Taxonomy)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagAliasesCTX: Failed to scan row"), err)
+		}
+
+		tag_aliases = append(tag_aliases, tag_alias)
+	}
+
+	return tag_aliases, nil
+}
+
+func (dt_db *DungeonTagsDB) GetTagAliases(tag_id int) ([]service_models.TagAlias, error) {
+	return dt_db.GetTagAliasesCTX(context.Background(), tag_id)
+}
+
+// Returns the canonical tag an alias resolves to.
+func (dt_db *DungeonTagsDB) GetTagByAliasCTX(ctx context.Context, alias, taxonomy string) (service_models.DungeonTag, error) {
+	var tag service_models.DungeonTag
+
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "SELECT `id`, `name`, `taxonomy`, `name_taxonomy`, IFNULL(`parent_id`, 0) FROM `dungeon_tags` WHERE `id`=(SELECT `tag` FROM `tag_aliases` WHERE `alias`=? AND `taxonomy`=?)")
+	if err != nil {
+		return tag, err
+	}
+	defer stmt.Close()
+
+	err = stmt.QueryRowContext(ctx, alias, taxonomy).Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
+	if err != nil {
+		return tag, err
+	}
+
+	return tag, nil
+}
+
+func (dt_db *DungeonTagsDB) GetTagByAlias(alias, taxonomy string) (service_models.DungeonTag, error) {
+	return dt_db.GetTagByAliasCTX(context.Background(), alias, taxonomy)
+}
+
+// Returns all the tags below the given tag on its hierarchy, not including the tag itself.
+func (dt_db *DungeonTagsDB) GetTagDescendantsCTX(ctx context.Context, tag_id int) ([]service_models.DungeonTag, error) {
+	var descendants []service_models.DungeonTag = make([]service_models.DungeonTag, 0)
+
+	sql_query := `
+		WITH RECURSIVE tag_tree(id) AS (
+			SELECT id FROM dungeon_tags WHERE parent_id = ?
+			UNION
+			SELECT dungeon_tags.id FROM dungeon_tags JOIN tag_tree ON dungeon_tags.parent_id = tag_tree.id
+		)
+		SELECT id, name, taxonomy, name_taxonomy, IFNULL(parent_id, 0) FROM dungeon_tags WHERE id IN (SELECT id FROM tag_tree) ORDER BY name
+	`
+
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, sql_query)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagDescendantsCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx, tag_id)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagDescendantsCTX: Failed to execute statement"), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tag service_models.DungeonTag
+
+		err = rows.Scan(&tag.ID, &tag.Name, &tag.Taxonomy, &tag.NameTaxonomy, &tag.ParentID)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In database/dungeon_tags.GetTagDescendantsCTX: Failed to scan row"), err)
+		}
+
+		descendants = append(descendants, tag)
+	}
+
+	return descendants, nil
+}
+
+func (dt_db *DungeonTagsDB) GetTagDescendants(tag_id int) ([]service_models.DungeonTag, error) {
+	return dt_db.GetTagDescendantsCTX(context.Background(), tag_id)
+}
+
+// Moves every tagging, child tag and alias of the source tag to the target tag, keeps the source tag name as an alias of the target and
+// deletes the source tag. Entities tagged with both tags end up with a single tagging. If the target tag is a descendant of the source
+// tag, lift_target must be set so the target takes the source tag's place on the hierarchy instead of becoming its own ancestor.
+func (dt_db *DungeonTagsDB) MergeTagsCTX(ctx context.Context, source_tag service_models.DungeonTag, target_tag_id int, lift_target bool) error {
+	tx, err := dt_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.MergeTagsCTX: Failed to begin transaction"), err)
+	}
+
+	if lift_target {
+		_, err = tx.ExecContext(ctx, "UPDATE `dungeon_tags` SET `parent_id`=? WHERE `id`=?", nullableTagID(source_tag.ParentID), target_tag_id)
+		if err != nil {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("In database/dungeon_tags.MergeTagsCTX: Failed to move the target tag up the hierarchy"), err)
+		}
+	}
+
+	merge_statements := []struct {
+		sql_stmt string
+		args     []interface{}
+	}{
+		{"INSERT OR IGNORE INTO `taggings`(`tag`, `entity_type`, `taggable_id`) SELECT ?, `entity_type`, `taggable_id` FROM `taggings` WHERE `tag`=?", []interface{}{target_tag_id, source_tag.ID}},
+		{"DELETE FROM `taggings` WHERE `tag`=?", []interface{}{source_tag.ID}},
+		{"UPDATE `dungeon_tags` SET `parent_id`=? WHERE `parent_id`=?", []interface{}{target_tag_id, source_tag.ID}},
+		{"UPDATE `tag_aliases` SET `tag`=? WHERE `tag`=?", []interface{}{target_tag_id, source_tag.ID}},
+		{"DELETE FROM `dungeon_tags` WHERE `id`=?", []interface{}{source_tag.ID}},
+		{"INSERT OR IGNORE INTO `tag_aliases`(`tag`, `alias`, `taxonomy`) VALUES (?, ?, ?)", []interface{}{target_tag_id, source_tag.Name, source_tag.Taxonomy}},
+	}
+
+	for _, merge_statement := range merge_statements {
+		_, err = tx.ExecContext(ctx, merge_statement.sql_stmt, merge_statement.args...)
+		if err != nil {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("In database/dungeon_tags.MergeTagsCTX: Failed to execute '%s'", merge_statement.sql_stmt), err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.MergeTagsCTX: Failed to commit transaction"), err)
+	}
+
+	return nil
+}
+
+func (dt_db *DungeonTagsDB) MergeTags(source_tag service_models.DungeonTag, target_tag_id int, lift_target bool) error {
+	return dt_db.MergeTagsCTX(context.Background(), source_tag, target_tag_id, lift_target)
+}
+
+// Sets the parent of a tag, a parent_id of 0 makes it a root tag. Performs no cycle checks.
+func (dt_db *DungeonTagsDB) UpdateTagParentCTX(ctx context.Context, tag_id int, parent_id int) error {
+	stmt, err := dt_db.db_conn.PrepareContext(ctx, "UPDATE `dungeon_tags` SET `parent_id`=? WHERE `id`=?")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.UpdateTagParentCTX: Failed to prepare statement"), err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, nullableTagID(int64(parent_id)), tag_id)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/dungeon_tags.UpdateTagParentCTX: Failed to execute statement"), err)
+	}
+
+	return nil
+}
+
+func (dt_db *DungeonTagsDB) UpdateTagParent(tag_id int, parent_id int) error {
+	return dt_db.UpdateTagParentCTX(context.Background(), tag_id, parent_id)
+}
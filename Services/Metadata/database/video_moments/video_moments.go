@@ -27,9 +27,54 @@ func NewVideoMomentsDB() *VideoMomentsDB {
 
 	video_moments_db.db_conn = db
 
+	err = video_moments_db.ensureMomentEndTimeColumn()
+	if err != nil {
+		panic(err)
+	}
+
 	return video_moments_db
 }
 
+// Databases created before moments could have an end time lack the `moment_end_time` column, the schema is only written when the
+// database file is created so we add it here.
+func (video_moments_db VideoMomentsDB) ensureMomentEndTimeColumn() error {
+	rows, err := video_moments_db.db_conn.Query("PRAGMA table_info(`video_moments`)")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ensureMomentEndTimeColumn: While reading table info."), err)
+	}
+
+	var column_exists bool = false
+
+	for rows.Next() {
+		var column_id int
+		var column_name, column_type string
+		var not_null, primary_key int
+		var default_value sql.NullString
+
+		err = rows.Scan(&column_id, &column_name, &column_type, &not_null, &default_value, &primary_key)
+		if err != nil {
+			rows.Close()
+			return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ensureMomentEndTimeColumn: While scanning table info."), err)
+		}
+
+		if column_name == "moment_end_time" {
+			column_exists = true
+		}
+	}
+	rows.Close()
+
+	if column_exists {
+		return nil
+	}
+
+	_, err = video_moments_db.db_conn.Exec("ALTER TABLE `video_moments` ADD COLUMN `moment_end_time` INTEGER NOT NULL DEFAULT 0")
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ensureMomentEndTimeColumn: While adding column."), err)
+	}
+
+	return nil
+}
+
 func (video_moments_db VideoMomentsDB) AddVideoCTX(ctx context.Context, video video_moment_models.Video) error {
 	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "INSERT INTO `videos` (`uuid`, `cluster_uuid`) VALUES (?, ?)")
 	if err != nil {
@@ -50,13 +95,13 @@ func (video_moments_db VideoMomentsDB) AddVideo(video video_moment_models.Video)
 }
 
 func (video_moments_db VideoMomentsDB) AddVideoMomentCTX(ctx context.Context, video_moment video_moment_models.VideoMoment) (int, error) {
-	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "INSERT INTO `video_moments` (`video_uuid`, `moment_time`, `moment_title`) VALUES (?, ?, ?)")
+	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "INSERT INTO `video_moments` (`video_uuid`, `moment_time`, `moment_end_time`, `moment_title`) VALUES (?, ?, ?, ?)")
 	if err != nil {
 		return -1, errors.Join(fmt.Errorf("In database/video_moments/video_moments.AddVideoMomentCTX: While preparing statement."), err)
 	}
 	defer stmt.Close()
 
-	results, err := stmt.ExecContext(ctx, video_moment.VideoUUID, video_moment.MomentTime, video_moment.MomentTitle)
+	results, err := stmt.ExecContext(ctx, video_moment.VideoUUID, video_moment.MomentTime, video_moment.MomentEndTime, video_moment.MomentTitle)
 	if err != nil {
 		return -1, errors.Join(fmt.Errorf("In database/video_moments/video_moments.AddVideoMomentCTX: While executing statement."), err)
 	}
@@ -89,6 +134,125 @@ func (video_moments_db VideoMomentsDB) DeleteVideoMoment(video_moment video_mome
 	return video_moments_db.DeleteVideoMomentCTX(context.Background(), video_moment)
 }
 
+// Deletes the given videos along with all their moments.
+func (video_moments_db VideoMomentsDB) DeleteVideosCTX(ctx context.Context, video_uuids []string) error {
+	if len(video_uuids) == 0 {
+		return nil
+	}
+
+	tx, err := video_moments_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While beginning transaction."), err)
+	}
+
+	moments_stmt, err := tx.PrepareContext(ctx, "DELETE FROM `video_moments` WHERE `video_uuid` = ?")
+	if err != nil {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While preparing moments statement."), err)
+	}
+	defer moments_stmt.Close()
+
+	videos_stmt, err := tx.PrepareContext(ctx, "DELETE FROM `videos` WHERE `uuid` = ?")
+	if err != nil {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While preparing videos statement."), err)
+	}
+	defer videos_stmt.Close()
+
+	for _, video_uuid := range video_uuids {
+		_, err = moments_stmt.ExecContext(ctx, video_uuid)
+		if err != nil {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While deleting moments of video<%s>.", video_uuid), err)
+		}
+
+		_, err = videos_stmt.ExecContext(ctx, video_uuid)
+		if err != nil {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While deleting video<%s>.", video_uuid), err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.DeleteVideosCTX: While committing transaction."), err)
+	}
+
+	return nil
+}
+
+func (video_moments_db VideoMomentsDB) DeleteVideos(video_uuids []string) error {
+	return video_moments_db.DeleteVideosCTX(context.Background(), video_uuids)
+}
+
+// Deletes all the moments of a video and adds the given ones in their place. Either all of it happens or nothing does.
+func (video_moments_db VideoMomentsDB) ReplaceVideoMomentsCTX(ctx context.Context, video_uuid string, video_moments []video_moment_models.VideoMoment) error {
+	tx, err := video_moments_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ReplaceVideoMomentsCTX: While beginning transaction."), err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `video_moments` WHERE `video_uuid` = ?", video_uuid)
+	if err != nil {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ReplaceVideoMomentsCTX: While deleting moments of video<%s>.", video_uuid), err)
+	}
+
+	insert_stmt, err := tx.PrepareContext(ctx, "INSERT INTO `video_moments` (`video_uuid`, `moment_time`, `moment_end_time`, `moment_title`) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ReplaceVideoMomentsCTX: While preparing statement."), err)
+	}
+	defer insert_stmt.Close()
+
+	for _, video_moment := range video_moments {
+		_, err = insert_stmt.ExecContext(ctx, video_uuid, video_moment.MomentTime, video_moment.MomentEndTime, video_moment.MomentTitle)
+		if err != nil {
+			tx.Rollback()
+			return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ReplaceVideoMomentsCTX: While adding moment at <%d>.", video_moment.MomentTime), err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.ReplaceVideoMomentsCTX: While committing transaction."), err)
+	}
+
+	return nil
+}
+
+func (video_moments_db VideoMomentsDB) ReplaceVideoMoments(video_uuid string, video_moments []video_moment_models.VideoMoment) error {
+	return video_moments_db.ReplaceVideoMomentsCTX(context.Background(), video_uuid, video_moments)
+}
+
+// Returns the uuids of all the videos registered, regardless of their cluster.
+func (video_moments_db VideoMomentsDB) GetAllVideoUUIDsCTX(ctx context.Context) ([]string, error) {
+	var video_uuids []string = make([]string, 0)
+
+	rows, err := video_moments_db.db_conn.QueryContext(ctx, "SELECT `uuid` FROM `videos`")
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetAllVideoUUIDsCTX: While executing query."), err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var video_uuid string
+
+		err = rows.Scan(&video_uuid)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetAllVideoUUIDsCTX: While scanning rows."), err)
+		}
+
+		video_uuids = append(video_uuids, video_uuid)
+	}
+
+	return video_uuids, nil
+}
+
+func (video_moments_db VideoMomentsDB) GetAllVideoUUIDs() ([]string, error) {
+	return video_moments_db.GetAllVideoUUIDsCTX(context.Background())
+}
+
 func (video_moments_db VideoMomentsDB) GetVideoCTX(ctx context.Context, uuid string, cluster_uuid string) (*video_moment_models.Video, error) {
 	var video video_moment_models.Video
 
@@ -113,13 +277,13 @@ func (video_moments_db VideoMomentsDB) GetVideo(uuid string, cluster_uuid string
 func (video_moments_db VideoMomentsDB) GetVideoMomentCTX(ctx context.Context, moment_id int) (*video_moment_models.VideoMoment, error) {
 	var video_moment video_moment_models.VideoMoment
 
-	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_title` FROM `video_moments` WHERE `id` = ?")
+	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_end_time`, `moment_title` FROM `video_moments` WHERE `id` = ?")
 	if err != nil {
 		return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetVideoMomentCTX: While preparing statement."), err)
 	}
 	defer stmt.Close()
 
-	err = stmt.QueryRowContext(ctx, moment_id).Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentTitle)
+	err = stmt.QueryRowContext(ctx, moment_id).Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentEndTime, &video_moment.MomentTitle)
 	if err != nil {
 		return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetVideoMomentCTX: While executing statement."), err)
 	}
@@ -134,7 +298,7 @@ func (video_moments_db VideoMomentsDB) GetVideoMoment(moment_id int) (*video_mom
 func (video_moments_db VideoMomentsDB) GetVideoMomentsCTX(ctx context.Context, video *video_moment_models.Video) ([]video_moment_models.VideoMoment, error) {
 	var video_moments []video_moment_models.VideoMoment
 
-	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_title` FROM `video_moments` WHERE `video_uuid` = ?")
+	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_end_time`, `moment_title` FROM `video_moments` WHERE `video_uuid` = ?")
 	if err != nil {
 		return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetVideoMomentsCTX: While preparing statement."), err)
 	}
@@ -149,7 +313,7 @@ func (video_moments_db VideoMomentsDB) GetVideoMomentsCTX(ctx context.Context, v
 	for rows.Next() {
 		var video_moment video_moment_models.VideoMoment
 
-		err = rows.Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentTitle)
+		err = rows.Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentEndTime, &video_moment.MomentTitle)
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetVideoMomentsCTX: While scanning rows."), err)
 		}
@@ -200,7 +364,7 @@ func (video_moments_db VideoMomentsDB) GetClusterVideos(cluster_uuid string) ([]
 func (video_moments_db VideoMomentsDB) GetClusterMomentsCTX(ctx context.Context, cluster_uuid string) ([]video_moment_models.VideoMoment, error) {
 	var video_moments []video_moment_models.VideoMoment
 
-	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_title` FROM `video_moments` WHERE `video_uuid` IN (SELECT `uuid` FROM `videos` WHERE `cluster_uuid` = ?)")
+	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "SELECT `id`, `video_uuid`, `moment_time`, `moment_end_time`, `moment_title` FROM `video_moments` WHERE `video_uuid` IN (SELECT `uuid` FROM `videos` WHERE `cluster_uuid` = ?)")
 	if err != nil {
 		return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetClusterMomentsCTX: While preparing statement."), err)
 	}
@@ -215,7 +379,7 @@ func (video_moments_db VideoMomentsDB) GetClusterMomentsCTX(ctx context.Context,
 	for rows.Next() {
 		var video_moment video_moment_models.VideoMoment
 
-		err = rows.Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentTitle)
+		err = rows.Scan(&video_moment.ID, &video_moment.VideoUUID, &video_moment.MomentTime, &video_moment.MomentEndTime, &video_moment.MomentTitle)
 		if err != nil {
 			return nil, errors.Join(fmt.Errorf("In database/video_moments/video_moments.GetClusterMomentsCTX: While scanning rows."), err)
 		}
@@ -261,13 +425,13 @@ func (video_moments_db VideoMomentsDB) GetClusterVideoMoments(cluster_uuid strin
 }
 
 func (video_moments_db VideoMomentsDB) UpdateVideoMomentCTX(ctx context.Context, video_moment video_moment_models.VideoMoment) error {
-	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "UPDATE `video_moments` SET `moment_title` = ?, `moment_time` = ? WHERE `id` = ?")
+	stmt, err := video_moments_db.db_conn.PrepareContext(ctx, "UPDATE `video_moments` SET `moment_title` = ?, `moment_time` = ?, `moment_end_time` = ? WHERE `id` = ?")
 	if err != nil {
 		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.UpdateVideoMomentCTX: While preparing statement."), err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, video_moment.MomentTitle, video_moment.MomentTime, video_moment.ID)
+	_, err = stmt.ExecContext(ctx, video_moment.MomentTitle, video_moment.MomentTime, video_moment.MomentEndTime, video_moment.ID)
 	if err != nil {
 		return errors.Join(fmt.Errorf("In database/video_moments/video_moments.UpdateVideoMomentCTX: While executing statement."), err)
 	}
@@ -7,13 +7,18 @@ import (
 	dungeon_models "libery-dungeon-libs/models"
 	service_models "libery-metadata-service/models"
 	"os"
+	"sync"
 
 	"github.com/Gerardo115pp/patriots_lib/echo"
 )
 
+// Keeps the watch points in memory. It's used by the http handlers, the grpc server and the orphan sweeper, so every
+// access goes through the mutex. Reading the stream moves its seek point, so only lookups that don't go through the
+// stream share the lock.
 type WatchPointDatabase struct {
 	mediaToOrd map[string]uint16
 	stream     *service_models.WatchPointStream
+	mutex      sync.RWMutex
 }
 
 func NewWatchPointDatabase() *WatchPointDatabase {
@@ -50,7 +55,10 @@ func (wdb *WatchPointDatabase) populateMediaToOrd() {
 	}
 }
 
-func (wdb WatchPointDatabase) GetWatchPointByMediaID(ctx context.Context, media_uuid string) (*service_models.WatchPoint, *dungeon_models.LabeledError) {
+func (wdb *WatchPointDatabase) GetWatchPointByMediaID(ctx context.Context, media_uuid string) (*service_models.WatchPoint, *dungeon_models.LabeledError) {
+	wdb.mutex.Lock()
+	defer wdb.mutex.Unlock()
+
 	requested_ord, exists := wdb.mediaToOrd[media_uuid]
 
 	if !exists {
@@ -76,11 +84,17 @@ func (wdb WatchPointDatabase) GetWatchPointByMediaID(ctx context.Context, media_
 	return wdb.stream.ReadWatchPoint(requested_ord)
 }
 
-func (wdb WatchPointDatabase) GetWatchPointByORD(ctx context.Context, ord uint16) (*service_models.WatchPoint, *dungeon_models.LabeledError) {
+func (wdb *WatchPointDatabase) GetWatchPointByORD(ctx context.Context, ord uint16) (*service_models.WatchPoint, *dungeon_models.LabeledError) {
+	wdb.mutex.RLock()
+	defer wdb.mutex.RUnlock()
+
 	return wdb.stream.ReadWatchPoint(ord)
 }
 
 func (wdb *WatchPointDatabase) InsertWatchPoint(ctx context.Context, media_uuid string, start_time uint32) *dungeon_models.LabeledError {
+	wdb.mutex.Lock()
+	defer wdb.mutex.Unlock()
+
 	requested_ord, exists := wdb.mediaToOrd[media_uuid]
 
 	if exists {
@@ -97,12 +111,62 @@ func (wdb *WatchPointDatabase) InsertWatchPoint(ctx context.Context, media_uuid
 		wdb.mediaToOrd[media_uuid] = new_watch_point.Ord
 	}
 
-	wdb.SaveToDisk()
+	wdb.saveToDisk()
 
 	return nil
 }
 
-func (wdb WatchPointDatabase) SaveToDisk() error {
+// Removes the watch points of all the given medias and persists the resulting stream.
+func (wdb *WatchPointDatabase) DeleteWatchPoints(ctx context.Context, media_uuids []string) *dungeon_models.LabeledError {
+	wdb.mutex.Lock()
+	defer wdb.mutex.Unlock()
+
+	var deleted_medias map[string]struct{} = make(map[string]struct{})
+
+	for _, media_uuid := range media_uuids {
+		if _, exists := wdb.mediaToOrd[media_uuid]; exists {
+			deleted_medias[media_uuid] = struct{}{}
+		}
+	}
+
+	if len(deleted_medias) == 0 {
+		return nil
+	}
+
+	removed_count, lerr := wdb.stream.RemoveWatchPoints(deleted_medias)
+	if lerr != nil {
+		return lerr
+	}
+
+	echo.EchoDebug(fmt.Sprintf("Removed %d watch points", removed_count))
+
+	wdb.mediaToOrd = make(map[string]uint16)
+	wdb.populateMediaToOrd()
+
+	err := wdb.saveToDisk()
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In database/watch_points.DeleteWatchPoints: while saving watch points", dungeon_models.ErrIOError)
+	}
+
+	return nil
+}
+
+// Returns the uuids of all the medias that have a watch point.
+func (wdb *WatchPointDatabase) GetWatchPointMedias(ctx context.Context) []string {
+	wdb.mutex.RLock()
+	defer wdb.mutex.RUnlock()
+
+	var media_uuids []string = make([]string, 0, len(wdb.mediaToOrd))
+
+	for media_uuid := range wdb.mediaToOrd {
+		media_uuids = append(media_uuids, media_uuid)
+	}
+
+	return media_uuids
+}
+
+// Writes the watch points to disk, the caller must hold the mutex.
+func (wdb *WatchPointDatabase) saveToDisk() error {
 	var watch_points_filename string = getWatchPointsFilename()
 
 	err := os.WriteFile(watch_points_filename, wdb.stream.WatchPoints, 0644)
@@ -113,10 +177,13 @@ func (wdb WatchPointDatabase) SaveToDisk() error {
 	return nil
 }
 
-func (wdb WatchPointDatabase) Close() error {
+func (wdb *WatchPointDatabase) Close() error {
 	echo.Echo(echo.BlueBG, "Closing watch point database")
 
-	err := wdb.SaveToDisk()
+	wdb.mutex.Lock()
+	defer wdb.mutex.Unlock()
+
+	err := wdb.saveToDisk()
 	if err != nil {
 		return err
 	}
@@ -6,8 +6,10 @@ import (
 	"libery-dungeon-libs/communication/service_requests/metadata_requests"
 	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
 	dungeon_helpers "libery-dungeon-libs/helpers"
+	dungeon_models "libery-dungeon-libs/models"
 	service_models "libery-metadata-service/models"
 	"libery-metadata-service/repository"
+	"libery-metadata-service/workflows"
 	"net/http"
 	"strconv"
 	"strings"
@@ -57,6 +59,10 @@ func getTagHandler(response http.ResponseWriter, request *http.Request) {
 		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getEntitiesWithTagsHandler)
 	case "/dungeon-tags/tags/paginated/matching-entities":
 		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getEntitiesWithTagsPaginatedHandler)
+	case "/dungeon-tags/tags/aliases":
+		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getDungeonTagAliasesHandler)
+	case "/dungeon-tags/tags/descendants":
+		handler_func = dungeon_middlewares.CheckUserCan_ViewContent(getDungeonTagDescendantsHandler)
 	}
 
 	handler_func(response, request)
@@ -126,7 +132,7 @@ func getDungeonTagByNameHandler(response http.ResponseWriter, request *http.Requ
 	var taxonomy_uuid string = request.URL.Query().Get("taxonomy")
 	var tag_name string = request.URL.Query().Get("name")
 
-	tag, err := repository.DungeonTagsRepo.GetTagByNameCTX(request.Context(), tag_name, taxonomy_uuid)
+	tag, err := workflows.ResolveTagByNameCTX(request.Context(), tag_name, taxonomy_uuid)
 	if err != nil {
 		echo.Echo(echo.RedFG, fmt.Sprintf("In getDungeonTagByNameHandler, while getting tag by name: %s\n", err))
 		response.WriteHeader(404)
@@ -139,6 +145,48 @@ func getDungeonTagByNameHandler(response http.ResponseWriter, request *http.Requ
 	json.NewEncoder(response).Encode(tag)
 }
 
+func getDungeonTagAliasesHandler(response http.ResponseWriter, request *http.Request) {
+	tag_id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getDungeonTagAliasesHandler, while converting tag_id to int: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	tag_aliases, err := repository.DungeonTagsRepo.GetTagAliasesCTX(request.Context(), tag_id)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getDungeonTagAliasesHandler, while getting tag aliases: %s\n", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Add("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(tag_aliases)
+}
+
+func getDungeonTagDescendantsHandler(response http.ResponseWriter, request *http.Request) {
+	tag_id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getDungeonTagDescendantsHandler, while converting tag_id to int: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	descendants, err := repository.DungeonTagsRepo.GetTagDescendantsCTX(request.Context(), tag_id)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In getDungeonTagDescendantsHandler, while getting tag descendants: %s\n", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Add("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(descendants)
+}
+
 func getEntityTagsHandler(response http.ResponseWriter, request *http.Request) {
 	var entity_uuid string = request.URL.Query().Get("entity")
 	var cluster_domain string = request.URL.Query().Get("cluster_domain")
@@ -333,6 +381,10 @@ func postTagHandler(response http.ResponseWriter, request *http.Request) {
 
 	case "/dungeon-tags/tags":
 		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(postDungeonTagHandler)
+	case "/dungeon-tags/tags/aliases":
+		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(postDungeonTagAliasHandler)
+	case "/dungeon-tags/tags/merge":
+		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(postMergeDungeonTagsHandler)
 	default:
 		echo.Echo(echo.RedFG, fmt.Sprintf("In postDungeonTagsHandler, invalid resource: %s\n", resource))
 	}
@@ -358,6 +410,21 @@ func postDungeonTagHandler(response http.ResponseWriter, request *http.Request)
 
 	new_tag.Name = strings.ToLower(new_tag.Name)
 
+	if _, err = repository.DungeonTagsRepo.GetTagByAliasCTX(request.Context(), new_tag.Name, new_tag.Taxonomy); err == nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In postDungeonTagHandler, '%s' is already an alias on taxonomy '%s'\n", new_tag.Name, new_tag.Taxonomy))
+		dungeon_helpers.WriteRejection(response, 409, "Name already used by a tag alias")
+		return
+	}
+
+	if new_tag.ParentID != 0 {
+		parent_tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(request.Context(), int(new_tag.ParentID))
+		if err != nil || parent_tag.Taxonomy != new_tag.Taxonomy {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In postDungeonTagHandler, parent tag '%d' doesn't exist on taxonomy '%s'\n", new_tag.ParentID, new_tag.Taxonomy))
+			dungeon_helpers.WriteRejection(response, 400, "Invalid parent tag")
+			return
+		}
+	}
+
 	new_tag.RecalculateNameTaxonomy()
 
 	err = repository.DungeonTagsRepo.CreateTagCTX(request.Context(), new_tag)
@@ -373,6 +440,61 @@ func postDungeonTagHandler(response http.ResponseWriter, request *http.Request)
 	json.NewEncoder(response).Encode(new_tag)
 }
 
+func postDungeonTagAliasHandler(response http.ResponseWriter, request *http.Request) {
+	var request_body *metadata_requests.CreateTagAliasRequest = new(metadata_requests.CreateTagAliasRequest)
+
+	err := json.NewDecoder(request.Body).Decode(request_body)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In postDungeonTagAliasHandler, while decoding request body: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	if request_body.DungeonTagID == 0 || strings.TrimSpace(request_body.Alias) == "" {
+		echo.Echo(echo.RedFG, "In postDungeonTagAliasHandler, tag_id or alias is empty\n")
+		response.WriteHeader(400)
+		return
+	}
+
+	tag_alias, lerr := workflows.CreateTagAliasCTX(request.Context(), request_body.DungeonTagID, request_body.Alias)
+	if lerr != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In postDungeonTagAliasHandler, while creating tag alias: %s\n", lerr))
+		dungeon_helpers.WriteRejection(response, tagLabeledErrorStatus(lerr), string(lerr.Label))
+		return
+	}
+
+	response.Header().Add("Content-Type", "application/json")
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(tag_alias)
+}
+
+func postMergeDungeonTagsHandler(response http.ResponseWriter, request *http.Request) {
+	var request_body *metadata_requests.MergeTagsRequest = new(metadata_requests.MergeTagsRequest)
+
+	err := json.NewDecoder(request.Body).Decode(request_body)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In postMergeDungeonTagsHandler, while decoding request body: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	if request_body.SourceTagID == 0 || request_body.TargetTagID == 0 {
+		echo.Echo(echo.RedFG, "In postMergeDungeonTagsHandler, source_tag_id or target_tag_id is empty\n")
+		response.WriteHeader(400)
+		return
+	}
+
+	lerr := workflows.MergeTagsCTX(request.Context(), request_body.SourceTagID, request_body.TargetTagID)
+	if lerr != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In postMergeDungeonTagsHandler, while merging tags: %s\n", lerr))
+		dungeon_helpers.WriteRejection(response, tagLabeledErrorStatus(lerr), string(lerr.Label))
+		return
+	}
+
+	response.WriteHeader(204)
+}
+
 func patchTagHandler(response http.ResponseWriter, request *http.Request) {
 	var resource string = request.URL.Path
 	var handler_func http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
@@ -380,6 +502,8 @@ func patchTagHandler(response http.ResponseWriter, request *http.Request) {
 	switch resource {
 	case "/dungeon-tags/tags/name":
 		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(patchDungeonTagNameHandler)
+	case "/dungeon-tags/tags/parent":
+		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(patchDungeonTagParentHandler)
 	default:
 		echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagsHandler, invalid resource: %s\n", resource))
 	}
@@ -405,10 +529,43 @@ func patchDungeonTagNameHandler(response http.ResponseWriter, request *http.Requ
 		return
 	}
 
-	err = repository.DungeonTagsRepo.UpdateTagNameCTX(request.Context(), tag_id, new_name)
+	lerr := workflows.RenameTagCTX(request.Context(), tag_id, new_name)
+	if lerr != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagNameHandler, while updating tag name: %s\n", lerr))
+		dungeon_helpers.WriteRejection(response, tagLabeledErrorStatus(lerr), string(lerr.Label))
+		return
+	}
+
+	response.WriteHeader(204)
+}
+
+// Sets the parent of a tag. A parent_id of 0 or no parent_id at all makes the tag a root tag.
+func patchDungeonTagParentHandler(response http.ResponseWriter, request *http.Request) {
+	var tag_id_str string = request.URL.Query().Get("id")
+	var parent_id_str string = request.URL.Query().Get("parent_id")
+
+	tag_id, err := strconv.Atoi(tag_id_str)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagNameHandler, while updating tag name: %s\n", err))
-		response.WriteHeader(500)
+		echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagParentHandler, while converting tag_id to int: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	var parent_id int = 0
+
+	if parent_id_str != "" {
+		parent_id, err = strconv.Atoi(parent_id_str)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagParentHandler, while converting parent_id to int: %s\n", err))
+			response.WriteHeader(400)
+			return
+		}
+	}
+
+	lerr := workflows.SetTagParentCTX(request.Context(), tag_id, parent_id)
+	if lerr != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In patchDungeonTagParentHandler, while setting tag parent: %s\n", lerr))
+		dungeon_helpers.WriteRejection(response, tagLabeledErrorStatus(lerr), string(lerr.Label))
 		return
 	}
 
@@ -422,6 +579,8 @@ func deleteTagHandler(response http.ResponseWriter, request *http.Request) {
 	switch resource {
 	case "/dungeon-tags/tags":
 		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(deleteDungeonTagHandler)
+	case "/dungeon-tags/tags/aliases":
+		handler_func = dungeon_middlewares.CheckUserCan_DungeonTagsCreate(deleteDungeonTagAliasHandler)
 	default:
 		echo.Echo(echo.RedFG, fmt.Sprintf("In deleteDungeonTagsHandler, invalid resource: %s\n", resource))
 	}
@@ -457,7 +616,39 @@ func deleteDungeonTagHandler(response http.ResponseWriter, request *http.Request
 	response.WriteHeader(204)
 }
 
+func deleteDungeonTagAliasHandler(response http.ResponseWriter, request *http.Request) {
+	alias_id, err := strconv.Atoi(request.URL.Query().Get("id"))
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In deleteDungeonTagAliasHandler, while converting alias_id to int: %s\n", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	err = repository.DungeonTagsRepo.DeleteTagAliasCTX(request.Context(), alias_id)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In deleteDungeonTagAliasHandler, while deleting tag alias: %s\n", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.WriteHeader(204)
+}
+
 func putTagHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
 	return
 }
+
+// Maps the labels returned by the dungeon tags workflows to http status codes.
+func tagLabeledErrorStatus(lerr *dungeon_models.LabeledError) int {
+	switch lerr.Label {
+	case service_models.ErrTagNotFound:
+		return 404
+	case service_models.ErrTagNameTaken:
+		return 409
+	case service_models.ErrTagHierarchyCycle, service_models.ErrTagTaxonomyMismatch, dungeon_models.ErrPreconditionFailed:
+		return 400
+	default:
+		return 500
+	}
+}
@@ -2,13 +2,19 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"libery-dungeon-libs/communication"
 	"libery-dungeon-libs/communication/service_requests/metadata_requests"
 	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
 	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
+	dungeon_models "libery-dungeon-libs/models"
+	service_helpers "libery-metadata-service/helpers"
 	video_moment_models "libery-metadata-service/models/video_moments"
 	"libery-metadata-service/repository"
+	"libery-metadata-service/workflows"
 	"net/http"
 
 	"github.com/Gerardo115pp/patriot_router"
@@ -17,6 +23,9 @@ import (
 
 var video_moments_path string = "/video-moments"
 
+// Max size of an uploaded chapters file.
+const max_chapters_file_size int64 = 1 << 20
+
 var VIDEO_MOMENTS_ROUTE *patriot_router.Route = patriot_router.NewRoute(fmt.Sprintf("%s(/.+)?", video_moments_path), false)
 
 func VideoMomentsHandler(service_instance libery_networking.Server) http.HandlerFunc {
@@ -53,6 +62,12 @@ func getVideoMomentsHandler(response http.ResponseWriter, request *http.Request)
 		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(get__VideoMomentsHandler)
 	case fmt.Sprintf("%s/cluster", video_moments_path):
 		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(get__ClusterVideoMomentsHandler)
+	case fmt.Sprintf("%s/chapters", video_moments_path):
+		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(get__VideoChaptersHandler)
+	case fmt.Sprintf("%s/search", video_moments_path):
+		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(get__SearchVideoMomentsHandler)
+	case fmt.Sprintf("%s/moment", video_moments_path):
+		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(get__VideoMomentByIDHandler)
 	}
 
 	resource_handler(response, request)
@@ -87,6 +102,27 @@ func get__VideoMomentsHandler(response http.ResponseWriter, request *http.Reques
 	err = json.NewEncoder(response).Encode(moments)
 }
 
+func get__VideoMomentByIDHandler(response http.ResponseWriter, request *http.Request) {
+	request_params, err := metadata_requests.ParseMomentIdentifierParams(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__VideoMomentByIDHandler: error parsing request params\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 400, "Malformed request")
+		return
+	}
+
+	moment_instance, err := repository.VideoMomentsRepo.GetVideoMomentCTX(request.Context(), request_params.MomentID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__VideoMomentByIDHandler: error getting video moment\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 404, "Video moment not found")
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(moment_instance)
+}
+
 func get__ClusterVideoMomentsHandler(response http.ResponseWriter, request *http.Request) {
 	var cluster_uuid string = request.URL.Query().Get("cluster_uuid")
 
@@ -109,6 +145,99 @@ func get__ClusterVideoMomentsHandler(response http.ResponseWriter, request *http
 	json.NewEncoder(response).Encode(video_moments)
 }
 
+// Searches the titles of all the moments in a cluster.
+func get__SearchVideoMomentsHandler(response http.ResponseWriter, request *http.Request) {
+	request_params, err := metadata_requests.ParseVideoMomentsSearchParams(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__SearchVideoMomentsHandler: error parsing request params\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 400, "Malformed request")
+		return
+	}
+
+	if request_params.ClusterUUID == "" || request_params.Query == "" {
+		echo.Echo(echo.RedFG, "In handlers/video_moments.get__SearchVideoMomentsHandler: request was malformed, either cluster_uuid or query was empty")
+		dungeon_helpers.WriteRejection(response, 400, "Missing parameters")
+		return
+	}
+
+	switch request_params.Mode {
+	case metadata_requests.VideoMoments_SearchMode_Prefix, metadata_requests.VideoMoments_SearchMode_Substring, metadata_requests.VideoMoments_SearchMode_Fuzzy:
+	default:
+		dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("Unsupported search mode '%s'", request_params.Mode))
+		return
+	}
+
+	search_results, err := workflows.SearchClusterMomentsCTX(request.Context(), request_params.ClusterUUID, request_params.Query, request_params.Mode, request_params.Limit)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__SearchVideoMomentsHandler: error searching video moments\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 500, "Error searching video moments")
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(search_results)
+}
+
+// Exports the moments of a video as a chapters file in the requested format.
+func get__VideoChaptersHandler(response http.ResponseWriter, request *http.Request) {
+	request_params, err := metadata_requests.ParseVideoChaptersParams(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__VideoChaptersHandler: error parsing request params\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 400, "Malformed request")
+		return
+	}
+
+	if request_params.VideoUUID == "" || request_params.VideoCluster == "" {
+		echo.Echo(echo.RedFG, "In handlers/video_moments.get__VideoChaptersHandler: request was malformed, either video_uuid or video_cluster was empty")
+		dungeon_helpers.WriteRejection(response, 400, "Missing parameters")
+		return
+	}
+
+	var content_type string
+	var file_extension string
+
+	switch request_params.Format {
+	case metadata_requests.VideoMoments_ChaptersFormat_WebVTT:
+		content_type = "text/vtt; charset=utf-8"
+		file_extension = "vtt"
+	case metadata_requests.VideoMoments_ChaptersFormat_FFMetadata:
+		content_type = "text/plain; charset=utf-8"
+		file_extension = "ffmetadata"
+	default:
+		dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("Unsupported chapters format '%s'", request_params.Format))
+		return
+	}
+
+	chapters, err := workflows.ExportVideoChaptersCTX(request.Context(), video_moment_models.Video{VideoUUID: request_params.VideoUUID, VideoCluster: request_params.VideoCluster}, request_params.VideoDuration)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.get__VideoChaptersHandler: error exporting video chapters\n\n%s", err))
+
+		if errors.Is(err, workflows.ErrVideoNotFound) {
+			dungeon_helpers.WriteRejection(response, 404, "Video not found")
+			return
+		}
+
+		response.WriteHeader(500)
+		return
+	}
+
+	var chapters_file []byte
+
+	if request_params.Format == metadata_requests.VideoMoments_ChaptersFormat_WebVTT {
+		chapters_file = service_helpers.EncodeWebVTTChapters(chapters)
+	} else {
+		chapters_file = service_helpers.EncodeFFMetadataChapters(chapters)
+	}
+
+	response.Header().Set("Content-Type", content_type)
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.chapters.%s\"", request_params.VideoUUID, file_extension))
+	response.WriteHeader(200)
+
+	response.Write(chapters_file)
+}
+
 func postVideoMomentsHandler(response http.ResponseWriter, request *http.Request) {
 	var resource_path string = request.URL.Path
 	var resource_handler http.HandlerFunc = dungeon_helpers.ResourceNotFoundHandler
@@ -116,6 +245,8 @@ func postVideoMomentsHandler(response http.ResponseWriter, request *http.Request
 	switch resource_path {
 	case fmt.Sprintf("%s/moments", video_moments_path):
 		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(post__NewVideoMomentHandler)
+	case fmt.Sprintf("%s/chapters", video_moments_path):
+		resource_handler = dungeon_middlewares.CheckUserCan_ViewContent(post__ImportVideoChaptersHandler)
 	}
 
 	resource_handler(response, request)
@@ -137,6 +268,12 @@ func post__NewVideoMomentHandler(response http.ResponseWriter, request *http.Req
 		return
 	}
 
+	if !request_body.HasValidRange() {
+		echo.Echo(echo.RedFG, "In handlers/video_moments.post__NewVideoMomentHandler: moment_end_time must be greater than moment_time")
+		dungeon_helpers.WriteRejection(response, 400, "Invalid moment range")
+		return
+	}
+
 	video_instance, err := repository.VideoMomentsRepo.GetVideoCTX(request.Context(), request_body.VideoUUID, request_body.VideoCluster)
 	if err != nil {
 		// If the video doesn't exist, create it.
@@ -155,9 +292,10 @@ func post__NewVideoMomentHandler(response http.ResponseWriter, request *http.Req
 	}
 
 	video_moment_instance := video_moment_models.VideoMoment{
-		VideoUUID:   video_instance.VideoUUID,
-		MomentTime:  request_body.MomentTime,
-		MomentTitle: request_body.MomentTitle,
+		VideoUUID:     video_instance.VideoUUID,
+		MomentTime:    request_body.MomentTime,
+		MomentEndTime: request_body.MomentEndTime,
+		MomentTitle:   request_body.MomentTitle,
 	}
 
 	moment_id, err := repository.VideoMomentsRepo.AddVideoMomentCTX(request.Context(), video_moment_instance)
@@ -170,6 +308,66 @@ func post__NewVideoMomentHandler(response http.ResponseWriter, request *http.Req
 	dungeon_helpers.WriteSingleIntResponseWithStatus(response, moment_id, 201)
 }
 
+// Creates video moments from a chapters file sent as the request body. For the embedded format the body is ignored and the chapters
+// are read from the video file itself by the medias service.
+func post__ImportVideoChaptersHandler(response http.ResponseWriter, request *http.Request) {
+	request_params, err := metadata_requests.ParseVideoChaptersParams(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.post__ImportVideoChaptersHandler: error parsing request params\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 400, "Malformed request")
+		return
+	}
+
+	if request_params.VideoUUID == "" || request_params.VideoCluster == "" {
+		echo.Echo(echo.RedFG, "In handlers/video_moments.post__ImportVideoChaptersHandler: request was malformed, either video_uuid or video_cluster was empty")
+		dungeon_helpers.WriteRejection(response, 400, "Missing parameters")
+		return
+	}
+
+	var chapters []dungeon_models.MediaChapter
+
+	switch request_params.Format {
+	case metadata_requests.VideoMoments_ChaptersFormat_Embedded:
+		chapters, err = communication.Medias.GetMediaChapters(request_params.VideoUUID)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.post__ImportVideoChaptersHandler: error getting embedded chapters\n\n%s", err))
+			dungeon_helpers.WriteRejection(response, 502, "Could not read the video chapters")
+			return
+		}
+	case metadata_requests.VideoMoments_ChaptersFormat_WebVTT, metadata_requests.VideoMoments_ChaptersFormat_FFMetadata:
+		chapters_file, err := io.ReadAll(http.MaxBytesReader(response, request.Body, max_chapters_file_size))
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.post__ImportVideoChaptersHandler: error reading chapters file\n\n%s", err))
+			dungeon_helpers.WriteRejection(response, 413, "Chapters file too large")
+			return
+		}
+
+		if request_params.Format == metadata_requests.VideoMoments_ChaptersFormat_WebVTT {
+			chapters, err = service_helpers.ParseWebVTTChapters(chapters_file)
+		} else {
+			chapters, err = service_helpers.ParseFFMetadataChapters(chapters_file)
+		}
+
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.post__ImportVideoChaptersHandler: error parsing chapters file\n\n%s", err))
+			dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("Invalid chapters file: %s", err))
+			return
+		}
+	default:
+		dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("Unsupported chapters format '%s'", request_params.Format))
+		return
+	}
+
+	imported_count, err := workflows.ImportVideoChaptersCTX(request.Context(), video_moment_models.Video{VideoUUID: request_params.VideoUUID, VideoCluster: request_params.VideoCluster}, chapters, request_params.Replace)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.post__ImportVideoChaptersHandler: error importing chapters\n\n%s", err))
+		dungeon_helpers.WriteRejection(response, 500, "Error importing chapters")
+		return
+	}
+
+	dungeon_helpers.WriteSingleIntResponseWithStatus(response, imported_count, 201)
+}
+
 func patchVideoMomentsHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
 	return
@@ -242,6 +440,12 @@ func put__VideoMomentDataHandler(response http.ResponseWriter, request *http.Req
 		return
 	}
 
+	if !request_body.HasValidRange() {
+		echo.Echo(echo.RedFG, "In handlers/video_moments.put__VideoMomentDataHandler: moment_end_time must be greater than moment_time")
+		dungeon_helpers.WriteRejection(response, 400, "Invalid moment range")
+		return
+	}
+
 	moment, err := repository.VideoMomentsRepo.GetVideoMomentCTX(request.Context(), request_body.MomentID)
 	if err != nil {
 		echo.Echo(echo.RedFG, fmt.Sprintf("In handlers/video_moments.put__VideoMomentDataHandler: error getting video moment\n\n%s", err))
@@ -251,6 +455,7 @@ func put__VideoMomentDataHandler(response http.ResponseWriter, request *http.Req
 
 	moment.MomentTitle = request_body.MomentTitle
 	moment.MomentTime = request_body.MomentTime
+	moment.MomentEndTime = request_body.MomentEndTime
 
 	err = repository.VideoMomentsRepo.UpdateVideoMomentCTX(request.Context(), *moment)
 	if err != nil {
@@ -0,0 +1,353 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	webvtt_header          string = "WEBVTT"
+	webvtt_cue_separator   string = "-->"
+	ffmetadata_header      string = ";FFMETADATA1"
+	ffmetadata_chapter     string = "[CHAPTER]"
+	ffmetadata_special     string = "=;#\\"
+	ffmetadata_ms_timebase string = "1/1000"
+)
+
+/* ---------------------------------- WebVTT --------------------------------- */
+
+// Parses the cues of a WebVTT file as chapters. The cue payload is used as the chapter title, multiline payloads are joined with a space.
+func ParseWebVTTChapters(webvtt_content []byte) ([]dungeon_models.MediaChapter, error) {
+	var chapters []dungeon_models.MediaChapter = make([]dungeon_models.MediaChapter, 0)
+
+	webvtt_content = bytes.TrimPrefix(webvtt_content, []byte("\xef\xbb\xbf")) // utf-8 BOM
+
+	scanner := bufio.NewScanner(bytes.NewReader(webvtt_content))
+
+	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), webvtt_header) {
+		return nil, fmt.Errorf("Content is not a WebVTT file, missing '%s' header", webvtt_header)
+	}
+
+	var current_chapter *dungeon_models.MediaChapter
+	var title_lines []string
+
+	closeCue := func() {
+		if current_chapter != nil {
+			current_chapter.Title = strings.Join(title_lines, " ")
+			chapters = append(chapters, *current_chapter)
+		}
+
+		current_chapter = nil
+		title_lines = nil
+	}
+
+	for line_number := 2; scanner.Scan(); line_number++ {
+		var line string = strings.TrimSpace(scanner.Text())
+
+		if line == "" {
+			closeCue()
+			continue
+		}
+
+		if current_chapter != nil {
+			title_lines = append(title_lines, line)
+			continue
+		}
+
+		if !strings.Contains(line, webvtt_cue_separator) {
+			// Cue identifiers, NOTE, STYLE and REGION blocks are not chapters.
+			continue
+		}
+
+		cue_times := strings.SplitN(line, webvtt_cue_separator, 2)
+
+		start_time, err := parseWebVTTTimestamp(strings.TrimSpace(cue_times[0]))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cue start time on line %d: %s", line_number, err)
+		}
+
+		end_time_fields := strings.Fields(cue_times[1]) // Cue settings may follow the end time.
+		if len(end_time_fields) == 0 {
+			return nil, fmt.Errorf("Missing cue end time on line %d", line_number)
+		}
+
+		end_time, err := parseWebVTTTimestamp(end_time_fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cue end time on line %d: %s", line_number, err)
+		}
+
+		current_chapter = &dungeon_models.MediaChapter{
+			StartTime: start_time,
+			EndTime:   end_time,
+		}
+	}
+
+	closeCue()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+// Parses a WebVTT timestamp([hh:]mm:ss.ttt) into milliseconds.
+func parseWebVTTTimestamp(timestamp string) (int64, error) {
+	clock_and_fraction := strings.SplitN(timestamp, ".", 2)
+	if len(clock_and_fraction) != 2 || len(clock_and_fraction[1]) != 3 {
+		return 0, fmt.Errorf("Malformed timestamp '%s'", timestamp)
+	}
+
+	milliseconds, err := strconv.ParseInt(clock_and_fraction[1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Malformed timestamp '%s'", timestamp)
+	}
+
+	clock_parts := strings.Split(clock_and_fraction[0], ":")
+	if len(clock_parts) < 2 || len(clock_parts) > 3 {
+		return 0, fmt.Errorf("Malformed timestamp '%s'", timestamp)
+	}
+
+	var total_seconds int64 = 0
+
+	for _, clock_part := range clock_parts {
+		clock_value, err := strconv.ParseInt(clock_part, 10, 64)
+		if err != nil || clock_value < 0 {
+			return 0, fmt.Errorf("Malformed timestamp '%s'", timestamp)
+		}
+
+		total_seconds = total_seconds*60 + clock_value
+	}
+
+	return total_seconds*1000 + milliseconds, nil
+}
+
+func formatWebVTTTimestamp(milliseconds int64) string {
+	var hours int64 = milliseconds / 3_600_000
+	var minutes int64 = (milliseconds / 60_000) % 60
+	var seconds int64 = (milliseconds / 1000) % 60
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds%1000)
+}
+
+// Encodes the chapters as a WebVTT chapters track.
+func EncodeWebVTTChapters(chapters []dungeon_models.MediaChapter) []byte {
+	var webvtt_builder strings.Builder
+
+	webvtt_builder.WriteString(webvtt_header + "\n")
+
+	for h, chapter := range chapters {
+		// Cue payloads cannot contain blank lines nor the cue separator.
+		var cue_title string = strings.Join(strings.Fields(chapter.Title), " ")
+		cue_title = strings.ReplaceAll(cue_title, webvtt_cue_separator, "->")
+
+		webvtt_builder.WriteString(fmt.Sprintf("\n%d\n%s %s %s\n%s\n", h+1, formatWebVTTTimestamp(chapter.StartTime), webvtt_cue_separator, formatWebVTTTimestamp(chapter.EndTime), cue_title))
+	}
+
+	return []byte(webvtt_builder.String())
+}
+
+/* -------------------------------- FFmetadata ------------------------------- */
+
+// Parses the chapter sections of an ffmpeg metadata file(the format produced by 'ffmpeg -f ffmetadata').
+func ParseFFMetadataChapters(ffmetadata_content []byte) ([]dungeon_models.MediaChapter, error) {
+	var chapters []dungeon_models.MediaChapter = make([]dungeon_models.MediaChapter, 0)
+
+	var ffmetadata_lines []string = splitFFMetadataLines(ffmetadata_content)
+
+	if len(ffmetadata_lines) == 0 || ffmetadata_lines[0] != ffmetadata_header {
+		return nil, fmt.Errorf("Content is not an ffmetadata file, missing '%s' header", ffmetadata_header)
+	}
+
+	var chapter_fields map[string]string
+
+	closeChapter := func() error {
+		if chapter_fields == nil {
+			return nil
+		}
+
+		chapter, err := ffmetadataFieldsToChapter(chapter_fields)
+		if err != nil {
+			return fmt.Errorf("Invalid chapter %d: %s", len(chapters)+1, err)
+		}
+
+		chapters = append(chapters, chapter)
+		chapter_fields = nil
+
+		return nil
+	}
+
+	for _, line := range ffmetadata_lines[1:] {
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			err := closeChapter()
+			if err != nil {
+				return nil, err
+			}
+
+			if line == ffmetadata_chapter {
+				chapter_fields = make(map[string]string)
+			}
+
+			continue
+		}
+
+		if chapter_fields == nil {
+			continue // Global or stream metadata.
+		}
+
+		key, value, found := cutFFMetadataPair(line)
+		if found {
+			chapter_fields[strings.ToLower(key)] = value
+		}
+	}
+
+	err := closeChapter()
+	if err != nil {
+		return nil, err
+	}
+
+	return chapters, nil
+}
+
+// Splits ffmetadata content into logical lines. A backslash escapes the next character, including newlines.
+func splitFFMetadataLines(ffmetadata_content []byte) []string {
+	var lines []string = make([]string, 0)
+	var current_line strings.Builder
+
+	ffmetadata_content = bytes.ReplaceAll(ffmetadata_content, []byte("\r\n"), []byte("\n"))
+
+	for h := 0; h < len(ffmetadata_content); h++ {
+		var current_byte byte = ffmetadata_content[h]
+
+		if current_byte == '\\' && h+1 < len(ffmetadata_content) {
+			current_line.WriteByte('\\')
+			current_line.WriteByte(ffmetadata_content[h+1])
+			h++
+			continue
+		}
+
+		if current_byte == '\n' {
+			lines = append(lines, current_line.String())
+			current_line.Reset()
+			continue
+		}
+
+		current_line.WriteByte(current_byte)
+	}
+
+	if current_line.Len() > 0 {
+		lines = append(lines, current_line.String())
+	}
+
+	return lines
+}
+
+// Splits an escaped 'key=value' line on the first unescaped '=' and unescapes both sides.
+func cutFFMetadataPair(line string) (key string, value string, found bool) {
+	for h := 0; h < len(line); h++ {
+		if line[h] == '\\' {
+			h++
+			continue
+		}
+
+		if line[h] == '=' {
+			return unescapeFFMetadata(line[:h]), unescapeFFMetadata(line[h+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+func unescapeFFMetadata(escaped string) string {
+	var unescaped strings.Builder
+
+	for h := 0; h < len(escaped); h++ {
+		if escaped[h] == '\\' && h+1 < len(escaped) {
+			h++
+		}
+
+		unescaped.WriteByte(escaped[h])
+	}
+
+	return unescaped.String()
+}
+
+func escapeFFMetadata(unescaped string) string {
+	var escaped strings.Builder
+
+	for _, character := range unescaped {
+		if character == '\n' || strings.ContainsRune(ffmetadata_special, character) {
+			escaped.WriteByte('\\')
+		}
+
+		escaped.WriteRune(character)
+	}
+
+	return escaped.String()
+}
+
+func ffmetadataFieldsToChapter(chapter_fields map[string]string) (dungeon_models.MediaChapter, error) {
+	var chapter dungeon_models.MediaChapter
+
+	var timebase string = chapter_fields["timebase"]
+	if timebase == "" {
+		timebase = "1/1000000000" // ffmpeg assumes nanoseconds when the timebase is missing.
+	}
+
+	timebase_rat, valid := new(big.Rat).SetString(timebase)
+	if !valid || timebase_rat.Sign() <= 0 {
+		return chapter, fmt.Errorf("Malformed timebase '%s'", timebase)
+	}
+
+	start_time, err := ffmetadataTimeToMilliseconds(chapter_fields["start"], timebase_rat)
+	if err != nil {
+		return chapter, err
+	}
+
+	end_time, err := ffmetadataTimeToMilliseconds(chapter_fields["end"], timebase_rat)
+	if err != nil {
+		return chapter, err
+	}
+
+	chapter.StartTime = start_time
+	chapter.EndTime = end_time
+	chapter.Title = chapter_fields["title"]
+
+	return chapter, nil
+}
+
+func ffmetadataTimeToMilliseconds(time_value string, timebase *big.Rat) (int64, error) {
+	time_units, valid := new(big.Rat).SetString(time_value)
+	if !valid || !time_units.IsInt() {
+		return 0, fmt.Errorf("Malformed chapter time '%s'", time_value)
+	}
+
+	milliseconds := new(big.Rat).Mul(time_units, timebase)
+	milliseconds.Mul(milliseconds, big.NewRat(1000, 1))
+
+	milliseconds_int := new(big.Int).Quo(milliseconds.Num(), milliseconds.Denom())
+
+	return milliseconds_int.Int64(), nil
+}
+
+// Encodes the chapters as an ffmpeg metadata file using a millisecond timebase.
+func EncodeFFMetadataChapters(chapters []dungeon_models.MediaChapter) []byte {
+	var ffmetadata_builder strings.Builder
+
+	ffmetadata_builder.WriteString(ffmetadata_header + "\n")
+
+	for _, chapter := range chapters {
+		ffmetadata_builder.WriteString(fmt.Sprintf("\n%s\nTIMEBASE=%s\nSTART=%d\nEND=%d\ntitle=%s\n", ffmetadata_chapter, ffmetadata_ms_timebase, chapter.StartTime, chapter.EndTime, escapeFFMetadata(chapter.Title)))
+	}
+
+	return []byte(ffmetadata_builder.String())
+}
@@ -14,6 +14,8 @@ import (
 	"libery-metadata-service/handlers/dungeon_tags_handler"
 	"libery-metadata-service/repository"
 	"libery-metadata-service/server"
+	"libery-metadata-service/workflows/workers"
+	"time"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -60,6 +62,14 @@ func main() {
 
 	repository.SetDungeonTagsRepository(dungeon_tags_impl)
 
+	// ----------------- Workers -----------------
+
+	var orphan_sweeper *workers.OrphanSweeper
+
+	if app_config.ORPHAN_SWEEP_INTERVAL_MINUTES > 0 {
+		orphan_sweeper = workers.NewOrphanSweeper(time.Duration(app_config.ORPHAN_SWEEP_INTERVAL_MINUTES) * time.Minute)
+	}
+
 	// ----------------- Services -----------------
 
 	var new_server_config *libery_networking.ServerConfig = new(libery_networking.ServerConfig)
@@ -82,7 +92,13 @@ func main() {
 
 	metadata_service.SetGrpcServer(grpc_server)
 
-	metadata_service.OnAfterShutdown(app_config.ClosePlatformCommunication)
+	metadata_service.OnAfterShutdown(func() {
+		if orphan_sweeper != nil {
+			orphan_sweeper.Stop()
+		}
+
+		app_config.ClosePlatformCommunication()
+	})
 
 	metadata_service.StartServer(BinderRoutes)
 }
@@ -19,6 +19,7 @@ type DungeonTag struct {
 	Name         string `json:"name"`
 	Taxonomy     string `json:"taxonomy"`      // The UUID of the taxonomy the tag belongs to
 	NameTaxonomy string `json:"name_taxonomy"` // A hash of the Name and Taxonomy fields. Meant to keep name uniqueness within a taxonomy
+	ParentID     int64  `json:"parent_id"`     // The id of the parent tag within the same taxonomy, 0 for root tags. Querying a tag matches its descendants too.
 }
 
 func (dt *DungeonTag) RecalculateNameTaxonomy() {
@@ -27,6 +28,14 @@ func (dt *DungeonTag) RecalculateNameTaxonomy() {
 	dt.NameTaxonomy = dungeon_helpers.GenerateSha1ID(new_source)
 }
 
+// An alternative name that resolves to a canonical tag. Alias names are unique within a taxonomy and can't shadow a tag name.
+type TagAlias struct {
+	ID       int64  `json:"id"`
+	Alias    string `json:"alias"`
+	TagID    int64  `json:"tag_id"`
+	Taxonomy string `json:"taxonomy"`
+}
+
 type DungeonTagging struct {
 	TaggingID        int64       `json:"tagging_id"`
 	EntityType       string      `json:"entity_type"`
@@ -8,4 +8,8 @@ const (
 	ErrInvalidWatchPointSize dungeon_models.ErrorLabel = "Invalid watch point size"
 	ErrEndOfStream           dungeon_models.ErrorLabel = "End of stream"
 	ErrWatchPointNotFound    dungeon_models.ErrorLabel = "Watch point not found"
+	ErrTagNotFound           dungeon_models.ErrorLabel = "Dungeon tag not found"
+	ErrTagNameTaken          dungeon_models.ErrorLabel = "Dungeon tag name or alias already taken"
+	ErrTagHierarchyCycle     dungeon_models.ErrorLabel = "Dungeon tag hierarchy cycle"
+	ErrTagTaxonomyMismatch   dungeon_models.ErrorLabel = "Dungeon tags belong to different taxonomies"
 )
@@ -1,18 +1,32 @@
 package video_moment_models
 
+import dungeon_models "libery-dungeon-libs/models"
+
 type Video struct {
 	VideoUUID    string `json:"video_uuid"`
 	VideoCluster string `json:"video_cluster"`
 }
 
 type VideoMoment struct {
-	ID          int    `json:"id"`
-	VideoUUID   string `json:"video_uuid"`
-	MomentTitle string `json:"moment_title"`
-	MomentTime  int    `json:"moment_time"`
+	ID            int    `json:"id"`
+	VideoUUID     string `json:"video_uuid"`
+	MomentTitle   string `json:"moment_title"`
+	MomentTime    int    `json:"moment_time"`
+	MomentEndTime int    `json:"moment_end_time"` // 0 means the moment is a single point in time rather than a range.
+}
+
+func (video_moment VideoMoment) IsRange() bool {
+	return video_moment.MomentEndTime > video_moment.MomentTime
 }
 
 type VideoMoments struct {
 	Video
 	Moments []VideoMoment `json:"moments"`
 }
+
+// A moment matched by a moment search, along with what's needed to show and play the scene it points to.
+type VideoMomentSearchResult struct {
+	VideoMoment
+	MediaIdentity *dungeon_models.MediaIdentity `json:"media_identity"`
+	ThumbnailURL  string                        `json:"thumbnail_url"` // Relative to the medias service, shows the frame at the moment time.
+}
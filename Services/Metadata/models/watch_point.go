@@ -145,6 +145,38 @@ func (w WatchPointStream) ReadWatchPoint(ord uint16) (*WatchPoint, *dungeon_mode
 	return WatchPointFromBytes(watch_point_bytes)
 }
 
+// Removes the watch points owned by any of the given media uuids. As the ord of a watch point is its position on the stream,
+// the remaining watch points are re-written with new ords. Returns the amount of removed watch points.
+func (w *WatchPointStream) RemoveWatchPoints(media_uuids map[string]struct{}) (int, *dungeon_models.LabeledError) {
+	var kept_watch_points *WatchPointStream = NewWatchPointStream()
+	var removed_count int = 0
+
+	w.Seek(0, io.SeekStart)
+
+	for {
+		watch_p, lerr := w.Yield()
+		if lerr != nil {
+			if lerr.Label == ErrEndOfStream {
+				break
+			}
+
+			return 0, lerr
+		}
+
+		if _, removed := media_uuids[watch_p.MediaUUID]; removed {
+			removed_count++
+			continue
+		}
+
+		kept_watch_points.AddWatchPoint(watch_p.MediaUUID, watch_p.StartTime)
+	}
+
+	w.WatchPoints = kept_watch_points.WatchPoints
+	w.SeekPoint = 0
+
+	return removed_count, nil
+}
+
 func (w *WatchPointStream) Seek(offset int64, whence int) (int64, error) {
 	switch whence {
 	case io.SeekStart:
@@ -4,7 +4,9 @@ import "libery-metadata-service/models"
 
 type CategoriesConfigurationRepository interface {
 	CreateCategoryConfig(category_uuid string) *models.CategoryConfig // Creates a new category config with default values.
+	DeleteCategoryConfig(category_uuid string) error
 	GetCategoryConfig(category_uuid string) *models.CategoryConfig
+	GetAllCategoryConfigs() ([]*models.CategoryConfig, error) // Returns every category config stored, not just the cached ones.
 	UpdateCategoryConfig(category_config *models.CategoryConfig) error
 }
 
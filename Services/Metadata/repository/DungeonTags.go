@@ -10,10 +10,14 @@ type DungeonTagsRepository interface {
 	CreateTaxonomy(taxonomy *service_models.TagTaxonomy) error
 	CreateTagCTX(ctx context.Context, tag *service_models.DungeonTag) error
 	CreateTag(tag *service_models.DungeonTag) error
+	CreateTagAliasCTX(ctx context.Context, tag_alias *service_models.TagAlias) error
+	CreateTagAlias(tag_alias *service_models.TagAlias) error
 	DeleteTaxonomyCTX(ctx context.Context, taxonomy_uuid string) error
 	DeleteTaxonomy(taxonomy_uuid string) error
 	DeleteTagCTX(ctx context.Context, tag_id int) error
 	DeleteTag(tag_id int) error
+	DeleteTagAliasCTX(ctx context.Context, alias_id int) error
+	DeleteTagAlias(alias_id int) error
 	GetGlobalTaxonomiesCTX(ctx context.Context) ([]service_models.TagTaxonomy, error)
 	GetGlobalTaxonomies() ([]service_models.TagTaxonomy, error)
 	GetClusterTaxonomiesCTX(ctx context.Context, cluster_uuid string) ([]service_models.TagTaxonomy, error)
@@ -30,6 +34,12 @@ type DungeonTagsRepository interface {
 	GetTagsByIDs(tag_ids []int) ([]service_models.DungeonTag, error)
 	GetTagByNameCTX(ctx context.Context, tag_name, taxonomy string) (service_models.DungeonTag, error)
 	GetTagByName(tag_name, taxonomy string) (service_models.DungeonTag, error)
+	GetTagByAliasCTX(ctx context.Context, alias, taxonomy string) (service_models.DungeonTag, error)
+	GetTagByAlias(alias, taxonomy string) (service_models.DungeonTag, error)
+	GetTagAliasesCTX(ctx context.Context, tag_id int) ([]service_models.TagAlias, error)
+	GetTagAliases(tag_id int) ([]service_models.TagAlias, error)
+	GetTagDescendantsCTX(ctx context.Context, tag_id int) ([]service_models.DungeonTag, error)
+	GetTagDescendants(tag_id int) ([]service_models.DungeonTag, error)
 	GetTaxonomyTagsCTX(ctx context.Context, taxonomy_uuid string) ([]service_models.DungeonTag, error)
 	GetTaxonomyTags(taxonomy_uuid string) ([]service_models.DungeonTag, error)
 	GetTagTaxonomyCTX(ctx context.Context, taxonomy_uuid string) (service_models.TagTaxonomy, error)
@@ -38,6 +48,10 @@ type DungeonTagsRepository interface {
 	GetEntityTaggings(entity_uuid, cluster_domain string) ([]service_models.DungeonTagging, error)
 	GetEntitiesWithTaggingsCTX(ctx context.Context, tags []int) ([]service_models.DungeonTaggingCompact, error)
 	GetEntitiesWithTaggings(tags []int) ([]service_models.DungeonTaggingCompact, error)
+	GetTaggedEntitiesByTypeCTX(ctx context.Context, entity_type string) ([]string, error)
+	GetTaggedEntitiesByType(entity_type string) ([]string, error)
+	MergeTagsCTX(ctx context.Context, source_tag service_models.DungeonTag, target_tag_id int, lift_target bool) error
+	MergeTags(source_tag service_models.DungeonTag, target_tag_id int, lift_target bool) error
 	MultiTagEntityCTX(ctx context.Context, tag_ids []int, entity_uuid string, entity_type string) error
 	MultiTagEntity(tag_ids []int, entity_uuid string, entity_type string) error
 	MultiTagEntitiesCTX(ctx context.Context, tag_ids []int, entities_uuids []string, entity_type string) error
@@ -56,6 +70,8 @@ type DungeonTagsRepository interface {
 	UpdateTaxonomyName(taxonomy_uuid, new_name string) error
 	UpdateTagNameCTX(ctx context.Context, tag_id int, new_name string) error
 	UpdateTagName(tag_id int, new_name string) error
+	UpdateTagParentCTX(ctx context.Context, tag_id int, parent_id int) error
+	UpdateTagParent(tag_id int, parent_id int) error
 }
 
 var DungeonTagsRepo DungeonTagsRepository
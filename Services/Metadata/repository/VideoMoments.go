@@ -12,6 +12,10 @@ type VideoMomentsRepository interface {
 	AddVideoMoment(video_moment video_moment_models.VideoMoment) (int, error)
 	DeleteVideoMomentCTX(ctx context.Context, video_moment video_moment_models.VideoMoment) error
 	DeleteVideoMoment(video_moment video_moment_models.VideoMoment) error
+	DeleteVideosCTX(ctx context.Context, video_uuids []string) error
+	DeleteVideos(video_uuids []string) error
+	GetAllVideoUUIDsCTX(ctx context.Context) ([]string, error)
+	GetAllVideoUUIDs() ([]string, error)
 	GetVideoCTX(ctx context.Context, video_uuid string, cluster_uuid string) (*video_moment_models.Video, error)
 	GetVideo(video_uuid string, cluster_uuid string) (*video_moment_models.Video, error)
 	GetVideoMomentCTX(ctx context.Context, moment_id int) (*video_moment_models.VideoMoment, error)
@@ -24,6 +28,8 @@ type VideoMomentsRepository interface {
 	GetClusterMoments(cluster_uuid string) ([]video_moment_models.VideoMoment, error)
 	GetClusterVideoMomentsCTX(ctx context.Context, cluster_uuid string) ([]video_moment_models.VideoMoments, error)
 	GetClusterVideoMoments(cluster_uuid string) ([]video_moment_models.VideoMoments, error)
+	ReplaceVideoMomentsCTX(ctx context.Context, video_uuid string, video_moments []video_moment_models.VideoMoment) error
+	ReplaceVideoMoments(video_uuid string, video_moments []video_moment_models.VideoMoment) error
 	UpdateVideoMomentCTX(ctx context.Context, video_moment video_moment_models.VideoMoment) error
 	UpdateVideoMoment(video_moment video_moment_models.VideoMoment) error
 }
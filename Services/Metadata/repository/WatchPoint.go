@@ -10,6 +10,8 @@ type WatchPointRepository interface {
 	InsertWatchPoint(ctx context.Context, media_uuid string, start_time uint32) *dungeon_models.LabeledError
 	GetWatchPointByMediaID(ctx context.Context, media_uuid string) (*models.WatchPoint, *dungeon_models.LabeledError)
 	GetWatchPointByORD(ctx context.Context, ord uint16) (*models.WatchPoint, *dungeon_models.LabeledError)
+	GetWatchPointMedias(ctx context.Context) []string
+	DeleteWatchPoints(ctx context.Context, media_uuids []string) *dungeon_models.LabeledError
 	Close() error
 }
 
@@ -165,7 +165,7 @@ func (ms *MetadataGrpcServer) DeleteEntitiesTaggings(ctx context.Context, reques
 
 	err := repository.DungeonTagsRepo.RemoveAllTaggingsForEntitiesCTX(ctx, entity_uuids)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("Error while deleting taggings for %d entities"))
+		echo.Echo(echo.RedFG, fmt.Sprintf("Error while deleting taggings for %d entities", len(entity_uuids)))
 		err = errors.Join(fmt.Errorf("In server/grpc_metadata_server.DeleteEntitiesTaggings: Error while calling repository.DungeonTagsRepo.RemoveAllTaggingsForEntitiesCTX"), err)
 		echo.EchoErr(err)
 		response.Response = false
@@ -174,6 +174,24 @@ func (ms *MetadataGrpcServer) DeleteEntitiesTaggings(ctx context.Context, reques
 	return response, err
 }
 
+func (ms *MetadataGrpcServer) NotifyEntitiesDeleted(ctx context.Context, request *metadata_service_pb.EntityList) (*metadata_service_pb.BooleanResponse, error) {
+	var response *metadata_service_pb.BooleanResponse = new(metadata_service_pb.BooleanResponse)
+	entity_uuids := request.EntitiesUuids
+
+	echo.Echo(echo.BlueFG, fmt.Sprintf("Purging metadata for %d deleted entities", len(entity_uuids)))
+
+	response.Response = true
+
+	err := workflows.PurgeDeletedEntitiesCTX(ctx, entity_uuids)
+	if err != nil {
+		err = errors.Join(fmt.Errorf("In server/grpc_metadata_server.NotifyEntitiesDeleted: Error while calling workflows.PurgeDeletedEntitiesCTX"), err)
+		echo.EchoErr(err)
+		response.Response = false
+	}
+
+	return response, err
+}
+
 func (ms *MetadataGrpcServer) Connect() error {
 	listener, err := net.Listen("tcp", ms.port)
 	if err != nil {
@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	service_models "libery-metadata-service/models"
 	"libery-metadata-service/repository"
+	"strings"
 )
 
 func CopyEntityTagsToTagListCTX(ctx context.Context, entity_uuid string, entities_uuids []string, cluster_domain, entities_type string) error {
@@ -22,3 +25,150 @@ func CopyEntityTagsToTagListCTX(ctx context.Context, entity_uuid string, entitie
 
 	return nil
 }
+
+// Returns the tag with the given name on a taxonomy, falling back to the canonical tag of an alias with that name.
+func ResolveTagByNameCTX(ctx context.Context, tag_name, taxonomy string) (service_models.DungeonTag, error) {
+	tag, err := repository.DungeonTagsRepo.GetTagByNameCTX(ctx, tag_name, taxonomy)
+	if err == nil {
+		return tag, nil
+	}
+
+	tag, alias_err := repository.DungeonTagsRepo.GetTagByAliasCTX(ctx, tag_name, taxonomy)
+	if alias_err != nil {
+		return tag, errors.Join(fmt.Errorf("In workflows/dungeon_tags.ResolveTagByNameCTX: No tag or alias named '%s' in taxonomy<%s>", tag_name, taxonomy), err, alias_err)
+	}
+
+	return tag, nil
+}
+
+// Creates an alias for a tag. Alias names are lowercased like tag names and can't collide with a tag name or another alias on the same taxonomy.
+func CreateTagAliasCTX(ctx context.Context, tag_id int, alias string) (*service_models.TagAlias, *dungeon_models.LabeledError) {
+	tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, tag_id)
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.CreateTagAliasCTX: While getting tag<%d>", tag_id), service_models.ErrTagNotFound)
+	}
+
+	alias = strings.ToLower(strings.TrimSpace(alias))
+
+	if _, err = ResolveTagByNameCTX(ctx, alias, tag.Taxonomy); err == nil {
+		return nil, dungeon_models.NewLabeledError(fmt.Errorf("'%s' is already used on taxonomy<%s>", alias, tag.Taxonomy), "In workflows/dungeon_tags.CreateTagAliasCTX", service_models.ErrTagNameTaken)
+	}
+
+	var tag_alias *service_models.TagAlias = &service_models.TagAlias{
+		Alias:    alias,
+		TagID:    tag.ID,
+		Taxonomy: tag.Taxonomy,
+	}
+
+	err = repository.DungeonTagsRepo.CreateTagAliasCTX(ctx, tag_alias)
+	if err != nil {
+		return nil, dungeon_models.NewLabeledError(err, "In workflows/dungeon_tags.CreateTagAliasCTX: While creating alias", dungeon_models.ErrProcessError)
+	}
+
+	return tag_alias, nil
+}
+
+// Renames a tag. Like on creation the name is lowercased and it can't collide with another tag name or an alias on the tag's taxonomy.
+func RenameTagCTX(ctx context.Context, tag_id int, new_name string) *dungeon_models.LabeledError {
+	tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, tag_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.RenameTagCTX: While getting tag<%d>", tag_id), service_models.ErrTagNotFound)
+	}
+
+	new_name = strings.ToLower(strings.TrimSpace(new_name))
+
+	if named_tag, err := ResolveTagByNameCTX(ctx, new_name, tag.Taxonomy); err == nil && named_tag.ID != tag.ID {
+		return dungeon_models.NewLabeledError(fmt.Errorf("'%s' is already used on taxonomy<%s>", new_name, tag.Taxonomy), "In workflows/dungeon_tags.RenameTagCTX", service_models.ErrTagNameTaken)
+	}
+
+	err = repository.DungeonTagsRepo.UpdateTagNameCTX(ctx, tag_id, new_name)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In workflows/dungeon_tags.RenameTagCTX: While updating tag name", dungeon_models.ErrProcessError)
+	}
+
+	return nil
+}
+
+// Sets the parent of a tag, parent_id 0 turns it into a root tag. Both tags must share a taxonomy and the parent can't be the tag itself or one of its descendants.
+func SetTagParentCTX(ctx context.Context, tag_id, parent_id int) *dungeon_models.LabeledError {
+	tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, tag_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.SetTagParentCTX: While getting tag<%d>", tag_id), service_models.ErrTagNotFound)
+	}
+
+	if parent_id != 0 {
+		parent_tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, parent_id)
+		if err != nil {
+			return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.SetTagParentCTX: While getting parent tag<%d>", parent_id), service_models.ErrTagNotFound)
+		}
+
+		if parent_tag.Taxonomy != tag.Taxonomy {
+			return dungeon_models.NewLabeledError(fmt.Errorf("tag<%d> and parent tag<%d> are on different taxonomies", tag_id, parent_id), "In workflows/dungeon_tags.SetTagParentCTX", service_models.ErrTagTaxonomyMismatch)
+		}
+
+		if parent_tag.ID == tag.ID {
+			return dungeon_models.NewLabeledError(fmt.Errorf("tag<%d> can't be its own parent", tag_id), "In workflows/dungeon_tags.SetTagParentCTX", service_models.ErrTagHierarchyCycle)
+		}
+
+		descendants, err := repository.DungeonTagsRepo.GetTagDescendantsCTX(ctx, tag_id)
+		if err != nil {
+			return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.SetTagParentCTX: While getting tag<%d> descendants", tag_id), dungeon_models.ErrProcessError)
+		}
+
+		for _, descendant := range descendants {
+			if descendant.ID == parent_tag.ID {
+				return dungeon_models.NewLabeledError(fmt.Errorf("tag<%d> is a descendant of tag<%d>", parent_id, tag_id), "In workflows/dungeon_tags.SetTagParentCTX", service_models.ErrTagHierarchyCycle)
+			}
+		}
+	}
+
+	err = repository.DungeonTagsRepo.UpdateTagParentCTX(ctx, tag_id, parent_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In workflows/dungeon_tags.SetTagParentCTX: While updating tag parent", dungeon_models.ErrProcessError)
+	}
+
+	return nil
+}
+
+// Merges the source tag into the target tag. See DungeonTagsRepository.MergeTagsCTX for what gets moved. If the target tag is a
+// descendant of the source tag, it takes the source tag place on the hierarchy so no cycle is created.
+func MergeTagsCTX(ctx context.Context, source_tag_id, target_tag_id int) *dungeon_models.LabeledError {
+	if source_tag_id == target_tag_id {
+		return dungeon_models.NewLabeledError(fmt.Errorf("can't merge tag<%d> into itself", source_tag_id), "In workflows/dungeon_tags.MergeTagsCTX", dungeon_models.ErrPreconditionFailed)
+	}
+
+	source_tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, source_tag_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.MergeTagsCTX: While getting source tag<%d>", source_tag_id), service_models.ErrTagNotFound)
+	}
+
+	target_tag, err := repository.DungeonTagsRepo.GetTagByIdCTX(ctx, target_tag_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.MergeTagsCTX: While getting target tag<%d>", target_tag_id), service_models.ErrTagNotFound)
+	}
+
+	if source_tag.Taxonomy != target_tag.Taxonomy {
+		return dungeon_models.NewLabeledError(fmt.Errorf("tag<%d> and tag<%d> are on different taxonomies", source_tag_id, target_tag_id), "In workflows/dungeon_tags.MergeTagsCTX", service_models.ErrTagTaxonomyMismatch)
+	}
+
+	source_descendants, err := repository.DungeonTagsRepo.GetTagDescendantsCTX(ctx, source_tag_id)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, fmt.Sprintf("In workflows/dungeon_tags.MergeTagsCTX: While getting tag<%d> descendants", source_tag_id), dungeon_models.ErrProcessError)
+	}
+
+	var lift_target bool = false
+
+	for _, descendant := range source_descendants {
+		if descendant.ID == target_tag.ID {
+			lift_target = true
+			break
+		}
+	}
+
+	err = repository.DungeonTagsRepo.MergeTagsCTX(ctx, source_tag, target_tag_id, lift_target)
+	if err != nil {
+		return dungeon_models.NewLabeledError(err, "In workflows/dungeon_tags.MergeTagsCTX: While merging tags", dungeon_models.ErrProcessError)
+	}
+
+	return nil
+}
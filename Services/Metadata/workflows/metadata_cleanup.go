@@ -0,0 +1,224 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	dungeon_models "libery-dungeon-libs/models"
+	"libery-metadata-service/repository"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Max amount of uuids sent to the categories service on a single existence check.
+const orphan_check_batch_size int = 500
+
+// Removes all the metadata owned by the given entities. Entities can be medias or categories, everything that
+// references them (taggings, watch points, video moments, category configs and billboard references) is removed.
+// It attempts every cleanup step even if a previous one failed, the returned error joins all the failures.
+func PurgeDeletedEntitiesCTX(ctx context.Context, entities_uuids []string) error {
+	if len(entities_uuids) == 0 {
+		return nil
+	}
+
+	var purge_errors []error
+
+	err := repository.DungeonTagsRepo.RemoveAllTaggingsForEntitiesCTX(ctx, entities_uuids)
+	if err != nil {
+		purge_errors = append(purge_errors, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.PurgeDeletedEntitiesCTX: While removing taggings"), err))
+	}
+
+	lerr := repository.WatchPointRepo.DeleteWatchPoints(ctx, entities_uuids)
+	if lerr != nil {
+		purge_errors = append(purge_errors, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.PurgeDeletedEntitiesCTX: While removing watch points"), lerr))
+	}
+
+	err = repository.VideoMomentsRepo.DeleteVideosCTX(ctx, entities_uuids)
+	if err != nil {
+		purge_errors = append(purge_errors, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.PurgeDeletedEntitiesCTX: While removing video moments"), err))
+	}
+
+	err = removeCategoryConfigsReferences(entities_uuids)
+	if err != nil {
+		purge_errors = append(purge_errors, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.PurgeDeletedEntitiesCTX: While removing category configs references"), err))
+	}
+
+	return errors.Join(purge_errors...)
+}
+
+func PurgeDeletedEntities(entities_uuids []string) error {
+	return PurgeDeletedEntitiesCTX(context.Background(), entities_uuids)
+}
+
+// Deletes the category configs owned by any of the given uuids and removes them from the billboard medias of every other category config.
+func removeCategoryConfigsReferences(entities_uuids []string) error {
+	category_configs, err := repository.CategoriesConfigRepo.GetAllCategoryConfigs()
+	if err != nil {
+		return err
+	}
+
+	var removed_entities map[string]struct{} = make(map[string]struct{})
+	for _, entity_uuid := range entities_uuids {
+		removed_entities[entity_uuid] = struct{}{}
+	}
+
+	var update_errors []error
+
+	for _, category_config := range category_configs {
+		if _, removed := removed_entities[category_config.CategoryUUID]; removed {
+			err = repository.CategoriesConfigRepo.DeleteCategoryConfig(category_config.CategoryUUID)
+			if err != nil {
+				update_errors = append(update_errors, err)
+			}
+
+			continue
+		}
+
+		var kept_billboard_medias []string = make([]string, 0, len(category_config.BillboardMediaUUIDs))
+
+		for _, media_uuid := range category_config.BillboardMediaUUIDs {
+			if _, removed := removed_entities[media_uuid]; !removed {
+				kept_billboard_medias = append(kept_billboard_medias, media_uuid)
+			}
+		}
+
+		if len(kept_billboard_medias) == len(category_config.BillboardMediaUUIDs) {
+			continue
+		}
+
+		category_config.BillboardMediaUUIDs = kept_billboard_medias
+
+		err = repository.CategoriesConfigRepo.UpdateCategoryConfig(category_config)
+		if err != nil {
+			update_errors = append(update_errors, err)
+		}
+	}
+
+	return errors.Join(update_errors...)
+}
+
+// Cross-checks every media and category referenced by the metadata service against the categories service and purges
+// the metadata of the ones that no longer exist. If the categories service cannot be reached nothing is purged.
+func SweepOrphanedMetadataCTX(ctx context.Context) (int, error) {
+	referenced_medias, referenced_categories, err := collectReferencedEntities(ctx)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.SweepOrphanedMetadataCTX: While collecting referenced entities"), err)
+	}
+
+	orphaned_medias, err := findOrphans(referenced_medias, communication.Categories.FilterExistingMedias)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.SweepOrphanedMetadataCTX: While checking medias existence"), err)
+	}
+
+	orphaned_categories, err := findOrphans(referenced_categories, communication.Categories.FilterExistingCategories)
+	if err != nil {
+		return 0, errors.Join(fmt.Errorf("In workflows/metadata_cleanup.SweepOrphanedMetadataCTX: While checking categories existence"), err)
+	}
+
+	var orphaned_entities []string = append(orphaned_medias, orphaned_categories...)
+
+	if len(orphaned_entities) == 0 {
+		return 0, nil
+	}
+
+	echo.Echo(echo.YellowFG, fmt.Sprintf("Purging metadata of %d orphaned entities", len(orphaned_entities)))
+
+	err = PurgeDeletedEntitiesCTX(ctx, orphaned_entities)
+
+	return len(orphaned_entities), err
+}
+
+// Returns the deduplicated uuids of every media and category that owns some metadata.
+func collectReferencedEntities(ctx context.Context) (media_uuids []string, category_uuids []string, err error) {
+	var medias_set map[string]struct{} = make(map[string]struct{})
+	var categories_set map[string]struct{} = make(map[string]struct{})
+
+	for _, media_uuid := range repository.WatchPointRepo.GetWatchPointMedias(ctx) {
+		medias_set[media_uuid] = struct{}{}
+	}
+
+	video_uuids, err := repository.VideoMomentsRepo.GetAllVideoUUIDsCTX(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, video_uuid := range video_uuids {
+		medias_set[video_uuid] = struct{}{}
+	}
+
+	tagged_medias, err := repository.DungeonTagsRepo.GetTaggedEntitiesByTypeCTX(ctx, dungeon_models.ENTITY_TYPE_MEDIA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, media_uuid := range tagged_medias {
+		medias_set[media_uuid] = struct{}{}
+	}
+
+	tagged_categories, err := repository.DungeonTagsRepo.GetTaggedEntitiesByTypeCTX(ctx, dungeon_models.ENTITY_TYPE_CATEGORY)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, category_uuid := range tagged_categories {
+		categories_set[category_uuid] = struct{}{}
+	}
+
+	category_configs, err := repository.CategoriesConfigRepo.GetAllCategoryConfigs()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, category_config := range category_configs {
+		categories_set[category_config.CategoryUUID] = struct{}{}
+
+		for _, media_uuid := range category_config.BillboardMediaUUIDs {
+			medias_set[media_uuid] = struct{}{}
+		}
+	}
+
+	media_uuids = make([]string, 0, len(medias_set))
+	for media_uuid := range medias_set {
+		media_uuids = append(media_uuids, media_uuid)
+	}
+
+	category_uuids = make([]string, 0, len(categories_set))
+	for category_uuid := range categories_set {
+		category_uuids = append(category_uuids, category_uuid)
+	}
+
+	return media_uuids, category_uuids, nil
+}
+
+// Returns the uuids that the filter_existing function did not report as existing. uuids are checked in batches.
+func findOrphans(uuids []string, filter_existing func([]string) ([]string, error)) ([]string, error) {
+	var orphans []string = make([]string, 0)
+
+	for batch_start := 0; batch_start < len(uuids); batch_start += orphan_check_batch_size {
+		batch_end := batch_start + orphan_check_batch_size
+		if batch_end > len(uuids) {
+			batch_end = len(uuids)
+		}
+
+		var batch []string = uuids[batch_start:batch_end]
+
+		existing_uuids, err := filter_existing(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		var existing_set map[string]struct{} = make(map[string]struct{}, len(existing_uuids))
+		for _, existing_uuid := range existing_uuids {
+			existing_set[existing_uuid] = struct{}{}
+		}
+
+		for _, uuid := range batch {
+			if _, exists := existing_set[uuid]; !exists {
+				orphans = append(orphans, uuid)
+			}
+		}
+	}
+
+	return orphans, nil
+}
@@ -0,0 +1,150 @@
+package workflows
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	video_moment_models "libery-metadata-service/models/video_moments"
+	"libery-metadata-service/repository"
+	"sort"
+)
+
+var ErrVideoNotFound error = errors.New("Video not found")
+
+// Length given to the last exported chapter when the video duration is unknown.
+const default_last_chapter_length int64 = 1000
+
+// Creates a video moment for each of the given chapters. Chapters that start at the same time as an existing moment are skipped,
+// unless replace_existing is set, in which case the existing moments of the video are replaced by the chapters in a single transaction.
+// Returns the amount of created moments.
+func ImportVideoChaptersCTX(ctx context.Context, video_identifier video_moment_models.Video, chapters []dungeon_models.MediaChapter, replace_existing bool) (int, error) {
+	video, err := repository.VideoMomentsRepo.GetVideoCTX(ctx, video_identifier.VideoUUID, video_identifier.VideoCluster)
+	if err != nil {
+		// If the video doesn't exist, create it.
+		video = &video_identifier
+
+		err = repository.VideoMomentsRepo.AddVideoCTX(ctx, *video)
+		if err != nil {
+			return 0, errors.Join(fmt.Errorf("In workflows/video_chapters.ImportVideoChaptersCTX: While adding video<%s>", video_identifier.VideoUUID), err)
+		}
+	}
+
+	var taken_times map[int]struct{} = make(map[int]struct{})
+
+	if !replace_existing {
+		existing_moments, err := repository.VideoMomentsRepo.GetVideoMomentsCTX(ctx, video)
+		if err != nil {
+			return 0, errors.Join(fmt.Errorf("In workflows/video_chapters.ImportVideoChaptersCTX: While getting video<%s> moments", video.VideoUUID), err)
+		}
+
+		for _, existing_moment := range existing_moments {
+			taken_times[existing_moment.MomentTime] = struct{}{}
+		}
+	}
+
+	var new_moments []video_moment_models.VideoMoment = make([]video_moment_models.VideoMoment, 0, len(chapters))
+
+	for h, chapter := range chapters {
+		var moment_time int = int(chapter.StartTime)
+
+		if _, taken := taken_times[moment_time]; taken {
+			continue
+		}
+
+		var moment_title string = chapter.Title
+		if moment_title == "" {
+			moment_title = fmt.Sprintf("Chapter %d", h+1)
+		}
+
+		new_moment := video_moment_models.VideoMoment{
+			VideoUUID:   video.VideoUUID,
+			MomentTime:  moment_time,
+			MomentTitle: moment_title,
+		}
+
+		if chapter.EndTime > chapter.StartTime {
+			new_moment.MomentEndTime = int(chapter.EndTime)
+		}
+
+		new_moments = append(new_moments, new_moment)
+		taken_times[moment_time] = struct{}{}
+	}
+
+	if replace_existing {
+		err = repository.VideoMomentsRepo.ReplaceVideoMomentsCTX(ctx, video.VideoUUID, new_moments)
+		if err != nil {
+			return 0, errors.Join(fmt.Errorf("In workflows/video_chapters.ImportVideoChaptersCTX: While replacing video<%s> moments", video.VideoUUID), err)
+		}
+
+		return len(new_moments), nil
+	}
+
+	var imported_count int = 0
+
+	for _, new_moment := range new_moments {
+		_, err = repository.VideoMomentsRepo.AddVideoMomentCTX(ctx, new_moment)
+		if err != nil {
+			return imported_count, errors.Join(fmt.Errorf("In workflows/video_chapters.ImportVideoChaptersCTX: While adding moment at <%d>", new_moment.MomentTime), err)
+		}
+
+		imported_count++
+	}
+
+	return imported_count, nil
+}
+
+// Returns the moments of a video as chapters sorted by start time. Range moments keep their own end time, the rest end where the next
+// chapter starts, the last one ends at video_duration(milliseconds) if it's known. Returns ErrVideoNotFound if the video is not
+// registered.
+func ExportVideoChaptersCTX(ctx context.Context, video_identifier video_moment_models.Video, video_duration int64) ([]dungeon_models.MediaChapter, error) {
+	video, err := repository.VideoMomentsRepo.GetVideoCTX(ctx, video_identifier.VideoUUID, video_identifier.VideoCluster)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrVideoNotFound
+	}
+
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/video_chapters.ExportVideoChaptersCTX: While getting video<%s>", video_identifier.VideoUUID), err)
+	}
+
+	moments, err := repository.VideoMomentsRepo.GetVideoMomentsCTX(ctx, video)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/video_chapters.ExportVideoChaptersCTX: While getting video<%s> moments", video.VideoUUID), err)
+	}
+
+	sort.Slice(moments, func(i, j int) bool {
+		return moments[i].MomentTime < moments[j].MomentTime
+	})
+
+	var chapters []dungeon_models.MediaChapter = make([]dungeon_models.MediaChapter, len(moments))
+
+	for h, moment := range moments {
+		chapters[h] = dungeon_models.MediaChapter{
+			StartTime: int64(moment.MomentTime),
+			Title:     moment.MomentTitle,
+		}
+
+		if moment.IsRange() {
+			chapters[h].EndTime = int64(moment.MomentEndTime)
+		}
+
+		if h > 0 && chapters[h-1].EndTime == 0 {
+			chapters[h-1].EndTime = chapters[h].StartTime
+		}
+	}
+
+	if len(chapters) > 0 {
+		last_chapter := &chapters[len(chapters)-1]
+
+		if last_chapter.EndTime == 0 {
+			last_chapter.EndTime = last_chapter.StartTime + default_last_chapter_length
+
+			if video_duration > last_chapter.StartTime {
+				last_chapter.EndTime = video_duration
+			}
+		}
+	}
+
+	return chapters, nil
+}
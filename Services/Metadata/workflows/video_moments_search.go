@@ -0,0 +1,208 @@
+package workflows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"libery-dungeon-libs/communication"
+	"libery-dungeon-libs/communication/service_requests/metadata_requests"
+	dungeon_models "libery-dungeon-libs/models"
+	video_moment_models "libery-metadata-service/models/video_moments"
+	"libery-metadata-service/repository"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+const (
+	moments_search_default_limit int = 50
+	moments_search_max_limit     int = 200
+)
+
+// Match quality tiers, a better tier always ranks above a worse one regardless of the fuzzy penalty.
+const (
+	moment_match_fuzzy int = iota
+	moment_match_substring
+	moment_match_prefix
+	moment_match_exact
+)
+
+// Max penalty a fuzzy match can get, keeps penalties from spilling into the tier above.
+const moment_match_max_penalty int = 9999
+
+type scoredMoment struct {
+	moment video_moment_models.VideoMoment
+	score  int
+}
+
+// Searches the titles of all the moments in a cluster. mode is one of the metadata_requests.VideoMoments_SearchMode_* values, prefix only
+// accepts titles starting with the query, substring titles containing it and fuzzy titles containing its characters in order. Matching
+// is case insensitive and results are sorted from best to worst match. Moments whose media can't be resolved(e.g. deleted medias) are skipped.
+func SearchClusterMomentsCTX(ctx context.Context, cluster_uuid, query, mode string, limit int) ([]video_moment_models.VideoMomentSearchResult, error) {
+	var search_results []video_moment_models.VideoMomentSearchResult = make([]video_moment_models.VideoMomentSearchResult, 0)
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return search_results, nil
+	}
+
+	if limit <= 0 {
+		limit = moments_search_default_limit
+	}
+
+	if limit > moments_search_max_limit {
+		limit = moments_search_max_limit
+	}
+
+	var min_tier int
+
+	switch mode {
+	case metadata_requests.VideoMoments_SearchMode_Prefix:
+		min_tier = moment_match_prefix
+	case metadata_requests.VideoMoments_SearchMode_Substring:
+		min_tier = moment_match_substring
+	case metadata_requests.VideoMoments_SearchMode_Fuzzy:
+		min_tier = moment_match_fuzzy
+	default:
+		return nil, fmt.Errorf("In workflows/video_moments_search.SearchClusterMomentsCTX: Unknown search mode '%s'", mode)
+	}
+
+	cluster_moments, err := repository.VideoMomentsRepo.GetClusterMomentsCTX(ctx, cluster_uuid)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("In workflows/video_moments_search.SearchClusterMomentsCTX: While getting cluster<%s> moments", cluster_uuid), err)
+	}
+
+	var matched_moments []scoredMoment = make([]scoredMoment, 0)
+
+	for _, moment := range cluster_moments {
+		score, matches := scoreMomentTitle(query, strings.ToLower(moment.MomentTitle), min_tier)
+		if !matches {
+			continue
+		}
+
+		matched_moments = append(matched_moments, scoredMoment{moment: moment, score: score})
+	}
+
+	sort.SliceStable(matched_moments, func(i, j int) bool {
+		if matched_moments[i].score != matched_moments[j].score {
+			return matched_moments[i].score > matched_moments[j].score
+		}
+
+		if matched_moments[i].moment.VideoUUID != matched_moments[j].moment.VideoUUID {
+			return matched_moments[i].moment.VideoUUID < matched_moments[j].moment.VideoUUID
+		}
+
+		return matched_moments[i].moment.MomentTime < matched_moments[j].moment.MomentTime
+	})
+
+	var media_identities map[string]*dungeon_models.MediaIdentity = make(map[string]*dungeon_models.MediaIdentity)
+
+	for _, matched_moment := range matched_moments {
+		if len(search_results) >= limit {
+			break
+		}
+
+		media_identity, resolved := media_identities[matched_moment.moment.VideoUUID]
+		if !resolved {
+			media_identity, err = communication.Medias.GetMediaIdentity(matched_moment.moment.VideoUUID)
+			if err != nil {
+				echo.EchoWarn(fmt.Sprintf("In workflows/video_moments_search.SearchClusterMomentsCTX: Could not resolve media<%s>: %s", matched_moment.moment.VideoUUID, err.Error()))
+			}
+
+			media_identities[matched_moment.moment.VideoUUID] = media_identity
+		}
+
+		if media_identity == nil {
+			continue
+		}
+
+		search_result := video_moment_models.VideoMomentSearchResult{
+			VideoMoment:   matched_moment.moment,
+			MediaIdentity: media_identity,
+			ThumbnailURL:  getMomentThumbnailURL(media_identity, matched_moment.moment.MomentTime),
+		}
+
+		search_results = append(search_results, search_result)
+	}
+
+	return search_results, nil
+}
+
+// Scores how well a lowercased title matches a lowercased query. Returns false if the match is worse than min_tier.
+func scoreMomentTitle(query, title string, min_tier int) (int, bool) {
+	var tier int = -1
+	var penalty int = 0
+
+	switch {
+	case title == query:
+		tier = moment_match_exact
+	case strings.HasPrefix(title, query):
+		tier = moment_match_prefix
+		penalty = len(title) - len(query)
+	case strings.Contains(title, query):
+		tier = moment_match_substring
+		penalty = strings.Index(title, query)
+	default:
+		gaps, is_subsequence := fuzzyMatchGaps(query, title)
+		if is_subsequence {
+			tier = moment_match_fuzzy
+			penalty = gaps
+		}
+	}
+
+	if tier < min_tier {
+		return 0, false
+	}
+
+	if penalty > moment_match_max_penalty {
+		penalty = moment_match_max_penalty
+	}
+
+	return tier*(moment_match_max_penalty+1) + (moment_match_max_penalty - penalty), true
+}
+
+// Checks whether the characters of query appear in title in the same order. Returns how many title characters were skipped between
+// the first and last matched characters, the fewer the closer the match.
+func fuzzyMatchGaps(query, title string) (int, bool) {
+	var query_runes []rune = []rune(query)
+	var query_index int = 0
+	var last_match int = -1
+	var gaps int = 0
+
+	for title_index, title_rune := range []rune(title) {
+		if query_index == len(query_runes) {
+			break
+		}
+
+		if title_rune != query_runes[query_index] {
+			continue
+		}
+
+		if last_match >= 0 {
+			gaps += title_index - last_match - 1
+		}
+
+		last_match = title_index
+		query_index++
+	}
+
+	return gaps, query_index == len(query_runes)
+}
+
+// Returns the thumbnails-fs url, relative to the medias service, for the frame of a video at moment_time(milliseconds).
+func getMomentThumbnailURL(media_identity *dungeon_models.MediaIdentity, moment_time int) string {
+	thumbnail_query := url.Values{}
+	thumbnail_query.Set("cluster_uuid", media_identity.ClusterUUID)
+	thumbnail_query.Set("at", strconv.Itoa(moment_time))
+
+	thumbnail_url := url.URL{
+		Path:     path.Join("/thumbnails-fs", media_identity.CategoryPath, media_identity.Media.Name),
+		RawQuery: thumbnail_query.Encode(),
+	}
+
+	return thumbnail_url.String()
+}
@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"libery-metadata-service/workflows"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Periodically purges metadata owned by medias or categories that no longer exist on the categories service.
+// Catches whatever was missed by the entities deleted notifications(e.g. the metadata service was down).
+type OrphanSweeper struct {
+	sweep_interval time.Duration
+	stop_signal    chan bool
+}
+
+func NewOrphanSweeper(sweep_interval time.Duration) *OrphanSweeper {
+	var orphan_sweeper *OrphanSweeper = new(OrphanSweeper)
+
+	orphan_sweeper.sweep_interval = sweep_interval
+	orphan_sweeper.stop_signal = make(chan bool)
+
+	go orphan_sweeper.monitorSweepSchedule()
+
+	return orphan_sweeper
+}
+
+func (sweeper *OrphanSweeper) monitorSweepSchedule() {
+	sweep_ticker := time.NewTicker(sweeper.sweep_interval)
+	defer sweep_ticker.Stop()
+
+	for {
+		select {
+		case <-sweep_ticker.C:
+			sweeper.sweep()
+		case <-sweeper.stop_signal:
+			return
+		}
+	}
+}
+
+func (sweeper *OrphanSweeper) sweep() {
+	purged_count, err := workflows.SweepOrphanedMetadataCTX(context.Background())
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("In workflows/workers/orphan_sweeper.sweep: While sweeping orphaned metadata\n\n%s", err))
+		return
+	}
+
+	echo.EchoDebug(fmt.Sprintf("Orphan sweep finished, purged %d entities", purged_count))
+}
+
+func (sweeper *OrphanSweeper) Stop() {
+	close(sweeper.stop_signal)
+}
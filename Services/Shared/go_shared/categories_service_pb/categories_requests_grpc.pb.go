@@ -19,9 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	CategoriesService_CreateCategory_FullMethodName       = "/categories_service.CategoriesService/CreateCategory"
-	CategoriesService_GetCategory_FullMethodName          = "/categories_service.CategoriesService/GetCategory"
-	CategoriesService_GetCategoriesCluster_FullMethodName = "/categories_service.CategoriesService/GetCategoriesCluster"
+	CategoriesService_CreateCategory_FullMethodName           = "/categories_service.CategoriesService/CreateCategory"
+	CategoriesService_GetCategory_FullMethodName              = "/categories_service.CategoriesService/GetCategory"
+	CategoriesService_GetCategoriesCluster_FullMethodName     = "/categories_service.CategoriesService/GetCategoriesCluster"
+	CategoriesService_FilterExistingMedias_FullMethodName     = "/categories_service.CategoriesService/FilterExistingMedias"
+	CategoriesService_FilterExistingCategories_FullMethodName = "/categories_service.CategoriesService/FilterExistingCategories"
 )
 
 // CategoriesServiceClient is the client API for CategoriesService service.
@@ -31,6 +33,8 @@ type CategoriesServiceClient interface {
 	CreateCategory(ctx context.Context, in *CreateCategoryRequest, opts ...grpc.CallOption) (*CreateCategoryResponse, error)
 	GetCategory(ctx context.Context, in *GetCategoryRequest, opts ...grpc.CallOption) (*GetCategoryResponse, error)
 	GetCategoriesCluster(ctx context.Context, in *GetCategoriesClusterRequest, opts ...grpc.CallOption) (*GetCategoriesClusterResponse, error)
+	FilterExistingMedias(ctx context.Context, in *UuidList, opts ...grpc.CallOption) (*UuidList, error)
+	FilterExistingCategories(ctx context.Context, in *UuidList, opts ...grpc.CallOption) (*UuidList, error)
 }
 
 type categoriesServiceClient struct {
@@ -68,6 +72,24 @@ func (c *categoriesServiceClient) GetCategoriesCluster(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *categoriesServiceClient) FilterExistingMedias(ctx context.Context, in *UuidList, opts ...grpc.CallOption) (*UuidList, error) {
+	out := new(UuidList)
+	err := c.cc.Invoke(ctx, CategoriesService_FilterExistingMedias_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *categoriesServiceClient) FilterExistingCategories(ctx context.Context, in *UuidList, opts ...grpc.CallOption) (*UuidList, error) {
+	out := new(UuidList)
+	err := c.cc.Invoke(ctx, CategoriesService_FilterExistingCategories_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CategoriesServiceServer is the server API for CategoriesService service.
 // All implementations must embed UnimplementedCategoriesServiceServer
 // for forward compatibility
@@ -75,6 +97,8 @@ type CategoriesServiceServer interface {
 	CreateCategory(context.Context, *CreateCategoryRequest) (*CreateCategoryResponse, error)
 	GetCategory(context.Context, *GetCategoryRequest) (*GetCategoryResponse, error)
 	GetCategoriesCluster(context.Context, *GetCategoriesClusterRequest) (*GetCategoriesClusterResponse, error)
+	FilterExistingMedias(context.Context, *UuidList) (*UuidList, error)
+	FilterExistingCategories(context.Context, *UuidList) (*UuidList, error)
 	mustEmbedUnimplementedCategoriesServiceServer()
 }
 
@@ -91,6 +115,12 @@ func (UnimplementedCategoriesServiceServer) GetCategory(context.Context, *GetCat
 func (UnimplementedCategoriesServiceServer) GetCategoriesCluster(context.Context, *GetCategoriesClusterRequest) (*GetCategoriesClusterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetCategoriesCluster not implemented")
 }
+func (UnimplementedCategoriesServiceServer) FilterExistingMedias(context.Context, *UuidList) (*UuidList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FilterExistingMedias not implemented")
+}
+func (UnimplementedCategoriesServiceServer) FilterExistingCategories(context.Context, *UuidList) (*UuidList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FilterExistingCategories not implemented")
+}
 func (UnimplementedCategoriesServiceServer) mustEmbedUnimplementedCategoriesServiceServer() {}
 
 // UnsafeCategoriesServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -158,6 +188,42 @@ func _CategoriesService_GetCategoriesCluster_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CategoriesService_FilterExistingMedias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoriesServiceServer).FilterExistingMedias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoriesService_FilterExistingMedias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoriesServiceServer).FilterExistingMedias(ctx, req.(*UuidList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CategoriesService_FilterExistingCategories_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UuidList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CategoriesServiceServer).FilterExistingCategories(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CategoriesService_FilterExistingCategories_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CategoriesServiceServer).FilterExistingCategories(ctx, req.(*UuidList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CategoriesService_ServiceDesc is the grpc.ServiceDesc for CategoriesService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -177,6 +243,14 @@ var CategoriesService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetCategoriesCluster",
 			Handler:    _CategoriesService_GetCategoriesCluster_Handler,
 		},
+		{
+			MethodName: "FilterExistingMedias",
+			Handler:    _CategoriesService_FilterExistingMedias_Handler,
+		},
+		{
+			MethodName: "FilterExistingCategories",
+			Handler:    _CategoriesService_FilterExistingCategories_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "categories_requests.proto",
@@ -1,9 +1,14 @@
 package communication
 
 import (
+	"fmt"
 	"libery-dungeon-libs/communication/service_clients"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
 	"libery-dungeon-libs/dungeonsec/dungeon_secrets"
+	"libery-dungeon-libs/models/platform_services"
+	"net/http"
 	"os"
+	"time"
 )
 
 var (
@@ -22,39 +27,24 @@ var (
 	DOMAIN_SECRET     string = os.Getenv("DOMAIN_SECRET")
 )
 
+// How long the service routes resolved from JD are trusted before asking JD again.
+const SERVICE_DIRECTORY_MAX_AGE time.Duration = 30 * time.Second
+
 // type ServiceCommunicationSetupParams struct {
 // 	CaPath string `json:"ca_path"`
 // }
 
 // Verifies that all the endpoints are set and valid to the possible extent
-// if it finds an error it will panic
+// if it finds an error it will panic. The addresses of the services other than JD and the users service are optional, they
+// are resolved from JD and the ones set are only used while JD doesn't know of an online instance of the service.
 func verifyEndpointConfig() {
 	if JD_SERVER == "" {
 		panic("JD_SERVER environment variable is required")
 	}
 
-	if CATEGORIES_SERVER == "" {
-		panic("CATEGORIES_SERVER environment variable is required")
-	}
-
-	if MEDIAS_SERVER == "" {
-		panic("MEDIAS_SERVER environment variable is required")
-	}
-
-	if PWEDITOR_SERVER == "" {
-		panic("PWEDITOR_SERVER environment variable is required")
-	}
-
-	if COLLECT_SERVER == "" {
-		panic("COLLECT_SERVER environment variable is required")
-	}
-
-	if DOWNLOADS_SERVER == "" {
-		panic("DOWNLOADS_SERVER environment variable is required")
-	}
-
-	if METADATA_SERVER == "" {
-		panic("METADATA_SERVER environment variable is required")
+	if USERS_SERVER == "" {
+		// The user claims of every request are checked against the users service, its address can't wait for JD to resolve it
+		panic("USERS_SERVER environment variable is required")
 	}
 
 	if GRPC_SERVER == "" {
@@ -109,30 +99,83 @@ func Setup() (err error) {
 		BaseServiceClient: base_service_data,
 	}
 
+	service_clients.SetServiceDirectoryResolver(JD.GetOnlineServicesRoutes, SERVICE_DIRECTORY_MAX_AGE)
+
 	// Metadata Communication
 
 	base_service_data.HttpAddress = METADATA_SERVER
+	base_service_data.ServiceName = platform_services.METADATA_SERVICE
 
 	Metadata = &service_clients.MetadataServiceClient{
 		BaseServiceClient: base_service_data,
 	}
 
+	// Categories Communication
+
+	base_service_data.HttpAddress = CATEGORIES_SERVER
+	base_service_data.ServiceName = platform_services.CATEGORIES_SERVICE
+
+	Categories = &service_clients.CategoriesServiceClient{
+		BaseServiceClient: base_service_data,
+	}
+
 	// Medias Communication
 
 	base_service_data.HttpAddress = MEDIAS_SERVER
+	base_service_data.ServiceName = platform_services.MEDIAS_SERVICE
 
 	Medias = &service_clients.MediaServiceClient{
 		BaseServiceClient: base_service_data,
 	}
 
+	// Users Communication
+
+	base_service_data.HttpAddress = USERS_SERVER
+	base_service_data.ServiceName = platform_services.USERS_SERVICE
+
+	Users = &service_clients.UsersServiceClient{
+		BaseServiceClient: base_service_data,
+	}
+
 	// ----------------- Dungeonsec secrets -----------------
 
 	dungeon_secrets.SetDungeonJwtSecret(JWT_SECRET)
 	dungeon_secrets.SetDungeonDomainSecret(DOMAIN_SECRET)
 
+	// User claims of revoked sessions are rejected by the dungeon middlewares
+	dungeon_middlewares.SetSessionRevocationChecker(Users.IsSessionRevoked)
+
+	// And requests authenticated with an API token get the claims of the token's owner
+	dungeon_middlewares.SetApiTokenResolver(Users.ResolveApiToken)
+
 	return
 }
 
 var JD *service_clients.JD_Client
 var Metadata *service_clients.MetadataServiceClient
+var Categories *service_clients.CategoriesServiceClient
 var Medias *service_clients.MediaServiceClient
+var Users *service_clients.UsersServiceClient
+
+// Calls the /alive endpoint of the service registered on the given route. Used by JD to check on the registered services.
+func ServiceRouteAlive(service_route string, timeout time.Duration) (bool, error) {
+	var alive_endpoint string = fmt.Sprintf("https://%s%s/alive", BASE_DOMAIN, service_route)
+
+	request, err := http.NewRequest("GET", alive_endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{
+		Transport: http_transport,
+		Timeout:   timeout,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode >= 200 && response.StatusCode < 300, nil
+}
@@ -1,6 +1,7 @@
 package communication
 
 import (
+	"errors"
 	"fmt"
 	dungeon_models "libery-dungeon-libs/models"
 
@@ -18,12 +19,16 @@ const (
 	PlatformEvent_Public_ClusterFSChange   = "cluster_fs_change"
 	PlatformEvent_Public_MediaDeleted      = "media_deleted"
 	PlatformEvent_Public_MediaAdded        = "media_added"
+	PlatformEvent_Public_MediaMoved        = "media_moved"
+	PlatformEvent_Public_DownloadProgress  = "download_progress"
 )
 
 var public_events = [...]string{
 	PlatformEvent_Public_ClusterFSChange,
 	PlatformEvent_Public_MediaDeleted,
 	PlatformEvent_Public_MediaAdded,
+	PlatformEvent_Public_MediaMoved,
+	PlatformEvent_Public_DownloadProgress,
 }
 
 var private_events = [...]string{
@@ -59,6 +64,26 @@ func (pe PlatformEvent) IsAuthenticated(jwt_sk string) bool {
 	return token.Valid
 }
 
+// Payload claims shared by the events that concern a single categories cluster.
+type clusterScopedPayload struct {
+	ClusterUUID string `json:"cluster_uuid"`
+	jwt.StandardClaims
+}
+
+// Returns the uuid of the cluster the event concerns. Events that are not about a cluster return an empty string.
+func (pe PlatformEvent) GetClusterUUID(jwt_sk string) (string, error) {
+	var payload *clusterScopedPayload = new(clusterScopedPayload)
+
+	_, err := jwt.ParseWithClaims(pe.EventPayload, payload, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwt_sk), nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("In PlatformEvent.GetClusterUUID: While parsing JWT token: %s", err.Error())
+	}
+
+	return payload.ClusterUUID, nil
+}
+
 func (pe PlatformEvent) Emit() (err error) {
 	err = JD.EmitPlatformEvent(
 		pe.Uuid,
@@ -156,3 +181,127 @@ func NewClusterFSChangeEvent(sk, cluster_uuid string, medias_deleted, medias_add
 
 	return NewPlatformEvent(event_uuid, PlatformEvent_Public_ClusterFSChange, event_message, singed_payload)
 }
+
+// Media events carry the identities of the affected medias, so large changes are split into several events of at most this many medias each.
+const MAX_MEDIAS_PER_EVENT int = 100
+
+type MediasChangePayload struct {
+	ClusterUUID string                          `json:"cluster_uuid"`
+	Medias      []*dungeon_models.MediaIdentity `json:"medias"`
+	jwt.StandardClaims
+}
+
+func (mcp MediasChangePayload) SignPayload(sk string) (string, error) {
+	token := jwt.NewWithClaims(dungeon_models.JwtSigningMethod, mcp)
+	return token.SignedString([]byte(sk))
+}
+
+func newMediasChangeEvents(sk, event_type, cluster_uuid, change_description string, medias []*dungeon_models.MediaIdentity) []*PlatformEvent {
+	var events []*PlatformEvent = make([]*PlatformEvent, 0)
+
+	for batch_start := 0; batch_start < len(medias); batch_start += MAX_MEDIAS_PER_EVENT {
+		batch_end := batch_start + MAX_MEDIAS_PER_EVENT
+		if batch_end > len(medias) {
+			batch_end = len(medias)
+		}
+
+		payload := &MediasChangePayload{
+			ClusterUUID: cluster_uuid,
+			Medias:      medias[batch_start:batch_end],
+		}
+
+		signed_payload, err := payload.SignPayload(sk)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In newMediasChangeEvents: %s", err.Error()))
+			continue
+		}
+
+		event_uuid, err := GenerateEventUUID()
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In newMediasChangeEvents: %s", err.Error()))
+			continue
+		}
+
+		event_message := fmt.Sprintf("%d medias %s in cluster '%s'", batch_end-batch_start, change_description, cluster_uuid)
+
+		events = append(events, NewPlatformEvent(event_uuid, event_type, event_message, signed_payload))
+	}
+
+	return events
+}
+
+// Builds the events announcing medias were added to a cluster. Returns no events if medias is empty.
+func NewMediaAddedEvents(sk, cluster_uuid string, medias []*dungeon_models.MediaIdentity) []*PlatformEvent {
+	return newMediasChangeEvents(sk, PlatformEvent_Public_MediaAdded, cluster_uuid, "added", medias)
+}
+
+// Builds the events announcing medias were removed from a cluster. Returns no events if medias is empty.
+func NewMediaDeletedEvents(sk, cluster_uuid string, medias []*dungeon_models.MediaIdentity) []*PlatformEvent {
+	return newMediasChangeEvents(sk, PlatformEvent_Public_MediaDeleted, cluster_uuid, "deleted", medias)
+}
+
+// Builds the events announcing medias were moved to another category of the cluster. The identities are the ones at the destination.
+func NewMediaMovedEvents(sk, cluster_uuid string, medias []*dungeon_models.MediaIdentity) []*PlatformEvent {
+	return newMediasChangeEvents(sk, PlatformEvent_Public_MediaMoved, cluster_uuid, "moved", medias)
+}
+
+type DownloadProgressPayload struct {
+	ClusterUUID     string  `json:"cluster_uuid"`
+	DownloadUUID    string  `json:"download_uuid"`
+	TotalFiles      int     `json:"total_files"`
+	DownloadedFiles int     `json:"downloaded_files"`
+	Completed       bool    `json:"completed"`
+	FileProgress    float64 `json:"file_progress,omitempty"`
+	jwt.StandardClaims
+}
+
+func (dpp DownloadProgressPayload) SignPayload(sk string) (string, error) {
+	token := jwt.NewWithClaims(dungeon_models.JwtSigningMethod, dpp)
+	return token.SignedString([]byte(sk))
+}
+
+// Builds the event announcing the progress of a download into a cluster. Returns nil if the event can't be built.
+func NewDownloadProgressEvent(sk, cluster_uuid, download_uuid string, total_files, downloaded_files int, completed bool, file_progress float64) *PlatformEvent {
+	payload := &DownloadProgressPayload{
+		ClusterUUID:     cluster_uuid,
+		DownloadUUID:    download_uuid,
+		TotalFiles:      total_files,
+		DownloadedFiles: downloaded_files,
+		Completed:       completed,
+		FileProgress:    file_progress,
+	}
+
+	signed_payload, err := payload.SignPayload(sk)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In NewDownloadProgressEvent: %s", err.Error()))
+		return nil
+	}
+
+	event_uuid, err := GenerateEventUUID()
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In NewDownloadProgressEvent: %s", err.Error()))
+		return nil
+	}
+
+	event_message := fmt.Sprintf("Download '%s': %d of %d files downloaded", download_uuid, downloaded_files, total_files)
+
+	if completed {
+		event_message = fmt.Sprintf("Download '%s' completed: %d of %d files downloaded", download_uuid, downloaded_files, total_files)
+	}
+
+	return NewPlatformEvent(event_uuid, PlatformEvent_Public_DownloadProgress, event_message, signed_payload)
+}
+
+// Emits every event, events that fail to emit don't stop the rest from being emitted.
+func EmitPlatformEvents(events []*PlatformEvent) error {
+	var emit_errors []error
+
+	for _, event := range events {
+		err := event.Emit()
+		if err != nil {
+			emit_errors = append(emit_errors, fmt.Errorf("While emitting event '%s': %s", event.Uuid, err.Error()))
+		}
+	}
+
+	return errors.Join(emit_errors...)
+}
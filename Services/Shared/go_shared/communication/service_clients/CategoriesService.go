@@ -0,0 +1,95 @@
+package service_clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"libery-dungeon-libs/categories_service_pb"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type CategoriesServiceClient struct {
+	BaseServiceClient
+}
+
+func (categories_client CategoriesServiceClient) Alive() (bool, error) {
+	var categories_endpoint string
+
+	categories_endpoint = categories_client.getHttpsEndpoint()
+
+	categories_endpoint += "/alive"
+
+	request, err := http.NewRequest("GET", categories_endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+
+	client := &http.Client{
+		Transport: categories_client.HttpTransport,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false, err
+	}
+	defer response.Body.Close()
+
+	return response.StatusCode >= 200 && response.StatusCode < 300, nil
+}
+
+func (categories_client CategoriesServiceClient) getHttpsEndpoint() string {
+	return fmt.Sprintf("https://%s%s", categories_client.BaseDomain, categories_client.resolveHttpAddress())
+}
+
+// Returns the subset of the given media uuids that still exist on the categories service.
+func (categories_client CategoriesServiceClient) FilterExistingMedias(media_uuids []string) ([]string, error) {
+	conn, err := grpc.Dial(categories_client.GrpcAddress, grpc.WithTransportCredentials(categories_client.GrpcTransport))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	categories_grpc_client := categories_service_pb.NewCategoriesServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message := categories_service_pb.UuidList{
+		Uuids: media_uuids,
+	}
+
+	response, err := categories_grpc_client.FilterExistingMedias(ctx, &message)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In Communication/CategoriesService.FilterExistingMedias, while calling categories_grpc_client.FilterExistingMedias"))
+	}
+
+	return response.Uuids, nil
+}
+
+// Returns the subset of the given category uuids that still exist on the categories service.
+func (categories_client CategoriesServiceClient) FilterExistingCategories(category_uuids []string) ([]string, error) {
+	conn, err := grpc.Dial(categories_client.GrpcAddress, grpc.WithTransportCredentials(categories_client.GrpcTransport))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	categories_grpc_client := categories_service_pb.NewCategoriesServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message := categories_service_pb.UuidList{
+		Uuids: category_uuids,
+	}
+
+	response, err := categories_grpc_client.FilterExistingCategories(ctx, &message)
+	if err != nil {
+		return nil, errors.Join(err, fmt.Errorf("In Communication/CategoriesService.FilterExistingCategories, while calling categories_grpc_client.FilterExistingCategories"))
+	}
+
+	return response.Uuids, nil
+}
@@ -2,7 +2,10 @@ package service_clients
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"libery-dungeon-libs/communication/service_requests/jd_requests"
+	"libery-dungeon-libs/dungeonsec"
 	JD_service_pb "libery-dungeon-libs/jd_service_pb"
 	"libery-dungeon-libs/models/platform_services"
 	"net/http"
@@ -44,6 +47,65 @@ func (jd_conf JD_Client) getHttpsEndpoint() string {
 	return fmt.Sprintf("https://%s%s", jd_conf.BaseDomain, jd_conf.HttpAddress)
 }
 
+// Requests the platform services JD knows about, with the route each one registered and whether JD considers it online.
+func (jd_conf JD_Client) GetPlatformServices() ([]jd_requests.PlatformServiceEntry, error) {
+	var request_url string = fmt.Sprintf("%s/platform-services", jd_conf.getHttpsEndpoint())
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: jd_conf.HttpTransport,
+		Timeout:   5 * time.Second,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var platform_services_entries []jd_requests.PlatformServiceEntry = make([]jd_requests.PlatformServiceEntry, 0)
+
+	err = json.NewDecoder(response.Body).Decode(&platform_services_entries)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding platform services: %s", err.Error())
+	}
+
+	return platform_services_entries, nil
+}
+
+// Returns the routes of the services JD considers online. Meant to be used as the service directory resolver.
+func (jd_conf JD_Client) GetOnlineServicesRoutes() (map[platform_services.PlatformServiceName]string, error) {
+	platform_services_entries, err := jd_conf.GetPlatformServices()
+	if err != nil {
+		return nil, err
+	}
+
+	var services_routes map[platform_services.PlatformServiceName]string = make(map[platform_services.PlatformServiceName]string)
+
+	for _, service_entry := range platform_services_entries {
+		if !service_entry.IsOnline || service_entry.ServiceRoute == "" {
+			continue
+		}
+
+		services_routes[platform_services.PlatformServiceName(service_entry.ServiceName)] = service_entry.ServiceRoute
+	}
+
+	return services_routes, nil
+}
+
 func (jd_conf JD_Client) EmitPlatformEvent(event_uuid, event_type, event_message, event_payload string) error {
 	conn, err := grpc.Dial(jd_conf.GrpcAddress, grpc.WithTransportCredentials(jd_conf.GrpcTransport))
 	if err != nil {
@@ -70,7 +132,13 @@ func (jd_conf JD_Client) EmitPlatformEvent(event_uuid, event_type, event_message
 
 // TODO: sign in messages should be signed.
 
+// Registers the service route on JD. The route is what other services resolve this service to, so while the routes of the
+// other services are optional, a service's own route is required and an empty one is a configuration error, it will panic.
 func (jd_conf JD_Client) NotifyServiceOnline(service_name platform_services.PlatformServiceName, service_route, service_port string) error {
+	if service_route == "" {
+		panic(fmt.Sprintf("The route of %s is required to notify JD, set its *_SERVER environment variable", service_name))
+	}
+
 	conn, err := grpc.Dial(jd_conf.GrpcAddress, grpc.WithTransportCredentials(jd_conf.GrpcTransport))
 	if err != nil {
 		return err
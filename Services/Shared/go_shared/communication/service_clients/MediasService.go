@@ -2,20 +2,27 @@ package service_clients
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"libery-dungeon-libs/dungeonsec"
+	dungeon_models "libery-dungeon-libs/models"
 	"mime/multipart"
 	"net/http"
 	"net/http/cookiejar"
 	"net/textproto"
+	"net/url"
+	"sync"
 )
 
 type MediaServiceClient struct {
 	BaseServiceClient
 	tickets_cookie_jar *cookiejar.Jar
+	upload_tickets     map[string]*cookiejar.Jar // Ticket cookie jars by upload uuid, guarded by upload_tickets_mutex
 }
 
+var upload_tickets_mutex sync.Mutex
+
 func (medias_client MediaServiceClient) Alive() (bool, error) {
 	var metadata_endpoint string
 
@@ -42,7 +49,7 @@ func (medias_client MediaServiceClient) Alive() (bool, error) {
 }
 
 func (medias_client MediaServiceClient) getHttpsEndpoint() string {
-	return fmt.Sprintf("https://%s%s", medias_client.BaseDomain, medias_client.HttpAddress)
+	return fmt.Sprintf("https://%s%s", medias_client.BaseDomain, medias_client.resolveHttpAddress())
 }
 
 // Requests the media service to send an upload ticket for a new stream of medias. This ticket is sent as a cookie
@@ -83,14 +90,40 @@ func (medias_client *MediaServiceClient) GetUploadStreamTicket(upload_uuid, cate
 		return fmt.Errorf("Error sending request: %s", response.Status)
 	}
 
+	upload_tickets_mutex.Lock()
+
+	if medias_client.upload_tickets == nil {
+		medias_client.upload_tickets = make(map[string]*cookiejar.Jar)
+	}
+
 	medias_client.tickets_cookie_jar = clear_cookiejar
+	medias_client.upload_tickets[upload_uuid] = clear_cookiejar
+
+	upload_tickets_mutex.Unlock()
 
 	return nil
 }
 
-func (medias_client *MediaServiceClient) UploadMediaFile(file io.ReadCloser, filename string) error {
-	message_body := &bytes.Buffer{}
+// Forgets the ticket of an upload stream. Uploads that use UploadMediaStream should release their ticket once done.
+func (medias_client *MediaServiceClient) ReleaseUploadStreamTicket(upload_uuid string) {
+	upload_tickets_mutex.Lock()
+	defer upload_tickets_mutex.Unlock()
+
+	delete(medias_client.upload_tickets, upload_uuid)
+}
+
+func (medias_client *MediaServiceClient) uploadTicketJar(upload_uuid string) *cookiejar.Jar {
+	upload_tickets_mutex.Lock()
+	defer upload_tickets_mutex.Unlock()
+
+	if tickets_jar, exists := medias_client.upload_tickets[upload_uuid]; exists {
+		return tickets_jar
+	}
 
+	return medias_client.tickets_cookie_jar
+}
+
+func (medias_client *MediaServiceClient) UploadMediaFile(file io.ReadCloser, filename string) error {
 	file_type_buffer := make([]byte, 512)
 	n, err := file.Read(file_type_buffer)
 	if err != nil {
@@ -101,6 +134,30 @@ func (medias_client *MediaServiceClient) UploadMediaFile(file io.ReadCloser, fil
 
 	multi_reader := io.MultiReader(bytes.NewReader(file_type_buffer[:n]), file)
 
+	_, err = medias_client.uploadMediaFile(multi_reader, filename, content_type, 0, medias_client.uploadTicketJar(""))
+
+	return err
+}
+
+// Uploads a media file to the upload stream of upload_uuid, which allows several upload streams to be open at once. The
+// content type must be resolved by the caller, the medias service relies on it to tell videos apart from images.
+// downloaded_from is stored as the DownloadedFrom of the new media, 0 if it wasn't downloaded. Returns the uuid of the new media.
+func (medias_client *MediaServiceClient) UploadMediaStream(upload_uuid string, file io.Reader, filename string, content_type string, downloaded_from int64) (string, error) {
+	added_medias, err := medias_client.uploadMediaFile(file, filename, content_type, downloaded_from, medias_client.uploadTicketJar(upload_uuid))
+	if err != nil {
+		return "", err
+	}
+
+	if len(added_medias) == 0 || added_medias[0].Media == nil {
+		return "", fmt.Errorf("The medias service didn't report the media it created for '%s'", filename)
+	}
+
+	return added_medias[0].Media.Uuid, nil
+}
+
+func (medias_client *MediaServiceClient) uploadMediaFile(file io.Reader, filename string, content_type string, downloaded_from int64, tickets_jar *cookiejar.Jar) ([]*dungeon_models.MediaIdentity, error) {
+	message_body := &bytes.Buffer{}
+
 	writer := multipart.NewWriter(message_body)
 
 	header := make(textproto.MIMEHeader)
@@ -109,47 +166,181 @@ func (medias_client *MediaServiceClient) UploadMediaFile(file io.ReadCloser, fil
 
 	part, err := writer.CreatePart(header)
 	if err != nil {
-		return fmt.Errorf("Error adding file to multipart message: %s", err.Error())
+		return nil, fmt.Errorf("Error adding file to multipart message: %s", err.Error())
 	}
 
-	_, err = io.Copy(part, multi_reader)
+	_, err = io.Copy(part, file)
 	if err != nil {
-		return fmt.Errorf("Error copying file content to multipart message: %s", err.Error())
+		return nil, fmt.Errorf("Error copying file content to multipart message: %s", err.Error())
 	}
 
 	err = writer.Close()
 	if err != nil {
-		return fmt.Errorf("Error closing multipart message: %s", err.Error())
+		return nil, fmt.Errorf("Error closing multipart message: %s", err.Error())
 	}
 
-	return medias_client.sendNewMediaFile(message_body, writer.FormDataContentType())
+	return medias_client.sendNewMediaFile(message_body, writer.FormDataContentType(), downloaded_from, tickets_jar)
 }
 
-func (medias_client *MediaServiceClient) sendNewMediaFile(message_body *bytes.Buffer, content_type string) error {
+// Sends a multipart message with a media file to the upload stream. Returns the medias the medias service created from it.
+func (medias_client *MediaServiceClient) sendNewMediaFile(message_body *bytes.Buffer, content_type string, downloaded_from int64, tickets_jar *cookiejar.Jar) ([]*dungeon_models.MediaIdentity, error) {
 	var endpoint string = medias_client.getHttpsEndpoint()
 	request_url := fmt.Sprintf("%s/upload-streams/stream-fragment", endpoint)
 
+	if downloaded_from != 0 {
+		request_url = fmt.Sprintf("%s?download_from=%d", request_url, downloaded_from)
+	}
+
 	request, err := http.NewRequest("POST", request_url, message_body)
 	if err != nil {
-		return fmt.Errorf("Error creating request: %s", err.Error())
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
 	}
 
 	request.Header.Set("Content-Type", content_type)
 
 	client := &http.Client{
 		Transport: medias_client.HttpTransport,
-		Jar:       medias_client.tickets_cookie_jar,
+		Jar:       tickets_jar,
 	}
 
 	response, err := client.Do(request)
 	if err != nil {
-		return fmt.Errorf("Error sending request: %s", err.Error())
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode < 200 || response.StatusCode > 299 {
-		return fmt.Errorf("Error sending request: %s", response.Status)
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
 	}
 
-	return nil
+	var added_medias []*dungeon_models.MediaIdentity = make([]*dungeon_models.MediaIdentity, 0)
+
+	err = json.NewDecoder(response.Body).Decode(&added_medias)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("Error decoding added medias: %s", err.Error())
+	}
+
+	return added_medias, nil
+}
+
+// Requests the chapters embedded on a video media file(mp4/mkv chapter atoms).
+func (medias_client MediaServiceClient) GetMediaChapters(media_uuid string) ([]dungeon_models.MediaChapter, error) {
+	var endpoint string = medias_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/medias/chapters?uuid=%s", endpoint, url.QueryEscape(media_uuid))
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: medias_client.HttpTransport,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var media_chapters []dungeon_models.MediaChapter = make([]dungeon_models.MediaChapter, 0)
+
+	err = json.NewDecoder(response.Body).Decode(&media_chapters)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding media chapters: %s", err.Error())
+	}
+
+	return media_chapters, nil
+}
+
+// Requests the MediaIdentity of a media, which includes the category and cluster paths needed to address the media file.
+func (medias_client MediaServiceClient) GetMediaIdentity(media_uuid string) (*dungeon_models.MediaIdentity, error) {
+	var endpoint string = medias_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/medias/identity?uuid=%s", endpoint, url.QueryEscape(media_uuid))
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: medias_client.HttpTransport,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var media_identity *dungeon_models.MediaIdentity = new(dungeon_models.MediaIdentity)
+
+	err = json.NewDecoder(response.Body).Decode(media_identity)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding media identity: %s", err.Error())
+	}
+
+	return media_identity, nil
+}
+
+// Requests the MediaIdentity of a media of the cluster whose content has the given hex encoded sha256. Returns nil if the cluster
+// has no media with that content.
+func (medias_client MediaServiceClient) GetClusterMediaByContentHash(cluster_uuid string, content_hash string) (*dungeon_models.MediaIdentity, error) {
+	var endpoint string = medias_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/medias/by-content-hash?cluster_uuid=%s&content_hash=%s", endpoint, url.QueryEscape(cluster_uuid), url.QueryEscape(content_hash))
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: medias_client.HttpTransport,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var media_identity *dungeon_models.MediaIdentity = new(dungeon_models.MediaIdentity)
+
+	err = json.NewDecoder(response.Body).Decode(media_identity)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding media identity: %s", err.Error())
+	}
+
+	return media_identity, nil
 }
@@ -2,9 +2,12 @@ package service_clients
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"libery-dungeon-libs/dungeonsec"
 	"libery-dungeon-libs/metadata_service_pb"
+	dungeon_models "libery-dungeon-libs/models"
 	"net/http"
 	"time"
 
@@ -42,7 +45,46 @@ func (metadata_client MetadataServiceClient) Alive() (bool, error) {
 }
 
 func (metadata_client MetadataServiceClient) getHttpsEndpoint() string {
-	return fmt.Sprintf("https://%s%s", metadata_client.BaseDomain, metadata_client.HttpAddress)
+	return fmt.Sprintf("https://%s%s", metadata_client.BaseDomain, metadata_client.resolveHttpAddress())
+}
+
+// Requests a stored video moment by its id.
+func (metadata_client MetadataServiceClient) GetVideoMoment(moment_id int) (*dungeon_models.VideoMoment, error) {
+	var endpoint string = metadata_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/video-moments/moment?id=%d", endpoint, moment_id)
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: metadata_client.HttpTransport,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var video_moment *dungeon_models.VideoMoment = new(dungeon_models.VideoMoment)
+
+	err = json.NewDecoder(response.Body).Decode(video_moment)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding video moment: %s", err.Error())
+	}
+
+	return video_moment, nil
 }
 
 func (metadata_client MetadataServiceClient) CheckClusterPrivate(cluster_uuid string) (bool, error) {
@@ -62,8 +104,11 @@ func (metadata_client MetadataServiceClient) CheckClusterPrivate(cluster_uuid st
 	}
 
 	boolean_response, err := metadata_grpc_client.CheckClusterPrivate(ctx, &message)
+	if err != nil {
+		return false, err
+	}
 
-	return boolean_response.Response, err
+	return boolean_response.Response, nil
 }
 
 func (metadata_client MetadataServiceClient) CopyEntityTagsToEntities(source_entity, cluster_domain, entities_type string, entities_uuids []string) (bool, error) {
@@ -201,8 +246,11 @@ func (metadata_client MetadataServiceClient) TagEntities(tag_id int, entities_uu
 	}
 
 	boolean_response, err := metadata_grpc_client.TagEntities(ctx, &message)
+	if err != nil {
+		return false, errors.Join(err, fmt.Errorf("In Communication/MetadataService.TagEntities, while calling metadata_grpc_client.TagEntities"))
+	}
 
-	return boolean_response.Response, err
+	return boolean_response.Response, nil
 }
 
 func (metadata_client MetadataServiceClient) UntagEntities(tag_id int, entities_uuids []string) (bool, error) {
@@ -250,3 +298,29 @@ func (metadata_client MetadataServiceClient) RemoveAllTaggingsForEntities(entiti
 
 	return boolean_response.Response, nil
 }
+
+// Notifies the metadata service that the given entities no longer exist, so all the metadata owned by them
+// (taggings, watch points, video moments, billboard references) gets removed.
+func (metadata_client MetadataServiceClient) NotifyEntitiesDeleted(entities []string) (bool, error) {
+	conn, err := grpc.Dial(metadata_client.GrpcAddress, grpc.WithTransportCredentials(metadata_client.GrpcTransport))
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	metadata_grpc_client := metadata_service_pb.NewMetadataServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message := metadata_service_pb.EntityList{
+		EntitiesUuids: entities,
+	}
+
+	boolean_response, err := metadata_grpc_client.NotifyEntitiesDeleted(ctx, &message)
+	if err != nil {
+		return false, errors.Join(err, fmt.Errorf("In Communication/MetadataService.NotifyEntitiesDeleted, while calling metadata_grpc_client.NotifyEntitiesDeleted"))
+	}
+
+	return boolean_response.Response, nil
+}
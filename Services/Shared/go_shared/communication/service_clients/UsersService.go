@@ -0,0 +1,234 @@
+package service_clients
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"libery-dungeon-libs/dungeonsec"
+	dungeon_models "libery-dungeon-libs/models"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// How long the users service's answer about a session is trusted. Revocations are final, so they are kept for longer.
+const active_session_cache_ttl time.Duration = 15 * time.Second
+const revoked_session_cache_ttl time.Duration = time.Hour
+
+// How long the claims an API token resolved to are trusted, so revoking a token or removing grants from its user takes
+// at most this long to reach the other services.
+const api_token_cache_ttl time.Duration = 15 * time.Second
+
+type UsersServiceClient struct {
+	BaseServiceClient
+	session_revocations map[string]sessionRevocation // Guarded by session_revocations_mutex
+	api_token_claims    map[string]resolvedApiToken  // By the sha256 of the token. Guarded by api_token_claims_mutex
+}
+
+type resolvedApiToken struct {
+	user_claims *dungeon_models.PlatformUserClaims // nil if the token was not valid
+	resolved_at time.Time
+}
+
+var api_token_claims_mutex sync.Mutex
+
+type sessionRevocation struct {
+	revoked    bool
+	checked_at time.Time
+}
+
+var session_revocations_mutex sync.Mutex
+
+func (users_client UsersServiceClient) getHttpsEndpoint() string {
+	return fmt.Sprintf("https://%s%s", users_client.BaseDomain, users_client.resolveHttpAddress())
+}
+
+// Asks the users service whether a login session was revoked. Answers are cached for a few seconds, so checking the
+// claims of every request doesn't mean a request to the users service each time.
+func (users_client *UsersServiceClient) IsSessionRevoked(session_uuid string) (bool, error) {
+	if revocation, is_cached := users_client.cachedSessionRevocation(session_uuid); is_cached {
+		return revocation.revoked, nil
+	}
+
+	var endpoint string = users_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/user-auth/sessions/revoked?session_uuid=%s", endpoint, url.QueryEscape(session_uuid))
+
+	request, err := http.NewRequest("GET", request_url, nil)
+	if err != nil {
+		return false, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return false, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: users_client.HttpTransport,
+		Timeout:   5 * time.Second,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return false, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return false, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var revocation_response struct {
+		Response bool `json:"response"`
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&revocation_response)
+	if err != nil {
+		return false, fmt.Errorf("Error decoding session revocation: %s", err.Error())
+	}
+
+	users_client.cacheSessionRevocation(session_uuid, revocation_response.Response)
+
+	return revocation_response.Response, nil
+}
+
+func (users_client *UsersServiceClient) cachedSessionRevocation(session_uuid string) (sessionRevocation, bool) {
+	session_revocations_mutex.Lock()
+	defer session_revocations_mutex.Unlock()
+
+	revocation, exists := users_client.session_revocations[session_uuid]
+	if !exists || time.Since(revocation.checked_at) > revocation.ttl() {
+		return revocation, false
+	}
+
+	return revocation, true
+}
+
+func (users_client *UsersServiceClient) cacheSessionRevocation(session_uuid string, revoked bool) {
+	session_revocations_mutex.Lock()
+	defer session_revocations_mutex.Unlock()
+
+	if users_client.session_revocations == nil {
+		users_client.session_revocations = make(map[string]sessionRevocation)
+	}
+
+	var now time.Time = time.Now()
+
+	for cached_session, revocation := range users_client.session_revocations {
+		if now.Sub(revocation.checked_at) > revocation.ttl() {
+			delete(users_client.session_revocations, cached_session)
+		}
+	}
+
+	users_client.session_revocations[session_uuid] = sessionRevocation{
+		revoked:    revoked,
+		checked_at: now,
+	}
+}
+
+func (revocation sessionRevocation) ttl() time.Duration {
+	if revocation.revoked {
+		return revoked_session_cache_ttl
+	}
+
+	return active_session_cache_ttl
+}
+
+// Asks the users service for the claims of the user that owns an API token. Returns nil claims if the token is not
+// valid. Answers, including rejections, are cached for a few seconds.
+func (users_client *UsersServiceClient) ResolveApiToken(api_token string) (*dungeon_models.PlatformUserClaims, error) {
+	var token_hash [32]byte = sha256.Sum256([]byte(api_token))
+	var token_key string = hex.EncodeToString(token_hash[:])
+
+	if resolved_token, is_cached := users_client.cachedApiToken(token_key); is_cached {
+		return resolved_token.user_claims, nil
+	}
+
+	var endpoint string = users_client.getHttpsEndpoint()
+	request_url := fmt.Sprintf("%s/user-auth/api-tokens/resolve", endpoint)
+
+	request_body, err := json.Marshal(map[string]string{"api_token": api_token})
+	if err != nil {
+		return nil, fmt.Errorf("Error encoding request body: %s", err.Error())
+	}
+
+	request, err := http.NewRequest("POST", request_url, bytes.NewReader(request_body))
+	if err != nil {
+		return nil, fmt.Errorf("Error creating request: %s", err.Error())
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	err = dungeonsec.SignInternalHTTPRequest(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error signing internal request: %s\nThis is likely because someone skipped a configuration step", err.Error())
+	}
+
+	client := &http.Client{
+		Transport: users_client.HttpTransport,
+		Timeout:   5 * time.Second,
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("Error sending request: %s", err.Error())
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == 404 {
+		users_client.cacheApiToken(token_key, nil)
+		return nil, nil
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("Error sending request: %s", response.Status)
+	}
+
+	var user_claims *dungeon_models.PlatformUserClaims = new(dungeon_models.PlatformUserClaims)
+
+	err = json.NewDecoder(response.Body).Decode(user_claims)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding API token claims: %s", err.Error())
+	}
+
+	users_client.cacheApiToken(token_key, user_claims)
+
+	return user_claims, nil
+}
+
+func (users_client *UsersServiceClient) cachedApiToken(token_key string) (resolvedApiToken, bool) {
+	api_token_claims_mutex.Lock()
+	defer api_token_claims_mutex.Unlock()
+
+	resolved_token, exists := users_client.api_token_claims[token_key]
+	if !exists || time.Since(resolved_token.resolved_at) > api_token_cache_ttl {
+		return resolved_token, false
+	}
+
+	return resolved_token, true
+}
+
+func (users_client *UsersServiceClient) cacheApiToken(token_key string, user_claims *dungeon_models.PlatformUserClaims) {
+	api_token_claims_mutex.Lock()
+	defer api_token_claims_mutex.Unlock()
+
+	if users_client.api_token_claims == nil {
+		users_client.api_token_claims = make(map[string]resolvedApiToken)
+	}
+
+	var now time.Time = time.Now()
+
+	for cached_token, resolved_token := range users_client.api_token_claims {
+		if now.Sub(resolved_token.resolved_at) > api_token_cache_ttl {
+			delete(users_client.api_token_claims, cached_token)
+		}
+	}
+
+	users_client.api_token_claims[token_key] = resolvedApiToken{
+		user_claims: user_claims,
+		resolved_at: now,
+	}
+}
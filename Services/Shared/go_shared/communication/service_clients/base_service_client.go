@@ -1,6 +1,7 @@
 package service_clients
 
 import (
+	"libery-dungeon-libs/models/platform_services"
 	"net/http"
 
 	"google.golang.org/grpc/credentials"
@@ -10,6 +11,7 @@ type BaseServiceClient struct {
 	GrpcTransport credentials.TransportCredentials
 	BaseDomain    string
 	HttpTransport *http.Transport
-	HttpAddress   string
+	HttpAddress   string // Used when the service directory has no route for the service
 	GrpcAddress   string
+	ServiceName   platform_services.PlatformServiceName
 }
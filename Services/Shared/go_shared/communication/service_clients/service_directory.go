@@ -0,0 +1,91 @@
+package service_clients
+
+import (
+	"fmt"
+	"libery-dungeon-libs/models/platform_services"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+type ServiceDirectoryResolver func() (map[platform_services.PlatformServiceName]string, error)
+
+// Keeps the http routes of the platform services as reported by JD. Routes are refreshed lazily, the first time a client
+// needs an address after the directory went stale, so services that come online after this one started are still found.
+type serviceDirectory struct {
+	resolver     ServiceDirectoryResolver
+	routes       map[platform_services.PlatformServiceName]string
+	refreshed_at time.Time
+	max_age      time.Duration
+	mutex        sync.Mutex
+}
+
+var platform_directory *serviceDirectory = &serviceDirectory{
+	routes: make(map[platform_services.PlatformServiceName]string),
+}
+
+// Sets where the service routes are resolved from and for how long a resolution is trusted. Without a resolver,
+// clients use the address they were configured with.
+func SetServiceDirectoryResolver(resolver ServiceDirectoryResolver, max_age time.Duration) {
+	platform_directory.mutex.Lock()
+	defer platform_directory.mutex.Unlock()
+
+	platform_directory.resolver = resolver
+	platform_directory.max_age = max_age
+	platform_directory.refreshed_at = time.Time{}
+}
+
+// Returns the route resolved for the service and whether there was one. The lock is not held while asking JD, so a slow
+// resolution doesn't block the lookups of other requests, those use the last known routes meanwhile.
+func (sd *serviceDirectory) lookup(service_name platform_services.PlatformServiceName) (string, bool) {
+	sd.mutex.Lock()
+
+	if sd.resolver == nil {
+		sd.mutex.Unlock()
+		return "", false
+	}
+
+	var resolver ServiceDirectoryResolver
+
+	if time.Since(sd.refreshed_at) > sd.max_age {
+		// Failed resolutions also count as a refresh, otherwise an unreachable JD would be asked on every request.
+		sd.refreshed_at = time.Now()
+		resolver = sd.resolver
+	}
+
+	sd.mutex.Unlock()
+
+	if resolver != nil {
+		resolved_routes, err := resolver()
+
+		sd.mutex.Lock()
+		if err != nil {
+			echo.EchoWarn(fmt.Sprintf("Could not refresh the platform services directory, keeping the last known routes: %s", err.Error()))
+		} else {
+			sd.routes = resolved_routes
+		}
+		sd.mutex.Unlock()
+	}
+
+	sd.mutex.Lock()
+	defer sd.mutex.Unlock()
+
+	service_route, exists := sd.routes[service_name]
+
+	return service_route, exists
+}
+
+// Returns the route JD reported for the client's service, or the configured address if JD doesn't know of an online instance.
+func (base_client BaseServiceClient) resolveHttpAddress() string {
+	if base_client.ServiceName == "" {
+		return base_client.HttpAddress
+	}
+
+	service_route, exists := platform_directory.lookup(base_client.ServiceName)
+	if !exists {
+		return base_client.HttpAddress
+	}
+
+	return service_route
+}
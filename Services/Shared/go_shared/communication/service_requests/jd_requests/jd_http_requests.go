@@ -0,0 +1,13 @@
+package jd_requests
+
+type CreateWebhookRequest struct {
+	TargetURL  string   `json:"target_url"`
+	EventTypes []string `json:"event_types"` // Empty means every public event
+}
+
+// An entry of the JD platform services listing. Only the fields service discovery needs are decoded.
+type PlatformServiceEntry struct {
+	ServiceName  string `json:"service_name"`
+	ServiceRoute string `json:"service_route"`
+	IsOnline     bool   `json:"is_online"`
+}
@@ -4,3 +4,10 @@ type RenameMediaRequest struct {
 	NewName   string `json:"new_name"`
 	MediaUUID string `json:"media_uuid"`
 }
+
+// The clip range and source video are read from the stored video moment, which must be a range.
+type ExportMediaClipRequest struct {
+	MomentID     int    `json:"moment_id"`
+	CategoryUUID string `json:"category_uuid"`
+	ClipName     string `json:"clip_name"`
+}
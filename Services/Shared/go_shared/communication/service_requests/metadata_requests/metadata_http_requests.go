@@ -26,6 +26,16 @@ type TagEntitiesRequest struct {
 	EntitiesUUIDs []string `json:"entities_uuids"`
 }
 
+type CreateTagAliasRequest struct {
+	DungeonTagID int    `json:"tag_id"`
+	Alias        string `json:"alias"`
+}
+
+type MergeTagsRequest struct {
+	SourceTagID int `json:"source_tag_id"` // The tag that gets merged and deleted.
+	TargetTagID int `json:"target_tag_id"` // The tag that receives the taggings.
+}
+
 type TagListRequest struct {
 	TagList []int `json:"tag_list"`
 }
@@ -55,8 +65,14 @@ type VideoMoments_VideoIdentifier struct {
 }
 
 type VideoMoments_VideoMomentData struct {
-	MomentTime  int    `json:"moment_time"`
-	MomentTitle string `json:"moment_title"`
+	MomentTime    int    `json:"moment_time"`
+	MomentEndTime int    `json:"moment_end_time"`
+	MomentTitle   string `json:"moment_title"`
+}
+
+// A moment end time is optional(0), but when given it must come after the moment time.
+func (moment_data VideoMoments_VideoMomentData) HasValidRange() bool {
+	return moment_data.MomentEndTime == 0 || moment_data.MomentEndTime > moment_data.MomentTime
 }
 
 type VideoMoments_NewVideoMoment struct {
@@ -106,6 +122,87 @@ func ParseMomentIdentifierParams(request *http.Request) (*VideoMoments_MomentIde
 	return new_request_body, nil
 }
 
+const (
+	VideoMoments_ChaptersFormat_WebVTT     string = "webvtt"
+	VideoMoments_ChaptersFormat_FFMetadata string = "ffmetadata"
+	VideoMoments_ChaptersFormat_Embedded   string = "embedded" // Chapters stored on the video file itself, only valid for imports.
+)
+
+type VideoMoments_ChaptersParams struct {
+	VideoMoments_VideoIdentifier
+	Format        string `json:"format"`
+	VideoDuration int64  `json:"video_duration"` // In milliseconds, used as the end of the last chapter on exports. Optional.
+	Replace       bool   `json:"replace"`        // Whether existing moments should be dropped on imports.
+}
+
+func ParseVideoChaptersParams(request *http.Request) (*VideoMoments_ChaptersParams, error) {
+	const (
+		format_key         string = "format"
+		video_duration_key string = "video_duration"
+		replace_key        string = "replace"
+	)
+
+	var request_params *VideoMoments_ChaptersParams = new(VideoMoments_ChaptersParams)
+
+	request_params.VideoMoments_VideoIdentifier = *ParseVideoIdentifierParams(request)
+	request_params.Format = request.URL.Query().Get(format_key)
+	request_params.Replace = request.URL.Query().Get(replace_key) == "true"
+
+	if video_duration_str := request.URL.Query().Get(video_duration_key); video_duration_str != "" {
+		video_duration, err := strconv.ParseInt(video_duration_str, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		request_params.VideoDuration = video_duration
+	}
+
+	return request_params, nil
+}
+
+const (
+	VideoMoments_SearchMode_Prefix    string = "prefix"
+	VideoMoments_SearchMode_Substring string = "substring"
+	VideoMoments_SearchMode_Fuzzy     string = "fuzzy"
+)
+
+type VideoMoments_SearchParams struct {
+	ClusterUUID string `json:"cluster_uuid"`
+	Query       string `json:"query"`
+	Mode        string `json:"mode"`
+	Limit       int    `json:"limit"`
+}
+
+func ParseVideoMomentsSearchParams(request *http.Request) (*VideoMoments_SearchParams, error) {
+	const (
+		cluster_uuid_key string = "cluster_uuid"
+		query_key        string = "query"
+		mode_key         string = "mode"
+		limit_key        string = "limit"
+	)
+
+	var request_params *VideoMoments_SearchParams = new(VideoMoments_SearchParams)
+
+	request_params.ClusterUUID = request.URL.Query().Get(cluster_uuid_key)
+	request_params.Query = request.URL.Query().Get(query_key)
+	request_params.Mode = request.URL.Query().Get(mode_key)
+
+	if request_params.Mode == "" {
+		request_params.Mode = VideoMoments_SearchMode_Substring
+	}
+
+	if limit_str := request.URL.Query().Get(limit_key); limit_str != "" {
+		limit, err := strconv.Atoi(limit_str)
+		if err != nil {
+			return nil, err
+		}
+
+		request_params.Limit = limit
+	}
+
+	return request_params, nil
+}
+
 // -------------------- Categories config --------------------
 
 type PatchCategoryBillboardTagsRequest struct {
@@ -1,20 +1,23 @@
 package dungeonsec
 
-var canGrant UserCanChecker = factory_UserCanChecker(PlatformGrant_GrantPrivileges, false)
-var canReadUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ReadUsers, true)
-var canModifyUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ModifyUsers, true)
-var canDeleteUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_DeleteUsers, true)
-var canCreateUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ModifyUsers, true)
-var canViewPrivateClusters UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_ReadPrivate, true)
-var canViewContent UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_Read, true)
-var canShareContent UserCanChecker = factory_UserCanChecker(PlatformGrant_ShareContent, true)
-var canAlterPrivateClusters UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_AlterPrivate, true)
-var canUploadFiles UserCanChecker = factory_UserCanChecker(PlatformGrant_UploadFiles, true)
-var canContentAlter UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_Alter, true)
-var canDungeonTagsCreate UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Create, true)
-var canDungeonTagsTag UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Tag, true)
-var canDungeonTagsUntag UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Untag, true)
-var canDungeonTagsTaxonomyCreate UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_TaxonomyCreate, true)
+var canGrant UserCanChecker = factory_UserCanChecker(PlatformGrant_GrantPrivileges)
+var canReadUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ReadUsers)
+var canModifyUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ModifyUsers)
+var canDeleteUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_DeleteUsers)
+var canCreateUsers UserCanChecker = factory_UserCanChecker(PlatformGrant_ModifyUsers)
+var canViewPrivateClusters UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_ReadPrivate)
+var canViewContent UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_Read)
+var canShareContent UserCanChecker = factory_UserCanChecker(PlatformGrant_ShareContent)
+var canAlterPrivateClusters UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_AlterPrivate)
+var canUploadFiles UserCanChecker = factory_UserCanChecker(PlatformGrant_UploadFiles)
+var canContentAlter UserCanChecker = factory_UserCanChecker(PlatformGrant_ClustersContent_Alter)
+var canDungeonTagsCreate UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Create)
+var canDungeonTagsTag UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Tag)
+var canDungeonTagsUntag UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_Untag)
+var canDungeonTagsTaxonomyCreate UserCanChecker = factory_UserCanChecker(PlatformGrant_DungeonTags_TaxonomyCreate)
+var canManageWebhooks UserCanChecker = factory_UserCanChecker(PlatformGrant_PlatformWebhooks_Manage)
+var canReadPlatformServices UserCanChecker = factory_UserCanChecker(PlatformGrant_PlatformServices_Read)
+var canDownloadFiles UserCanChecker = factory_UserCanChecker(PlatformGrant_DownloadFiles)
 
 func CanGrant(grants []string) bool {
 	return canGrant(grants)
@@ -56,6 +59,10 @@ func CanUploadFiles(grants []string) bool {
 	return canUploadFiles(grants)
 }
 
+func CanDownloadFiles(grants []string) bool {
+	return canDownloadFiles(grants)
+}
+
 func CanContentAlter(grants []string) bool {
 	return canContentAlter(grants)
 }
@@ -75,3 +82,11 @@ func CanDungeonTagsUntag(grants []string) bool {
 func CanDungeonTagsTaxonomyCreate(grants []string) bool {
 	return canDungeonTagsTaxonomyCreate(grants)
 }
+
+func CanManageWebhooks(grants []string) bool {
+	return canManageWebhooks(grants)
+}
+
+func CanReadPlatformServices(grants []string) bool {
+	return canReadPlatformServices(grants)
+}
@@ -0,0 +1,71 @@
+package dungeon_middlewares
+
+import (
+	"errors"
+	dungeon_models "libery-dungeon-libs/models"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Every personal API token starts with it, bearer credentials without it are not looked up as API tokens.
+const API_TOKEN_PREFIX string = "ldt_"
+
+// Returns the claims of the user that owns an API token, limited to the grants of the token. Returns nil claims and a
+// nil error when the token is not valid.
+type ApiTokenResolver func(api_token string) (*dungeon_models.PlatformUserClaims, error)
+
+var api_token_resolver ApiTokenResolver
+var api_token_mutex sync.RWMutex
+
+var ErrInvalidApiToken error = errors.New("The API token is not valid")
+var ErrApiTokensNotAccepted error = errors.New("API tokens are not accepted, no API token resolver was set")
+
+// Sets how GetUserClaims turns the API tokens sent as 'Authorization: Bearer <token>' into user claims. Until a
+// resolver is set, requests authenticated with API tokens are treated as unauthenticated.
+func SetApiTokenResolver(resolver ApiTokenResolver) {
+	api_token_mutex.Lock()
+	defer api_token_mutex.Unlock()
+
+	api_token_resolver = resolver
+}
+
+// Returns the API token in the Authorization header of the request, empty if there is none.
+func getBearerApiToken(request *http.Request) string {
+	var authorization string = request.Header.Get("Authorization")
+
+	const bearer_scheme string = "bearer "
+
+	if len(authorization) <= len(bearer_scheme) || !strings.EqualFold(authorization[:len(bearer_scheme)], bearer_scheme) {
+		return ""
+	}
+
+	var api_token string = strings.TrimSpace(authorization[len(bearer_scheme):])
+
+	if !strings.HasPrefix(api_token, API_TOKEN_PREFIX) {
+		return ""
+	}
+
+	return api_token
+}
+
+func resolveApiTokenClaims(api_token string) (*dungeon_models.PlatformUserClaims, error) {
+	api_token_mutex.RLock()
+	var resolver ApiTokenResolver = api_token_resolver
+	api_token_mutex.RUnlock()
+
+	if resolver == nil {
+		return nil, ErrApiTokensNotAccepted
+	}
+
+	user_claims, err := resolver(api_token)
+	if err != nil {
+		return nil, err
+	}
+
+	if user_claims == nil {
+		return nil, ErrInvalidApiToken
+	}
+
+	return user_claims, nil
+}
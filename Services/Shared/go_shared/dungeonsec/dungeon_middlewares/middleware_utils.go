@@ -9,7 +9,13 @@ const USER_CLAIMS_COOKIE_NAME string = "auth_user_claims"
 
 type MiddlewareFunc func(next http.HandlerFunc) http.HandlerFunc
 
+// Returns the claims of the user making the request. They come from the API token in the Authorization header if there
+// is one, otherwise from the user claims cookie.
 func GetUserClaims(request *http.Request, sk string) (*dungeon_models.PlatformUserClaims, error) {
+	if api_token := getBearerApiToken(request); api_token != "" {
+		return resolveApiTokenClaims(api_token)
+	}
+
 	user_cookie, err := request.Cookie(USER_CLAIMS_COOKIE_NAME)
 	if err != nil {
 		return nil, err
@@ -20,6 +26,11 @@ func GetUserClaims(request *http.Request, sk string) (*dungeon_models.PlatformUs
 		return nil, err
 	}
 
+	err = checkUserClaimsSession(user_claims)
+	if err != nil {
+		return nil, err
+	}
+
 	// TODO: Add a check to see if the user exists. this is a distributed system, so if a user did not changed the default jwt secret, a malicious user could create a jwt token
 	// on their system that has full access and send it to another user's system(that uses the default jwt secret) and get full access to that system. This another good argument
 	// to force users to set a jwt secret and not have a default one at all.
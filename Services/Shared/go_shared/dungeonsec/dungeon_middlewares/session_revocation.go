@@ -0,0 +1,55 @@
+package dungeon_middlewares
+
+import (
+	"errors"
+	"fmt"
+	dungeon_models "libery-dungeon-libs/models"
+	"sync"
+)
+
+// Returns whether a login session was revoked, either by its user logging out or by an admin.
+type SessionRevocationChecker func(session_uuid string) (bool, error)
+
+var session_revocation_checker SessionRevocationChecker
+var session_revocation_mutex sync.RWMutex
+
+var ErrSessionRevoked error = errors.New("The session of the user claims was revoked")
+var ErrSessionUnverified error = errors.New("Could not verify whether the session of the user claims was revoked")
+
+// Sets how GetUserClaims finds out whether the session of some claims was revoked. Until a checker is set, claims are
+// only rejected once they expire.
+func SetSessionRevocationChecker(checker SessionRevocationChecker) {
+	session_revocation_mutex.Lock()
+	defer session_revocation_mutex.Unlock()
+
+	session_revocation_checker = checker
+}
+
+// Claims issued before login sessions existed can't be revoked, so they are rejected once there is a checker. If the
+// checker fails the claims are rejected as well, a revoked session must not outlive the users service being unreachable.
+// The checker answers recently checked sessions from its cache, so a brief outage only affects sessions it didn't see lately.
+func checkUserClaimsSession(user_claims *dungeon_models.PlatformUserClaims) error {
+	session_revocation_mutex.RLock()
+	var checker SessionRevocationChecker = session_revocation_checker
+	session_revocation_mutex.RUnlock()
+
+	if checker == nil {
+		return nil
+	}
+
+	if user_claims.SessionUUID == "" {
+		return ErrSessionRevoked
+	}
+
+	is_revoked, err := checker(user_claims.SessionUUID)
+	if err != nil {
+		fmt.Printf("Failed to check whether session '%s' was revoked: %s\nRejecting the user claims.\n", user_claims.SessionUUID, err)
+		return errors.Join(ErrSessionUnverified, err)
+	}
+
+	if is_revoked {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
@@ -73,6 +73,12 @@ func CheckUserCan_UploadFiles(next func(response http.ResponseWriter, request *h
 	return checkUserCan_UploadFiles(next)
 }
 
+var checkUserCan_DownloadFiles MiddlewareFunc = factory_grantOnClaimCheckMiddleware(dungeonsec.CanDownloadFiles)
+
+func CheckUserCan_DownloadFiles(next func(response http.ResponseWriter, request *http.Request)) http.HandlerFunc {
+	return checkUserCan_DownloadFiles(next)
+}
+
 var checkUserCan_ContentAlter MiddlewareFunc = factory_grantOnClaimCheckMiddleware(dungeonsec.CanContentAlter)
 
 func CheckUserCan_ContentAlter(next func(response http.ResponseWriter, request *http.Request)) http.HandlerFunc {
@@ -102,3 +108,15 @@ var checkUserCan_DungeonTagsTaxonomyCreate MiddlewareFunc = factory_grantOnClaim
 func CheckUserCan_DungeonTagsTaxonomyCreate(next func(response http.ResponseWriter, request *http.Request)) http.HandlerFunc {
 	return checkUserCan_DungeonTagsTaxonomyCreate(next)
 }
+
+var checkUserCan_ManageWebhooks MiddlewareFunc = factory_grantOnClaimCheckMiddleware(dungeonsec.CanManageWebhooks)
+
+func CheckUserCan_ManageWebhooks(next func(response http.ResponseWriter, request *http.Request)) http.HandlerFunc {
+	return checkUserCan_ManageWebhooks(next)
+}
+
+var checkUserCan_ReadPlatformServices MiddlewareFunc = factory_grantOnClaimCheckMiddleware(dungeonsec.CanReadPlatformServices)
+
+func CheckUserCan_ReadPlatformServices(next func(response http.ResponseWriter, request *http.Request)) http.HandlerFunc {
+	return checkUserCan_ReadPlatformServices(next)
+}
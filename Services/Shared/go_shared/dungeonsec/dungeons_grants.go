@@ -26,18 +26,26 @@ const (
 	PlatformGrant_DungeonTags_Tag              string = "dungeon_tags_tag"
 	PlatformGrant_DungeonTags_Untag            string = "dungeon_tags_untag"
 	PlatformGrant_DungeonTags_TaxonomyCreate   string = "dungeon_tags_taxonomy_create"
+	PlatformGrant_PlatformWebhooks_Manage      string = "platform_webhooks_manage"
+	PlatformGrant_PlatformServices_Read        string = "platform_services_read"
 )
 
+// Grants that ALL_PRIVILEGES doesn't include, they can only be held by being granted on their own.
+var grants_excluded_from_all_privileges []string = []string{PlatformGrant_GrantPrivileges}
+
 type UserCanChecker func([]string) bool
 
-func factory_UserCanChecker(specific_grant string, included_in_all_privileges bool) UserCanChecker {
+func factory_UserCanChecker(specific_grant string) UserCanChecker {
 	return func(grants []string) bool {
-		var user_can bool = slices.Contains(grants, PlatformGrant_ALL_PRIVILEGES) && included_in_all_privileges
-
-		if !user_can {
-			user_can = slices.Contains(grants, specific_grant)
-		}
+		return GrantsInclude(grants, specific_grant)
+	}
+}
 
-		return user_can
+// Whether the grants include the given grant, either on its own or through ALL_PRIVILEGES.
+func GrantsInclude(grants []string, grant string) bool {
+	if slices.Contains(grants, grant) {
+		return true
 	}
+
+	return slices.Contains(grants, PlatformGrant_ALL_PRIVILEGES) && !slices.Contains(grants_excluded_from_all_privileges, grant)
 }
@@ -551,7 +551,7 @@ var file_metadata_requests_proto_rawDesc = []byte{
 	0x01, 0x28, 0x09, 0x52, 0x0d, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x44, 0x6f, 0x6d, 0x61,
 	0x69, 0x6e, 0x12, 0x23, 0x0a, 0x0d, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x5f, 0x74,
 	0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x65, 0x6e, 0x74, 0x69, 0x74,
-	0x69, 0x65, 0x73, 0x54, 0x79, 0x70, 0x65, 0x32, 0xd8, 0x05, 0x0a, 0x0f, 0x4d, 0x65, 0x74, 0x61,
+	0x69, 0x65, 0x73, 0x54, 0x79, 0x70, 0x65, 0x32, 0xb2, 0x06, 0x0a, 0x0f, 0x4d, 0x65, 0x74, 0x61,
 	0x64, 0x61, 0x74, 0x61, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5c, 0x0a, 0x13, 0x43,
 	0x68, 0x65, 0x63, 0x6b, 0x43, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x50, 0x72, 0x69, 0x76, 0x61,
 	0x74, 0x65, 0x12, 0x22, 0x2e, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65,
@@ -597,12 +597,18 @@ var file_metadata_requests_proto_rawDesc = []byte{
 	0x76, 0x69, 0x63, 0x65, 0x2e, 0x45, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x1a,
 	0x21, 0x2e, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69,
 	0x63, 0x65, 0x2e, 0x42, 0x6f, 0x6f, 0x6c, 0x65, 0x61, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
-	0x73, 0x65, 0x42, 0x54, 0x5a, 0x52, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
-	0x2f, 0x47, 0x65, 0x72, 0x61, 0x72, 0x64, 0x6f, 0x31, 0x31, 0x35, 0x70, 0x70, 0x2f, 0x6c, 0x69,
-	0x62, 0x65, 0x72, 0x79, 0x2d, 0x64, 0x75, 0x6e, 0x67, 0x65, 0x6f, 0x6e, 0x2f, 0x6c, 0x69, 0x62,
-	0x65, 0x72, 0x79, 0x5f, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x3b, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65,
-	0x72, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x65, 0x12, 0x58, 0x0a, 0x15, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x79, 0x45, 0x6e, 0x74, 0x69,
+	0x74, 0x69, 0x65, 0x73, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x64, 0x12, 0x1c, 0x2e, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x45,
+	0x6e, 0x74, 0x69, 0x74, 0x79, 0x4c, 0x69, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x6d, 0x65, 0x74, 0x61,
+	0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x42, 0x6f, 0x6f,
+	0x6c, 0x65, 0x61, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x54, 0x5a, 0x52,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x47, 0x65, 0x72, 0x61, 0x72,
+	0x64, 0x6f, 0x31, 0x31, 0x35, 0x70, 0x70, 0x2f, 0x6c, 0x69, 0x62, 0x65, 0x72, 0x79, 0x2d, 0x64,
+	0x75, 0x6e, 0x67, 0x65, 0x6f, 0x6e, 0x2f, 0x6c, 0x69, 0x62, 0x65, 0x72, 0x79, 0x5f, 0x6d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x3b, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x5f,
+	0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -642,16 +648,18 @@ var file_metadata_requests_proto_depIdxs = []int32{
 	1,  // 7: metadata_service.MetadataService.UntagEntities:input_type -> metadata_service.TaggableEntities
 	4,  // 8: metadata_service.MetadataService.GetEntitiesWithTaggings:input_type -> metadata_service.TagList
 	6,  // 9: metadata_service.MetadataService.DeleteEntitiesTaggings:input_type -> metadata_service.EntityList
-	2,  // 10: metadata_service.MetadataService.CheckClusterPrivate:output_type -> metadata_service.BooleanResponse
-	2,  // 11: metadata_service.MetadataService.CopyEntityTagsToEntityList:output_type -> metadata_service.BooleanResponse
-	3,  // 12: metadata_service.MetadataService.GetAllPrivateClusters:output_type -> metadata_service.AllPrivateClustersResponse
-	4,  // 13: metadata_service.MetadataService.GetEntityTags:output_type -> metadata_service.TagList
-	2,  // 14: metadata_service.MetadataService.TagEntities:output_type -> metadata_service.BooleanResponse
-	2,  // 15: metadata_service.MetadataService.UntagEntities:output_type -> metadata_service.BooleanResponse
-	5,  // 16: metadata_service.MetadataService.GetEntitiesWithTaggings:output_type -> metadata_service.EntitiesByType
-	2,  // 17: metadata_service.MetadataService.DeleteEntitiesTaggings:output_type -> metadata_service.BooleanResponse
-	10, // [10:18] is the sub-list for method output_type
-	2,  // [2:10] is the sub-list for method input_type
+	6,  // 10: metadata_service.MetadataService.NotifyEntitiesDeleted:input_type -> metadata_service.EntityList
+	2,  // 11: metadata_service.MetadataService.CheckClusterPrivate:output_type -> metadata_service.BooleanResponse
+	2,  // 12: metadata_service.MetadataService.CopyEntityTagsToEntityList:output_type -> metadata_service.BooleanResponse
+	3,  // 13: metadata_service.MetadataService.GetAllPrivateClusters:output_type -> metadata_service.AllPrivateClustersResponse
+	4,  // 14: metadata_service.MetadataService.GetEntityTags:output_type -> metadata_service.TagList
+	2,  // 15: metadata_service.MetadataService.TagEntities:output_type -> metadata_service.BooleanResponse
+	2,  // 16: metadata_service.MetadataService.UntagEntities:output_type -> metadata_service.BooleanResponse
+	5,  // 17: metadata_service.MetadataService.GetEntitiesWithTaggings:output_type -> metadata_service.EntitiesByType
+	2,  // 18: metadata_service.MetadataService.DeleteEntitiesTaggings:output_type -> metadata_service.BooleanResponse
+	2,  // 19: metadata_service.MetadataService.NotifyEntitiesDeleted:output_type -> metadata_service.BooleanResponse
+	11, // [11:20] is the sub-list for method output_type
+	2,  // [2:11] is the sub-list for method input_type
 	2,  // [2:2] is the sub-list for extension type_name
 	2,  // [2:2] is the sub-list for extension extendee
 	0,  // [0:2] is the sub-list for field type_name
@@ -28,6 +28,7 @@ const (
 	MetadataService_UntagEntities_FullMethodName              = "/metadata_service.MetadataService/UntagEntities"
 	MetadataService_GetEntitiesWithTaggings_FullMethodName    = "/metadata_service.MetadataService/GetEntitiesWithTaggings"
 	MetadataService_DeleteEntitiesTaggings_FullMethodName     = "/metadata_service.MetadataService/DeleteEntitiesTaggings"
+	MetadataService_NotifyEntitiesDeleted_FullMethodName      = "/metadata_service.MetadataService/NotifyEntitiesDeleted"
 )
 
 // MetadataServiceClient is the client API for MetadataService service.
@@ -42,6 +43,7 @@ type MetadataServiceClient interface {
 	UntagEntities(ctx context.Context, in *TaggableEntities, opts ...grpc.CallOption) (*BooleanResponse, error)
 	GetEntitiesWithTaggings(ctx context.Context, in *TagList, opts ...grpc.CallOption) (*EntitiesByType, error)
 	DeleteEntitiesTaggings(ctx context.Context, in *EntityList, opts ...grpc.CallOption) (*BooleanResponse, error)
+	NotifyEntitiesDeleted(ctx context.Context, in *EntityList, opts ...grpc.CallOption) (*BooleanResponse, error)
 }
 
 type metadataServiceClient struct {
@@ -124,6 +126,15 @@ func (c *metadataServiceClient) DeleteEntitiesTaggings(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *metadataServiceClient) NotifyEntitiesDeleted(ctx context.Context, in *EntityList, opts ...grpc.CallOption) (*BooleanResponse, error) {
+	out := new(BooleanResponse)
+	err := c.cc.Invoke(ctx, MetadataService_NotifyEntitiesDeleted_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MetadataServiceServer is the server API for MetadataService service.
 // All implementations must embed UnimplementedMetadataServiceServer
 // for forward compatibility
@@ -136,6 +147,7 @@ type MetadataServiceServer interface {
 	UntagEntities(context.Context, *TaggableEntities) (*BooleanResponse, error)
 	GetEntitiesWithTaggings(context.Context, *TagList) (*EntitiesByType, error)
 	DeleteEntitiesTaggings(context.Context, *EntityList) (*BooleanResponse, error)
+	NotifyEntitiesDeleted(context.Context, *EntityList) (*BooleanResponse, error)
 	mustEmbedUnimplementedMetadataServiceServer()
 }
 
@@ -167,6 +179,9 @@ func (UnimplementedMetadataServiceServer) GetEntitiesWithTaggings(context.Contex
 func (UnimplementedMetadataServiceServer) DeleteEntitiesTaggings(context.Context, *EntityList) (*BooleanResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntitiesTaggings not implemented")
 }
+func (UnimplementedMetadataServiceServer) NotifyEntitiesDeleted(context.Context, *EntityList) (*BooleanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method NotifyEntitiesDeleted not implemented")
+}
 func (UnimplementedMetadataServiceServer) mustEmbedUnimplementedMetadataServiceServer() {}
 
 // UnsafeMetadataServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -324,6 +339,24 @@ func _MetadataService_DeleteEntitiesTaggings_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MetadataService_NotifyEntitiesDeleted_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EntityList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetadataServiceServer).NotifyEntitiesDeleted(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MetadataService_NotifyEntitiesDeleted_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetadataServiceServer).NotifyEntitiesDeleted(ctx, req.(*EntityList))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MetadataService_ServiceDesc is the grpc.ServiceDesc for MetadataService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -363,6 +396,10 @@ var MetadataService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEntitiesTaggings",
 			Handler:    _MetadataService_DeleteEntitiesTaggings_Handler,
 		},
+		{
+			MethodName: "NotifyEntitiesDeleted",
+			Handler:    _MetadataService_NotifyEntitiesDeleted_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "metadata_requests.proto",
@@ -54,13 +54,21 @@ type PlatformUserClaims struct {
 	UserName             string   `json:"username"`
 	UserHighestHierarchy int      `json:"user_highest_hierarchy"` // Lower values mean higher hierarchy
 	UserGrants           []string `json:"user_grants"`
+	SessionUUID          string   `json:"session_uuid,omitempty"`   // The login session the claims were issued for, claims of revoked sessions are rejected
+	ApiTokenUUID         string   `json:"api_token_uuid,omitempty"` // The API token the claims were resolved from, empty when they come from a login
 }
 
 func GeneratePlatformUserClaims(user_uuid, username string, user_highest_hierarchy int, user_grants []string, expires_at time.Time, sk string) (string, error) {
+	return GeneratePlatformUserSessionClaims("", user_uuid, username, user_highest_hierarchy, user_grants, expires_at, sk)
+}
+
+// Generates the claims of a user for one of their login sessions, so the claims stop being accepted when the session is revoked.
+func GeneratePlatformUserSessionClaims(session_uuid, user_uuid, username string, user_highest_hierarchy int, user_grants []string, expires_at time.Time, sk string) (string, error) {
 	claims := &PlatformUserClaims{
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expires_at.Unix(),
 		},
+		SessionUUID:          session_uuid,
 		UserUUID:             user_uuid,
 		UserName:             username,
 		UserHighestHierarchy: user_highest_hierarchy,
@@ -14,7 +14,7 @@ type Media struct {
 	MainCategory   string    `json:"main_category"`
 	MediaThumbnail string    `json:"media_thumbnail"`
 	Type           MediaType `json:"type"`
-	DownloadedFrom int64     `json:"downloaded_from"` // Id of the thread download that created this media this media
+	DownloadedFrom int64     `json:"downloaded_from"` // Id of the downloaded media record on the downloads service, 0 if the media was not downloaded
 }
 
 func (media Media) isVideo() bool {
@@ -64,6 +64,26 @@ func (weak_identity MediaWeakIdentity) ToClusterWeakIdentity() *CategoryClusterW
 	}
 }
 
+// A named section of a video media as stored on chapter formats(webvtt, ffmetadata, mp4/mkv chapters). Times are in milliseconds.
+type MediaChapter struct {
+	StartTime int64  `json:"start_time"`
+	EndTime   int64  `json:"end_time"`
+	Title     string `json:"title"`
+}
+
+// A video moment as stored by the metadata service. Times are in milliseconds and a MomentEndTime of 0 means the moment is not a range.
+type VideoMoment struct {
+	ID            int    `json:"id"`
+	VideoUUID     string `json:"video_uuid"`
+	MomentTitle   string `json:"moment_title"`
+	MomentTime    int64  `json:"moment_time"`
+	MomentEndTime int64  `json:"moment_end_time"`
+}
+
+func (video_moment VideoMoment) IsRange() bool {
+	return video_moment.MomentEndTime > video_moment.MomentTime
+}
+
 type MediaType string
 
 const (
@@ -49,6 +49,16 @@ class CategoriesServiceStub(object):
                 request_serializer=categories__requests__pb2.GetCategoriesClusterRequest.SerializeToString,
                 response_deserializer=categories__requests__pb2.GetCategoriesClusterResponse.FromString,
                 _registered_method=True)
+        self.FilterExistingMedias = channel.unary_unary(
+                '/categories_service.CategoriesService/FilterExistingMedias',
+                request_serializer=categories__requests__pb2.UuidList.SerializeToString,
+                response_deserializer=categories__requests__pb2.UuidList.FromString,
+                _registered_method=True)
+        self.FilterExistingCategories = channel.unary_unary(
+                '/categories_service.CategoriesService/FilterExistingCategories',
+                request_serializer=categories__requests__pb2.UuidList.SerializeToString,
+                response_deserializer=categories__requests__pb2.UuidList.FromString,
+                _registered_method=True)
 
 
 class CategoriesServiceServicer(object):
@@ -72,6 +82,18 @@ class CategoriesServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def FilterExistingMedias(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
+    def FilterExistingCategories(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_CategoriesServiceServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -90,6 +112,16 @@ def add_CategoriesServiceServicer_to_server(servicer, server):
                     request_deserializer=categories__requests__pb2.GetCategoriesClusterRequest.FromString,
                     response_serializer=categories__requests__pb2.GetCategoriesClusterResponse.SerializeToString,
             ),
+            'FilterExistingMedias': grpc.unary_unary_rpc_method_handler(
+                    servicer.FilterExistingMedias,
+                    request_deserializer=categories__requests__pb2.UuidList.FromString,
+                    response_serializer=categories__requests__pb2.UuidList.SerializeToString,
+            ),
+            'FilterExistingCategories': grpc.unary_unary_rpc_method_handler(
+                    servicer.FilterExistingCategories,
+                    request_deserializer=categories__requests__pb2.UuidList.FromString,
+                    response_serializer=categories__requests__pb2.UuidList.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'categories_service.CategoriesService', rpc_method_handlers)
@@ -181,3 +213,57 @@ class CategoriesService(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def FilterExistingMedias(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/categories_service.CategoriesService/FilterExistingMedias',
+            categories__requests__pb2.UuidList.SerializeToString,
+            categories__requests__pb2.UuidList.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
+
+    @staticmethod
+    def FilterExistingCategories(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/categories_service.CategoriesService/FilterExistingCategories',
+            categories__requests__pb2.UuidList.SerializeToString,
+            categories__requests__pb2.UuidList.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
@@ -75,6 +75,11 @@ class MetadataServiceStub(object):
                 request_serializer=metadata__requests__pb2.EntityList.SerializeToString,
                 response_deserializer=metadata__requests__pb2.BooleanResponse.FromString,
                 _registered_method=True)
+        self.NotifyEntitiesDeleted = channel.unary_unary(
+                '/metadata_service.MetadataService/NotifyEntitiesDeleted',
+                request_serializer=metadata__requests__pb2.EntityList.SerializeToString,
+                response_deserializer=metadata__requests__pb2.BooleanResponse.FromString,
+                _registered_method=True)
 
 
 class MetadataServiceServicer(object):
@@ -128,6 +133,12 @@ class MetadataServiceServicer(object):
         context.set_details('Method not implemented!')
         raise NotImplementedError('Method not implemented!')
 
+    def NotifyEntitiesDeleted(self, request, context):
+        """Missing associated documentation comment in .proto file."""
+        context.set_code(grpc.StatusCode.UNIMPLEMENTED)
+        context.set_details('Method not implemented!')
+        raise NotImplementedError('Method not implemented!')
+
 
 def add_MetadataServiceServicer_to_server(servicer, server):
     rpc_method_handlers = {
@@ -171,6 +182,11 @@ def add_MetadataServiceServicer_to_server(servicer, server):
                     request_deserializer=metadata__requests__pb2.EntityList.FromString,
                     response_serializer=metadata__requests__pb2.BooleanResponse.SerializeToString,
             ),
+            'NotifyEntitiesDeleted': grpc.unary_unary_rpc_method_handler(
+                    servicer.NotifyEntitiesDeleted,
+                    request_deserializer=metadata__requests__pb2.EntityList.FromString,
+                    response_serializer=metadata__requests__pb2.BooleanResponse.SerializeToString,
+            ),
     }
     generic_handler = grpc.method_handlers_generic_handler(
             'metadata_service.MetadataService', rpc_method_handlers)
@@ -397,3 +413,30 @@ class MetadataService(object):
             timeout,
             metadata,
             _registered_method=True)
+
+    @staticmethod
+    def NotifyEntitiesDeleted(request,
+            target,
+            options=(),
+            channel_credentials=None,
+            call_credentials=None,
+            insecure=False,
+            compression=None,
+            wait_for_ready=None,
+            timeout=None,
+            metadata=None):
+        return grpc.experimental.unary_unary(
+            request,
+            target,
+            '/metadata_service.MetadataService/NotifyEntitiesDeleted',
+            metadata__requests__pb2.EntityList.SerializeToString,
+            metadata__requests__pb2.BooleanResponse.FromString,
+            options,
+            channel_credentials,
+            insecure,
+            call_credentials,
+            compression,
+            wait_for_ready,
+            timeout,
+            metadata,
+            _registered_method=True)
@@ -26,11 +26,40 @@ var service_settings map[string]any = make(map[string]any)
 
 var LOCALTIME string = "America/Mexico_City"
 
+// How long a login session lasts without being refreshed. The user claims are short lived access tokens renewed
+// with the refresh token of the session, see ACCESS_TOKEN_EXPIRATION.
 var USER_CLAIMS_EXPIRATION_HOURS time.Duration = 7 * (24 * time.Hour)
 
+// How long the user claims are valid for. Changes to the grants of a user reach their claims when they are renewed.
+var ACCESS_TOKEN_EXPIRATION time.Duration = 15 * time.Minute
+
 var USER_CLAIMS_COOKIE_NAME string = "auth_user_claims"
+var REFRESH_TOKEN_COOKIE_NAME string = "auth_refresh_token"
 var INITIAL_SETUP_SECRET string = ""
 
+// The issuer authenticator apps show next to the TOTP codes of the platform.
+var TOTP_ISSUER string = "LiberyDungeon"
+
+// How long a user that passed the first login step has to send their second factor.
+var TWO_FACTOR_CHALLENGE_EXPIRATION time.Duration = 5 * time.Minute
+
+// Failed logins a username or a client ip can have before being locked out. Every failure past the limit doubles the
+// lockout, starting from LOGIN_LOCKOUT_BASE and up to LOGIN_LOCKOUT_MAX.
+var LOGIN_MAX_FAILED_ATTEMPTS int = 5
+var LOGIN_MAX_FAILED_ATTEMPTS_PER_IP int = 20
+var LOGIN_LOCKOUT_BASE time.Duration = 30 * time.Second
+var LOGIN_LOCKOUT_MAX time.Duration = time.Hour
+
+// Whether the client ip of a login is taken from the X-Forwarded-For header. Only enable it when the service is behind
+// a reverse proxy that sets it, otherwise clients can pick their own ip.
+var LOGIN_TRUST_FORWARDED_FOR bool = false
+
+// Login attempts older than this are removed from the audit records on startup.
+var LOGIN_AUDIT_RETENTION time.Duration = 90 * (24 * time.Hour)
+
+// How many API tokens a user can have at once, expired ones included until they are revoked.
+var API_TOKENS_MAX_PER_USER int = 20
+
 func VerifyConfig() {
 
 	if SERVICE_PORT == "" {
@@ -104,6 +133,46 @@ func loadSettings() error {
 		panic(fmt.Sprintf("INITIAL_SETUP_SECRET is not set on '%s'", settings_path))
 	}
 
+	if _, exists := settings["TOTP_ISSUER"]; exists {
+		TOTP_ISSUER = settings["TOTP_ISSUER"].(string)
+	}
+
+	if _, exists := settings["TWO_FACTOR_CHALLENGE_EXPIRATION_MINUTES"]; exists {
+		TWO_FACTOR_CHALLENGE_EXPIRATION = time.Duration(settings["TWO_FACTOR_CHALLENGE_EXPIRATION_MINUTES"].(float64) * float64(time.Minute))
+	}
+
+	if _, exists := settings["ACCESS_TOKEN_EXPIRATION_MINUTES"]; exists {
+		ACCESS_TOKEN_EXPIRATION = time.Duration(settings["ACCESS_TOKEN_EXPIRATION_MINUTES"].(float64) * float64(time.Minute))
+	}
+
+	if _, exists := settings["LOGIN_MAX_FAILED_ATTEMPTS"]; exists {
+		LOGIN_MAX_FAILED_ATTEMPTS = int(settings["LOGIN_MAX_FAILED_ATTEMPTS"].(float64))
+	}
+
+	if _, exists := settings["LOGIN_MAX_FAILED_ATTEMPTS_PER_IP"]; exists {
+		LOGIN_MAX_FAILED_ATTEMPTS_PER_IP = int(settings["LOGIN_MAX_FAILED_ATTEMPTS_PER_IP"].(float64))
+	}
+
+	if _, exists := settings["LOGIN_LOCKOUT_BASE_SECONDS"]; exists {
+		LOGIN_LOCKOUT_BASE = time.Duration(settings["LOGIN_LOCKOUT_BASE_SECONDS"].(float64) * float64(time.Second))
+	}
+
+	if _, exists := settings["LOGIN_LOCKOUT_MAX_MINUTES"]; exists {
+		LOGIN_LOCKOUT_MAX = time.Duration(settings["LOGIN_LOCKOUT_MAX_MINUTES"].(float64) * float64(time.Minute))
+	}
+
+	if _, exists := settings["LOGIN_TRUST_FORWARDED_FOR"]; exists {
+		LOGIN_TRUST_FORWARDED_FOR = settings["LOGIN_TRUST_FORWARDED_FOR"].(bool)
+	}
+
+	if _, exists := settings["LOGIN_AUDIT_RETENTION_DAYS"]; exists {
+		LOGIN_AUDIT_RETENTION = time.Duration(settings["LOGIN_AUDIT_RETENTION_DAYS"].(float64) * float64(24*time.Hour))
+	}
+
+	if _, exists := settings["API_TOKENS_MAX_PER_USER"]; exists {
+		API_TOKENS_MAX_PER_USER = int(settings["API_TOKENS_MAX_PER_USER"].(float64))
+	}
+
 	service_settings = settings
 
 	return nil
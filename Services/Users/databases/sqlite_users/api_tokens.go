@@ -0,0 +1,217 @@
+package sqlite_users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	service_models "libery_users_service/models"
+)
+
+const api_token_columns string = "`uuid`, `user`, `name`, `token_prefix`, `token_hash`, `created_at`, `expires_at`, `last_used_at`"
+
+func (users_db *UsersDB) ensureApiTokensSchema() error {
+	_, err := users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `api_tokens` (`uuid` TEXT PRIMARY KEY, `user` TEXT NOT NULL, `name` TEXT NOT NULL, `token_prefix` TEXT NOT NULL, `token_hash` TEXT NOT NULL UNIQUE, `created_at` INTEGER NOT NULL, `expires_at` INTEGER NOT NULL DEFAULT 0, `last_used_at` INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		return fmt.Errorf("Error creating api_tokens table: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `api_tokens_user` ON `api_tokens`(`user`)")
+	if err != nil {
+		return fmt.Errorf("Error creating api_tokens user index: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `api_token_grants` (`token` TEXT NOT NULL, `grant` TEXT NOT NULL, PRIMARY KEY(`token`, `grant`))")
+	if err != nil {
+		return fmt.Errorf("Error creating api_token_grants table: %s", err)
+	}
+
+	return nil
+}
+
+func (users_db *UsersDB) InsertApiTokenCTX(ctx context.Context, api_token *service_models.ApiToken) error {
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO `api_tokens`("+api_token_columns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)", api_token.UUID, api_token.UserUUID, api_token.Name, api_token.TokenPrefix, api_token.TokenHash, api_token.CreatedAt, api_token.ExpiresAt, api_token.LastUsedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, grant := range api_token.Grants {
+		_, err = tx.ExecContext(ctx, "INSERT INTO `api_token_grants`(`token`, `grant`) VALUES (?, ?)", api_token.UUID, grant)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (users_db *UsersDB) InsertApiToken(api_token *service_models.ApiToken) error {
+	return users_db.InsertApiTokenCTX(context.Background(), api_token)
+}
+
+// Returns the token with the given hash, nil if there is none.
+func (users_db *UsersDB) GetApiTokenByHashCTX(ctx context.Context, token_hash string) (*service_models.ApiToken, error) {
+	var api_token *service_models.ApiToken = new(service_models.ApiToken)
+
+	err := users_db.db_conn.QueryRowContext(ctx, "SELECT "+api_token_columns+" FROM `api_tokens` WHERE `token_hash` = ?", token_hash).Scan(&api_token.UUID, &api_token.UserUUID, &api_token.Name, &api_token.TokenPrefix, &api_token.TokenHash, &api_token.CreatedAt, &api_token.ExpiresAt, &api_token.LastUsedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	api_token.Grants, err = users_db.getApiTokenGrants(ctx, api_token.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return api_token, nil
+}
+
+func (users_db *UsersDB) GetApiTokenByHash(token_hash string) (*service_models.ApiToken, error) {
+	return users_db.GetApiTokenByHashCTX(context.Background(), token_hash)
+}
+
+// Returns the tokens of the user, newest first.
+func (users_db *UsersDB) GetUserApiTokensCTX(ctx context.Context, user_uuid string) ([]*service_models.ApiToken, error) {
+	var api_tokens []*service_models.ApiToken = make([]*service_models.ApiToken, 0)
+
+	rows, err := users_db.db_conn.QueryContext(ctx, "SELECT "+api_token_columns+" FROM `api_tokens` WHERE `user` = ? ORDER BY `created_at` DESC", user_uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var api_token *service_models.ApiToken = new(service_models.ApiToken)
+
+		err = rows.Scan(&api_token.UUID, &api_token.UserUUID, &api_token.Name, &api_token.TokenPrefix, &api_token.TokenHash, &api_token.CreatedAt, &api_token.ExpiresAt, &api_token.LastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		api_tokens = append(api_tokens, api_token)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	rows.Close()
+
+	for _, api_token := range api_tokens {
+		api_token.Grants, err = users_db.getApiTokenGrants(ctx, api_token.UUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return api_tokens, nil
+}
+
+func (users_db *UsersDB) GetUserApiTokens(user_uuid string) ([]*service_models.ApiToken, error) {
+	return users_db.GetUserApiTokensCTX(context.Background(), user_uuid)
+}
+
+func (users_db *UsersDB) getApiTokenGrants(ctx context.Context, token_uuid string) ([]string, error) {
+	var grants []string = make([]string, 0)
+
+	rows, err := users_db.db_conn.QueryContext(ctx, "SELECT `grant` FROM `api_token_grants` WHERE `token` = ?", token_uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var grant string
+
+		err = rows.Scan(&grant)
+		if err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, grant)
+	}
+
+	return grants, rows.Err()
+}
+
+func (users_db *UsersDB) CountUserApiTokensCTX(ctx context.Context, user_uuid string) (int, error) {
+	var token_count int
+
+	err := users_db.db_conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM `api_tokens` WHERE `user` = ?", user_uuid).Scan(&token_count)
+
+	return token_count, err
+}
+
+func (users_db *UsersDB) CountUserApiTokens(user_uuid string) (int, error) {
+	return users_db.CountUserApiTokensCTX(context.Background(), user_uuid)
+}
+
+func (users_db *UsersDB) UpdateApiTokenLastUsedCTX(ctx context.Context, token_uuid string, last_used_at int64) error {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `api_tokens` SET `last_used_at` = ? WHERE `uuid` = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, last_used_at, token_uuid)
+
+	return err
+}
+
+func (users_db *UsersDB) UpdateApiTokenLastUsed(token_uuid string, last_used_at int64) error {
+	return users_db.UpdateApiTokenLastUsedCTX(context.Background(), token_uuid, last_used_at)
+}
+
+// Deletes a token of the user. Returns false if the user has no token with that uuid.
+func (users_db *UsersDB) DeleteUserApiTokenCTX(ctx context.Context, user_uuid string, token_uuid string) (bool, error) {
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM `api_tokens` WHERE `uuid` = ? AND `user` = ?", token_uuid, user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	rows_affected, err := result.RowsAffected()
+	if err != nil || rows_affected == 0 {
+		tx.Rollback()
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `api_token_grants` WHERE `token` = ?", token_uuid)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (users_db *UsersDB) DeleteUserApiToken(user_uuid string, token_uuid string) (bool, error) {
+	return users_db.DeleteUserApiTokenCTX(context.Background(), user_uuid, token_uuid)
+}
+
+func deleteUserApiTokensTx(ctx context.Context, tx *sql.Tx, user_uuid string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM `api_token_grants` WHERE `token` IN (SELECT `uuid` FROM `api_tokens` WHERE `user` = ?)", user_uuid)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `api_tokens` WHERE `user` = ?", user_uuid)
+
+	return err
+}
@@ -0,0 +1,101 @@
+package sqlite_users
+
+import (
+	"context"
+	"fmt"
+	service_models "libery_users_service/models"
+)
+
+func (users_db *UsersDB) ensureLoginAttemptsSchema() error {
+	_, err := users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `login_attempts` (`id` INTEGER PRIMARY KEY AUTOINCREMENT, `username` TEXT NOT NULL, `client_ip` TEXT NOT NULL, `user_agent` TEXT NOT NULL, `outcome` TEXT NOT NULL, `attempted_at` INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating login_attempts table: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `login_attempts_username` ON `login_attempts`(`username`, `attempted_at`)")
+	if err != nil {
+		return fmt.Errorf("Error creating login_attempts index: %s", err)
+	}
+
+	return nil
+}
+
+func (users_db *UsersDB) InsertLoginAttemptCTX(ctx context.Context, login_attempt *service_models.LoginAttempt) error {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "INSERT INTO `login_attempts`(`username`, `client_ip`, `user_agent`, `outcome`, `attempted_at`) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, login_attempt.Username, login_attempt.ClientIP, login_attempt.UserAgent, string(login_attempt.Outcome), login_attempt.AttemptedAt)
+	if err != nil {
+		return err
+	}
+
+	login_attempt.ID, err = result.LastInsertId()
+
+	return err
+}
+
+func (users_db *UsersDB) InsertLoginAttempt(login_attempt *service_models.LoginAttempt) error {
+	return users_db.InsertLoginAttemptCTX(context.Background(), login_attempt)
+}
+
+// Returns the login attempts newest first, only those made with the given username if it's not empty.
+func (users_db *UsersDB) GetLoginAttemptsCTX(ctx context.Context, username string, limit int, offset int) ([]service_models.LoginAttempt, error) {
+	var login_attempts []service_models.LoginAttempt = make([]service_models.LoginAttempt, 0)
+
+	var attempts_query string = "SELECT `id`, `username`, `client_ip`, `user_agent`, `outcome`, `attempted_at` FROM `login_attempts`"
+	var query_args []interface{} = make([]interface{}, 0)
+
+	if username != "" {
+		attempts_query += " WHERE `username` = ?"
+		query_args = append(query_args, username)
+	}
+
+	attempts_query += " ORDER BY `id` DESC LIMIT ? OFFSET ?"
+	query_args = append(query_args, limit, offset)
+
+	rows, err := users_db.db_conn.QueryContext(ctx, attempts_query, query_args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var login_attempt service_models.LoginAttempt
+
+		err = rows.Scan(&login_attempt.ID, &login_attempt.Username, &login_attempt.ClientIP, &login_attempt.UserAgent, &login_attempt.Outcome, &login_attempt.AttemptedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		login_attempts = append(login_attempts, login_attempt)
+	}
+
+	return login_attempts, rows.Err()
+}
+
+func (users_db *UsersDB) GetLoginAttempts(username string, limit int, offset int) ([]service_models.LoginAttempt, error) {
+	return users_db.GetLoginAttemptsCTX(context.Background(), username, limit, offset)
+}
+
+// Removes the login attempts made before the given unix timestamp. Returns how many were removed.
+func (users_db *UsersDB) DeleteLoginAttemptsBeforeCTX(ctx context.Context, before int64) (int64, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "DELETE FROM `login_attempts` WHERE `attempted_at` < ?")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (users_db *UsersDB) DeleteLoginAttemptsBefore(before int64) (int64, error) {
+	return users_db.DeleteLoginAttemptsBeforeCTX(context.Background(), before)
+}
@@ -0,0 +1,194 @@
+package sqlite_users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	service_models "libery_users_service/models"
+	"time"
+)
+
+const user_session_columns string = "`uuid`, `user`, `refresh_hash`, `previous_refresh_hash`, `client_ip`, `user_agent`, `created_at`, `last_refreshed_at`, `expires_at`, `revoked_at`"
+
+func (users_db *UsersDB) ensureSessionsSchema() error {
+	_, err := users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `user_sessions` (`uuid` TEXT PRIMARY KEY, `user` TEXT NOT NULL, `refresh_hash` TEXT NOT NULL, `previous_refresh_hash` TEXT NOT NULL DEFAULT '', `client_ip` TEXT NOT NULL, `user_agent` TEXT NOT NULL, `created_at` INTEGER NOT NULL, `last_refreshed_at` INTEGER NOT NULL, `expires_at` INTEGER NOT NULL, `revoked_at` INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_sessions table: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `user_sessions_user` ON `user_sessions`(`user`)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_sessions user index: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `user_sessions_refresh_hash` ON `user_sessions`(`refresh_hash`)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_sessions refresh index: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `user_sessions_previous_refresh_hash` ON `user_sessions`(`previous_refresh_hash`)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_sessions previous refresh index: %s", err)
+	}
+
+	return nil
+}
+
+func (users_db *UsersDB) InsertUserSessionCTX(ctx context.Context, user_session *service_models.UserSession) error {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "INSERT INTO `user_sessions`("+user_session_columns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, user_session.UUID, user_session.UserUUID, user_session.RefreshHash, user_session.PreviousRefreshHash, user_session.ClientIP, user_session.UserAgent, user_session.CreatedAt, user_session.LastRefreshedAt, user_session.ExpiresAt, user_session.RevokedAt)
+
+	return err
+}
+
+func (users_db *UsersDB) InsertUserSession(user_session *service_models.UserSession) error {
+	return users_db.InsertUserSessionCTX(context.Background(), user_session)
+}
+
+// Returns the session, nil if it doesn't exist.
+func (users_db *UsersDB) GetUserSessionCTX(ctx context.Context, session_uuid string) (*service_models.UserSession, error) {
+	return users_db.queryUserSession(ctx, "SELECT "+user_session_columns+" FROM `user_sessions` WHERE `uuid` = ?", session_uuid)
+}
+
+func (users_db *UsersDB) GetUserSession(session_uuid string) (*service_models.UserSession, error) {
+	return users_db.GetUserSessionCTX(context.Background(), session_uuid)
+}
+
+// Returns the session whose current or previous refresh token has the given hash, nil if there is none.
+func (users_db *UsersDB) GetUserSessionByRefreshHashCTX(ctx context.Context, refresh_hash string) (*service_models.UserSession, error) {
+	return users_db.queryUserSession(ctx, "SELECT "+user_session_columns+" FROM `user_sessions` WHERE `refresh_hash` = ? OR `previous_refresh_hash` = ?", refresh_hash, refresh_hash)
+}
+
+func (users_db *UsersDB) GetUserSessionByRefreshHash(refresh_hash string) (*service_models.UserSession, error) {
+	return users_db.GetUserSessionByRefreshHashCTX(context.Background(), refresh_hash)
+}
+
+func (users_db *UsersDB) queryUserSession(ctx context.Context, session_query string, query_args ...interface{}) (*service_models.UserSession, error) {
+	var user_session *service_models.UserSession = new(service_models.UserSession)
+
+	err := users_db.db_conn.QueryRowContext(ctx, session_query, query_args...).Scan(&user_session.UUID, &user_session.UserUUID, &user_session.RefreshHash, &user_session.PreviousRefreshHash, &user_session.ClientIP, &user_session.UserAgent, &user_session.CreatedAt, &user_session.LastRefreshedAt, &user_session.ExpiresAt, &user_session.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user_session, nil
+}
+
+// Returns the sessions of the user that were not revoked and didn't expire, most recently refreshed first.
+func (users_db *UsersDB) GetActiveUserSessionsCTX(ctx context.Context, user_uuid string) ([]*service_models.UserSession, error) {
+	var user_sessions []*service_models.UserSession = make([]*service_models.UserSession, 0)
+
+	rows, err := users_db.db_conn.QueryContext(ctx, "SELECT "+user_session_columns+" FROM `user_sessions` WHERE `user` = ? AND `revoked_at` = 0 AND `expires_at` > ? ORDER BY `last_refreshed_at` DESC", user_uuid, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user_session *service_models.UserSession = new(service_models.UserSession)
+
+		err = rows.Scan(&user_session.UUID, &user_session.UserUUID, &user_session.RefreshHash, &user_session.PreviousRefreshHash, &user_session.ClientIP, &user_session.UserAgent, &user_session.CreatedAt, &user_session.LastRefreshedAt, &user_session.ExpiresAt, &user_session.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		user_sessions = append(user_sessions, user_session)
+	}
+
+	return user_sessions, rows.Err()
+}
+
+func (users_db *UsersDB) GetActiveUserSessions(user_uuid string) ([]*service_models.UserSession, error) {
+	return users_db.GetActiveUserSessionsCTX(context.Background(), user_uuid)
+}
+
+// Replaces the refresh token of an active session and pushes back its expiration. Returns false if the session's
+// refresh token is no longer the given one, which means another refresh used it first.
+func (users_db *UsersDB) RotateSessionRefreshCTX(ctx context.Context, session_uuid string, old_refresh_hash string, new_refresh_hash string, expires_at int64) (bool, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `user_sessions` SET `refresh_hash` = ?, `previous_refresh_hash` = ?, `last_refreshed_at` = ?, `expires_at` = ? WHERE `uuid` = ? AND `refresh_hash` = ? AND `revoked_at` = 0")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, new_refresh_hash, old_refresh_hash, time.Now().Unix(), expires_at, session_uuid, old_refresh_hash)
+	if err != nil {
+		return false, err
+	}
+
+	rows_affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows_affected > 0, nil
+}
+
+func (users_db *UsersDB) RotateSessionRefresh(session_uuid string, old_refresh_hash string, new_refresh_hash string, expires_at int64) (bool, error) {
+	return users_db.RotateSessionRefreshCTX(context.Background(), session_uuid, old_refresh_hash, new_refresh_hash, expires_at)
+}
+
+func (users_db *UsersDB) RevokeUserSessionCTX(ctx context.Context, session_uuid string) error {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `user_sessions` SET `revoked_at` = ? WHERE `uuid` = ? AND `revoked_at` = 0")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, time.Now().Unix(), session_uuid)
+
+	return err
+}
+
+func (users_db *UsersDB) RevokeUserSession(session_uuid string) error {
+	return users_db.RevokeUserSessionCTX(context.Background(), session_uuid)
+}
+
+// Revokes every session of the user. Returns how many sessions were active.
+func (users_db *UsersDB) RevokeUserSessionsCTX(ctx context.Context, user_uuid string) (int64, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `user_sessions` SET `revoked_at` = ? WHERE `user` = ? AND `revoked_at` = 0")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, time.Now().Unix(), user_uuid)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (users_db *UsersDB) RevokeUserSessions(user_uuid string) (int64, error) {
+	return users_db.RevokeUserSessionsCTX(context.Background(), user_uuid)
+}
+
+// Removes the sessions that expired or were revoked before the given unix timestamp. Returns how many were removed.
+func (users_db *UsersDB) DeleteEndedSessionsCTX(ctx context.Context, before int64) (int64, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "DELETE FROM `user_sessions` WHERE `expires_at` < ? OR (`revoked_at` > 0 AND `revoked_at` < ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, before, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (users_db *UsersDB) DeleteEndedSessions(before int64) (int64, error) {
+	return users_db.DeleteEndedSessionsCTX(context.Background(), before)
+}
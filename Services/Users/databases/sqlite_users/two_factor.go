@@ -0,0 +1,209 @@
+package sqlite_users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	service_models "libery_users_service/models"
+	"time"
+)
+
+// The users schema is only written when the database is created, so tables added after it are created on startup.
+func (users_db *UsersDB) ensureTwoFactorSchema() error {
+	_, err := users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `user_totp` (`user` TEXT PRIMARY KEY, `secret` TEXT NOT NULL, `confirmed` INTEGER NOT NULL DEFAULT 0, `last_used_step` INTEGER NOT NULL DEFAULT 0, `created_at` INTEGER NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_totp table: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE TABLE IF NOT EXISTS `user_recovery_codes` (`id` INTEGER PRIMARY KEY AUTOINCREMENT, `user` TEXT NOT NULL, `code_hash` TEXT NOT NULL, `used_at` INTEGER)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_recovery_codes table: %s", err)
+	}
+
+	_, err = users_db.db_conn.Exec("CREATE INDEX IF NOT EXISTS `user_recovery_codes_user` ON `user_recovery_codes`(`user`)")
+	if err != nil {
+		return fmt.Errorf("Error creating user_recovery_codes index: %s", err)
+	}
+
+	return nil
+}
+
+// Saves a new TOTP enrollment for the user, replacing the one they had along with its recovery codes.
+func (users_db *UsersDB) SaveUserTOTPCTX(ctx context.Context, user_totp *service_models.UserTOTP) error {
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO `user_totp`(`user`, `secret`, `confirmed`, `last_used_step`, `created_at`) VALUES (?, ?, ?, ?, ?)", user_totp.UserUUID, user_totp.SealedSecret, user_totp.Confirmed, user_totp.LastUsedStep, user_totp.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `user_recovery_codes` WHERE `user` = ?", user_totp.UserUUID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (users_db *UsersDB) SaveUserTOTP(user_totp *service_models.UserTOTP) error {
+	return users_db.SaveUserTOTPCTX(context.Background(), user_totp)
+}
+
+// Returns the TOTP enrollment of the user, nil if they have none.
+func (users_db *UsersDB) GetUserTOTPCTX(ctx context.Context, user_uuid string) (*service_models.UserTOTP, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "SELECT `user`, `secret`, `confirmed`, `last_used_step`, `created_at` FROM `user_totp` WHERE `user` = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var user_totp *service_models.UserTOTP = new(service_models.UserTOTP)
+
+	err = stmt.QueryRowContext(ctx, user_uuid).Scan(&user_totp.UserUUID, &user_totp.SealedSecret, &user_totp.Confirmed, &user_totp.LastUsedStep, &user_totp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return user_totp, nil
+}
+
+func (users_db *UsersDB) GetUserTOTP(user_uuid string) (*service_models.UserTOTP, error) {
+	return users_db.GetUserTOTPCTX(context.Background(), user_uuid)
+}
+
+// Records the time step of an accepted code, confirming the enrollment if it wasn't. Returns false if a code of that
+// step or a later one was already accepted, which means the code is being replayed.
+func (users_db *UsersDB) UseTOTPStepCTX(ctx context.Context, user_uuid string, step int64) (bool, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `user_totp` SET `last_used_step` = ?, `confirmed` = 1 WHERE `user` = ? AND `last_used_step` < ?")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, step, user_uuid, step)
+	if err != nil {
+		return false, err
+	}
+
+	rows_affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows_affected > 0, nil
+}
+
+func (users_db *UsersDB) UseTOTPStep(user_uuid string, step int64) (bool, error) {
+	return users_db.UseTOTPStepCTX(context.Background(), user_uuid, step)
+}
+
+// Replaces the recovery codes of the user with the given ones. Only the hashes of the codes are stored.
+func (users_db *UsersDB) ReplaceRecoveryCodesCTX(ctx context.Context, user_uuid string, code_hashes []string) error {
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `user_recovery_codes` WHERE `user` = ?", user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, code_hash := range code_hashes {
+		_, err = tx.ExecContext(ctx, "INSERT INTO `user_recovery_codes`(`user`, `code_hash`) VALUES (?, ?)", user_uuid, code_hash)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (users_db *UsersDB) ReplaceRecoveryCodes(user_uuid string, code_hashes []string) error {
+	return users_db.ReplaceRecoveryCodesCTX(context.Background(), user_uuid, code_hashes)
+}
+
+// Marks an unused recovery code of the user as used. Returns false if the user has no unused code with that hash.
+func (users_db *UsersDB) UseRecoveryCodeCTX(ctx context.Context, user_uuid string, code_hash string) (bool, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "UPDATE `user_recovery_codes` SET `used_at` = ? WHERE `user` = ? AND `code_hash` = ? AND `used_at` IS NULL")
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, time.Now().Unix(), user_uuid, code_hash)
+	if err != nil {
+		return false, err
+	}
+
+	rows_affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows_affected > 0, nil
+}
+
+func (users_db *UsersDB) UseRecoveryCode(user_uuid string, code_hash string) (bool, error) {
+	return users_db.UseRecoveryCodeCTX(context.Background(), user_uuid, code_hash)
+}
+
+func (users_db *UsersDB) CountRecoveryCodesCTX(ctx context.Context, user_uuid string) (int, error) {
+	stmt, err := users_db.db_conn.PrepareContext(ctx, "SELECT count(*) FROM `user_recovery_codes` WHERE `user` = ? AND `used_at` IS NULL")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var codes_left int
+
+	err = stmt.QueryRowContext(ctx, user_uuid).Scan(&codes_left)
+
+	return codes_left, err
+}
+
+func (users_db *UsersDB) CountRecoveryCodes(user_uuid string) (int, error) {
+	return users_db.CountRecoveryCodesCTX(context.Background(), user_uuid)
+}
+
+// Removes the TOTP enrollment and recovery codes of the user.
+func (users_db *UsersDB) DeleteUserTwoFactorCTX(ctx context.Context, user_uuid string) error {
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = deleteUserTwoFactorTx(ctx, tx, user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (users_db *UsersDB) DeleteUserTwoFactor(user_uuid string) error {
+	return users_db.DeleteUserTwoFactorCTX(context.Background(), user_uuid)
+}
+
+func deleteUserTwoFactorTx(ctx context.Context, tx *sql.Tx, user_uuid string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM `user_totp` WHERE `user` = ?", user_uuid)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `user_recovery_codes` WHERE `user` = ?", user_uuid)
+
+	return err
+}
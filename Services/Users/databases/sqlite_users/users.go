@@ -29,6 +29,26 @@ func NewUsersDB() (*UsersDB, error) {
 
 	db.Exec("PRAGMA foreign_keys = ON")
 
+	err = users_db.ensureTwoFactorSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = users_db.ensureLoginAttemptsSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = users_db.ensureSessionsSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	err = users_db.ensureApiTokensSchema()
+	if err != nil {
+		return nil, err
+	}
+
 	return users_db, nil
 }
 
@@ -182,15 +202,36 @@ func (users_db *UsersDB) CreateNewRole(role service_models.RoleTaxonomy) error {
 }
 
 func (users_db *UsersDB) DeleteUserByUuidCTX(ctx context.Context, user_uuid string) error {
-	delete_user_stmt, err := users_db.db_conn.PrepareContext(ctx, "DELETE FROM `users` WHERE `uuid` = ?")
+	tx, err := users_db.db_conn.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer delete_user_stmt.Close()
 
-	_, err = delete_user_stmt.ExecContext(ctx, user_uuid)
+	err = deleteUserTwoFactorTx(ctx, tx, user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 
-	return err
+	_, err = tx.ExecContext(ctx, "DELETE FROM `user_sessions` WHERE `user` = ?", user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = deleteUserApiTokensTx(ctx, tx, user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM `users` WHERE `uuid` = ?", user_uuid)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (users_db *UsersDB) DeleteUserByUuid(user_uuid string) error {
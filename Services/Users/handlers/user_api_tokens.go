@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	app_config "libery_users_service/Config"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"libery_users_service/workflows"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+const api_token_name_max_length int = 100
+
+// Lists the API tokens of the authenticated user. The tokens themselves are not included, only their first characters.
+func getApiTokensHandler(response http.ResponseWriter, request *http.Request) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getApiTokensHandler, while getting session claims, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	api_tokens, err := repository.ApiTokensRepo.GetUserApiTokensCTX(request.Context(), user_claims.UserUUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getApiTokensHandler, while getting API tokens of user<%s>, error: %s", user_claims.UserUUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(api_tokens)
+}
+
+// Creates an API token for the authenticated user. The token can only have grants the user has and is only sent back
+// in this response.
+func postApiTokenHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postApiTokenHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	var token_params struct {
+		Name      string   `json:"name"`
+		Grants    []string `json:"grants"`
+		ExpiresAt int64    `json:"expires_at"` // Unix timestamp in seconds, 0 for a token that never expires
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&token_params)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postApiTokenHandler, while decoding token params, error: %s", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	token_params.Name = strings.TrimSpace(token_params.Name)
+
+	if token_params.Name == "" || len(token_params.Name) > api_token_name_max_length {
+		dungeon_helpers.WriteRejection(response, 400, fmt.Sprintf("The token name must have between 1 and %d characters", api_token_name_max_length))
+		return
+	}
+
+	if token_params.ExpiresAt != 0 && token_params.ExpiresAt <= time.Now().Unix() {
+		dungeon_helpers.WriteRejection(response, 400, "The token expiration must be in the future")
+		return
+	}
+
+	user_roles, err := repository.UsersRepo.GetUserRolesCTX(request.Context(), user)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postApiTokenHandler, while getting roles of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	var token_grants []string = make([]string, 0, len(token_params.Grants))
+	var seen_grants map[string]struct{} = make(map[string]struct{}, len(token_params.Grants))
+
+	for _, grant := range token_params.Grants {
+		if _, seen := seen_grants[grant]; !seen {
+			seen_grants[grant] = struct{}{}
+			token_grants = append(token_grants, grant)
+		}
+	}
+
+	if len(workflows.FilterApiTokenGrants(token_grants, workflows.CompileUserGrants(user_roles))) != len(token_grants) {
+		dungeon_helpers.WriteRejection(response, 403, "API tokens can only have grants their user has")
+		return
+	}
+
+	token_count, err := repository.ApiTokensRepo.CountUserApiTokensCTX(request.Context(), user.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postApiTokenHandler, while counting API tokens of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if token_count >= app_config.API_TOKENS_MAX_PER_USER {
+		dungeon_helpers.WriteRejection(response, 409, fmt.Sprintf("Users can't have more than %d API tokens, revoke one first", app_config.API_TOKENS_MAX_PER_USER))
+		return
+	}
+
+	token_record, api_token, err := workflows.CreateApiToken(request.Context(), user.UUID, token_params.Name, token_grants, token_params.ExpiresAt)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postApiTokenHandler, while creating API token for user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	token_response := &struct {
+		ApiToken string                   `json:"api_token"` // Sent as 'Authorization: Bearer <api_token>', it can't be retrieved again
+		Token    *service_models.ApiToken `json:"token"`
+	}{
+		ApiToken: api_token,
+		Token:    token_record,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(token_response)
+}
+
+// Revokes one of the API tokens of the authenticated user.
+func deleteApiTokenHandler(response http.ResponseWriter, request *http.Request) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteApiTokenHandler, while getting session claims, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	var token_uuid string = request.URL.Query().Get("token_uuid")
+
+	if token_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/deleteApiTokenHandler, missing token_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	deleted, err := repository.ApiTokensRepo.DeleteUserApiTokenCTX(request.Context(), user_claims.UserUUID, token_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteApiTokenHandler, while deleting API token<%s>, error: %s", token_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if !deleted {
+		dungeon_helpers.WriteRejection(response, 404, "API token not found")
+		return
+	}
+
+	echo.Echo(echo.YellowFG, fmt.Sprintf("User<%s> revoked API token '%s'", user_claims.UserUUID, token_uuid))
+
+	response.WriteHeader(204)
+}
+
+// Used by the other services to get the user claims of the API tokens they are sent. Responds 404 if the token is not
+// valid.
+func postResolveApiTokenHandler(response http.ResponseWriter, request *http.Request) {
+	var resolve_params struct {
+		ApiToken string `json:"api_token"`
+	}
+
+	err := json.NewDecoder(request.Body).Decode(&resolve_params)
+	if err != nil || resolve_params.ApiToken == "" {
+		echo.Echo(echo.RedFG, "In Handlers/postResolveApiTokenHandler, missing api_token in body")
+		response.WriteHeader(400)
+		return
+	}
+
+	user_claims, err := workflows.ResolveApiToken(resolve_params.ApiToken)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postResolveApiTokenHandler, while resolving API token, error: %s", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if user_claims == nil {
+		response.WriteHeader(404)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(user_claims)
+}
@@ -3,14 +3,17 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
 	dungeon_helpers "libery-dungeon-libs/helpers"
 	"libery-dungeon-libs/libs/libery_networking"
 	dungeon_models "libery-dungeon-libs/models"
 	app_config "libery_users_service/Config"
+	"libery_users_service/helpers"
 	service_models "libery_users_service/models"
 	"libery_users_service/repository"
 	"libery_users_service/workflows"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/Gerardo115pp/patriot_router"
@@ -55,64 +58,203 @@ func getUserAuthHandler(response http.ResponseWriter, request *http.Request) {
 	case "/user-auth/logout":
 		echo.Echo(echo.SkyBlueFG, "Requesting user logout")
 		getLogoutUserHandler(response, request)
+	case "/user-auth/2fa":
+		echo.Echo(echo.SkyBlueFG, "Requesting two factor status")
+		getTwoFactorStatusHandler(response, request)
+	case "/user-auth/sessions":
+		echo.Echo(echo.SkyBlueFG, "Requesting user sessions")
+		getUserSessionsHandler(response, request)
+	case "/user-auth/sessions/revoked":
+		dungeon_middlewares.CheckDomainSecretMiddleware(getSessionRevokedHandler)(response, request)
+	case "/user-auth/api-tokens":
+		echo.Echo(echo.SkyBlueFG, "Requesting user API tokens")
+		getApiTokensHandler(response, request)
 	default:
 		response.WriteHeader(404)
 	}
 }
 
+// Deprecated: the credentials end up in proxy and access logs. Use POST /user-auth instead.
 func getLoginUserHandler(response http.ResponseWriter, request *http.Request) {
-	var err error
 	var auth_username string = request.URL.Query().Get("username")
 	var auth_secret string = request.URL.Query().Get("secret")
 
 	if auth_username == "" || auth_secret == "" {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserAuthHandler, missing username<%s> or secret<%s> in query", auth_username, auth_secret))
+		echo.Echo(echo.RedFG, "In Handlers/getLoginUserHandler, missing username or secret in query")
 		response.WriteHeader(400)
 		return
 	}
 
+	echo.EchoWarn(fmt.Sprintf("Login of user<%s> through the deprecated GET /user-auth, which exposes credentials in urls", auth_username))
+
+	response.Header().Set("Deprecation", "true")
+	response.Header().Set("Link", "</user-auth>; rel=\"successor-version\"")
+
+	loginUser(response, request, auth_username, auth_secret)
+}
+
+func postLoginUserHandler(response http.ResponseWriter, request *http.Request) {
+	var login_params struct {
+		Username string `json:"username"`
+		Secret   string `json:"secret"`
+	}
+
+	err := json.NewDecoder(request.Body).Decode(&login_params)
+	if err != nil || login_params.Username == "" || login_params.Secret == "" {
+		echo.Echo(echo.RedFG, "In Handlers/postLoginUserHandler, missing username or secret in body")
+		response.WriteHeader(400)
+		return
+	}
+
+	loginUser(response, request, login_params.Username, login_params.Secret)
+}
+
+// First login step. Checks the credentials unless the username or the client are locked out, and issues the user
+// claims or, if the user has two factor authentication, a login challenge. Every attempt is recorded. Unknown users get
+// the same response as wrong secrets, so usernames can't be probed.
+func loginUser(response http.ResponseWriter, request *http.Request, auth_username string, auth_secret string) {
+	var client_ip string = helpers.RequestClientIP(request, app_config.LOGIN_TRUST_FORWARDED_FOR)
+	var user_agent string = request.UserAgent()
+
 	access_response := &struct {
-		Granted  bool                         `json:"granted"`
-		UserData *service_models.UserIdentity `json:"user_data"`
+		Granted           bool                         `json:"granted"`
+		UserData          *service_models.UserIdentity `json:"user_data"`
+		TwoFactorRequired bool                         `json:"two_factor_required"`
+		Challenge         string                       `json:"challenge,omitempty"` // Sent back with the second factor to /user-auth/2fa/verify
 	}{
 		Granted:  false,
 		UserData: nil,
 	}
 
+	if !reserveLoginAttempt(response, request, auth_username, client_ip) {
+		return
+	}
+
 	user_credentials, err := repository.UsersRepo.GetUserByUsernameCTX(request.Context(), auth_username)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserAuthHandler, while getting user<%s> by username, error: %s. Assuming not found", auth_username, err))
-		response.WriteHeader(404)
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/loginUser, while getting user<%s> by username, error: %s. Assuming not found", auth_username, err))
+		service_models.CompareDummySecret(auth_secret)
+		workflows.RecordLoginAttempt(request.Context(), auth_username, client_ip, user_agent, service_models.LoginOutcome_UnknownUser)
+
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(200)
+		json.NewEncoder(response).Encode(access_response)
 		return
 	}
 
 	err = user_credentials.CompareSecret(auth_secret)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserAuthHandler, while comparing user<%s> secret, error: %s", user_credentials.Username, err))
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/loginUser, while comparing user<%s> secret, error: %s", user_credentials.Username, err))
+		workflows.RecordLoginAttempt(request.Context(), auth_username, client_ip, user_agent, service_models.LoginOutcome_InvalidSecret)
+
+		response.Header().Set("Content-Type", "application/json")
 		response.WriteHeader(200)
+		json.NewEncoder(response).Encode(access_response)
+		return
+	}
+
+	workflows.ReleaseLoginAttempt(auth_username, client_ip)
+
+	two_factor_required, err := workflows.TwoFactorRequired(request.Context(), user_credentials.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/loginUser, while checking two factor enrollment of user<%s>, error: %s", user_credentials.Username, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if two_factor_required {
+		access_response.TwoFactorRequired = true
+
+		access_response.Challenge, err = workflows.CreateLoginChallenge(user_credentials.UUID)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/loginUser, while creating login challenge for user<%s>, error: %s", user_credentials.Username, err))
+			response.WriteHeader(500)
+			return
+		}
+
+		// The failed logins of the username are kept until the second factor is verified as well.
+		workflows.RecordLoginAttempt(request.Context(), auth_username, client_ip, user_agent, service_models.LoginOutcome_SecondFactorPending)
+
 		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(200)
 		json.NewEncoder(response).Encode(access_response)
 		return
 	}
 
-	user_roles, err := repository.UsersRepo.GetUserRolesCTX(request.Context(), user_credentials)
+	access_response.UserData, err = grantUserAccess(response, request, user_credentials)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserAuthHandler, while getting user roles for user<%s>, error: %s", user_credentials.Username, err))
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/loginUser, while granting access to user<%s>, error: %s", user_credentials.Username, err))
 		response.WriteHeader(500)
 		return
 	}
 
+	workflows.RegisterSuccessfulLogin(auth_username)
+	workflows.RecordLoginAttempt(request.Context(), auth_username, client_ip, user_agent, service_models.LoginOutcome_Granted)
+
+	access_response.Granted = true
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(access_response)
+}
+
+// Reserves a login attempt for the username and the client, which counts as failed until it is released. If they are
+// locked out, responds with 429 and records the attempt instead. Returns whether the login can go on.
+func reserveLoginAttempt(response http.ResponseWriter, request *http.Request, auth_username string, client_ip string) bool {
+	locked_until := workflows.ReserveLoginAttempt(auth_username, client_ip)
+	if locked_until.IsZero() {
+		return true
+	}
+
+	echo.Echo(echo.RedFG, fmt.Sprintf("Rejected login of user<%s> from %s, locked out until %s", auth_username, client_ip, locked_until.Format(time.RFC3339)))
+	workflows.RecordLoginAttempt(request.Context(), auth_username, client_ip, request.UserAgent(), service_models.LoginOutcome_LockedOut)
+
+	var retry_after int = int(time.Until(locked_until).Seconds()) + 1
+
+	response.Header().Set("Retry-After", strconv.Itoa(retry_after))
+	dungeon_helpers.WriteRejection(response, 429, "Too many failed login attempts, try again later")
+
+	return false
+}
+
+// Starts a login session for the user and issues its refresh token and user claims as cookies. Returns the identity
+// the claims were issued for.
+func grantUserAccess(response http.ResponseWriter, request *http.Request, user_credentials *service_models.User) (*service_models.UserIdentity, error) {
+	var client_ip string = helpers.RequestClientIP(request, app_config.LOGIN_TRUST_FORWARDED_FOR)
+
+	user_session, refresh_token, err := workflows.CreateUserSession(request.Context(), user_credentials.UUID, client_ip, request.UserAgent())
+	if err != nil {
+		return nil, fmt.Errorf("Error creating user session: %s", err)
+	}
+
+	user_identity, _, err := issueUserClaims(response, request, user_credentials, user_session.UUID)
+	if err != nil {
+		return nil, err
+	}
+
+	setRefreshTokenCookie(response, refresh_token, time.Unix(user_session.ExpiresAt, 0))
+
+	return user_identity, nil
+}
+
+// Issues the PlatformUserClaims of the user for one of their sessions as the user claims cookie, with the grants the
+// user has right now. Returns the identity the claims were issued for and when they expire.
+func issueUserClaims(response http.ResponseWriter, request *http.Request, user_credentials *service_models.User, session_uuid string) (*service_models.UserIdentity, time.Time, error) {
+	user_roles, err := repository.UsersRepo.GetUserRolesCTX(request.Context(), user_credentials)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("Error getting user roles: %s", err)
+	}
+
 	var user_highest_role_hierarchy int = workflows.GetHighestRoleHierarchy(user_roles)
 
 	var user_grants []string = workflows.CompileUserGrants(user_roles)
 
-	claims_expiration_time := time.Now().Add(app_config.USER_CLAIMS_EXPIRATION_HOURS)
+	claims_expiration_time := time.Now().Add(app_config.ACCESS_TOKEN_EXPIRATION)
 
-	user_token, err := dungeon_models.GeneratePlatformUserClaims(user_credentials.UUID, user_credentials.Username, user_highest_role_hierarchy, user_grants, claims_expiration_time, app_config.JWT_SECRET)
+	user_token, err := dungeon_models.GeneratePlatformUserSessionClaims(session_uuid, user_credentials.UUID, user_credentials.Username, user_highest_role_hierarchy, user_grants, claims_expiration_time, app_config.JWT_SECRET)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserAuthHandler, while generating user token for user<%s>, error: %s", user_credentials.Username, err))
-		response.WriteHeader(500)
-		return
+		return nil, time.Time{}, fmt.Errorf("Error generating user token: %s", err)
 	}
 
 	var user_claims_cookie http.Cookie = http.Cookie{
@@ -125,52 +267,415 @@ func getLoginUserHandler(response http.ResponseWriter, request *http.Request) {
 
 	http.SetCookie(response, &user_claims_cookie)
 
-	access_response.Granted = true
+	return service_models.CreateUserIdentity(user_credentials, user_highest_role_hierarchy, user_grants), claims_expiration_time, nil
+}
 
-	access_response.UserData = service_models.CreateUserIdentity(user_credentials, user_highest_role_hierarchy, user_grants)
+func setRefreshTokenCookie(response http.ResponseWriter, refresh_token string, expires_at time.Time) {
+	var refresh_token_cookie http.Cookie = http.Cookie{
+		Name:     app_config.REFRESH_TOKEN_COOKIE_NAME,
+		Value:    refresh_token,
+		Path:     "/",
+		Expires:  expires_at,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	}
 
-	response.Header().Set("Content-Type", "application/json")
-	response.WriteHeader(200)
+	http.SetCookie(response, &refresh_token_cookie)
+}
 
-	json.NewEncoder(response).Encode(access_response)
+func clearAuthCookies(response http.ResponseWriter) {
+	for _, cookie_name := range []string{app_config.USER_CLAIMS_COOKIE_NAME, app_config.REFRESH_TOKEN_COOKIE_NAME} {
+		http.SetCookie(response, &http.Cookie{
+			Name:     cookie_name,
+			Value:    "",
+			Expires:  time.Now().Add(-1 * time.Hour),
+			HttpOnly: true,
+			Path:     "/",
+		})
+	}
 }
 
-func getVerifyUserHandler(response http.ResponseWriter, request *http.Request) {
-	var user_claims_cookie *http.Cookie
-	var user_claims_token string
+// Returns the claims of the login session the request was made from. Requests made with an API token are rejected,
+// managing the user's credentials, sessions or tokens requires them to log in.
+func getSessionUserClaims(request *http.Request) (*dungeon_models.PlatformUserClaims, error) {
+	user_claims, err := dungeon_middlewares.GetUserClaims(request, app_config.JWT_SECRET)
+	if err != nil {
+		return nil, err
+	}
+
+	if user_claims.ApiTokenUUID != "" {
+		return nil, fmt.Errorf("Request was authenticated with API token '%s'", user_claims.ApiTokenUUID)
+	}
+
+	return user_claims, nil
+}
+
+// Returns the user the request's session claims belong to, as long as that user still exists. See getSessionUserClaims.
+func getAuthenticatedUser(request *http.Request) (*service_models.User, error) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return repository.UsersRepo.GetUserByUuidCTX(request.Context(), user_claims.UserUUID)
+}
+
+func getTwoFactorStatusHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getTwoFactorStatusHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
 
-	user_claims_cookie, err := request.Cookie(app_config.USER_CLAIMS_COOKIE_NAME)
+	two_factor_status, err := workflows.GetTwoFactorStatus(request.Context(), user.UUID)
 	if err != nil {
-		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getVerifyUserHandler, while getting user claims cookie, error: %s", err))
-		dungeon_helpers.WriteBooleanResponse(response, false)
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getTwoFactorStatusHandler, while getting two factor status of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
 		return
 	}
 
-	user_claims_token = user_claims_cookie.Value
-	_, err = dungeon_models.ParsePlatformUserClaims(user_claims_token, app_config.JWT_SECRET)
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(two_factor_status)
+}
+
+func getVerifyUserHandler(response http.ResponseWriter, request *http.Request) {
+	_, err := dungeon_middlewares.GetUserClaims(request, app_config.JWT_SECRET)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getVerifyUserHandler, while getting user claims, error: %s", err))
+	}
 
 	dungeon_helpers.WriteBooleanResponse(response, err == nil)
 }
 
+// Revokes the session of the request and removes the auth cookies.
 func getLogoutUserHandler(response http.ResponseWriter, request *http.Request) {
 	echo.Echo(echo.SkyBlueFG, "Logging out user")
 
-	var user_claims_cookie *http.Cookie = &http.Cookie{
-		Name:     app_config.USER_CLAIMS_COOKIE_NAME,
-		Value:    "",
-		Expires:  time.Now().Add(-1 * time.Hour),
-		HttpOnly: true,
-		Path:     "/",
+	var session_uuid string
+
+	if refresh_token_cookie, err := request.Cookie(app_config.REFRESH_TOKEN_COOKIE_NAME); err == nil {
+		user_session, err := workflows.GetRefreshTokenSession(request.Context(), refresh_token_cookie.Value)
+		if err == nil && user_session != nil {
+			session_uuid = user_session.UUID
+		}
+	}
+
+	if session_uuid == "" {
+		if user_claims, err := dungeon_middlewares.GetUserClaims(request, app_config.JWT_SECRET); err == nil {
+			session_uuid = user_claims.SessionUUID
+		}
+	}
+
+	if session_uuid != "" {
+		err := repository.SessionsRepo.RevokeUserSessionCTX(request.Context(), session_uuid)
+		if err != nil {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getLogoutUserHandler, while revoking session<%s>, error: %s", session_uuid, err))
+		}
 	}
 
-	http.SetCookie(response, user_claims_cookie)
+	clearAuthCookies(response)
 
 	response.WriteHeader(200)
 }
 
 func postUserAuthHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource string = request.URL.Path
+
+	switch resource {
+	case "/user-auth":
+		echo.Echo(echo.SkyBlueFG, "Requesting user login")
+		postLoginUserHandler(response, request)
+	case "/user-auth/refresh":
+		echo.Echo(echo.SkyBlueFG, "Requesting user claims renewal")
+		postRefreshUserClaimsHandler(response, request)
+	case "/user-auth/api-tokens":
+		echo.Echo(echo.SkyBlueFG, "Requesting API token creation")
+		postApiTokenHandler(response, request)
+	case "/user-auth/api-tokens/resolve":
+		dungeon_middlewares.CheckDomainSecretMiddleware(postResolveApiTokenHandler)(response, request)
+	case "/user-auth/2fa/verify":
+		echo.Echo(echo.SkyBlueFG, "Requesting second login step")
+		postVerifyLoginChallengeHandler(response, request)
+	case "/user-auth/2fa/enroll":
+		echo.Echo(echo.SkyBlueFG, "Requesting two factor enrollment")
+		postTwoFactorEnrollHandler(response, request)
+	case "/user-auth/2fa/confirm":
+		echo.Echo(echo.SkyBlueFG, "Requesting two factor enrollment confirmation")
+		postTwoFactorConfirmHandler(response, request)
+	case "/user-auth/2fa/recovery-codes":
+		echo.Echo(echo.SkyBlueFG, "Requesting new recovery codes")
+		postRecoveryCodesHandler(response, request)
+	default:
+		response.WriteHeader(404)
+	}
+}
+
+// Second login step of users with two factor authentication. Takes the challenge the first step returned and a TOTP
+// or recovery code, and issues the user claims if the code is valid.
+func postVerifyLoginChallengeHandler(response http.ResponseWriter, request *http.Request) {
+	var challenge_answer struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+	}
+
+	err := json.NewDecoder(request.Body).Decode(&challenge_answer)
+	if err != nil || challenge_answer.Challenge == "" || challenge_answer.Code == "" {
+		echo.Echo(echo.RedFG, "In Handlers/postVerifyLoginChallengeHandler, missing challenge or code in body")
+		response.WriteHeader(400)
+		return
+	}
+
+	access_response := &struct {
+		Granted  bool                         `json:"granted"`
+		UserData *service_models.UserIdentity `json:"user_data"`
+	}{
+		Granted:  false,
+		UserData: nil,
+	}
+
+	var user_uuid string = workflows.LoginChallengeUser(challenge_answer.Challenge)
+
+	if user_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/postVerifyLoginChallengeHandler, login challenge doesn't exist or expired")
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(200)
+		json.NewEncoder(response).Encode(access_response)
+		return
+	}
+
+	user_credentials, err := repository.UsersRepo.GetUserByUuidCTX(request.Context(), user_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postVerifyLoginChallengeHandler, while getting user<%s>, error: %s", user_uuid, err))
+		response.WriteHeader(404)
+		return
+	}
+
+	var client_ip string = helpers.RequestClientIP(request, app_config.LOGIN_TRUST_FORWARDED_FOR)
+
+	if !reserveLoginAttempt(response, request, user_credentials.Username, client_ip) {
+		return
+	}
+
+	user_uuid, code_accepted, err := workflows.AnswerLoginChallenge(request.Context(), challenge_answer.Challenge, challenge_answer.Code)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postVerifyLoginChallengeHandler, while verifying second factor, error: %s", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if user_uuid != user_credentials.UUID {
+		// The challenge expired or ran out of attempts in the meantime
+		code_accepted = false
+	}
+
+	if !code_accepted {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postVerifyLoginChallengeHandler, invalid second factor for user<%s>", user_credentials.Username))
+		workflows.RecordLoginAttempt(request.Context(), user_credentials.Username, client_ip, request.UserAgent(), service_models.LoginOutcome_InvalidSecondFactor)
+
+		response.Header().Set("Content-Type", "application/json")
+		response.WriteHeader(200)
+		json.NewEncoder(response).Encode(access_response)
+		return
+	}
+
+	workflows.ReleaseLoginAttempt(user_credentials.Username, client_ip)
+
+	access_response.UserData, err = grantUserAccess(response, request, user_credentials)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postVerifyLoginChallengeHandler, while granting access to user<%s>, error: %s", user_credentials.Username, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	workflows.RegisterSuccessfulLogin(user_credentials.Username)
+	workflows.RecordLoginAttempt(request.Context(), user_credentials.Username, client_ip, request.UserAgent(), service_models.LoginOutcome_Granted)
+
+	access_response.Granted = true
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(access_response)
+}
+
+// Starts a TOTP enrollment for the authenticated user. The enrollment is not enforced until it's confirmed.
+func postTwoFactorEnrollHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorEnrollHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	two_factor_required, err := workflows.TwoFactorRequired(request.Context(), user.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorEnrollHandler, while checking two factor enrollment of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if two_factor_required {
+		dungeon_helpers.WriteRejection(response, 409, "Two factor authentication is already enabled, disable it before enrolling again")
+		return
+	}
+
+	totp_secret, provisioning_url, err := workflows.BeginTOTPEnrollment(request.Context(), user)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorEnrollHandler, while enrolling user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	enrollment_response := &struct {
+		Secret          string `json:"secret"`
+		ProvisioningUrl string `json:"provisioning_url"`
+	}{
+		Secret:          totp_secret,
+		ProvisioningUrl: provisioning_url,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(201)
+
+	json.NewEncoder(response).Encode(enrollment_response)
+}
+
+// Confirms the pending enrollment of the authenticated user with a code from their authenticator app and returns
+// their recovery codes.
+func postTwoFactorConfirmHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorConfirmHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	code, err := decodeSecondFactorCode(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorConfirmHandler, while decoding code, error: %s", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	two_factor_status, err := workflows.GetTwoFactorStatus(request.Context(), user.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorConfirmHandler, while getting two factor status of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if !two_factor_status.PendingEnrollment {
+		dungeon_helpers.WriteRejection(response, 409, "There is no two factor enrollment to confirm")
+		return
+	}
+
+	recovery_codes, err := workflows.ConfirmTOTPEnrollment(request.Context(), user.UUID, code)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postTwoFactorConfirmHandler, while confirming enrollment of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if recovery_codes == nil {
+		dungeon_helpers.WriteRejection(response, 401, "Invalid code")
+		return
+	}
+
+	writeRecoveryCodes(response, recovery_codes)
+}
+
+// Replaces the recovery codes of the authenticated user. Requires a valid second factor, so a stolen session can't
+// be used to get codes.
+func postRecoveryCodesHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRecoveryCodesHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	code, err := decodeSecondFactorCode(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRecoveryCodesHandler, while decoding code, error: %s", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	if !verifyUserSecondFactor(response, request, user, code) {
+		return
+	}
+
+	recovery_codes, err := workflows.RegenerateRecoveryCodes(request.Context(), user.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRecoveryCodesHandler, while regenerating recovery codes of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	writeRecoveryCodes(response, recovery_codes)
+}
+
+// Verifies a second factor of the authenticated user before a sensitive change. Invalid codes count against the same
+// lockout as failed logins, so a stolen session can't be used to guess codes. If the code is not accepted the rejection
+// is written and false is returned.
+func verifyUserSecondFactor(response http.ResponseWriter, request *http.Request, user *service_models.User, code string) bool {
+	var client_ip string = helpers.RequestClientIP(request, app_config.LOGIN_TRUST_FORWARDED_FOR)
+
+	if !reserveLoginAttempt(response, request, user.Username, client_ip) {
+		return false
+	}
+
+	code_accepted, err := workflows.VerifySecondFactor(request.Context(), user.UUID, code)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/verifyUserSecondFactor, while verifying second factor of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return false
+	}
+
+	if !code_accepted {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/verifyUserSecondFactor, invalid second factor for user<%s>", user.Username))
+		workflows.RecordLoginAttempt(request.Context(), user.Username, client_ip, request.UserAgent(), service_models.LoginOutcome_InvalidSecondFactor)
+
+		dungeon_helpers.WriteRejection(response, 401, "Invalid code")
+		return false
+	}
+
+	workflows.ReleaseLoginAttempt(user.Username, client_ip)
+
+	return true
+}
+
+func decodeSecondFactorCode(request *http.Request) (string, error) {
+	var code_params struct {
+		Code string `json:"code"`
+	}
+
+	err := json.NewDecoder(request.Body).Decode(&code_params)
+	if err != nil {
+		return "", err
+	}
+
+	if code_params.Code == "" {
+		return "", fmt.Errorf("Missing code")
+	}
+
+	return code_params.Code, nil
+}
+
+func writeRecoveryCodes(response http.ResponseWriter, recovery_codes []string) {
+	codes_response := &struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{
+		RecoveryCodes: recovery_codes,
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(codes_response)
 }
 
 func patchUserAuthHandler(response http.ResponseWriter, request *http.Request) {
@@ -179,8 +684,55 @@ func patchUserAuthHandler(response http.ResponseWriter, request *http.Request) {
 }
 
 func deleteUserAuthHandler(response http.ResponseWriter, request *http.Request) {
-	response.WriteHeader(http.StatusMethodNotAllowed)
-	return
+	var resource string = request.URL.Path
+
+	switch resource {
+	case "/user-auth/2fa":
+		echo.Echo(echo.SkyBlueFG, "Requesting two factor removal")
+		deleteTwoFactorHandler(response, request)
+	case "/user-auth/sessions":
+		echo.Echo(echo.SkyBlueFG, "Requesting session revocation")
+		deleteUserSessionHandler(response, request)
+	case "/user-auth/sessions/all":
+		echo.Echo(echo.SkyBlueFG, "Requesting log out everywhere")
+		deleteAllUserSessionsHandler(response, request)
+	case "/user-auth/api-tokens":
+		echo.Echo(echo.SkyBlueFG, "Requesting API token revocation")
+		deleteApiTokenHandler(response, request)
+	default:
+		response.WriteHeader(404)
+	}
+}
+
+// Disables two factor authentication for the authenticated user. Requires a valid second factor sent as the 'code'
+// of the json body.
+func deleteTwoFactorHandler(response http.ResponseWriter, request *http.Request) {
+	user, err := getAuthenticatedUser(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteTwoFactorHandler, while getting authenticated user, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	code, err := decodeSecondFactorCode(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteTwoFactorHandler, while decoding code, error: %s", err))
+		response.WriteHeader(400)
+		return
+	}
+
+	if !verifyUserSecondFactor(response, request, user, code) {
+		return
+	}
+
+	err = workflows.DisableTwoFactor(request.Context(), user.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteTwoFactorHandler, while disabling two factor of user<%s>, error: %s", user.UUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.WriteHeader(204)
 }
 func putUserAuthHandler(response http.ResponseWriter, request *http.Request) {
 	response.WriteHeader(http.StatusMethodNotAllowed)
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	dungeon_helpers "libery-dungeon-libs/helpers"
+	app_config "libery_users_service/Config"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"libery_users_service/workflows"
+	"net/http"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Exchanges the refresh token cookie for new user claims and a new refresh token. The claims carry the grants the user
+// has at the time of the refresh.
+func postRefreshUserClaimsHandler(response http.ResponseWriter, request *http.Request) {
+	refresh_token_cookie, err := request.Cookie(app_config.REFRESH_TOKEN_COOKIE_NAME)
+	if err != nil {
+		echo.Echo(echo.RedFG, "In Handlers/postRefreshUserClaimsHandler, missing refresh token cookie")
+		response.WriteHeader(401)
+		return
+	}
+
+	user_session, refresh_token, err := workflows.RefreshUserSession(request.Context(), refresh_token_cookie.Value)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRefreshUserClaimsHandler, while refreshing session, error: %s", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if user_session == nil {
+		echo.Echo(echo.RedFG, "In Handlers/postRefreshUserClaimsHandler, refresh token doesn't belong to an active session")
+		clearAuthCookies(response)
+		response.WriteHeader(401)
+		return
+	}
+
+	user_credentials, err := repository.UsersRepo.GetUserByUuidCTX(request.Context(), user_session.UserUUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRefreshUserClaimsHandler, while getting user<%s> of session<%s>, error: %s", user_session.UserUUID, user_session.UUID, err))
+		repository.SessionsRepo.RevokeUserSessionCTX(request.Context(), user_session.UUID)
+		clearAuthCookies(response)
+		response.WriteHeader(401)
+		return
+	}
+
+	user_identity, claims_expiration_time, err := issueUserClaims(response, request, user_credentials, user_session.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/postRefreshUserClaimsHandler, while issuing claims for user<%s>, error: %s", user_credentials.Username, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	setRefreshTokenCookie(response, refresh_token, time.Unix(user_session.ExpiresAt, 0))
+
+	refresh_response := &struct {
+		Granted         bool                         `json:"granted"`
+		UserData        *service_models.UserIdentity `json:"user_data"`
+		AccessExpiresAt int64                        `json:"access_expires_at"` // Unix timestamp in seconds, the claims have to be refreshed before it
+	}{
+		Granted:         true,
+		UserData:        user_identity,
+		AccessExpiresAt: claims_expiration_time.Unix(),
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(refresh_response)
+}
+
+// Lists the active sessions of the authenticated user, marking the one of the request.
+func getUserSessionsHandler(response http.ResponseWriter, request *http.Request) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserSessionsHandler, while getting session claims, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	user_sessions, err := repository.SessionsRepo.GetActiveUserSessionsCTX(request.Context(), user_claims.UserUUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getUserSessionsHandler, while getting sessions of user<%s>, error: %s", user_claims.UserUUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	for _, user_session := range user_sessions {
+		user_session.Current = user_session.UUID == user_claims.SessionUUID
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(user_sessions)
+}
+
+// Revokes one of the sessions of the authenticated user.
+func deleteUserSessionHandler(response http.ResponseWriter, request *http.Request) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteUserSessionHandler, while getting session claims, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	var session_uuid string = request.URL.Query().Get("session_uuid")
+
+	if session_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/deleteUserSessionHandler, missing session_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	user_session, err := repository.SessionsRepo.GetUserSessionCTX(request.Context(), session_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteUserSessionHandler, while getting session<%s>, error: %s", session_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if user_session == nil || user_session.UserUUID != user_claims.UserUUID {
+		dungeon_helpers.WriteRejection(response, 404, "Session not found")
+		return
+	}
+
+	err = repository.SessionsRepo.RevokeUserSessionCTX(request.Context(), session_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteUserSessionHandler, while revoking session<%s>, error: %s", session_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	if session_uuid == user_claims.SessionUUID {
+		clearAuthCookies(response)
+	}
+
+	response.WriteHeader(204)
+}
+
+// Logs the authenticated user out everywhere, including the session of the request.
+func deleteAllUserSessionsHandler(response http.ResponseWriter, request *http.Request) {
+	user_claims, err := getSessionUserClaims(request)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteAllUserSessionsHandler, while getting session claims, error: %s", err))
+		response.WriteHeader(401)
+		return
+	}
+
+	_, err = workflows.RevokeAllUserSessions(request.Context(), user_claims.UserUUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/deleteAllUserSessionsHandler, while revoking sessions of user<%s>, error: %s", user_claims.UserUUID, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	clearAuthCookies(response)
+
+	response.WriteHeader(204)
+}
+
+// Used by the other services to find out whether the user claims they are given belong to a revoked session.
+func getSessionRevokedHandler(response http.ResponseWriter, request *http.Request) {
+	var session_uuid string = request.URL.Query().Get("session_uuid")
+
+	if session_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/getSessionRevokedHandler, missing session_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	is_revoked, err := workflows.IsSessionRevoked(session_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/getSessionRevokedHandler, while checking session<%s>, error: %s", session_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	dungeon_helpers.WriteBooleanResponse(response, is_revoked)
+}
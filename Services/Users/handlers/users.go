@@ -14,6 +14,7 @@ import (
 	"libery_users_service/workflows"
 	"libery_users_service/workflows/common_workflows"
 	"net/http"
+	"strconv"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -59,6 +60,10 @@ func getUsersHandler(response http.ResponseWriter, request *http.Request) {
 		handler_func = dungeon_middlewares.CheckUserCan_ReadUsers(getAllUsersHandler)
 	case "/users/roles":
 		handler_func = dungeon_middlewares.CheckUserCan_Grant(getAllRolesOfUserHandler)
+	case "/users/login-attempts":
+		handler_func = dungeon_middlewares.CheckUserCan_ReadUsers(getLoginAttemptsHandler)
+	case "/users/user/sessions":
+		handler_func = dungeon_middlewares.CheckUserCan_ReadUsers(getUserSessionsByUUIDHandler)
 	default:
 		handler_func = dungeon_helpers.ResourceNotFoundHandler
 	}
@@ -105,6 +110,70 @@ func getAllRolesOfUserHandler(response http.ResponseWriter, request *http.Reques
 	json.NewEncoder(response).Encode(user_role_labels)
 }
 
+// Returns the audit records of login attempts newest first, optionally only those made with a username.
+func getLoginAttemptsHandler(response http.ResponseWriter, request *http.Request) {
+	var username string = request.URL.Query().Get("username")
+	var limit int = 100
+	var offset int = 0
+
+	if limit_param := request.URL.Query().Get("limit"); limit_param != "" {
+		parsed_limit, err := strconv.Atoi(limit_param)
+		if err != nil || parsed_limit <= 0 || parsed_limit > 1000 {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.getLoginAttemptsHandler, invalid limit<%s>", limit_param))
+			response.WriteHeader(400)
+			return
+		}
+
+		limit = parsed_limit
+	}
+
+	if offset_param := request.URL.Query().Get("offset"); offset_param != "" {
+		parsed_offset, err := strconv.Atoi(offset_param)
+		if err != nil || parsed_offset < 0 {
+			echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.getLoginAttemptsHandler, invalid offset<%s>", offset_param))
+			response.WriteHeader(400)
+			return
+		}
+
+		offset = parsed_offset
+	}
+
+	login_attempts, err := repository.LoginAttemptsRepo.GetLoginAttemptsCTX(request.Context(), username, limit, offset)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.getLoginAttemptsHandler, while getting login attempts, error: %s", err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(login_attempts)
+}
+
+// Lists the active sessions of a user.
+func getUserSessionsByUUIDHandler(response http.ResponseWriter, request *http.Request) {
+	var user_uuid string = request.URL.Query().Get("user_uuid")
+
+	if user_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/UsersHandler.getUserSessionsByUUIDHandler, missing user_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	user_sessions, err := repository.SessionsRepo.GetActiveUserSessionsCTX(request.Context(), user_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.getUserSessionsByUUIDHandler, while getting sessions of user<%s>, error: %s", user_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(200)
+
+	json.NewEncoder(response).Encode(user_sessions)
+}
+
 func getAllUsersHandler(response http.ResponseWriter, request *http.Request) {
 	var all_users_entires []service_models.UserEntry = make([]service_models.UserEntry, 0)
 
@@ -260,6 +329,10 @@ func deleteUsersHandler(response http.ResponseWriter, request *http.Request) {
 	switch resource {
 	case "/users/user":
 		handler_func = dungeon_middlewares.CheckUserCan_DeleteUsers(deleteUserByUUIDHandler)
+	case "/users/user/2fa":
+		handler_func = dungeon_middlewares.CheckUserCan_ModifyUsers(deleteUserTwoFactorHandler)
+	case "/users/user/sessions":
+		handler_func = dungeon_middlewares.CheckUserCan_ModifyUsers(deleteUserSessionsHandler)
 	case "/users/role":
 		handler_func = dungeon_middlewares.CheckUserCan_Grant(deleteUserRoleHandler)
 	default:
@@ -329,6 +402,58 @@ func deleteUserByUUIDHandler(response http.ResponseWriter, request *http.Request
 	response.WriteHeader(200)
 }
 
+// Resets the two factor authentication of a user that lost access to their second factor, they can log in with their
+// secret alone until they enroll again.
+func deleteUserTwoFactorHandler(response http.ResponseWriter, request *http.Request) {
+	var user_uuid string = request.URL.Query().Get("user_uuid")
+
+	if user_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/UsersHandler.deleteUserTwoFactorHandler, missing user_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	_, err := repository.UsersRepo.GetUserByUuidCTX(request.Context(), user_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.deleteUserTwoFactorHandler, while getting user<%s>, error: %s", user_uuid, err))
+		response.WriteHeader(404)
+		return
+	}
+
+	err = workflows.DisableTwoFactor(request.Context(), user_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.deleteUserTwoFactorHandler, while resetting two factor of user<%s>, error: %s", user_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	echo.Echo(echo.YellowFG, fmt.Sprintf("Two factor authentication of user<%s> was reset", user_uuid))
+
+	response.WriteHeader(204)
+}
+
+// Revokes every session of a user, the user claims they hold stop being accepted and they have to log in again.
+func deleteUserSessionsHandler(response http.ResponseWriter, request *http.Request) {
+	var user_uuid string = request.URL.Query().Get("user_uuid")
+
+	if user_uuid == "" {
+		echo.Echo(echo.RedFG, "In Handlers/UsersHandler.deleteUserSessionsHandler, missing user_uuid in query")
+		response.WriteHeader(400)
+		return
+	}
+
+	revoked_count, err := workflows.RevokeAllUserSessions(request.Context(), user_uuid)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.deleteUserSessionsHandler, while revoking sessions of user<%s>, error: %s", user_uuid, err))
+		response.WriteHeader(500)
+		return
+	}
+
+	echo.Echo(echo.YellowFG, fmt.Sprintf("Revoked %d sessions of user<%s>", revoked_count, user_uuid))
+
+	response.WriteHeader(204)
+}
+
 func deleteUserRoleHandler(response http.ResponseWriter, request *http.Request) {
 	var role_label string = request.URL.Query().Get("role_label")
 
@@ -429,6 +554,12 @@ func putUserSecretHandler(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	// Whoever knew the old secret must not keep their access.
+	_, err = workflows.RevokeAllUserSessions(request.Context(), current_user_data.UUID)
+	if err != nil {
+		echo.Echo(echo.RedFG, fmt.Sprintf("In Handlers/UsersHandler.putUserSecretHandler, while revoking sessions of user<%s>, error: %s", new_user_data.UUID, err))
+	}
+
 	response.WriteHeader(204)
 }
 
@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Returns the ip of the client that made the request. If trust_forwarded_for is set, the last address on the
+// X-Forwarded-For header is used, which is the one the reverse proxy in front of the service saw.
+func RequestClientIP(request *http.Request, trust_forwarded_for bool) string {
+	if trust_forwarded_for {
+		var forwarded_for []string = strings.Split(request.Header.Get("X-Forwarded-For"), ",")
+
+		if last_hop := strings.TrimSpace(forwarded_for[len(forwarded_for)-1]); last_hop != "" {
+			return last_hop
+		}
+	}
+
+	client_ip, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return request.RemoteAddr
+	}
+
+	return client_ip
+}
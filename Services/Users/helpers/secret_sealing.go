@@ -0,0 +1,64 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Encrypts a value that has to be read back, like a TOTP secret, so it's not stored in plain text. The key is derived
+// from the sealing secret, changing that secret makes the sealed values unreadable.
+func SealSecret(plain_value string, sealing_secret string) (string, error) {
+	aead, err := sealingCipher(sealing_secret)
+	if err != nil {
+		return "", err
+	}
+
+	var nonce []byte = make([]byte, aead.NonceSize())
+
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	var sealed_value []byte = aead.Seal(nonce, nonce, []byte(plain_value), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed_value), nil
+}
+
+func OpenSealedSecret(sealed_value string, sealing_secret string) (string, error) {
+	aead, err := sealingCipher(sealing_secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed_bytes, err := base64.StdEncoding.DecodeString(sealed_value)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed_bytes) < aead.NonceSize() {
+		return "", fmt.Errorf("Sealed secret is too short")
+	}
+
+	plain_value, err := aead.Open(nil, sealed_bytes[:aead.NonceSize()], sealed_bytes[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain_value), nil
+}
+
+func sealingCipher(sealing_secret string) (cipher.AEAD, error) {
+	var sealing_key [32]byte = sha256.Sum256([]byte(sealing_secret))
+
+	block, err := aes.NewCipher(sealing_key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,96 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Time-based one time passwords as described in RFC 6238, with the parameters every authenticator app supports:
+// sha1, 6 digits and 30 second steps.
+
+const TOTP_STEP_SECONDS int64 = 30
+
+const totp_digits int = 6
+
+const totp_secret_size int = 20
+
+var totp_secret_encoding *base32.Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Returns a new random secret, base32 encoded as authenticator apps expect it.
+func GenerateTOTPSecret() (string, error) {
+	var secret []byte = make([]byte, totp_secret_size)
+
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return totp_secret_encoding.EncodeToString(secret), nil
+}
+
+// Returns the otpauth:// url authenticator apps enroll from, usually shown as a QR code.
+func TOTPProvisioningUrl(secret string, issuer string, account_name string) string {
+	var label string = url.PathEscape(fmt.Sprintf("%s:%s", issuer, account_name))
+
+	var params url.Values = url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totp_digits))
+	params.Set("period", fmt.Sprintf("%d", TOTP_STEP_SECONDS))
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+func TOTPStep(at time.Time) int64 {
+	return at.Unix() / TOTP_STEP_SECONDS
+}
+
+// Returns the step the code is valid for, accepting the steps right before and after the current one to tolerate
+// clock drift. Returns -1 if the code is not valid for any of them.
+func MatchTOTPCode(secret string, code string, at time.Time) int64 {
+	var current_step int64 = TOTPStep(at)
+
+	code = strings.TrimSpace(code)
+
+	for _, step := range []int64{current_step, current_step - 1, current_step + 1} {
+		expected_code, err := totpCode(secret, step)
+		if err != nil {
+			return -1
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected_code), []byte(code)) == 1 {
+			return step
+		}
+	}
+
+	return -1
+}
+
+func totpCode(secret string, step int64) (string, error) {
+	secret_bytes, err := totp_secret_encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var step_bytes []byte = make([]byte, 8)
+	binary.BigEndian.PutUint64(step_bytes, uint64(step))
+
+	code_hash := hmac.New(sha1.New, secret_bytes)
+	code_hash.Write(step_bytes)
+
+	var hash_sum []byte = code_hash.Sum(nil)
+	var offset int = int(hash_sum[len(hash_sum)-1] & 0x0f)
+
+	var truncated_hash uint32 = binary.BigEndian.Uint32(hash_sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totp_digits, truncated_hash%1000000), nil
+}
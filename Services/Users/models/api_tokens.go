@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A token a user created to authenticate scripts and integrations. Requests made with it get the claims of the user
+// limited to the grants of the token.
+type ApiToken struct {
+	UUID        string   `json:"uuid"`
+	UserUUID    string   `json:"user_uuid"`
+	Name        string   `json:"name"`
+	TokenPrefix string   `json:"token_prefix"` // The first characters of the token, so the user can tell which one it is
+	TokenHash   string   `json:"-"`            // sha256 of the token, the token itself is only shown once
+	Grants      []string `json:"grants"`
+	CreatedAt   int64    `json:"created_at"`   // Unix timestamp in seconds
+	ExpiresAt   int64    `json:"expires_at"`   // Unix timestamp in seconds, 0 if the token never expires
+	LastUsedAt  int64    `json:"last_used_at"` // Unix timestamp in seconds, 0 if the token was never used
+}
+
+func CreateNewApiToken(user_uuid string, name string, token_prefix string, token_hash string, grants []string, expires_at int64) *ApiToken {
+	return &ApiToken{
+		UUID:        uuid.NewString(),
+		UserUUID:    user_uuid,
+		Name:        name,
+		TokenPrefix: token_prefix,
+		TokenHash:   token_hash,
+		Grants:      grants,
+		CreatedAt:   time.Now().Unix(),
+		ExpiresAt:   expires_at,
+	}
+}
+
+func (api_token *ApiToken) IsExpired() bool {
+	return api_token.ExpiresAt != 0 && time.Now().Unix() >= api_token.ExpiresAt
+}
@@ -0,0 +1,22 @@
+package models
+
+type LoginOutcome string
+
+const (
+	LoginOutcome_Granted             LoginOutcome = "granted"
+	LoginOutcome_UnknownUser         LoginOutcome = "unknown_user"
+	LoginOutcome_InvalidSecret       LoginOutcome = "invalid_secret"
+	LoginOutcome_LockedOut           LoginOutcome = "locked_out"            // Rejected without checking the credentials
+	LoginOutcome_SecondFactorPending LoginOutcome = "second_factor_pending" // The secret was valid and a login challenge was issued
+	LoginOutcome_InvalidSecondFactor LoginOutcome = "invalid_second_factor"
+)
+
+// The audit record of a login attempt.
+type LoginAttempt struct {
+	ID          int64        `json:"id"`
+	Username    string       `json:"username"`
+	ClientIP    string       `json:"client_ip"`
+	UserAgent   string       `json:"user_agent"`
+	Outcome     LoginOutcome `json:"outcome"`
+	AttemptedAt int64        `json:"attempted_at"` // Unix timestamp in seconds
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// A login session of a user. The session is kept alive by its refresh token, which changes every time it's used to
+// renew the user claims.
+type UserSession struct {
+	UUID                string `json:"uuid"`
+	UserUUID            string `json:"user_uuid"`
+	RefreshHash         string `json:"-"` // sha256 of the current refresh token
+	PreviousRefreshHash string `json:"-"` // sha256 of the refresh token it replaced, presenting it again means it was stolen
+	ClientIP            string `json:"client_ip"`
+	UserAgent           string `json:"user_agent"`
+	CreatedAt           int64  `json:"created_at"`        // Unix timestamp in seconds
+	LastRefreshedAt     int64  `json:"last_refreshed_at"` // Unix timestamp in seconds
+	ExpiresAt           int64  `json:"expires_at"`        // Unix timestamp in seconds, pushed back on every refresh
+	RevokedAt           int64  `json:"revoked_at"`        // Unix timestamp in seconds, 0 if the session was not revoked
+	Current             bool   `json:"current"`           // Whether it's the session of the request that listed it, not stored
+}
+
+func CreateNewUserSession(user_uuid string, refresh_hash string, client_ip string, user_agent string, lifetime time.Duration) *UserSession {
+	var now time.Time = time.Now()
+
+	return &UserSession{
+		UUID:            uuid.NewString(),
+		UserUUID:        user_uuid,
+		RefreshHash:     refresh_hash,
+		ClientIP:        client_ip,
+		UserAgent:       user_agent,
+		CreatedAt:       now.Unix(),
+		LastRefreshedAt: now.Unix(),
+		ExpiresAt:       now.Add(lifetime).Unix(),
+	}
+}
+
+// Whether the session can still be refreshed.
+func (session *UserSession) IsActive() bool {
+	return session.RevokedAt == 0 && time.Now().Unix() < session.ExpiresAt
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// The TOTP enrollment of a user. An enrollment is not enforced on login until the user confirms it with a code from
+// their authenticator app.
+type UserTOTP struct {
+	UserUUID     string `json:"user_uuid"`
+	SealedSecret string `json:"-"` // The TOTP secret, encrypted with the domain secret
+	Confirmed    bool   `json:"confirmed"`
+	LastUsedStep int64  `json:"-"` // The time step of the last accepted code, codes of that step or older are rejected
+	CreatedAt    int64  `json:"created_at"`
+}
+
+// What a user can see about their own second factor.
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	PendingEnrollment bool `json:"pending_enrollment"`
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+func CreateNewUserTOTP(user_uuid string, sealed_secret string) *UserTOTP {
+	return &UserTOTP{
+		UserUUID:     user_uuid,
+		SealedSecret: sealed_secret,
+		Confirmed:    false,
+		LastUsedStep: 0,
+		CreatedAt:    time.Now().Unix(),
+	}
+}
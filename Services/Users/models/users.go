@@ -56,6 +56,14 @@ func (user *User) CompareSecret(plain_text_secret string) error {
 	return bcrypt.CompareHashAndPassword([]byte(user.SecretHash), []byte(plain_text_secret))
 }
 
+var dummy_secret_hash, _ = bcrypt.GenerateFromPassword([]byte("dummy secret"), bcrypt.DefaultCost)
+
+// Runs the same comparison as CompareSecret against a hash that belongs to no user, so rejecting an unknown user takes as
+// long as rejecting a wrong secret.
+func CompareDummySecret(plain_text_secret string) {
+	bcrypt.CompareHashAndPassword(dummy_secret_hash, []byte(plain_text_secret))
+}
+
 func (user User) GetAsEntry() UserEntry {
 	return UserEntry{
 		UUID:     user.UUID,
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	service_models "libery_users_service/models"
+)
+
+type ApiTokensRepository interface {
+	InsertApiToken(api_token *service_models.ApiToken) error
+	InsertApiTokenCTX(ctx context.Context, api_token *service_models.ApiToken) error
+	GetApiTokenByHash(token_hash string) (*service_models.ApiToken, error)
+	GetApiTokenByHashCTX(ctx context.Context, token_hash string) (*service_models.ApiToken, error)
+	GetUserApiTokens(user_uuid string) ([]*service_models.ApiToken, error)
+	GetUserApiTokensCTX(ctx context.Context, user_uuid string) ([]*service_models.ApiToken, error)
+	CountUserApiTokens(user_uuid string) (int, error)
+	CountUserApiTokensCTX(ctx context.Context, user_uuid string) (int, error)
+	UpdateApiTokenLastUsed(token_uuid string, last_used_at int64) error
+	UpdateApiTokenLastUsedCTX(ctx context.Context, token_uuid string, last_used_at int64) error
+	DeleteUserApiToken(user_uuid string, token_uuid string) (bool, error)
+	DeleteUserApiTokenCTX(ctx context.Context, user_uuid string, token_uuid string) (bool, error)
+}
+
+var ApiTokensRepo ApiTokensRepository
+
+func SetApiTokensRepository(repo ApiTokensRepository) {
+	ApiTokensRepo = repo
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	service_models "libery_users_service/models"
+)
+
+type LoginAttemptsRepository interface {
+	InsertLoginAttempt(login_attempt *service_models.LoginAttempt) error
+	InsertLoginAttemptCTX(ctx context.Context, login_attempt *service_models.LoginAttempt) error
+	GetLoginAttempts(username string, limit int, offset int) ([]service_models.LoginAttempt, error)
+	GetLoginAttemptsCTX(ctx context.Context, username string, limit int, offset int) ([]service_models.LoginAttempt, error)
+	DeleteLoginAttemptsBefore(before int64) (int64, error)
+	DeleteLoginAttemptsBeforeCTX(ctx context.Context, before int64) (int64, error)
+}
+
+var LoginAttemptsRepo LoginAttemptsRepository
+
+func SetLoginAttemptsRepository(repo LoginAttemptsRepository) {
+	LoginAttemptsRepo = repo
+}
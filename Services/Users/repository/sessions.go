@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	service_models "libery_users_service/models"
+)
+
+type SessionsRepository interface {
+	InsertUserSession(user_session *service_models.UserSession) error
+	InsertUserSessionCTX(ctx context.Context, user_session *service_models.UserSession) error
+	GetUserSession(session_uuid string) (*service_models.UserSession, error)
+	GetUserSessionCTX(ctx context.Context, session_uuid string) (*service_models.UserSession, error)
+	GetUserSessionByRefreshHash(refresh_hash string) (*service_models.UserSession, error)
+	GetUserSessionByRefreshHashCTX(ctx context.Context, refresh_hash string) (*service_models.UserSession, error)
+	GetActiveUserSessions(user_uuid string) ([]*service_models.UserSession, error)
+	GetActiveUserSessionsCTX(ctx context.Context, user_uuid string) ([]*service_models.UserSession, error)
+	RotateSessionRefresh(session_uuid string, old_refresh_hash string, new_refresh_hash string, expires_at int64) (bool, error)
+	RotateSessionRefreshCTX(ctx context.Context, session_uuid string, old_refresh_hash string, new_refresh_hash string, expires_at int64) (bool, error)
+	RevokeUserSession(session_uuid string) error
+	RevokeUserSessionCTX(ctx context.Context, session_uuid string) error
+	RevokeUserSessions(user_uuid string) (int64, error)
+	RevokeUserSessionsCTX(ctx context.Context, user_uuid string) (int64, error)
+	DeleteEndedSessions(before int64) (int64, error)
+	DeleteEndedSessionsCTX(ctx context.Context, before int64) (int64, error)
+}
+
+var SessionsRepo SessionsRepository
+
+func SetSessionsRepository(repo SessionsRepository) {
+	SessionsRepo = repo
+}
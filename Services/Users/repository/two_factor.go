@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	service_models "libery_users_service/models"
+)
+
+type TwoFactorRepository interface {
+	SaveUserTOTP(user_totp *service_models.UserTOTP) error
+	SaveUserTOTPCTX(ctx context.Context, user_totp *service_models.UserTOTP) error
+	GetUserTOTP(user_uuid string) (*service_models.UserTOTP, error)
+	GetUserTOTPCTX(ctx context.Context, user_uuid string) (*service_models.UserTOTP, error)
+	UseTOTPStep(user_uuid string, step int64) (bool, error)
+	UseTOTPStepCTX(ctx context.Context, user_uuid string, step int64) (bool, error)
+	ReplaceRecoveryCodes(user_uuid string, code_hashes []string) error
+	ReplaceRecoveryCodesCTX(ctx context.Context, user_uuid string, code_hashes []string) error
+	UseRecoveryCode(user_uuid string, code_hash string) (bool, error)
+	UseRecoveryCodeCTX(ctx context.Context, user_uuid string, code_hash string) (bool, error)
+	CountRecoveryCodes(user_uuid string) (int, error)
+	CountRecoveryCodesCTX(ctx context.Context, user_uuid string) (int, error)
+	DeleteUserTwoFactor(user_uuid string) error
+	DeleteUserTwoFactorCTX(ctx context.Context, user_uuid string) error
+}
+
+var TwoFactorRepo TwoFactorRepository
+
+func SetTwoFactorRepository(repo TwoFactorRepository) {
+	TwoFactorRepo = repo
+}
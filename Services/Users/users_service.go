@@ -3,11 +3,13 @@ package main
 import (
 	"context"
 	"fmt"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
 	"libery-dungeon-libs/libs/libery_networking"
 	app_config "libery_users_service/Config"
 	"libery_users_service/databases/sqlite_users"
 	"libery_users_service/handlers"
 	"libery_users_service/repository"
+	"libery_users_service/workflows"
 
 	"github.com/Gerardo115pp/patriot_router"
 	"github.com/Gerardo115pp/patriots_lib/echo"
@@ -39,6 +41,17 @@ func main() {
 	}
 
 	repository.SetUsersRepository(users_db)
+	repository.SetTwoFactorRepository(users_db)
+	repository.SetLoginAttemptsRepository(users_db)
+	repository.SetSessionsRepository(users_db)
+	repository.SetApiTokensRepository(users_db)
+
+	workflows.PruneLoginAttempts()
+	workflows.PruneEndedSessions()
+
+	// The sessions and API tokens are in this service's database, there is no need to ask for them over http like the other services do
+	dungeon_middlewares.SetSessionRevocationChecker(workflows.IsSessionRevoked)
+	dungeon_middlewares.SetApiTokenResolver(workflows.ResolveApiToken)
 
 	// ------ Servers ------
 
@@ -0,0 +1,122 @@
+package workflows
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"libery-dungeon-libs/dungeonsec"
+	"libery-dungeon-libs/dungeonsec/dungeon_middlewares"
+	dungeon_models "libery-dungeon-libs/models"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// How many characters of a token, prefix included, are kept so its owner can recognize it.
+const api_token_visible_length int = 12
+
+// Writing the last use of a token on every request is wasteful, it's only updated once this much time passed.
+const api_token_last_used_resolution time.Duration = time.Minute
+
+// Creates an API token for the user with the given grants, which must already be checked against the user's grants.
+// Returns the token record and the token itself, only its hash is stored so it can't be shown again.
+func CreateApiToken(ctx context.Context, user_uuid string, name string, grants []string, expires_at int64) (*service_models.ApiToken, string, error) {
+	api_token, err := generateApiToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var token_record *service_models.ApiToken = service_models.CreateNewApiToken(user_uuid, name, api_token[:api_token_visible_length], hashApiToken(api_token), grants, expires_at)
+
+	err = repository.ApiTokensRepo.InsertApiTokenCTX(ctx, token_record)
+	if err != nil {
+		return nil, "", err
+	}
+
+	echo.Echo(echo.SkyBlueFG, fmt.Sprintf("User<%s> created API token '%s'", user_uuid, token_record.UUID))
+
+	return token_record, api_token, nil
+}
+
+// Returns the grants of a token its user still has, on their own or through ALL_PRIVILEGES. Grants removed from the user
+// after the token was created stop working for the token too.
+func FilterApiTokenGrants(token_grants []string, user_grants []string) []string {
+	var effective_grants []string = make([]string, 0, len(token_grants))
+
+	for _, grant := range token_grants {
+		if dungeonsec.GrantsInclude(user_grants, grant) {
+			effective_grants = append(effective_grants, grant)
+		}
+	}
+
+	return effective_grants
+}
+
+// Returns the claims of the user that owns an API token, with the grants of the token the user still has. Returns nil
+// claims if the token doesn't exist, expired or its user was deleted.
+func ResolveApiToken(api_token string) (*dungeon_models.PlatformUserClaims, error) {
+	var ctx context.Context = context.Background()
+
+	token_record, err := repository.ApiTokensRepo.GetApiTokenByHashCTX(ctx, hashApiToken(api_token))
+	if err != nil || token_record == nil {
+		return nil, err
+	}
+
+	if token_record.IsExpired() {
+		return nil, nil
+	}
+
+	user, err := repository.UsersRepo.GetUserByUuidCTX(ctx, token_record.UserUUID)
+	if err != nil {
+		echo.Echo(echo.YellowFG, fmt.Sprintf("API token '%s' belongs to user<%s> which could not be found: %s", token_record.UUID, token_record.UserUUID, err))
+		return nil, nil
+	}
+
+	user_roles, err := repository.UsersRepo.GetUserRolesCTX(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("Error getting roles of user<%s>: %s", user.UUID, err)
+	}
+
+	var now time.Time = time.Now()
+
+	if now.Sub(time.Unix(token_record.LastUsedAt, 0)) > api_token_last_used_resolution {
+		err = repository.ApiTokensRepo.UpdateApiTokenLastUsedCTX(ctx, token_record.UUID, now.Unix())
+		if err != nil {
+			echo.EchoErr(fmt.Errorf("Error updating last use of API token '%s': %s", token_record.UUID, err))
+		}
+	}
+
+	user_claims := &dungeon_models.PlatformUserClaims{
+		UserUUID:             user.UUID,
+		UserName:             user.Username,
+		UserHighestHierarchy: GetHighestRoleHierarchy(user_roles),
+		UserGrants:           FilterApiTokenGrants(token_record.Grants, CompileUserGrants(user_roles)),
+		ApiTokenUUID:         token_record.UUID,
+	}
+
+	user_claims.ExpiresAt = token_record.ExpiresAt
+
+	return user_claims, nil
+}
+
+func generateApiToken() (string, error) {
+	var token_bytes []byte = make([]byte, 32)
+
+	_, err := rand.Read(token_bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return dungeon_middlewares.API_TOKEN_PREFIX + base64.RawURLEncoding.EncodeToString(token_bytes), nil
+}
+
+func hashApiToken(api_token string) string {
+	var token_hash [32]byte = sha256.Sum256([]byte(api_token))
+
+	return hex.EncodeToString(token_hash[:])
+}
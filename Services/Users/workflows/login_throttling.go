@@ -0,0 +1,180 @@
+package workflows
+
+import (
+	"context"
+	"fmt"
+	app_config "libery_users_service/Config"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Counters are swept for stale entries at most this often.
+const failed_logins_sweep_interval time.Duration = time.Minute
+
+// The consecutive failed logins of a username or a client ip.
+type failedLogins struct {
+	failures        int
+	last_failure_at time.Time
+	locked_until    time.Time
+}
+
+var failed_logins_by_username map[string]*failedLogins = make(map[string]*failedLogins)
+var failed_logins_by_ip map[string]*failedLogins = make(map[string]*failedLogins)
+var failed_logins_swept_at time.Time
+var failed_logins_mutex sync.Mutex
+
+// Reserves a login attempt for the username and the client ip. The attempt is counted as failed right away, before the
+// secret is checked, so parallel guesses can't get past the limit, and is given back with ReleaseLoginAttempt if the secret
+// turns out to be right. If logins with the username or from the client ip are locked out, nothing is counted and until
+// when they are locked out is returned, otherwise the zero time.
+func ReserveLoginAttempt(username string, client_ip string) time.Time {
+	failed_logins_mutex.Lock()
+	defer failed_logins_mutex.Unlock()
+
+	var now time.Time = time.Now()
+	var locked_until time.Time
+
+	for _, failed_logins := range []*failedLogins{failed_logins_by_username[username], failed_logins_by_ip[client_ip]} {
+		if failed_logins != nil && failed_logins.locked_until.After(now) && failed_logins.locked_until.After(locked_until) {
+			locked_until = failed_logins.locked_until
+		}
+	}
+
+	if !locked_until.IsZero() {
+		return locked_until
+	}
+
+	sweepFailedLogins(now)
+
+	countFailedLogin(failed_logins_by_username, username, app_config.LOGIN_MAX_FAILED_ATTEMPTS, now)
+	countFailedLogin(failed_logins_by_ip, client_ip, app_config.LOGIN_MAX_FAILED_ATTEMPTS_PER_IP, now)
+
+	return time.Time{}
+}
+
+// Gives back an attempt reserved with ReserveLoginAttempt whose secret was right, lifting the lockout it may have caused.
+func ReleaseLoginAttempt(username string, client_ip string) {
+	failed_logins_mutex.Lock()
+	defer failed_logins_mutex.Unlock()
+
+	uncountFailedLogin(failed_logins_by_username, username, app_config.LOGIN_MAX_FAILED_ATTEMPTS)
+	uncountFailedLogin(failed_logins_by_ip, client_ip, app_config.LOGIN_MAX_FAILED_ATTEMPTS_PER_IP)
+}
+
+// Clears the failed logins of the username. Those of the client ip are kept, so a client can't keep guessing other
+// users' secrets by logging into its own account in between.
+func RegisterSuccessfulLogin(username string) {
+	failed_logins_mutex.Lock()
+	defer failed_logins_mutex.Unlock()
+
+	delete(failed_logins_by_username, username)
+}
+
+// Must be called with the failed logins mutex held.
+func countFailedLogin(failed_logins_by_key map[string]*failedLogins, key string, max_failures int, now time.Time) {
+	failed_logins, exists := failed_logins_by_key[key]
+	if !exists || failedLoginsExpired(failed_logins, now) {
+		failed_logins = new(failedLogins)
+		failed_logins_by_key[key] = failed_logins
+	}
+
+	failed_logins.failures++
+	failed_logins.last_failure_at = now
+
+	if failed_logins.failures < max_failures {
+		return
+	}
+
+	failed_logins.locked_until = now.Add(lockoutDuration(failed_logins.failures - max_failures))
+
+	echo.EchoWarn(fmt.Sprintf("Logins of '%s' locked out until %s after %d failed attempts", key, failed_logins.locked_until.Format(time.RFC3339), failed_logins.failures))
+}
+
+// Must be called with the failed logins mutex held.
+func uncountFailedLogin(failed_logins_by_key map[string]*failedLogins, key string, max_failures int) {
+	failed_logins, exists := failed_logins_by_key[key]
+	if !exists {
+		return
+	}
+
+	failed_logins.failures--
+
+	if failed_logins.failures <= 0 {
+		delete(failed_logins_by_key, key)
+		return
+	}
+
+	if failed_logins.failures < max_failures {
+		failed_logins.locked_until = time.Time{}
+	}
+}
+
+// The lockout doubles with every failure past the limit.
+func lockoutDuration(failures_past_limit int) time.Duration {
+	var lockout time.Duration = app_config.LOGIN_LOCKOUT_BASE
+
+	for h := 0; h < failures_past_limit && lockout < app_config.LOGIN_LOCKOUT_MAX; h++ {
+		lockout *= 2
+	}
+
+	if lockout > app_config.LOGIN_LOCKOUT_MAX {
+		lockout = app_config.LOGIN_LOCKOUT_MAX
+	}
+
+	return lockout
+}
+
+// Failures are forgotten once the lockout they caused is over and nothing failed for as long as the longest lockout.
+func failedLoginsExpired(failed_logins *failedLogins, now time.Time) bool {
+	return now.After(failed_logins.locked_until) && now.Sub(failed_logins.last_failure_at) > app_config.LOGIN_LOCKOUT_MAX
+}
+
+// Must be called with the failed logins mutex held.
+func sweepFailedLogins(now time.Time) {
+	if now.Sub(failed_logins_swept_at) < failed_logins_sweep_interval {
+		return
+	}
+
+	failed_logins_swept_at = now
+
+	for _, failed_logins_by_key := range []map[string]*failedLogins{failed_logins_by_username, failed_logins_by_ip} {
+		for key, failed_logins := range failed_logins_by_key {
+			if failedLoginsExpired(failed_logins, now) {
+				delete(failed_logins_by_key, key)
+			}
+		}
+	}
+}
+
+// Adds a login attempt to the audit records. Failing to record it doesn't stop the login.
+func RecordLoginAttempt(ctx context.Context, username string, client_ip string, user_agent string, outcome service_models.LoginOutcome) {
+	err := repository.LoginAttemptsRepo.InsertLoginAttemptCTX(ctx, &service_models.LoginAttempt{
+		Username:    username,
+		ClientIP:    client_ip,
+		UserAgent:   user_agent,
+		Outcome:     outcome,
+		AttemptedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error recording login attempt of '%s' from %s: %s", username, client_ip, err))
+	}
+}
+
+// Removes the login attempts older than LOGIN_AUDIT_RETENTION from the audit records.
+func PruneLoginAttempts() {
+	var retention_limit int64 = time.Now().Add(-app_config.LOGIN_AUDIT_RETENTION).Unix()
+
+	removed_attempts, err := repository.LoginAttemptsRepo.DeleteLoginAttemptsBefore(retention_limit)
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error pruning login attempts: %s", err))
+		return
+	}
+
+	if removed_attempts > 0 {
+		echo.Echo(echo.SkyBlueFG, fmt.Sprintf("Removed %d expired login attempts", removed_attempts))
+	}
+}
@@ -0,0 +1,170 @@
+package workflows
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	app_config "libery_users_service/Config"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"sync"
+	"time"
+
+	"github.com/Gerardo115pp/patriots_lib/echo"
+)
+
+// Starts a login session for the user. Returns the session and its refresh token, only the hash of the token is stored.
+func CreateUserSession(ctx context.Context, user_uuid string, client_ip string, user_agent string) (*service_models.UserSession, string, error) {
+	refresh_token, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var user_session *service_models.UserSession = service_models.CreateNewUserSession(user_uuid, hashRefreshToken(refresh_token), client_ip, user_agent, app_config.USER_CLAIMS_EXPIRATION_HOURS)
+
+	err = repository.SessionsRepo.InsertUserSessionCTX(ctx, user_session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user_session, refresh_token, nil
+}
+
+// How long a replaced refresh token keeps being exchanged for the token that replaced it. Tabs or requests that refresh
+// the session at the same time all present the same token, only the first one rotates it.
+const replaced_refresh_token_grace time.Duration = 30 * time.Second
+
+// A refresh token handed out by a rotation, kept for the grace period under the hash of the token it replaced.
+type refreshRotation struct {
+	session_uuid  string
+	refresh_token string
+	rotated_at    time.Time
+}
+
+var recent_rotations map[string]*refreshRotation = make(map[string]*refreshRotation)
+
+// Refreshes are serialized so a refresh racing another one on the same token finds its rotation instead of failing.
+var session_refresh_mutex sync.Mutex
+
+// Exchanges a refresh token for a new one, extending the session. Returns a nil session if the token doesn't belong to
+// an active session. A token that was just replaced gets the token that replaced it. A token that was replaced longer
+// ago means either the user or someone that stole it used it before, and there is no telling which one, so the session
+// is revoked.
+func RefreshUserSession(ctx context.Context, refresh_token string) (*service_models.UserSession, string, error) {
+	var refresh_hash string = hashRefreshToken(refresh_token)
+
+	session_refresh_mutex.Lock()
+	defer session_refresh_mutex.Unlock()
+
+	var now time.Time = time.Now()
+
+	for replaced_hash, rotation := range recent_rotations {
+		if now.Sub(rotation.rotated_at) > replaced_refresh_token_grace {
+			delete(recent_rotations, replaced_hash)
+		}
+	}
+
+	user_session, err := repository.SessionsRepo.GetUserSessionByRefreshHashCTX(ctx, refresh_hash)
+	if err != nil || user_session == nil {
+		return nil, "", err
+	}
+
+	if !user_session.IsActive() {
+		return nil, "", nil
+	}
+
+	if user_session.RefreshHash != refresh_hash {
+		rotation, recently_rotated := recent_rotations[refresh_hash]
+		if recently_rotated && rotation.session_uuid == user_session.UUID && hashRefreshToken(rotation.refresh_token) == user_session.RefreshHash {
+			return user_session, rotation.refresh_token, nil
+		}
+
+		echo.EchoWarn(fmt.Sprintf("A replaced refresh token of session '%s' of user<%s> was used, revoking the session", user_session.UUID, user_session.UserUUID))
+
+		err = repository.SessionsRepo.RevokeUserSessionCTX(ctx, user_session.UUID)
+
+		return nil, "", err
+	}
+
+	new_refresh_token, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var expires_at int64 = now.Add(app_config.USER_CLAIMS_EXPIRATION_HOURS).Unix()
+
+	rotated, err := repository.SessionsRepo.RotateSessionRefreshCTX(ctx, user_session.UUID, refresh_hash, hashRefreshToken(new_refresh_token), expires_at)
+	if err != nil || !rotated {
+		return nil, "", err
+	}
+
+	recent_rotations[refresh_hash] = &refreshRotation{
+		session_uuid:  user_session.UUID,
+		refresh_token: new_refresh_token,
+		rotated_at:    now,
+	}
+
+	user_session.ExpiresAt = expires_at
+
+	return user_session, new_refresh_token, nil
+}
+
+// Returns the session a refresh token belongs to, nil if there is none.
+func GetRefreshTokenSession(ctx context.Context, refresh_token string) (*service_models.UserSession, error) {
+	return repository.SessionsRepo.GetUserSessionByRefreshHashCTX(ctx, hashRefreshToken(refresh_token))
+}
+
+// Whether the user claims issued for the session must be rejected. Sessions that no longer exist count as revoked.
+func IsSessionRevoked(session_uuid string) (bool, error) {
+	user_session, err := repository.SessionsRepo.GetUserSession(session_uuid)
+	if err != nil {
+		return false, err
+	}
+
+	return user_session == nil || user_session.RevokedAt != 0, nil
+}
+
+// Revokes every session of the user, their user claims stop being accepted and they have to log in again everywhere.
+func RevokeAllUserSessions(ctx context.Context, user_uuid string) (int64, error) {
+	revoked_sessions, err := repository.SessionsRepo.RevokeUserSessionsCTX(ctx, user_uuid)
+	if err != nil {
+		return 0, err
+	}
+
+	echo.Echo(echo.YellowFG, fmt.Sprintf("Revoked %d sessions of user<%s>", revoked_sessions, user_uuid))
+
+	return revoked_sessions, nil
+}
+
+// Removes the sessions that already ended.
+func PruneEndedSessions() {
+	removed_sessions, err := repository.SessionsRepo.DeleteEndedSessions(time.Now().Unix())
+	if err != nil {
+		echo.EchoErr(fmt.Errorf("Error pruning ended sessions: %s", err))
+		return
+	}
+
+	if removed_sessions > 0 {
+		echo.Echo(echo.SkyBlueFG, fmt.Sprintf("Removed %d ended sessions", removed_sessions))
+	}
+}
+
+func generateRefreshToken() (string, error) {
+	var token_bytes []byte = make([]byte, 32)
+
+	_, err := rand.Read(token_bytes)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token_bytes), nil
+}
+
+func hashRefreshToken(refresh_token string) string {
+	var token_hash [32]byte = sha256.Sum256([]byte(refresh_token))
+
+	return hex.EncodeToString(token_hash[:])
+}
@@ -0,0 +1,306 @@
+package workflows
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	app_config "libery_users_service/Config"
+	"libery_users_service/helpers"
+	service_models "libery_users_service/models"
+	"libery_users_service/repository"
+	"strings"
+	"sync"
+	"time"
+)
+
+const RECOVERY_CODES_COUNT int = 10
+
+// Wrong codes a login challenge takes before it's discarded and the user has to send their secret again.
+const login_challenge_max_attempts int = 5
+
+var recovery_code_encoding *base32.Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// A user that passed the first login step and still has to send their second factor.
+type loginChallenge struct {
+	user_uuid  string
+	expires_at time.Time
+	attempts   int
+}
+
+var login_challenges map[string]*loginChallenge = make(map[string]*loginChallenge)
+var login_challenges_mutex sync.Mutex
+
+// Returns whether the user has to send a second factor to log in.
+func TwoFactorRequired(ctx context.Context, user_uuid string) (bool, error) {
+	user_totp, err := repository.TwoFactorRepo.GetUserTOTPCTX(ctx, user_uuid)
+	if err != nil {
+		return false, err
+	}
+
+	return user_totp != nil && user_totp.Confirmed, nil
+}
+
+func GetTwoFactorStatus(ctx context.Context, user_uuid string) (*service_models.TwoFactorStatus, error) {
+	var two_factor_status *service_models.TwoFactorStatus = new(service_models.TwoFactorStatus)
+
+	user_totp, err := repository.TwoFactorRepo.GetUserTOTPCTX(ctx, user_uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if user_totp == nil {
+		return two_factor_status, nil
+	}
+
+	two_factor_status.Enabled = user_totp.Confirmed
+	two_factor_status.PendingEnrollment = !user_totp.Confirmed
+
+	two_factor_status.RecoveryCodesLeft, err = repository.TwoFactorRepo.CountRecoveryCodesCTX(ctx, user_uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	return two_factor_status, nil
+}
+
+// Starts a TOTP enrollment for the user, replacing any enrollment they didn't confirm. Users that already have 2FA
+// enabled have to disable it first. Returns the secret and the otpauth url to add it to an authenticator app.
+func BeginTOTPEnrollment(ctx context.Context, user *service_models.User) (string, string, error) {
+	user_totp, err := repository.TwoFactorRepo.GetUserTOTPCTX(ctx, user.UUID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user_totp != nil && user_totp.Confirmed {
+		return "", "", fmt.Errorf("User<%s> already has two factor authentication enabled", user.UUID)
+	}
+
+	totp_secret, err := helpers.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	sealed_secret, err := helpers.SealSecret(totp_secret, app_config.DOMAIN_SECRET)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = repository.TwoFactorRepo.SaveUserTOTPCTX(ctx, service_models.CreateNewUserTOTP(user.UUID, sealed_secret))
+	if err != nil {
+		return "", "", err
+	}
+
+	return totp_secret, helpers.TOTPProvisioningUrl(totp_secret, app_config.TOTP_ISSUER, user.Username), nil
+}
+
+// Confirms the pending enrollment of the user with a code from their authenticator app, from then on the user needs a
+// second factor to log in. Returns the recovery codes of the user, which are not stored in plain text and can't be
+// shown again. Returns nil codes if the code is not valid.
+func ConfirmTOTPEnrollment(ctx context.Context, user_uuid string, code string) ([]string, error) {
+	user_totp, err := repository.TwoFactorRepo.GetUserTOTPCTX(ctx, user_uuid)
+	if err != nil {
+		return nil, err
+	}
+
+	if user_totp == nil || user_totp.Confirmed {
+		return nil, fmt.Errorf("User<%s> has no pending two factor enrollment", user_uuid)
+	}
+
+	code_accepted, err := verifyTOTPCode(ctx, user_totp, code)
+	if err != nil || !code_accepted {
+		return nil, err
+	}
+
+	return RegenerateRecoveryCodes(ctx, user_uuid)
+}
+
+// Returns whether the code is a valid TOTP code or an unused recovery code of the user. Accepted codes can't be used again.
+func VerifySecondFactor(ctx context.Context, user_uuid string, code string) (bool, error) {
+	user_totp, err := repository.TwoFactorRepo.GetUserTOTPCTX(ctx, user_uuid)
+	if err != nil {
+		return false, err
+	}
+
+	if user_totp == nil || !user_totp.Confirmed {
+		return false, nil
+	}
+
+	if isRecoveryCode(code) {
+		return repository.TwoFactorRepo.UseRecoveryCodeCTX(ctx, user_uuid, hashRecoveryCode(code))
+	}
+
+	return verifyTOTPCode(ctx, user_totp, code)
+}
+
+// Replaces the recovery codes of the user with new ones and returns them.
+func RegenerateRecoveryCodes(ctx context.Context, user_uuid string) ([]string, error) {
+	var recovery_codes []string = make([]string, RECOVERY_CODES_COUNT)
+	var code_hashes []string = make([]string, RECOVERY_CODES_COUNT)
+
+	for h := range recovery_codes {
+		recovery_code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		recovery_codes[h] = recovery_code
+		code_hashes[h] = hashRecoveryCode(recovery_code)
+	}
+
+	err := repository.TwoFactorRepo.ReplaceRecoveryCodesCTX(ctx, user_uuid, code_hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return recovery_codes, nil
+}
+
+// Removes the second factor of the user, they can log in with their secret alone until they enroll again.
+func DisableTwoFactor(ctx context.Context, user_uuid string) error {
+	discardLoginChallengesOf(user_uuid)
+
+	return repository.TwoFactorRepo.DeleteUserTwoFactorCTX(ctx, user_uuid)
+}
+
+func verifyTOTPCode(ctx context.Context, user_totp *service_models.UserTOTP, code string) (bool, error) {
+	totp_secret, err := helpers.OpenSealedSecret(user_totp.SealedSecret, app_config.DOMAIN_SECRET)
+	if err != nil {
+		return false, fmt.Errorf("Error opening totp secret of user<%s>: %s", user_totp.UserUUID, err)
+	}
+
+	var matched_step int64 = helpers.MatchTOTPCode(totp_secret, code, time.Now())
+	if matched_step < 0 {
+		return false, nil
+	}
+
+	return repository.TwoFactorRepo.UseTOTPStepCTX(ctx, user_totp.UserUUID, matched_step)
+}
+
+// Recovery codes look like 'abcde-fghij', which can't be mistaken for a TOTP code.
+func generateRecoveryCode() (string, error) {
+	var code_bytes []byte = make([]byte, 7)
+
+	_, err := rand.Read(code_bytes)
+	if err != nil {
+		return "", err
+	}
+
+	var encoded_code string = strings.ToLower(recovery_code_encoding.EncodeToString(code_bytes))[:10]
+
+	return fmt.Sprintf("%s-%s", encoded_code[:5], encoded_code[5:]), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	return strings.ReplaceAll(code, "-", "")
+}
+
+func isRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == 10
+}
+
+func hashRecoveryCode(code string) string {
+	var code_hash [32]byte = sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+
+	return hex.EncodeToString(code_hash[:])
+}
+
+// ------ Login challenges ------
+
+// Registers that the user passed the first login step and returns the challenge they have to answer with their second factor.
+func CreateLoginChallenge(user_uuid string) (string, error) {
+	var challenge_bytes []byte = make([]byte, 32)
+
+	_, err := rand.Read(challenge_bytes)
+	if err != nil {
+		return "", err
+	}
+
+	var challenge string = hex.EncodeToString(challenge_bytes)
+
+	login_challenges_mutex.Lock()
+	defer login_challenges_mutex.Unlock()
+
+	removeExpiredLoginChallenges()
+
+	login_challenges[challenge] = &loginChallenge{
+		user_uuid:  user_uuid,
+		expires_at: time.Now().Add(app_config.TWO_FACTOR_CHALLENGE_EXPIRATION),
+		attempts:   0,
+	}
+
+	return challenge, nil
+}
+
+// Verifies the second factor sent for a login challenge. Returns the uuid of the user the challenge belongs to and
+// whether the code is valid, the uuid is empty if the challenge doesn't exist or expired. Answered, expired and
+// exhausted challenges are discarded.
+func AnswerLoginChallenge(ctx context.Context, challenge string, code string) (string, bool, error) {
+	login_challenges_mutex.Lock()
+
+	login_challenge, exists := login_challenges[challenge]
+	if !exists || time.Now().After(login_challenge.expires_at) {
+		delete(login_challenges, challenge)
+		login_challenges_mutex.Unlock()
+		return "", false, nil
+	}
+
+	login_challenge.attempts++
+	if login_challenge.attempts >= login_challenge_max_attempts {
+		delete(login_challenges, challenge)
+	}
+
+	var user_uuid string = login_challenge.user_uuid
+
+	login_challenges_mutex.Unlock()
+
+	code_accepted, err := VerifySecondFactor(ctx, user_uuid, code)
+	if err != nil || !code_accepted {
+		return user_uuid, false, err
+	}
+
+	login_challenges_mutex.Lock()
+	delete(login_challenges, challenge)
+	login_challenges_mutex.Unlock()
+
+	return user_uuid, true, nil
+}
+
+// Returns the uuid of the user a login challenge belongs to, an empty string if it doesn't exist or expired.
+func LoginChallengeUser(challenge string) string {
+	login_challenges_mutex.Lock()
+	defer login_challenges_mutex.Unlock()
+
+	login_challenge, exists := login_challenges[challenge]
+	if !exists || time.Now().After(login_challenge.expires_at) {
+		return ""
+	}
+
+	return login_challenge.user_uuid
+}
+
+func discardLoginChallengesOf(user_uuid string) {
+	login_challenges_mutex.Lock()
+	defer login_challenges_mutex.Unlock()
+
+	for challenge, login_challenge := range login_challenges {
+		if login_challenge.user_uuid == user_uuid {
+			delete(login_challenges, challenge)
+		}
+	}
+}
+
+// Must be called with the login challenges mutex held.
+func removeExpiredLoginChallenges() {
+	var now time.Time = time.Now()
+
+	for challenge, login_challenge := range login_challenges {
+		if now.After(login_challenge.expires_at) {
+			delete(login_challenges, challenge)
+		}
+	}
+}
@@ -170,4 +170,793 @@ export class GetCurrentDownloadRequest {
 
         return new HttpResponse(response, data);
     }
-}
\ No newline at end of file
+}
+/**
+ * @typedef {Object} QueuedDownloadState
+ * @property {string} download_uuid
+ * @property {string} category_uuid
+ * @property {string} cluster_uuid
+ * @property {"queued" | "downloading" | "paused" | "cancelled"} status
+ * @property {number} enqueued_at - unix timestamp in milliseconds
+ * @property {number} total_files
+ * @property {number} downloaded_files
+ * @property {number} failed_files
+ */
+
+/**
+ * Requests the state of every download on the download queue, in the order they will be downloaded.
+ */
+export class GetDownloadQueueRequest {
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<QueuedDownloadState[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads/queue`);
+
+        /** @type {QueuedDownloadState[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Pauses a queued download after the file currently being downloaded.
+ */
+export class PatchPauseDownloadRequest {
+
+    /**
+     * @param {string} download_uuid 
+     */
+    constructor(download_uuid) {
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads/pause?download_uuid=${this.download_uuid}`, {
+            method: "PATCH"
+        });
+
+        return new HttpResponse(response, response.status === 204);
+    }
+}
+
+/**
+ * Resumes a paused download.
+ */
+export class PatchResumeDownloadRequest {
+
+    /**
+     * @param {string} download_uuid 
+     */
+    constructor(download_uuid) {
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads/resume?download_uuid=${this.download_uuid}`, {
+            method: "PATCH"
+        });
+
+        return new HttpResponse(response, response.status === 204);
+    }
+}
+
+/**
+ * Cancels a queued download. Files that were already downloaded stay on the download history.
+ */
+export class DeleteCancelDownloadRequest {
+
+    /**
+     * @param {string} download_uuid 
+     */
+    constructor(download_uuid) {
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads?download_uuid=${this.download_uuid}`, {
+            method: "DELETE"
+        });
+
+        return new HttpResponse(response, response.status === 204);
+    }
+}
+
+/**
+ * Enqueues a batch of media urls(images, gifs, videos) to be downloaded into a category. If download_uuid matches a
+ * download that is still on the queue, the urls are added to it.
+ */
+export class PostMediasBatchDownloadRequest {
+
+    /**
+     * @param {string[]} media_urls
+     * @param {string} category_uuid
+     * @param {string} cluster_uuid
+     * @param {string} [download_uuid]
+     */
+    constructor(media_urls, category_uuid, cluster_uuid, download_uuid = "") {
+        this.media_urls = media_urls;
+        this.category_uuid = category_uuid;
+        this.cluster_uuid = cluster_uuid;
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJsonExclusive.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<{ download_uuid: string, queued_medias: number }>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let data = {
+            download_uuid: "",
+            queued_medias: 0
+        };
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Requests the names of the downloader backends(yt-dlp, gallery-dl...) configured on the downloads service.
+ */
+export class GetDownloaderBackendsRequest {
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<string[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads/backends`);
+
+        /** @type {string[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Hands a source url to a downloader backend, the medias it produces are imported into the category.
+ */
+export class PostExternalDownloadRequest {
+
+    /**
+     * @param {string} backend
+     * @param {string} source_url
+     * @param {string} category_uuid
+     * @param {string} cluster_uuid
+     */
+    constructor(backend, source_url, category_uuid, cluster_uuid) {
+        this.backend = backend;
+        this.source_url = source_url;
+        this.category_uuid = category_uuid;
+        this.cluster_uuid = cluster_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<{ download_uuid: string }>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/downloads/external`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let data = {
+            download_uuid: ""
+        };
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * @typedef {Object} SourceWatcher
+ * @property {string} uuid - also the download uuid of everything the watcher enqueues
+ * @property {string} source_url
+ * @property {"thread_json"|"feed"|"directory"} source_kind
+ * @property {string} category_uuid
+ * @property {string} cluster_uuid
+ * @property {number} interval_seconds
+ * @property {"active"|"stopped"} status
+ * @property {string} stop_reason
+ * @property {number} created_at - unix timestamp in seconds
+ * @property {number} last_polled_at - unix timestamp in seconds, 0 if never polled
+ * @property {number} consecutive_failures
+ */
+
+/**
+ * Requests every source watcher, active and stopped.
+ */
+export class GetSourceWatchersRequest {
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<SourceWatcher[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/source-watchers`);
+
+        /** @type {SourceWatcher[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Starts watching a thread, feed or directory listing. New medias found on it are downloaded into the category until
+ * the source is gone.
+ */
+export class PostSourceWatcherRequest {
+
+    /**
+     * @param {string} source_url
+     * @param {"thread_json"|"feed"|"directory"} source_kind
+     * @param {string} category_uuid
+     * @param {string} cluster_uuid
+     * @param {number} [interval_seconds] - 0 uses the service default
+     */
+    constructor(source_url, source_kind, category_uuid, cluster_uuid, interval_seconds = 0) {
+        this.source_url = source_url;
+        this.source_kind = source_kind;
+        this.category_uuid = category_uuid;
+        this.cluster_uuid = cluster_uuid;
+        this.interval_seconds = interval_seconds;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<SourceWatcher | null>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/source-watchers`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        /** @type {SourceWatcher | null} */
+        let data = null;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Stops watching a source. What it already downloaded stays on the download history.
+ */
+export class DeleteSourceWatcherRequest {
+
+    /**
+     * @param {string} watcher_uuid 
+     */
+    constructor(watcher_uuid) {
+        this.watcher_uuid = watcher_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/source-watchers?watcher_uuid=${this.watcher_uuid}`, {
+            method: "DELETE"
+        });
+
+        return new HttpResponse(response, response.status === 204);
+    }
+}
+
+/**
+ * @typedef {Object} DownloadedMedia
+ * @property {number} id - the downloaded_from of the media
+ * @property {string} media_uuid
+ * @property {string} download_uuid
+ * @property {string} source_url
+ * @property {number} downloaded_at - unix timestamp in seconds
+ */
+
+/**
+ * @typedef {Object} LocatedDownloadedMedia
+ * @property {number} id
+ * @property {string} media_uuid
+ * @property {string} download_uuid
+ * @property {string} source_url
+ * @property {number} downloaded_at - unix timestamp in seconds
+ * @property {import('@models/Medias').MediaIdentityParams | null} media_identity - where the media is now, null if it was deleted
+ */
+
+/**
+ * Requests the download and source url a media came from. The data is null if the media was not downloaded.
+ */
+export class GetMediaSourceRequest {
+
+    /**
+     * @param {string} media_uuid 
+     */
+    constructor(media_uuid) {
+        this.media_uuid = media_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<DownloadedMedia | null>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/download-history/media-source?media_uuid=${this.media_uuid}`);
+
+        /** @type {DownloadedMedia | null} */
+        let data = null;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Requests the medias a download produced along with where they are now.
+ */
+export class GetDownloadMediasRequest {
+
+    /**
+     * @param {string} download_uuid 
+     */
+    constructor(download_uuid) {
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<LocatedDownloadedMedia[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/download-history/download-medias?download_uuid=${this.download_uuid}`);
+
+        /** @type {LocatedDownloadedMedia[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * @typedef {Object} SkippedDownloadFile
+ * @property {string} download_uuid
+ * @property {string} source_url
+ * @property {string} content_hash - hex encoded sha256 of the file
+ * @property {string} duplicate_of - uuid of the media the cluster already had with the same content
+ * @property {number} skipped_at - unix timestamp in seconds
+ */
+
+/**
+ * Requests the files of a download that were not uploaded because the cluster already had their content.
+ */
+export class GetSkippedDownloadFilesRequest {
+
+    /**
+     * @param {string} download_uuid 
+     */
+    constructor(download_uuid) {
+        this.download_uuid = download_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<SkippedDownloadFile[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/download-history/skipped-files?download_uuid=${this.download_uuid}`);
+
+        /** @type {SkippedDownloadFile[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * @typedef {"queued"|"downloading"|"paused"|"cancelled"|"completed"} DownloadHistoryStatus
+ */
+
+/**
+ * @typedef {Object} DownloadHistoryFilter
+ * @property {string} [url] - matches downloads with a file url containing it, case insensitive
+ * @property {string} [category_uuid]
+ * @property {DownloadHistoryStatus} [status]
+ * @property {number} [from] - unix timestamp in seconds, inclusive
+ * @property {number} [to] - unix timestamp in seconds, inclusive
+ */
+
+/**
+ * @typedef {Object} DownloadHistoryEntry
+ * @property {string} download_uuid
+ * @property {number} download_timestamp - unix timestamp in seconds
+ * @property {string} category_uuid
+ * @property {DownloadHistoryStatus} status
+ * @property {number} file_count
+ * @property {number} skipped_files
+ */
+
+/**
+ * @typedef {Object} DownloadHistorySearchResult
+ * @property {DownloadHistoryEntry[]} downloads
+ * @property {number} total_matches
+ */
+
+/**
+ * @typedef {Object} DownloadHistoryExport
+ * @property {number} exported_at - unix timestamp in seconds
+ * @property {Array<{download_uuid: string, download_timestamp: number, category_uuid: string, status: DownloadHistoryStatus, file_urls: string[]}>} downloads
+ */
+
+/**
+ * @param {DownloadHistoryFilter} filter
+ * @returns {URLSearchParams}
+ */
+const downloadHistoryFilterParams = filter => {
+    const filter_params = new URLSearchParams();
+
+    for (const [param_name, param_value] of Object.entries(filter)) {
+        if (param_value != null && param_value !== "") {
+            filter_params.set(param_name, String(param_value));
+        }
+    }
+
+    return filter_params;
+}
+
+/**
+ * Searches the download history, newest downloads first.
+ */
+export class GetDownloadHistorySearchRequest {
+
+    /**
+     * @param {DownloadHistoryFilter} filter
+     * @param {number} [limit] - 0 uses the service default
+     * @param {number} [offset]
+     */
+    constructor(filter, limit = 0, offset = 0) {
+        this.filter = filter;
+        this.limit = limit;
+        this.offset = offset;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<DownloadHistorySearchResult>>}
+     */
+    do = async () => {
+        const search_params = downloadHistoryFilterParams(this.filter);
+
+        if (this.limit > 0) {
+            search_params.set("limit", String(this.limit));
+        }
+
+        search_params.set("offset", String(this.offset));
+
+        const response = await fetch(`${downloads_server}/download-history/search?${search_params.toString()}`);
+
+        /** @type {DownloadHistorySearchResult} */
+        let data = { downloads: [], total_matches: 0 };
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Exports the download history, or the part of it that matches the filter, so it can be imported on another install.
+ */
+export class GetDownloadHistoryExportRequest {
+
+    /**
+     * @param {DownloadHistoryFilter} [filter]
+     */
+    constructor(filter = {}) {
+        this.filter = filter;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<DownloadHistoryExport | null>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/download-history/export?${downloadHistoryFilterParams(this.filter).toString()}`);
+
+        /** @type {DownloadHistoryExport | null} */
+        let data = null;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Imports an exported download history. Downloads already on the history only get the file urls they are missing.
+ */
+export class PostDownloadHistoryImportRequest {
+
+    /**
+     * @param {DownloadHistoryExport} history_export
+     */
+    constructor(history_export) {
+        this.exported_at = history_export.exported_at;
+        this.downloads = history_export.downloads;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<{imported_downloads: number, imported_files: number} | null>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/download-history/import`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let data = null;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Removes the downloads registered before a date from the history, their urls can be downloaded again afterwards.
+ * Downloads still on the queue and those of active source watchers are kept.
+ */
+export class DeleteDownloadHistoryRequest {
+
+    /**
+     * @param {number} before - unix timestamp in seconds
+     * @param {string} [category_uuid] - only remove the downloads of this category
+     */
+    constructor(before, category_uuid = "") {
+        this.before = before;
+        this.category_uuid = category_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<number>>} the number of removed downloads
+     */
+    do = async () => {
+        const delete_params = new URLSearchParams({ before: String(this.before) });
+
+        if (this.category_uuid !== "") {
+            delete_params.set("category_uuid", this.category_uuid);
+        }
+
+        const response = await fetch(`${downloads_server}/download-history/downloads?${delete_params.toString()}`, {
+            method: "DELETE"
+        });
+
+        let data = 0;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = (await response.json()).deleted_downloads;
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * @typedef {"source_host"|"filename_regex"|"mime_type"} RoutingMatchKind
+ */
+
+/**
+ * Sends the downloaded files of a cluster that match it to another category and tags their medias. Rules are evaluated
+ * by priority, lowest first: the first matching rule with a target decides the category, the tags of every matching
+ * rule are applied.
+ * @typedef {Object} RoutingRule
+ * @property {string} uuid
+ * @property {string} cluster_uuid
+ * @property {number} priority
+ * @property {RoutingMatchKind} match_kind
+ * @property {string} pattern - a host for source_host, a regular expression for filename_regex, a mime type like 'video/*' for mime_type
+ * @property {string} target_category_uuid
+ * @property {string} target_category_name - used when target_category_uuid is empty, the category is created when missing
+ * @property {string} target_parent_uuid - parent of the named category, the category of the download when empty
+ * @property {number[]} tag_ids
+ * @property {number} created_at - unix timestamp in seconds
+ */
+
+/**
+ * Requests the routing rules in the order they are evaluated.
+ */
+export class GetRoutingRulesRequest {
+
+    /**
+     * @param {string} [cluster_uuid] - only the rules of this cluster
+     */
+    constructor(cluster_uuid = "") {
+        this.cluster_uuid = cluster_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<RoutingRule[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/routing-rules?cluster_uuid=${this.cluster_uuid}`);
+
+        /** @type {RoutingRule[]} */
+        let data = [];
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Adds a routing rule. It needs a target category, by uuid or by name, or tags to apply.
+ */
+export class PostRoutingRuleRequest {
+
+    /**
+     * @param {string} cluster_uuid
+     * @param {RoutingMatchKind} match_kind
+     * @param {string} pattern
+     * @param {Object} target
+     * @param {string} [target.target_category_uuid]
+     * @param {string} [target.target_category_name]
+     * @param {string} [target.target_parent_uuid]
+     * @param {number[]} [target.tag_ids]
+     * @param {number} [priority]
+     */
+    constructor(cluster_uuid, match_kind, pattern, target, priority = 0) {
+        this.cluster_uuid = cluster_uuid;
+        this.match_kind = match_kind;
+        this.pattern = pattern;
+        this.target_category_uuid = target.target_category_uuid ?? "";
+        this.target_category_name = target.target_category_name ?? "";
+        this.target_parent_uuid = target.target_parent_uuid ?? "";
+        this.tag_ids = target.tag_ids ?? [];
+        this.priority = priority;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<RoutingRule | null>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/routing-rules`, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        /** @type {RoutingRule | null} */
+        let data = null;
+
+        if (response.status >= 200 && response.status < 300) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Removes a routing rule. The categories it created and the medias it routed stay where they are.
+ */
+export class DeleteRoutingRuleRequest {
+
+    /**
+     * @param {string} rule_uuid 
+     */
+    constructor(rule_uuid) {
+        this.rule_uuid = rule_uuid;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${downloads_server}/routing-rules?rule_uuid=${this.rule_uuid}`, {
+            method: "DELETE"
+        });
+
+        return new HttpResponse(response, response.status === 204);
+    }
+}
@@ -94,6 +94,9 @@ export class PostCreateUserRequest {
     }
 }
 
+/**
+ * @deprecated the credentials end up in proxy and access logs, use PostUserSignAccessRequest instead.
+ */
 export class GetUserSignAccessRequest {
     static endpoint = `${users_server}/user-auth`;
 
@@ -113,6 +116,8 @@ export class GetUserSignAccessRequest {
      * @typedef {Object} UserSignAccessResponse
      * @property {boolean} granted
      * @property {import('@models/Users').UserIdentityParams} user_data
+     * @property {boolean} two_factor_required - the user has to send a second factor with PostTwoFactorLoginRequest before access is granted.
+     * @property {string} [challenge] - present when two_factor_required is true.
      */
     do = async () => {
         /** @type {UserSignAccessResponse} */
@@ -123,7 +128,8 @@ export class GetUserSignAccessRequest {
                 username: "",
                 role_hierarchy: 0,
                 grants: []
-            }
+            },
+            two_factor_required: false
         };
 
         const response = await fetch(`${GetUserSignAccessRequest.endpoint}?username=${this.username}&secret=${this.secret}`);
@@ -136,6 +142,56 @@ export class GetUserSignAccessRequest {
     }
 }
 
+/**
+ * Logs a user in with their credentials. Responds with 429 if there were too many failed attempts for the username or
+ * from this client, users with two factor authentication get a challenge to answer with PostTwoFactorLoginRequest.
+ */
+export class PostUserSignAccessRequest {
+    static endpoint = `${users_server}/user-auth`;
+
+    /**
+     * @param {string} username
+     * @param {string} secret
+     */
+    constructor(username, secret) {
+        this.username = username;
+        this.secret = secret;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<UserSignAccessResponse>>}
+     */
+    do = async () => {
+        /** @type {UserSignAccessResponse} */
+        let data = {
+            granted: false,
+            user_data: {
+                uuid: "",
+                username: "",
+                role_hierarchy: 0,
+                grants: []
+            },
+            two_factor_required: false
+        };
+
+        const response = await fetch(PostUserSignAccessRequest.endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
 export class GetUserAccessTokenValidationRequest {
     static endpoint = `${users_server}/user-auth/verify`;
 
@@ -781,4 +837,583 @@ export class PutChangeUsernameRequest {
 
         return new HttpResponse(response, changed);
     }
-}
\ No newline at end of file
+}
+
+/**
+ * Second login step of users with two factor authentication. Sends the challenge returned by GetUserSignAccessRequest
+ * along with a TOTP code or a recovery code.
+ */
+export class PostTwoFactorLoginRequest {
+    static endpoint = `${users_server}/user-auth/2fa/verify`;
+
+    /**
+     * @param {string} challenge
+     * @param {string} code
+     */
+    constructor(challenge, code) {
+        this.challenge = challenge;
+        this.code = code;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<TwoFactorLoginResponse>>}
+     * @typedef {Object} TwoFactorLoginResponse
+     * @property {boolean} granted
+     * @property {import('@models/Users').UserIdentityParams | null} user_data
+     */
+    do = async () => {
+        /** @type {TwoFactorLoginResponse} */
+        let data = {
+            granted: false,
+            user_data: null
+        };
+
+        const response = await fetch(PostTwoFactorLoginRequest.endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Returns the two factor authentication status of the current user.
+ */
+export class GetTwoFactorStatusRequest {
+    static endpoint = `${users_server}/user-auth/2fa`;
+
+    /**
+     * @returns {Promise<HttpResponse<TwoFactorStatus>>}
+     * @typedef {Object} TwoFactorStatus
+     * @property {boolean} enabled
+     * @property {boolean} pending_enrollment
+     * @property {number} recovery_codes_left
+     */
+    do = async () => {
+        const response = await fetch(GetTwoFactorStatusRequest.endpoint);
+
+        let data = null;
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Starts a TOTP enrollment for the current user. The returned provisioning url is meant to be shown as a QR code, the
+ * enrollment is not enforced until it's confirmed with PostConfirmTwoFactorRequest.
+ */
+export class PostEnrollTwoFactorRequest {
+    static endpoint = `${users_server}/user-auth/2fa/enroll`;
+
+    /**
+     * @returns {Promise<HttpResponse<TwoFactorEnrollment>>}
+     * @typedef {Object} TwoFactorEnrollment
+     * @property {string} secret
+     * @property {string} provisioning_url
+     */
+    do = async () => {
+        const response = await fetch(PostEnrollTwoFactorRequest.endpoint, {
+            method: "POST"
+        });
+
+        let data = null;
+
+        if (response.status === 201) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Confirms the pending TOTP enrollment of the current user with a code from their authenticator app. Returns the
+ * recovery codes of the user, they can't be requested again.
+ */
+export class PostConfirmTwoFactorRequest {
+    static endpoint = `${users_server}/user-auth/2fa/confirm`;
+
+    /**
+     * @param {string} code
+     */
+    constructor(code) {
+        this.code = code;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<RecoveryCodesResponse>>}
+     * @typedef {Object} RecoveryCodesResponse
+     * @property {string[]} recovery_codes
+     */
+    do = async () => {
+        const response = await fetch(PostConfirmTwoFactorRequest.endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let data = null;
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Replaces the recovery codes of the current user, requires a valid TOTP or recovery code.
+ */
+export class PostRegenerateRecoveryCodesRequest {
+    static endpoint = `${users_server}/user-auth/2fa/recovery-codes`;
+
+    /**
+     * @param {string} code
+     */
+    constructor(code) {
+        this.code = code;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<RecoveryCodesResponse>>}
+     */
+    do = async () => {
+        const response = await fetch(PostRegenerateRecoveryCodesRequest.endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let data = null;
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Disables two factor authentication for the current user, requires a valid TOTP or recovery code.
+ */
+export class DeleteTwoFactorRequest {
+    static endpoint = `${users_server}/user-auth/2fa`;
+
+    /**
+     * @param {string} code
+     */
+    constructor(code) {
+        this.code = code;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(DeleteTwoFactorRequest.endpoint, {
+            method: "DELETE",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        let disabled = false;
+
+        if (response.status === 204) {
+            disabled = true;
+        }
+
+        return new HttpResponse(response, disabled);
+    }
+}
+
+/**
+ * Resets the two factor authentication of a user that lost their second factor. requires the 'modify_users' grant.
+ */
+export class DeleteUserTwoFactorRequest {
+    static endpoint = `${users_server}/users/user/2fa`;
+
+    /**
+     * @param {string} user_uuid
+     */
+    constructor(user_uuid) {
+        this.user_uuid = user_uuid;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${DeleteUserTwoFactorRequest.endpoint}?user_uuid=${this.user_uuid}`, {
+            method: "DELETE"
+        });
+
+        let reset = false;
+
+        if (response.status === 204) {
+            reset = true;
+        }
+
+        return new HttpResponse(response, reset);
+    }
+}
+
+/**
+ * Returns the audit records of login attempts newest first. requires the 'read_users' grant.
+ */
+export class GetLoginAttemptsRequest {
+    static endpoint = `${users_server}/users/login-attempts`;
+
+    /**
+     * @param {string} username - only the attempts made with this username, all of them if empty.
+     * @param {number} limit
+     * @param {number} offset
+     */
+    constructor(username, limit, offset) {
+        this.username = username;
+        this.limit = limit;
+        this.offset = offset;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<LoginAttempt[]>>}
+     * @typedef {Object} LoginAttempt
+     * @property {number} id
+     * @property {string} username
+     * @property {string} client_ip
+     * @property {string} user_agent
+     * @property {"granted" | "unknown_user" | "invalid_secret" | "locked_out" | "second_factor_pending" | "invalid_second_factor"} outcome
+     * @property {number} attempted_at - unix timestamp in seconds
+     */
+    do = async () => {
+        const request_url = `${GetLoginAttemptsRequest.endpoint}?username=${encodeURIComponent(this.username)}&limit=${this.limit}&offset=${this.offset}`;
+
+        const response = await fetch(request_url);
+
+        let data = [];
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+/**
+ * Exchanges the refresh token cookie for new user claims. the user claims are short lived, this has to be done before they expire
+ * or the user will have to log in again.
+ */
+export class PostRefreshSessionRequest {
+    static endpoint = `${users_server}/user-auth/refresh`;
+
+    /**
+     * @returns {Promise<HttpResponse<RefreshSessionResponse>>}
+     * @typedef {Object} RefreshSessionResponse
+     * @property {boolean} granted
+     * @property {import('@models/Users').UserIdentityParams | null} user_data
+     * @property {number} access_expires_at - unix timestamp in seconds, the user claims have to be refreshed before it.
+     */
+    do = async () => {
+        /** @type {RefreshSessionResponse} */
+        let data = {
+            granted: false,
+            user_data: null,
+            access_expires_at: 0
+        };
+
+        const response = await fetch(PostRefreshSessionRequest.endpoint, {
+            method: "POST"
+        });
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Lists the active sessions of the logged in user.
+ */
+export class GetUserSessionsRequest {
+    static endpoint = `${users_server}/user-auth/sessions`;
+
+    /**
+     * @returns {Promise<HttpResponse<UserSession[]>>}
+     * @typedef {Object} UserSession
+     * @property {string} uuid
+     * @property {string} user_uuid
+     * @property {string} client_ip
+     * @property {string} user_agent
+     * @property {number} created_at - unix timestamp in seconds
+     * @property {number} last_refreshed_at - unix timestamp in seconds
+     * @property {number} expires_at - unix timestamp in seconds
+     * @property {number} revoked_at - unix timestamp in seconds, 0 if the session was not revoked
+     * @property {boolean} current - whether it's the session of this client
+     */
+    do = async () => {
+        const response = await fetch(GetUserSessionsRequest.endpoint);
+
+        let data = [];
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Revokes one of the sessions of the logged in user.
+ */
+export class DeleteUserSessionRequest {
+    static endpoint = `${users_server}/user-auth/sessions`;
+
+    /**
+     * @param {string} session_uuid
+     */
+    constructor(session_uuid) {
+        this.session_uuid = session_uuid;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${DeleteUserSessionRequest.endpoint}?session_uuid=${this.session_uuid}`, {
+            method: "DELETE"
+        });
+
+        let revoked = false;
+
+        if (response.status === 204) {
+            revoked = true;
+        }
+
+        return new HttpResponse(response, revoked);
+    }
+}
+
+/**
+ * Revokes every session of the logged in user, including the current one.
+ */
+export class DeleteAllUserSessionsRequest {
+    static endpoint = `${users_server}/user-auth/sessions/all`;
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(DeleteAllUserSessionsRequest.endpoint, {
+            method: "DELETE"
+        });
+
+        let revoked = false;
+
+        if (response.status === 204) {
+            revoked = true;
+        }
+
+        return new HttpResponse(response, revoked);
+    }
+}
+
+/**
+ * Lists the active sessions of a user. requires the 'read_users' grant.
+ */
+export class GetUserSessionsByUUIDRequest {
+    static endpoint = `${users_server}/users/user/sessions`;
+
+    /**
+     * @param {string} user_uuid
+     */
+    constructor(user_uuid) {
+        this.user_uuid = user_uuid;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<UserSession[]>>}
+     */
+    do = async () => {
+        const response = await fetch(`${GetUserSessionsByUUIDRequest.endpoint}?user_uuid=${this.user_uuid}`);
+
+        let data = [];
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Revokes every session of a user, forcing them to log in again. requires the 'modify_users' grant.
+ */
+export class DeleteUserSessionsByUUIDRequest {
+    static endpoint = `${users_server}/users/user/sessions`;
+
+    /**
+     * @param {string} user_uuid
+     */
+    constructor(user_uuid) {
+        this.user_uuid = user_uuid;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${DeleteUserSessionsByUUIDRequest.endpoint}?user_uuid=${this.user_uuid}`, {
+            method: "DELETE"
+        });
+
+        let revoked = false;
+
+        if (response.status === 204) {
+            revoked = true;
+        }
+
+        return new HttpResponse(response, revoked);
+    }
+}
+
+/**
+ * Lists the API tokens of the logged in user. the tokens themselves are only returned when they are created.
+ */
+export class GetApiTokensRequest {
+    static endpoint = `${users_server}/user-auth/api-tokens`;
+
+    /**
+     * @returns {Promise<HttpResponse<ApiToken[]>>}
+     * @typedef {Object} ApiToken
+     * @property {string} uuid
+     * @property {string} user_uuid
+     * @property {string} name
+     * @property {string} token_prefix - the first characters of the token, to tell it apart from the others.
+     * @property {string[]} grants
+     * @property {number} created_at - unix timestamp in seconds
+     * @property {number} expires_at - unix timestamp in seconds, 0 if the token never expires
+     * @property {number} last_used_at - unix timestamp in seconds, 0 if the token was never used
+     */
+    do = async () => {
+        const response = await fetch(GetApiTokensRequest.endpoint);
+
+        let data = [];
+
+        if (response.ok) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Creates an API token for the logged in user. scripts send it as 'Authorization: Bearer <api_token>' and get the user's
+ * access limited to the token's grants. the token can't be retrieved again after this request.
+ */
+export class PostCreateApiTokenRequest {
+    static endpoint = `${users_server}/user-auth/api-tokens`;
+
+    /**
+     * @param {string} name
+     * @param {string[]} grants - must be grants the user has.
+     * @param {number} expires_at - unix timestamp in seconds, 0 for a token that never expires.
+     */
+    constructor(name, grants, expires_at) {
+        this.name = name;
+        this.grants = grants;
+        this.expires_at = expires_at;
+    }
+
+    toJson = attributesToJson.bind(this);
+
+    /**
+     * @returns {Promise<HttpResponse<CreatedApiTokenResponse>>}
+     * @typedef {Object} CreatedApiTokenResponse
+     * @property {string} api_token
+     * @property {ApiToken | null} token
+     */
+    do = async () => {
+        /** @type {CreatedApiTokenResponse} */
+        let data = {
+            api_token: "",
+            token: null
+        };
+
+        const response = await fetch(PostCreateApiTokenRequest.endpoint, {
+            method: "POST",
+            headers: {
+                "Content-Type": "application/json"
+            },
+            body: this.toJson()
+        });
+
+        if (response.status === 201) {
+            data = await response.json();
+        }
+
+        return new HttpResponse(response, data);
+    }
+}
+
+/**
+ * Revokes one of the API tokens of the logged in user.
+ */
+export class DeleteApiTokenRequest {
+    static endpoint = `${users_server}/user-auth/api-tokens`;
+
+    /**
+     * @param {string} token_uuid
+     */
+    constructor(token_uuid) {
+        this.token_uuid = token_uuid;
+    }
+
+    /**
+     * @returns {Promise<HttpResponse<boolean>>}
+     */
+    do = async () => {
+        const response = await fetch(`${DeleteApiTokenRequest.endpoint}?token_uuid=${this.token_uuid}`, {
+            method: "DELETE"
+        });
+
+        let revoked = false;
+
+        if (response.status === 204) {
+            revoked = true;
+        }
+
+        return new HttpResponse(response, revoked);
+    }
+}
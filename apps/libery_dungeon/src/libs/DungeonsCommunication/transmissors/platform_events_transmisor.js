@@ -9,6 +9,12 @@ export class PlatformEventsTransmisor {
      */
     #socket;
 
+    /**
+     * Sequence of the last event received, sent on reconnection so the server replays the events we missed.
+     * @type {number | null}
+     */
+    #last_sequence;
+
     /**
      * set by the caller, valid events received will be forwarded to this callback  
      * @param {PlatformEventMessage<any>} event_message
@@ -18,10 +24,17 @@ export class PlatformEventsTransmisor {
     constructor() {
         this.host = `wss://${base_domain}${jd_server}/platform-events/public/suscribe`;
         this.#socket = null;
+        this.#last_sequence = null;
     }
 
     connect = () => {
-        this.#socket = new WebSocket(this.host);
+        let subscription_url = this.host;
+
+        if (this.#last_sequence !== null) {
+            subscription_url += `?last_sequence=${this.#last_sequence}`;
+        }
+
+        this.#socket = new WebSocket(subscription_url);
         this.#socket.onopen = this.onOpen;
         this.#socket.onmessage = this.onMessage;
         this.#socket.onclose = this.onClose;
@@ -53,6 +66,10 @@ export class PlatformEventsTransmisor {
             return;
         }
 
+        if (event_message.Sequence !== null) {
+            this.#last_sequence = event_message.Sequence;
+        }
+
         this.#on_message_callback(event_message);
     }
 
@@ -107,6 +124,12 @@ export class PlatformEventMessage {
      */
     #event_payload;
 
+    /**
+     * Position of the event on the platform events log
+     * @type {number | null}
+     */
+    #sequence;
+
     /**
      * @param {PlatformEventMessageParams} param0
      * @typedef {Object} PlatformEventMessageParams
@@ -114,12 +137,14 @@ export class PlatformEventMessage {
      * @property {string} event_type
      * @property {string} event_message
      * @property {string} event_payload
+     * @property {number} [sequence]
      */
-    constructor({uuid, event_type, event_message, event_payload}) {
+    constructor({uuid, event_type, event_message, event_payload, sequence}) {
         this.#event_uuid = uuid;
         this.#event_type = event_type;
         this.#event_message = event_message;
         this.#event_payload = event_payload;
+        this.#sequence = sequence ?? null;
     }
 
     get UUID() {
@@ -134,6 +159,10 @@ export class PlatformEventMessage {
         return this.#event_message;
     }
 
+    get Sequence() {
+        return this.#sequence;
+    }
+
     /**
      * Parses the event payload without verifying the signature
      * @returns {EventPayload<T>}
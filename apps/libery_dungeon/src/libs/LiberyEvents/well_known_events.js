@@ -2,6 +2,7 @@ export const platform_well_known_events = {
     FS_CHANGED: 'cluster_fs_change',
     MEDIA_DELETED: "media_deleted",
     MEDIA_ADDED: "media_added",
+    DOWNLOAD_PROGRESS: "download_progress",
 }
 
 /**
@@ -11,4 +12,14 @@ export const platform_well_known_events = {
  * @property {number} medias_added
  * @property {number} medias_deleted
  * @property {number} medias_updated
+*/
+/**
+ * A message for the DOWNLOAD_PROGRESS event.
+* @typedef {Object} DownloadProgressEvent
+ * @property {string} cluster_uuid
+ * @property {string} download_uuid
+ * @property {number} total_files
+ * @property {number} downloaded_files
+ * @property {boolean} completed
+ * @property {number} [file_progress] - percent of the file being downloaded, only reported by downloader backends
 */
\ No newline at end of file
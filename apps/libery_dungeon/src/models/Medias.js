@@ -19,7 +19,7 @@ const DEFAULT_IMAGE_WIDTH = 307;
  * @property {string} last_seen the date the media resource was last seen
  * @property {string} main_category the main category of the media resource
  * @property {string} type the type of the media resource, either IMAGE or VIDEO
- * @property {number} downloaded_from the id of the download batch that this media resource was downloaded from
+ * @property {number} downloaded_from the id of the downloaded media record on the downloads service, 0 if the media resource was not downloaded
 */
 
 /**
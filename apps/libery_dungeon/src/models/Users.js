@@ -2,7 +2,7 @@ import {
     GetIsInitialSetupRequest,
     PostCreateInitialUserRequest,
     PostCreateUserRequest,
-    GetUserSignAccessRequest,
+    PostUserSignAccessRequest,
     GetUserAccessTokenValidationRequest,
     GetUserIdentityRequest,
     GetUserSignOutRequest,
@@ -22,7 +22,8 @@ import {
     DeleteGrantRequest,
     DeleteRoleRequest, 
     PutChangeUserPasswordRequest,
-    PutChangeUsernameRequest
+    PutChangeUsernameRequest,
+    PostRefreshSessionRequest
 } from "@libs/DungeonsCommunication/services_requests/users_requests";
 
 
@@ -507,7 +508,7 @@ export const isUsersInInitialSetupMode = async () => {
  */
 export const loginPlatformUser = async (username, secret) => {
     let user_identity = null;
-    let request = new GetUserSignAccessRequest(username, secret);
+    let request = new PostUserSignAccessRequest(username, secret);
 
     let response = await request.do();
 
@@ -542,9 +543,31 @@ export const validateUserAccessToken = async () => {
         is_valid = response.data.response;
     }
 
+    if (!is_valid) {
+        is_valid = (await refreshPlatformUserSession()) !== null;
+    }
+
     return is_valid;
 }
 
+/**
+ * Renews the short lived user claims using the session's refresh token. returns when the new claims expire as a unix timestamp in seconds,
+ * or null if the session is no longer valid and the user has to log in again.
+ * @returns {Promise<number | null>}
+ */
+export const refreshPlatformUserSession = async () => {
+    let access_expires_at = null;
+    let request = new PostRefreshSessionRequest();
+
+    let response = await request.do();
+
+    if (response.Ok && response.data.granted) {
+        access_expires_at = response.data.access_expires_at;
+    }
+
+    return access_expires_at;
+}
+
 /**
  * Fetches the user identity of the currently logged in user. this is used to get the user's metadata and capabilities.
  * @returns {Promise<UserIdentity | null>}
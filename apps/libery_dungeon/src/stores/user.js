@@ -1,5 +1,5 @@
 import { get, writable } from "svelte/store";
-import { UserIdentity, deleteUser, getCurrentUserIdentity, logoutPlatformUser, validateUserAccessToken } from "@models/Users";
+import { UserIdentity, deleteUser, getCurrentUserIdentity, logoutPlatformUser, refreshPlatformUserSession, validateUserAccessToken } from "@models/Users";
 import { goto } from "$app/navigation";
 import { LOGIN_PAGE_PATH } from "@app/config/pages_routes";
 
@@ -30,6 +30,56 @@ export const setUserLoggedIn = (user_identity) => {
     current_user_identity.set(user_identity);
     has_user_access.set(true);
     access_state_confirmed.set(true);
+
+    scheduleSessionRefresh(DEFAULT_SESSION_REFRESH_DELAY);
+}
+
+/**
+ * How long to wait before the first refresh of the user claims, used while we don't know when they expire. Must be shorter than the
+ * users service's ACCESS_TOKEN_EXPIRATION.
+ * @type {number}
+ */
+const DEFAULT_SESSION_REFRESH_DELAY = 5 * 60 * 1000;
+
+/**
+ * How long before the user claims expire they are refreshed.
+ * @type {number}
+ */
+const SESSION_REFRESH_MARGIN = 60 * 1000;
+
+/**
+ * @type {number | null}
+ */
+let session_refresh_timeout = null;
+
+/**
+ * Refreshes the user claims after the given delay and keeps doing it right before they expire, until the user logs out.
+ * @param {number} delay - milliseconds
+ */
+const scheduleSessionRefresh = (delay) => {
+    cancelSessionRefresh();
+
+    session_refresh_timeout = setTimeout(async () => {
+        session_refresh_timeout = null;
+
+        let access_expires_at = await refreshPlatformUserSession();
+
+        if (access_expires_at === null) {
+            userLogout();
+            return;
+        }
+
+        let next_delay = Math.max((access_expires_at * 1000) - Date.now() - SESSION_REFRESH_MARGIN, SESSION_REFRESH_MARGIN);
+
+        scheduleSessionRefresh(next_delay);
+    }, delay);
+}
+
+const cancelSessionRefresh = () => {
+    if (session_refresh_timeout !== null) {
+        clearTimeout(session_refresh_timeout);
+        session_refresh_timeout = null;
+    }
 }
 
 /**
@@ -71,6 +121,8 @@ export const userLogout = async () => {
     has_user_access.set(false);
     access_state_confirmed.set(false);
 
+    cancelSessionRefresh();
+
     await logoutPlatformUser();
 
     goto(LOGIN_PAGE_PATH);
@@ -12,7 +12,19 @@ CREATE TABLE IF NOT EXISTS `dungeon_tags` (
     `name` TEXT NOT NULL,
     `taxonomy` TEXT NOT NULL,
     `name_taxonomy` TEXT NOT NULL UNIQUE,
-    FOREIGN KEY(`taxonomy`) REFERENCES `tag_taxonomies`(`uuid`) ON DELETE CASCADE
+    `parent_id` INTEGER DEFAULT NULL,
+    FOREIGN KEY(`taxonomy`) REFERENCES `tag_taxonomies`(`uuid`) ON DELETE CASCADE,
+    FOREIGN KEY(`parent_id`) REFERENCES `dungeon_tags`(`id`) ON DELETE SET NULL
+);
+
+DROP TABLE IF EXISTS `tag_aliases`;
+CREATE TABLE IF NOT EXISTS `tag_aliases` (
+    `alias_id` INTEGER PRIMARY KEY AUTOINCREMENT,
+    `tag` INTEGER NOT NULL,
+    `alias` TEXT NOT NULL,
+    `taxonomy` TEXT NOT NULL,
+    FOREIGN KEY(`tag`) REFERENCES `dungeon_tags`(`id`) ON DELETE CASCADE,
+    UNIQUE(`alias`, `taxonomy`)
 );
 
 DROP TABLE IF EXISTS `taggings`;
@@ -45,8 +45,10 @@ CREATE TABLE `medias` (`uuid` varchar(40) NOT NULL,
     `media_thumbnail` varchar(40),
     `type` enum('IMAGE','VIDEO') DEFAULT NULL,
     `downloaded_from` INT,
+    `content_hash` CHAR(64) DEFAULT NULL,
     PRIMARY KEY (`uuid`),
     KEY `main_category_fk` (`main_category`),
+    KEY `content_hash_idx` (`content_hash`),
     CONSTRAINT `main_category_fk` FOREIGN KEY (`main_category`) REFERENCES `categorys` (`uuid`) ON DELETE CASCADE,
     CONSTRAINT `fk_media_thumbnail` FOREIGN KEY (`media_thumbnail`) REFERENCES `medias` (`uuid`) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
@@ -64,7 +66,9 @@ CREATE TABLE `downloads`(
 
 SET @@SESSION.SQL_LOG_BIN = @MYSQLDUMP_TEMP_LOG_BIN;
 
-ALTER TABLE `medias` ADD CONSTRAINT `fk_download` FOREIGN KEY (`downloaded_from`) REFERENCES `downloads`(`uuid`);
+-- `medias`.`downloaded_from` holds the id of the downloaded media record on the downloads service, which keeps its
+-- records on its own database. On databases created with an older schema the medias service drops the old `fk_download`
+-- foreign key when it starts.
 
 CREATE VIEW media_paths AS SELECT medias.uuid, CONCAT(categorys.fullpath, '\\', medias.name) AS path FROM categorys, medias WHERE categorys.uuid=medias.main_category AND categorys.fullpath NOT LIKE '%\\';
 
@@ -12,5 +12,6 @@ CREATE TABLE IF NOT EXISTS `video_moments` (
     `video_uuid` TEXT NOT NULL,
     `moment_title` TEXT NOT NULL,
     `moment_time` INTEGER NOT NULL,
+    `moment_end_time` INTEGER NOT NULL DEFAULT 0,
     FOREIGN KEY(`video_uuid`) REFERENCES `videos`(`uuid`) ON DELETE CASCADE
 );
\ No newline at end of file